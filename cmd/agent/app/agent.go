@@ -17,6 +17,7 @@ package app
 
 import (
 	"context"
+	"io"
 	"net"
 	"net/http"
 	"sync/atomic"
@@ -34,6 +35,7 @@ type Agent struct {
 	httpServer *http.Server
 	httpAddr   atomic.Value // string, set once agent starts listening
 	logger     *zap.Logger
+	closers    []io.Closer
 }
 
 // NewAgent creates the new Agent.
@@ -97,4 +99,10 @@ func (a *Agent) Stop() {
 	for _, processor := range a.processors {
 		processor.Stop()
 	}
+
+	for _, closer := range a.closers {
+		if err := closer.Close(); err != nil {
+			a.logger.Error("failed to close agent component", zap.Error(err))
+		}
+	}
 }
@@ -19,6 +19,8 @@ import (
 	"fmt"
 	"io/ioutil"
 	"net/http"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
@@ -83,7 +85,45 @@ func TestAgentMetricsEndpoint(t *testing.T) {
 	})
 }
 
-func withRunningAgent(t *testing.T, testcase func(string, chan error)) {
+func TestAgentSpansEndpoint(t *testing.T) {
+	withRunningAgent(t, func(httpAddr string, errorch chan error) {
+		url := fmt.Sprintf("http://%s/api/v2/spans", httpAddr)
+		body := `[{"id":"1111111111111111", "traceId":"1111111111111111", "name":"foo"}]`
+		resp, err := http.Post(url, "application/json", strings.NewReader(body))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusAccepted, resp.StatusCode)
+	})
+}
+
+func TestAgentCreditsEndpoint(t *testing.T) {
+	dir, err := ioutil.TempDir("", "credits")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "credits.json")
+	require.NoError(t, ioutil.WriteFile(file, []byte(`{"default": {"creditsPerSecond": 1, "maxBalance": 3}}`), 0o600))
+
+	withRunningAgent(t, func(httpAddr string, errorch chan error) {
+		url := fmt.Sprintf("http://%s/credits?service=svc&uuid=1&operations=op", httpAddr)
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		body, err := ioutil.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.JSONEq(t, `{"balances":[{"operation":"op","balance":3}]}`, string(body))
+	}, func(cfg *Builder) {
+		cfg.HTTPServer.CreditsConfigFile = file
+	})
+}
+
+func TestAgentInvalidCreditsConfig(t *testing.T) {
+	cfg := Builder{HTTPServer: HTTPServerConfiguration{CreditsConfigFile: "/does/not/exist"}}
+	_, err := cfg.CreateAgent(fakeCollectorProxy{}, zap.NewNop(), metrics.NullFactory)
+	assert.Contains(t, err.Error(), "cannot create throttler")
+}
+
+func withRunningAgent(t *testing.T, testcase func(string, chan error), options ...func(*Builder)) {
 	resetDefaultPrometheusRegistry()
 	cfg := Builder{
 		Processors: []ProcessorConfiguration{
@@ -96,9 +136,13 @@ func withRunningAgent(t *testing.T, testcase func(string, chan error)) {
 			},
 		},
 		HTTPServer: HTTPServerConfiguration{
-			HostPort: "127.0.0.1:0",
+			HostPort:     "127.0.0.1:0",
+			SpansEnabled: true,
 		},
 	}
+	for _, option := range options {
+		option(&cfg)
+	}
 	logger, logBuf := testutils.NewLogger()
 	mBldr := &jmetrics.Builder{HTTPRoute: "/metrics", Backend: "prometheus"}
 	metricsFactory, err := mBldr.CreateMetricsFactory("jaeger")
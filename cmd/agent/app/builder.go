@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"strconv"
 
 	"github.com/apache/thrift/lib/go/thrift"
@@ -31,6 +32,8 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/servers"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/servers/thriftudp"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/servers/thriftunix"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/throttling"
 	"github.com/jaegertracing/jaeger/ports"
 	zipkinThrift "github.com/jaegertracing/jaeger/thrift-gen/agent"
 )
@@ -40,6 +43,10 @@ const (
 	defaultMaxPacketSize = 65000
 	defaultServerWorkers = 10
 
+	defaultUnixSocketMode = "0660"
+
+	defaultSpansMaxBodySizeMiB = 10
+
 	jaegerModel Model = "jaeger"
 	zipkinModel Model = "zipkin"
 
@@ -74,7 +81,8 @@ type Builder struct {
 	Processors []ProcessorConfiguration `yaml:"processors"`
 	HTTPServer HTTPServerConfiguration  `yaml:"httpServer"`
 
-	reporters []reporter.Reporter
+	reporters  []reporter.Reporter
+	rateLimits reporter.RateLimitOptions
 }
 
 // ProcessorConfiguration holds config for a processor that receives spans from Server
@@ -91,11 +99,25 @@ type ServerConfiguration struct {
 	MaxPacketSize    int    `yaml:"maxPacketSize"`
 	SocketBufferSize int    `yaml:"socketBufferSize"`
 	HostPort         string `yaml:"hostPort" validate:"nonzero"`
+	// UnixSocket is the path of a Unix domain socket to receive spans on, in addition to HostPort if set.
+	UnixSocket     string `yaml:"unixSocket"`
+	UnixSocketType string `yaml:"unixSocketType"`
+	UnixSocketMode string `yaml:"unixSocketMode"`
 }
 
 // HTTPServerConfiguration holds config for a server providing sampling strategies and baggage restrictions to clients
 type HTTPServerConfiguration struct {
 	HostPort string `yaml:"hostPort" validate:"nonzero"`
+
+	// SpansEnabled enables the endpoints used by local clients to submit spans over HTTP.
+	SpansEnabled bool `yaml:"spansEnabled"`
+	// SpansAllowRemote accepts spans over HTTP from non-loopback clients.
+	SpansAllowRemote bool `yaml:"spansAllowRemote"`
+	// SpansMaxBodySize is the maximum size in bytes of a decompressed span submission.
+	SpansMaxBodySize int64 `yaml:"spansMaxBodySize"`
+
+	// CreditsConfigFile enables the endpoint serving throttling credits to clients, configured by the file.
+	CreditsConfigFile string `yaml:"creditsConfigFile"`
 }
 
 // WithReporter adds auxiliary reporters.
@@ -104,17 +126,43 @@ func (b *Builder) WithReporter(r ...reporter.Reporter) *Builder {
 	return b
 }
 
+// WithRateLimits sets the per-service rate limits applied to the packets of spans received from clients,
+// before they are queued by the Thrift servers.
+func (b *Builder) WithRateLimits(options reporter.RateLimitOptions) *Builder {
+	b.rateLimits = options
+	return b
+}
+
 // CreateAgent creates the Agent
 func (b *Builder) CreateAgent(primaryProxy CollectorProxy, logger *zap.Logger, mFactory metrics.Factory) (*Agent, error) {
+	var credits *throttling.Throttler
+	if b.HTTPServer.CreditsConfigFile != "" {
+		var err error
+		credits, err = throttling.NewThrottler(b.HTTPServer.CreditsConfigFile, mFactory, logger)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create throttler: %w", err)
+		}
+	}
 	r := b.getReporter(primaryProxy)
-	processors, err := b.getProcessors(r, mFactory, logger)
+	var limiter *reporter.ServiceRateLimiter
+	if b.rateLimits.Enabled() {
+		limiter = reporter.NewServiceRateLimiter(b.rateLimits, mFactory)
+	}
+	processors, err := b.getProcessors(r, limiter, mFactory, logger)
 	if err != nil {
+		if credits != nil {
+			credits.Close()
+		}
 		return nil, fmt.Errorf("cannot create processors: %w", err)
 	}
-	server := b.HTTPServer.getHTTPServer(primaryProxy.GetManager(), mFactory)
+	server := b.HTTPServer.getHTTPServer(primaryProxy.GetManager(), credits, r, mFactory, logger)
 	b.publishOpts(mFactory)
 
-	return NewAgent(processors, server, logger), nil
+	agent := NewAgent(processors, server, logger)
+	if credits != nil {
+		agent.closers = append(agent.closers, credits)
+	}
+	return agent, nil
 }
 
 func (b *Builder) getReporter(primaryProxy CollectorProxy) reporter.Reporter {
@@ -142,9 +190,14 @@ func (b *Builder) publishOpts(mFactory metrics.Factory) {
 	}
 }
 
-func (b *Builder) getProcessors(rep reporter.Reporter, mFactory metrics.Factory, logger *zap.Logger) ([]processors.Processor, error) {
-	retMe := make([]processors.Processor, len(b.Processors))
-	for idx, cfg := range b.Processors {
+func (b *Builder) getProcessors(
+	rep reporter.Reporter,
+	limiter *reporter.ServiceRateLimiter,
+	mFactory metrics.Factory,
+	logger *zap.Logger,
+) ([]processors.Processor, error) {
+	retMe := make([]processors.Processor, 0, len(b.Processors))
+	for _, cfg := range b.Processors {
 		protoFactory, ok := protocolFactoryMap[cfg.Protocol]
 		if !ok {
 			return nil, fmt.Errorf("cannot find protocol factory for protocol %v", cfg.Protocol)
@@ -156,25 +209,70 @@ func (b *Builder) getProcessors(rep reporter.Reporter, mFactory metrics.Factory,
 		default:
 			return nil, fmt.Errorf("cannot find agent processor for data model %v", cfg.Model)
 		}
-		metrics := mFactory.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{
+		var filter servers.PacketFilter
+		if limiter != nil {
+			filter = rateLimitFilter(protoFactory, limiter)
+		}
+		tags := map[string]string{
 			"protocol": string(cfg.Protocol),
 			"model":    string(cfg.Model),
-		}})
-		processor, err := cfg.GetThriftProcessor(metrics, protoFactory, handler, logger)
-		if err != nil {
-			return nil, fmt.Errorf("cannot create Thrift Processor: %w", err)
 		}
-		retMe[idx] = processor
+		// the Unix socket is served in addition to the UDP host:port, unless the latter is empty
+		if cfg.Server.HostPort != "" || cfg.Server.UnixSocket == "" {
+			processor, err := cfg.getThriftProcessor(mFactory.Namespace(metrics.NSOptions{Tags: tags}), protoFactory, handler, filter, logger)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create Thrift Processor: %w", err)
+			}
+			retMe = append(retMe, processor)
+		}
+		if cfg.Server.UnixSocket != "" {
+			// a separate namespace keeps the metrics of both servers apart, e.g. the queue sizes
+			unixFactory := mFactory.Namespace(metrics.NSOptions{Name: "unix", Tags: tags})
+			processor, err := cfg.getUnixThriftProcessor(unixFactory, protoFactory, handler, filter, logger)
+			if err != nil {
+				return nil, fmt.Errorf("cannot create Thrift Processor: %w", err)
+			}
+			retMe = append(retMe, processor)
+		}
 	}
 	return retMe, nil
 }
 
+// rateLimitFilter drops the packets of the client services exceeding their rate limit. The packets
+// that cannot be peeked are queued, for the processors to report them as malformed.
+func rateLimitFilter(factory thrift.TProtocolFactory, limiter *reporter.ServiceRateLimiter) servers.PacketFilter {
+	return func(packet []byte) bool {
+		serviceName, spans, ok := processors.PeekBatch(factory, packet)
+		return !ok || limiter.Allow(serviceName, spans)
+	}
+}
+
 // GetHTTPServer creates an HTTP server that provides sampling strategies and baggage restrictions to client libraries.
-func (c HTTPServerConfiguration) getHTTPServer(manager configmanager.ClientConfigManager, mFactory metrics.Factory) *http.Server {
+func (c HTTPServerConfiguration) getHTTPServer(
+	manager configmanager.ClientConfigManager,
+	credits *throttling.Throttler,
+	rep reporter.Reporter,
+	mFactory metrics.Factory,
+	logger *zap.Logger,
+) *http.Server {
 	if c.HostPort == "" {
 		c.HostPort = defaultHTTPServerHostPort
 	}
-	return httpserver.NewHTTPServer(c.HostPort, manager, mFactory)
+	var spans *httpserver.SpansHandler
+	if c.SpansEnabled {
+		spans = httpserver.NewSpansHandler(httpserver.SpansHandlerParams{
+			Reporter:       rep,
+			MetricsFactory: mFactory,
+			Logger:         logger,
+			MaxBodySize:    c.SpansMaxBodySize,
+			AllowRemote:    c.SpansAllowRemote,
+		})
+	}
+	var creditsManager throttling.CreditsManager
+	if credits != nil {
+		creditsManager = credits
+	}
+	return httpserver.NewHTTPServer(c.HostPort, manager, creditsManager, mFactory, spans)
 }
 
 // GetThriftProcessor gets a TBufferedServer backed Processor using the collector configuration
@@ -183,10 +281,21 @@ func (c *ProcessorConfiguration) GetThriftProcessor(
 	factory thrift.TProtocolFactory,
 	handler processors.AgentProcessor,
 	logger *zap.Logger,
+) (processors.Processor, error) {
+	return c.getThriftProcessor(mFactory, factory, handler, nil, logger)
+}
+
+// getThriftProcessor gets a TBufferedServer backed Processor dropping the packets rejected by the filter, if any
+func (c *ProcessorConfiguration) getThriftProcessor(
+	mFactory metrics.Factory,
+	factory thrift.TProtocolFactory,
+	handler processors.AgentProcessor,
+	filter servers.PacketFilter,
+	logger *zap.Logger,
 ) (processors.Processor, error) {
 	c.applyDefaults()
 
-	server, err := c.Server.getUDPServer(mFactory)
+	server, err := c.Server.getServer(mFactory, filter)
 	if err != nil {
 		return nil, fmt.Errorf("cannot create UDP Server: %w", err)
 	}
@@ -194,6 +303,24 @@ func (c *ProcessorConfiguration) GetThriftProcessor(
 	return processors.NewThriftProcessor(server, c.Workers, mFactory, factory, handler, logger)
 }
 
+// getUnixThriftProcessor gets a Thrift processor receiving spans on the Unix socket of the server configuration
+func (c *ProcessorConfiguration) getUnixThriftProcessor(
+	mFactory metrics.Factory,
+	factory thrift.TProtocolFactory,
+	handler processors.AgentProcessor,
+	filter servers.PacketFilter,
+	logger *zap.Logger,
+) (processors.Processor, error) {
+	c.applyDefaults()
+
+	server, err := c.Server.getUnixServer(mFactory, filter)
+	if err != nil {
+		return nil, fmt.Errorf("cannot create Unix socket Server: %w", err)
+	}
+
+	return processors.NewThriftProcessor(server, c.Workers, mFactory, factory, handler, logger)
+}
+
 func (c *ProcessorConfiguration) applyDefaults() {
 	c.Workers = defaultInt(c.Workers, defaultServerWorkers)
 }
@@ -204,8 +331,8 @@ func (c *ServerConfiguration) applyDefaults() {
 	c.SocketBufferSize = defaultInt(c.SocketBufferSize, 0)
 }
 
-// getUDPServer gets a TBufferedServer backed server using the server configuration
-func (c *ServerConfiguration) getUDPServer(mFactory metrics.Factory) (servers.Server, error) {
+// getServer gets a TBufferedServer backed server listening on the UDP host:port of the server configuration
+func (c *ServerConfiguration) getServer(mFactory metrics.Factory, filter servers.PacketFilter) (servers.Server, error) {
 	c.applyDefaults()
 
 	if c.HostPort == "" {
@@ -221,7 +348,62 @@ func (c *ServerConfiguration) getUDPServer(mFactory metrics.Factory) (servers.Se
 		}
 	}
 
-	return servers.NewTBufferedServer(transport, c.QueueSize, c.MaxPacketSize, mFactory)
+	return c.newTBufferedServer(transport, mFactory, filter)
+}
+
+// getUnixServer gets a TBufferedServer backed server listening on the Unix socket
+func (c *ServerConfiguration) getUnixServer(mFactory metrics.Factory, filter servers.PacketFilter) (servers.Server, error) {
+	c.applyDefaults()
+
+	if c.UnixSocketType == "" {
+		c.UnixSocketType = thriftunix.Datagram
+	}
+	if c.UnixSocketMode == "" {
+		c.UnixSocketMode = defaultUnixSocketMode
+	}
+	mode, err := strconv.ParseUint(c.UnixSocketMode, 8, 32)
+	if err != nil || mode > 0777 {
+		return nil, fmt.Errorf("invalid Unix socket mode %q, expecting octal permissions, e.g. 0660", c.UnixSocketMode)
+	}
+	var transport servers.ThriftTransport
+	switch c.UnixSocketType {
+	case thriftunix.Datagram:
+		t, err := thriftunix.NewTUnixgramServerTransport(c.UnixSocket, os.FileMode(mode))
+		if err != nil {
+			return nil, fmt.Errorf("cannot create UnixgramServerTransport: %w", err)
+		}
+		if c.SocketBufferSize != 0 {
+			if err := t.SetSocketBufferSize(c.SocketBufferSize); err != nil {
+				t.Close()
+				return nil, fmt.Errorf("cannot set Unix socket buffer size: %w", err)
+			}
+		}
+		transport = t
+	case thriftunix.Stream:
+		t, err := thriftunix.NewTUnixStreamServerTransport(c.UnixSocket, os.FileMode(mode), c.MaxPacketSize)
+		if err != nil {
+			return nil, fmt.Errorf("cannot create UnixStreamServerTransport: %w", err)
+		}
+		transport = t
+	default:
+		return nil, fmt.Errorf("invalid Unix socket type %q, expecting %s or %s", c.UnixSocketType, thriftunix.Datagram, thriftunix.Stream)
+	}
+
+	return c.newTBufferedServer(transport, mFactory, filter)
+}
+
+// newTBufferedServer creates a TBufferedServer reading from the transport, which drops the packets rejected by the filter
+func (c *ServerConfiguration) newTBufferedServer(
+	transport servers.ThriftTransport,
+	mFactory metrics.Factory,
+	filter servers.PacketFilter,
+) (servers.Server, error) {
+	server, err := servers.NewTBufferedServer(transport, c.QueueSize, c.MaxPacketSize, mFactory)
+	if err != nil {
+		return nil, err
+	}
+	server.SetPacketFilter(filter)
+	return server, nil
 }
 
 func defaultInt(value int, defaultVal int) int {
@@ -17,13 +17,19 @@ package app
 
 import (
 	"context"
+	"encoding/binary"
 	"errors"
 	"flag"
 	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/apache/thrift/lib/go/thrift"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
@@ -37,6 +43,10 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter/grpc"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter/otlp"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/servers/thriftunix"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/testutils"
+	"github.com/jaegertracing/jaeger/thrift-gen/agent"
 	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
@@ -142,7 +152,7 @@ func TestBuilderWithProcessorErrors(t *testing.T) {
 	}{
 		{protocol: Protocol("bad"), err: "cannot find protocol factory for protocol bad"},
 		{protocol: compactProtocol, model: Model("bad"), err: "cannot find agent processor for data model bad"},
-		{protocol: compactProtocol, model: jaegerModel, err: "no host:port provided for udp server: {QueueSize:1000 MaxPacketSize:65000 SocketBufferSize:0 HostPort:}"},
+		{protocol: compactProtocol, model: jaegerModel, err: "no host:port provided for udp server: {QueueSize:1000 MaxPacketSize:65000 SocketBufferSize:0 HostPort: UnixSocket: UnixSocketType: UnixSocketMode:}"},
 		{protocol: compactProtocol, model: zipkinModel, hostPort: "bad-host-port", errContains: "bad-host-port"},
 	}
 	for _, tc := range testCases {
@@ -229,11 +239,16 @@ func TestCreateCollectorProxy(t *testing.T) {
 			flags:  []string{"--reporter.type=grpc", "--reporter.grpc.host-port=foo"},
 			metric: metricstest.ExpectedMetric{Name: "reporter.batches.failures", Tags: map[string]string{"protocol": "grpc", "format": "jaeger"}, Value: 1},
 		},
+		{
+			flags:  []string{"--reporter.type=otlp", "--reporter.otlp.endpoint=foo:4317"},
+			metric: metricstest.ExpectedMetric{Name: "reporter.batches.submitted", Tags: map[string]string{"protocol": "otlp", "format": "jaeger"}, Value: 1},
+		},
 	}
 
 	for _, test := range tests {
 		flags := &flag.FlagSet{}
 		grpc.AddFlags(flags)
+		otlp.AddFlags(flags)
 		reporter.AddFlags(flags)
 
 		command := cobra.Command{}
@@ -246,11 +261,13 @@ func TestCreateCollectorProxy(t *testing.T) {
 
 		rOpts := new(reporter.Options).InitFromViper(v, zap.NewNop())
 		grpcBuilder := grpc.NewConnBuilder().InitFromViper(v)
+		otlpOptions := new(otlp.Options).InitFromViper(v)
 
 		metricsFactory := metricstest.NewFactory(time.Microsecond)
 
 		builders := map[reporter.Type]CollectorProxyBuilder{
 			reporter.GRPC: GRPCCollectorProxyBuilder(grpcBuilder),
+			reporter.OTLP: OTLPCollectorProxyBuilder(otlpOptions),
 		}
 		proxy, err := CreateCollectorProxy(ProxyBuilderOptions{
 			Options: *rOpts,
@@ -317,3 +334,206 @@ func TestPublishOpts(t *testing.T) {
 		Value: 42,
 	})
 }
+
+func TestBuilderWithUnixSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	for _, socketType := range []string{thriftunix.Datagram, thriftunix.Stream} {
+		t.Run(socketType, func(t *testing.T) {
+			path := filepath.Join(dir, socketType+".sock")
+			cfg := &Builder{
+				Processors: []ProcessorConfiguration{
+					{
+						Model:    jaegerModel,
+						Protocol: compactProtocol,
+						Server: ServerConfiguration{
+							UnixSocket:     path,
+							UnixSocketType: socketType,
+							UnixSocketMode: "0600",
+						},
+					},
+				},
+			}
+			rep := testutils.NewInMemoryReporter()
+			procs, err := cfg.getProcessors(rep, nil, metrics.NullFactory, zap.NewNop())
+			require.NoError(t, err)
+			go procs[0].Serve()
+			defer procs[0].Stop()
+
+			fi, err := os.Stat(path)
+			require.NoError(t, err)
+			assert.Equal(t, os.FileMode(0o600), fi.Mode().Perm())
+
+			buf := thrift.NewTMemoryBuffer()
+			client := agent.NewAgentClientFactory(buf, thrift.NewTCompactProtocolFactoryConf(&thrift.TConfiguration{}))
+			require.NoError(t, client.EmitBatch(context.Background(), &jaeger.Batch{
+				Process: jaeger.NewProcess(),
+				Spans:   []*jaeger.Span{{OperationName: "op"}},
+			}))
+			msg := buf.Bytes()
+
+			var conn net.Conn
+			if socketType == thriftunix.Datagram {
+				conn, err = net.Dial("unixgram", path)
+			} else {
+				conn, err = net.Dial("unix", path)
+				header := make([]byte, 4)
+				binary.BigEndian.PutUint32(header, uint32(len(msg)))
+				msg = append(header, msg...)
+			}
+			require.NoError(t, err)
+			defer conn.Close()
+			_, err = conn.Write(msg)
+			require.NoError(t, err)
+
+			assert.Eventually(t, func() bool {
+				return len(rep.Spans()) == 1
+			}, 5*time.Second, 10*time.Millisecond)
+			assert.Equal(t, "op", rep.Spans()[0].OperationName)
+		})
+	}
+}
+
+func TestBuilderWithUnixSocketAndUDP(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.sock")
+
+	cfg := &Builder{
+		Processors: []ProcessorConfiguration{
+			{
+				Model:    jaegerModel,
+				Protocol: compactProtocol,
+				Server: ServerConfiguration{
+					HostPort:   "127.0.0.1:0",
+					UnixSocket: path,
+				},
+			},
+		},
+	}
+	mFactory := metricstest.NewFactory(time.Hour)
+	procs, err := cfg.getProcessors(testutils.NewInMemoryReporter(), nil, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	require.Len(t, procs, 2)
+	for _, p := range procs {
+		go p.Serve()
+		defer p.Stop()
+	}
+	_, err = os.Stat(path)
+	require.NoError(t, err)
+
+	conn, err := net.Dial("unixgram", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("not thrift"))
+	require.NoError(t, err)
+
+	// the servers report their metrics separately
+	assert.Eventually(t, func() bool {
+		counters, _ := mFactory.Snapshot()
+		return counters["unix.thrift.udp.server.packets.processed|model=jaeger|protocol=compact"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	counters, _ := mFactory.Snapshot()
+	assert.Zero(t, counters["thrift.udp.server.packets.processed|model=jaeger|protocol=compact"])
+}
+
+type inMemoryCollectorProxy struct {
+	fakeCollectorProxy
+	reporter *testutils.InMemoryReporter
+}
+
+func (p inMemoryCollectorProxy) GetReporter() reporter.Reporter {
+	return p.reporter
+}
+
+func TestBuilderWithRateLimits(t *testing.T) {
+	dir, err := ioutil.TempDir("", "agent")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "agent.sock")
+
+	cfg := &Builder{
+		Processors: []ProcessorConfiguration{
+			{
+				Model:    jaegerModel,
+				Protocol: compactProtocol,
+				Server:   ServerConfiguration{UnixSocket: path},
+			},
+		},
+		HTTPServer: HTTPServerConfiguration{HostPort: "127.0.0.1:0"},
+	}
+	cfg.WithRateLimits(reporter.RateLimitOptions{SpansPerSecond: 0.001, Burst: 2})
+	rep := testutils.NewInMemoryReporter()
+	mFactory := metricstest.NewFactory(time.Hour)
+	a, err := cfg.CreateAgent(inMemoryCollectorProxy{reporter: rep}, zap.NewNop(), mFactory)
+	require.NoError(t, err)
+	require.NoError(t, a.Run())
+	defer a.Stop()
+
+	conn, err := net.Dial("unixgram", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, spans := range [][]*jaeger.Span{
+		{{OperationName: "op1"}, {OperationName: "op2"}},
+		{{OperationName: "op3"}},
+	} {
+		buf := thrift.NewTMemoryBuffer()
+		client := agent.NewAgentClientFactory(buf, thrift.NewTCompactProtocolFactoryConf(&thrift.TConfiguration{}))
+		require.NoError(t, client.EmitBatch(context.Background(), &jaeger.Batch{
+			Process: &jaeger.Process{ServiceName: "noisy"},
+			Spans:   spans,
+		}))
+		_, err = conn.Write(buf.Bytes())
+		require.NoError(t, err)
+	}
+
+	// the packet above the limit is dropped by the server before it is queued
+	assert.Eventually(t, func() bool {
+		counters, _ := mFactory.Snapshot()
+		return counters["unix.thrift.udp.server.packets.filtered|model=jaeger|protocol=compact"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return len(rep.Spans()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "op1", rep.Spans()[0].OperationName)
+	counters, _ := mFactory.Snapshot()
+	assert.EqualValues(t, 1, counters["client_stats.spans_rate_limited|service=noisy"])
+	assert.EqualValues(t, 1, counters["unix.thrift.udp.server.packets.processed|model=jaeger|protocol=compact"])
+}
+
+func TestBuilderWithUnixSocketErrors(t *testing.T) {
+	testCases := []struct {
+		server ServerConfiguration
+		err    string
+	}{
+		{
+			server: ServerConfiguration{UnixSocket: "/tmp/agent.sock", UnixSocketType: "seqpacket"},
+			err:    `cannot create Unix socket Server: invalid Unix socket type "seqpacket", expecting datagram or stream`,
+		},
+		{
+			server: ServerConfiguration{UnixSocket: "/tmp/agent.sock", UnixSocketMode: "rw-rw----"},
+			err:    `cannot create Unix socket Server: invalid Unix socket mode "rw-rw----", expecting octal permissions, e.g. 0660`,
+		},
+		{
+			server: ServerConfiguration{UnixSocket: "/does/not/exist/agent.sock"},
+			err:    "cannot create Unix socket Server: cannot create UnixgramServerTransport",
+		},
+		{
+			server: ServerConfiguration{UnixSocket: "/does/not/exist/agent.sock", UnixSocketType: thriftunix.Stream},
+			err:    "cannot create Unix socket Server: cannot create UnixStreamServerTransport",
+		},
+	}
+	for _, tc := range testCases {
+		cfg := &Builder{
+			Processors: []ProcessorConfiguration{
+				{Model: jaegerModel, Protocol: compactProtocol, Server: tc.server},
+			},
+		}
+		_, err := cfg.CreateAgent(&fakeCollectorProxy{}, zap.NewNop(), metrics.NullFactory)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), tc.err)
+	}
+}
@@ -0,0 +1,358 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configmanager
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+const (
+	samplingCacheFile    = "sampling-strategies.json"
+	defaultFetchTimeout  = 5 * time.Second
+	samplingCacheFileTmp = samplingCacheFile + ".tmp"
+	// changed strategies are written to the cache file at most this often, and on Close
+	defaultPersistInterval = 10 * time.Second
+)
+
+// CacheOptions configures the cache of sampling strategies.
+type CacheOptions struct {
+	// Dir is the directory where the cached strategies are persisted, so that they survive restarts.
+	// The strategies are only cached in memory if empty.
+	Dir string
+	// RefreshInterval is how often the cached strategies are fetched again in the background.
+	// Background refreshes are disabled if zero.
+	RefreshInterval time.Duration
+	// MaxEntries is the maximum number of cached strategies, the strategy of the service
+	// requested least recently is evicted to make room for a new one. Unlimited if zero.
+	MaxEntries int
+	// TTL is how long the strategy of a service that clients no longer request is kept
+	// and refreshed. Strategies are kept forever if zero.
+	TTL time.Duration
+}
+
+// cacheMetrics holds metrics related to the sampling strategies cache
+type cacheMetrics struct {
+	// Number of strategies served from the cache because the collector could not be reached
+	Served metrics.Counter `metric:"sampling.cache.served"`
+
+	// Number of failed requests that could not be served from the cache
+	Misses metrics.Counter `metric:"sampling.cache.misses"`
+
+	// Number of successful background refreshes of cached strategies
+	RefreshSuccess metrics.Counter `metric:"sampling.cache.refreshes" tags:"result=ok"`
+
+	// Number of failed background refreshes of cached strategies
+	RefreshFailures metrics.Counter `metric:"sampling.cache.refreshes" tags:"result=err"`
+
+	// Number of strategies evicted because the cache was full
+	EvictedFull metrics.Counter `metric:"sampling.cache.evictions" tags:"cause=full"`
+
+	// Number of strategies evicted because clients stopped requesting them
+	EvictedExpired metrics.Counter `metric:"sampling.cache.evictions" tags:"cause=expired"`
+
+	// Number of cached strategies
+	Entries metrics.Gauge `metric:"sampling.cache.entries"`
+
+	// Seconds since the least recently fetched strategy was received from the collector
+	Age metrics.Gauge `metric:"sampling.cache.age_seconds"`
+}
+
+type cacheEntry struct {
+	Strategy *sampling.SamplingStrategyResponse `json:"strategy"`
+	Fetched  time.Time                          `json:"fetched"`
+	// Requested is when a client last asked for the strategy, background refreshes do not update it.
+	Requested time.Time `json:"requested"`
+}
+
+// CachingManager remembers the last known sampling strategy of the services requested
+// by clients and serves it when the wrapped manager fails, e.g. when the collector is
+// unreachable, so that clients do not fall back to their default sampling during incidents.
+type CachingManager struct {
+	wrapped         ClientConfigManager
+	options         CacheOptions
+	metrics         cacheMetrics
+	logger          *zap.Logger
+	timeNow         func() time.Time
+	persistInterval time.Duration
+
+	mux     sync.RWMutex
+	entries map[string]cacheEntry
+	dirty   bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// WrapWithCache wraps ClientConfigManager with a cache of sampling strategies.
+func WrapWithCache(manager ClientConfigManager, options CacheOptions, mFactory metrics.Factory, logger *zap.Logger) (*CachingManager, error) {
+	m := &CachingManager{
+		wrapped:         manager,
+		options:         options,
+		logger:          logger,
+		timeNow:         time.Now,
+		persistInterval: defaultPersistInterval,
+		entries:         make(map[string]cacheEntry),
+		done:            make(chan struct{}),
+	}
+	metrics.MustInit(&m.metrics, mFactory, nil)
+	if options.Dir != "" {
+		if err := os.MkdirAll(options.Dir, 0o700); err != nil {
+			return nil, fmt.Errorf("failed to create sampling cache directory: %w", err)
+		}
+		if err := m.load(); err != nil {
+			return nil, err
+		}
+	}
+	m.updateGauges()
+	if options.RefreshInterval > 0 || options.Dir != "" {
+		m.wg.Add(1)
+		go m.maintenanceLoop()
+	}
+	return m, nil
+}
+
+// GetSamplingStrategy returns the sampling strategy from the wrapped manager,
+// or the last known strategy of the service if the wrapped manager fails.
+func (m *CachingManager) GetSamplingStrategy(ctx context.Context, serviceName string) (*sampling.SamplingStrategyResponse, error) {
+	r, err := m.wrapped.GetSamplingStrategy(ctx, serviceName)
+	defer m.updateGauges()
+	if err == nil {
+		m.store(serviceName, r, true)
+		return r, nil
+	}
+	m.mux.Lock()
+	entry, ok := m.entries[serviceName]
+	if ok {
+		entry.Requested = m.timeNow()
+		m.entries[serviceName] = entry
+	}
+	m.mux.Unlock()
+	if !ok {
+		m.metrics.Misses.Inc(1)
+		return nil, err
+	}
+	m.metrics.Served.Inc(1)
+	m.logger.Debug("Serving cached sampling strategy",
+		zap.String("service", serviceName), zap.Time("fetched", entry.Fetched), zap.Error(err))
+	return entry.Strategy, nil
+}
+
+// GetBaggageRestrictions returns baggage restrictions from the wrapped manager.
+func (m *CachingManager) GetBaggageRestrictions(ctx context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	return m.wrapped.GetBaggageRestrictions(ctx, serviceName)
+}
+
+// Close stops the background refreshes and persists the cache.
+func (m *CachingManager) Close() error {
+	m.closeOnce.Do(func() { close(m.done) })
+	m.wg.Wait()
+	if m.options.Dir == "" {
+		return nil
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.dirty = false
+	return m.save()
+}
+
+// store caches the strategy of the service. Strategies fetched by background refreshes,
+// i.e. not requested by a client, are only stored if the service is still cached.
+func (m *CachingManager) store(serviceName string, strategy *sampling.SamplingStrategyResponse, requested bool) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	previous, ok := m.entries[serviceName]
+	if !ok && !requested {
+		return
+	}
+	now := m.timeNow()
+	entry := cacheEntry{Strategy: strategy, Fetched: now, Requested: previous.Requested}
+	if requested {
+		entry.Requested = now
+	}
+	if !ok && m.options.MaxEntries > 0 && len(m.entries) >= m.options.MaxEntries {
+		m.evictLeastRecentlyRequested()
+	}
+	m.entries[serviceName] = entry
+	// the file is rewritten only when a strategy changes, the fetch times are persisted on Close
+	if !ok || !strategy.Equals(previous.Strategy) {
+		m.dirty = true
+	}
+}
+
+// evictLeastRecentlyRequested must be called while holding the lock.
+func (m *CachingManager) evictLeastRecentlyRequested() {
+	var oldest string
+	var oldestRequested time.Time
+	for service, entry := range m.entries {
+		if oldest == "" || entry.Requested.Before(oldestRequested) {
+			oldest, oldestRequested = service, entry.Requested
+		}
+	}
+	delete(m.entries, oldest)
+	m.dirty = true
+	m.metrics.EvictedFull.Inc(1)
+}
+
+// expire evicts the strategies of services that clients did not request within the TTL.
+func (m *CachingManager) expire() {
+	if m.options.TTL <= 0 {
+		return
+	}
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	cutoff := m.timeNow().Add(-m.options.TTL)
+	for service, entry := range m.entries {
+		if entry.Requested.Before(cutoff) {
+			delete(m.entries, service)
+			m.dirty = true
+			m.metrics.EvictedExpired.Inc(1)
+		}
+	}
+}
+
+func (m *CachingManager) maintenanceLoop() {
+	defer m.wg.Done()
+	var refresh, persist <-chan time.Time
+	if m.options.RefreshInterval > 0 {
+		ticker := time.NewTicker(m.options.RefreshInterval)
+		defer ticker.Stop()
+		refresh = ticker.C
+	}
+	if m.options.Dir != "" {
+		ticker := time.NewTicker(m.persistInterval)
+		defer ticker.Stop()
+		persist = ticker.C
+	}
+	for {
+		select {
+		case <-refresh:
+			m.refresh()
+		case <-persist:
+			m.persist()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+func (m *CachingManager) persist() {
+	m.expire()
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	if !m.dirty {
+		return
+	}
+	if err := m.save(); err != nil {
+		m.logger.Error("Failed to persist sampling strategies", zap.Error(err))
+		return
+	}
+	m.dirty = false
+}
+
+func (m *CachingManager) refresh() {
+	m.expire()
+	m.mux.RLock()
+	services := make([]string, 0, len(m.entries))
+	for service := range m.entries {
+		services = append(services, service)
+	}
+	m.mux.RUnlock()
+	for _, service := range services {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultFetchTimeout)
+		r, err := m.wrapped.GetSamplingStrategy(ctx, service)
+		cancel()
+		if err != nil {
+			m.metrics.RefreshFailures.Inc(1)
+			m.logger.Debug("Failed to refresh sampling strategy", zap.String("service", service), zap.Error(err))
+			continue
+		}
+		m.metrics.RefreshSuccess.Inc(1)
+		m.store(service, r, false)
+	}
+	m.updateGauges()
+}
+
+func (m *CachingManager) updateGauges() {
+	m.mux.RLock()
+	defer m.mux.RUnlock()
+	var oldest time.Time
+	for _, entry := range m.entries {
+		if oldest.IsZero() || entry.Fetched.Before(oldest) {
+			oldest = entry.Fetched
+		}
+	}
+	m.metrics.Entries.Update(int64(len(m.entries)))
+	if oldest.IsZero() {
+		m.metrics.Age.Update(0)
+	} else {
+		m.metrics.Age.Update(int64(m.timeNow().Sub(oldest) / time.Second))
+	}
+}
+
+func (m *CachingManager) load() error {
+	content, err := ioutil.ReadFile(filepath.Join(m.options.Dir, samplingCacheFile))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read cached sampling strategies: %w", err)
+	}
+	if err := json.Unmarshal(content, &m.entries); err != nil {
+		// a corrupted cache must not prevent the agent from starting
+		m.logger.Warn("Ignoring corrupted sampling strategies cache", zap.Error(err))
+		m.entries = make(map[string]cacheEntry)
+		return nil
+	}
+	now := m.timeNow()
+	for service, entry := range m.entries {
+		// entries persisted before request times were recorded count as requested now
+		if entry.Requested.IsZero() {
+			entry.Requested = now
+			m.entries[service] = entry
+		}
+	}
+	for m.options.MaxEntries > 0 && len(m.entries) > m.options.MaxEntries {
+		m.evictLeastRecentlyRequested()
+	}
+	m.logger.Info("Loaded cached sampling strategies", zap.Int("services", len(m.entries)))
+	return nil
+}
+
+// save must be called while holding the lock.
+func (m *CachingManager) save() error {
+	content, err := json.Marshal(m.entries)
+	if err != nil {
+		return err
+	}
+	tmp := filepath.Join(m.options.Dir, samplingCacheFileTmp)
+	if err := ioutil.WriteFile(tmp, content, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, filepath.Join(m.options.Dir, samplingCacheFile))
+}
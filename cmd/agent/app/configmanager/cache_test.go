@@ -0,0 +1,262 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package configmanager
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+type flakyManager struct {
+	mux      sync.Mutex
+	err      error
+	rate     float64
+	requests int
+}
+
+func (m *flakyManager) set(rate float64, err error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.rate, m.err = rate, err
+}
+
+func (m *flakyManager) getRequests() int {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	return m.requests
+}
+
+func (m *flakyManager) GetSamplingStrategy(_ context.Context, _ string) (*sampling.SamplingStrategyResponse, error) {
+	m.mux.Lock()
+	defer m.mux.Unlock()
+	m.requests++
+	if m.err != nil {
+		return nil, m.err
+	}
+	return &sampling.SamplingStrategyResponse{
+		StrategyType:          sampling.SamplingStrategyType_PROBABILISTIC,
+		ProbabilisticSampling: &sampling.ProbabilisticSamplingStrategy{SamplingRate: m.rate},
+	}, nil
+}
+
+func (m *flakyManager) GetBaggageRestrictions(_ context.Context, _ string) ([]*baggage.BaggageRestriction, error) {
+	return []*baggage.BaggageRestriction{{BaggageKey: "foo"}}, nil
+}
+
+func tempCacheDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "sampling-cache")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestCachingManagerFallback(t *testing.T) {
+	wrapped := &flakyManager{rate: 0.5}
+	mFactory := metricstest.NewFactory(time.Hour)
+	m, err := WrapWithCache(wrapped, CacheOptions{}, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, m.Close()) }()
+	now := time.Now()
+	m.timeNow = func() time.Time { return now }
+
+	r, err := m.GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, r.ProbabilisticSampling.SamplingRate)
+
+	wrapped.set(0, errors.New("collector unavailable"))
+	now = now.Add(time.Minute)
+	r, err = m.GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Equal(t, 0.5, r.ProbabilisticSampling.SamplingRate)
+
+	_, err = m.GetSamplingStrategy(context.Background(), "unknown")
+	assert.EqualError(t, err, "collector unavailable")
+
+	b, err := m.GetBaggageRestrictions(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Len(t, b, 1)
+
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "sampling.cache.served", Value: 1},
+		metricstest.ExpectedMetric{Name: "sampling.cache.misses", Value: 1},
+	)
+	mFactory.AssertGaugeMetrics(t,
+		metricstest.ExpectedMetric{Name: "sampling.cache.entries", Value: 1},
+		metricstest.ExpectedMetric{Name: "sampling.cache.age_seconds", Value: 60},
+	)
+}
+
+func TestCachingManagerRefresh(t *testing.T) {
+	wrapped := &flakyManager{rate: 0.5}
+	mFactory := metricstest.NewFactory(time.Hour)
+	m, err := WrapWithCache(wrapped, CacheOptions{RefreshInterval: 10 * time.Millisecond}, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, m.Close()) }()
+
+	_, err = m.GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+
+	// the strategy is kept up to date even when clients stop polling
+	wrapped.set(0.1, nil)
+	assert.Eventually(t, func() bool {
+		m.mux.RLock()
+		defer m.mux.RUnlock()
+		return m.entries["svc"].Strategy.ProbabilisticSampling.SamplingRate == 0.1
+	}, time.Second, 10*time.Millisecond)
+
+	wrapped.set(0, errors.New("collector unavailable"))
+	requests := wrapped.getRequests()
+	assert.Eventually(t, func() bool {
+		return wrapped.getRequests() > requests
+	}, time.Second, 10*time.Millisecond)
+	counters, _ := mFactory.Snapshot()
+	assert.True(t, counters["sampling.cache.refreshes|result=ok"] > 0)
+	assert.Eventually(t, func() bool {
+		counters, _ := mFactory.Snapshot()
+		return counters["sampling.cache.refreshes|result=err"] > 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestCachingManagerPersistence(t *testing.T) {
+	dir := tempCacheDir(t)
+	m, err := WrapWithCache(&flakyManager{rate: 0.5}, CacheOptions{Dir: dir}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	_, err = m.GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	require.NoError(t, m.Close())
+
+	// the agent restarts while the collector is down
+	mFactory := metricstest.NewFactory(time.Hour)
+	m, err = WrapWithCache(&flakyManager{err: errors.New("collector unavailable")}, CacheOptions{Dir: dir}, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, m.Close()) }()
+	mFactory.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "sampling.cache.entries", Value: 1})
+	r, err := m.GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Equal(t, sampling.SamplingStrategyType_PROBABILISTIC, r.StrategyType)
+	assert.Equal(t, 0.5, r.ProbabilisticSampling.SamplingRate)
+}
+
+func TestCachingManagerMaxEntries(t *testing.T) {
+	mFactory := metricstest.NewFactory(time.Hour)
+	m, err := WrapWithCache(&flakyManager{rate: 0.5}, CacheOptions{MaxEntries: 2}, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, m.Close()) }()
+	now := time.Now()
+	m.timeNow = func() time.Time { return now }
+
+	for _, service := range []string{"a", "b", "a", "c"} {
+		now = now.Add(time.Second)
+		_, err := m.GetSamplingStrategy(context.Background(), service)
+		require.NoError(t, err)
+	}
+	assert.Len(t, m.entries, 2)
+	assert.Contains(t, m.entries, "a")
+	assert.Contains(t, m.entries, "c")
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "sampling.cache.evictions", Tags: map[string]string{"cause": "full"}, Value: 1})
+	mFactory.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "sampling.cache.entries", Value: 2})
+}
+
+func TestCachingManagerTTL(t *testing.T) {
+	wrapped := &flakyManager{rate: 0.5}
+	mFactory := metricstest.NewFactory(time.Hour)
+	m, err := WrapWithCache(wrapped, CacheOptions{TTL: time.Hour}, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, m.Close()) }()
+	now := time.Now()
+	m.timeNow = func() time.Time { return now }
+
+	_, err = m.GetSamplingStrategy(context.Background(), "old")
+	require.NoError(t, err)
+	now = now.Add(50 * time.Minute)
+	_, err = m.GetSamplingStrategy(context.Background(), "recent")
+	require.NoError(t, err)
+
+	// background refreshes do not keep a service cached
+	now = now.Add(20 * time.Minute)
+	m.refresh()
+	requests := wrapped.getRequests()
+	m.refresh()
+	assert.Equal(t, requests+1, wrapped.getRequests(), "only the strategy still requested is refreshed")
+	assert.Len(t, m.entries, 1)
+	assert.Contains(t, m.entries, "recent")
+
+	// a refresh completing after the service expired does not bring it back
+	m.store("old", &sampling.SamplingStrategyResponse{}, false)
+	assert.NotContains(t, m.entries, "old")
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "sampling.cache.evictions", Tags: map[string]string{"cause": "expired"}, Value: 1})
+}
+
+func TestCachingManagerPersistsChanges(t *testing.T) {
+	dir := tempCacheDir(t)
+	wrapped := &flakyManager{rate: 0.5}
+	m, err := WrapWithCache(wrapped, CacheOptions{Dir: dir}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, m.Close()) }()
+	file := filepath.Join(dir, samplingCacheFile)
+
+	_, err = m.GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	// the file is written periodically rather than on every change
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err))
+	m.persist()
+	_, err = os.Stat(file)
+	require.NoError(t, err)
+
+	require.NoError(t, os.Remove(file))
+	_, err = m.GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	m.persist()
+	_, err = os.Stat(file)
+	assert.True(t, os.IsNotExist(err), "unchanged strategies are not written again")
+
+	wrapped.set(0.1, nil)
+	_, err = m.GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	m.persist()
+	_, err = os.Stat(file)
+	assert.NoError(t, err)
+}
+
+func TestCachingManagerCorruptedFile(t *testing.T) {
+	dir := tempCacheDir(t)
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, samplingCacheFile), []byte("{"), 0o600))
+	m, err := WrapWithCache(&flakyManager{}, CacheOptions{Dir: dir}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	assert.Empty(t, m.entries)
+	require.NoError(t, m.Close())
+}
+
+func TestCachingManagerInvalidDir(t *testing.T) {
+	file := filepath.Join(tempCacheDir(t), "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0o600))
+	_, err := WrapWithCache(&flakyManager{}, CacheOptions{Dir: file}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to create sampling cache directory")
+}
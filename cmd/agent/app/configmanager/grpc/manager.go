@@ -19,22 +19,31 @@ import (
 	"errors"
 
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/proto-gen/baggage_v1"
 	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
+// errBaggageNotConfigured is returned when the collector does not serve baggage restrictions.
+// It is not mapped to an empty list of restrictions, which clients would read as all baggage being denied.
+var errBaggageNotConfigured = errors.New("baggage restrictions are not configured on the collector")
+
 // SamplingManager returns sampling decisions from collector over gRPC.
 type SamplingManager struct {
-	client api_v2.SamplingManagerClient
+	client        api_v2.SamplingManagerClient
+	baggageClient baggage_v1.BaggageRestrictionManagerClient
 }
 
 // NewConfigManager creates gRPC sampling manager.
 func NewConfigManager(conn *grpc.ClientConn) *SamplingManager {
 	return &SamplingManager{
-		client: api_v2.NewSamplingManagerClient(conn),
+		client:        api_v2.NewSamplingManagerClient(conn),
+		baggageClient: baggage_v1.NewBaggageRestrictionManagerClient(conn),
 	}
 }
 
@@ -48,6 +57,21 @@ func (s *SamplingManager) GetSamplingStrategy(ctx context.Context, serviceName s
 }
 
 // GetBaggageRestrictions returns baggage restrictions from collector.
-func (s *SamplingManager) GetBaggageRestrictions(_ context.Context, _ string) ([]*baggage.BaggageRestriction, error) {
-	return nil, errors.New("baggage not implemented")
+func (s *SamplingManager) GetBaggageRestrictions(ctx context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	r, err := s.baggageClient.GetBaggageRestrictions(ctx, &baggage_v1.GetBaggageRestrictionsRequest{ServiceName: serviceName})
+	if status.Code(err) == codes.Unimplemented {
+		// the collector serves baggage restrictions only when started with a restrictions file
+		return nil, errBaggageNotConfigured
+	}
+	if err != nil {
+		return nil, err
+	}
+	restrictions := make([]*baggage.BaggageRestriction, len(r.Restrictions))
+	for i, restriction := range r.Restrictions {
+		restrictions[i] = &baggage.BaggageRestriction{
+			BaggageKey:     restriction.BaggageKey,
+			MaxValueLength: restriction.MaxValueLength,
+		}
+	}
+	return restrictions, nil
 }
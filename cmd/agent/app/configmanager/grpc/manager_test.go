@@ -23,8 +23,12 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/proto-gen/baggage_v1"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
@@ -58,10 +62,56 @@ func TestSamplingManager_GetSamplingStrategy_error(t *testing.T) {
 }
 
 func TestSamplingManager_GetBaggageRestrictions(t *testing.T) {
-	manager := NewConfigManager(nil)
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		baggage_v1.RegisterBaggageRestrictionManagerServer(s, &mockBaggageHandler{})
+	})
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	defer close(t, conn)
+	require.NoError(t, err)
+	defer s.GracefulStop()
+	manager := NewConfigManager(conn)
+	rest, err := manager.GetBaggageRestrictions(context.Background(), "foo")
+	require.NoError(t, err)
+	assert.Equal(t, []*baggage.BaggageRestriction{{BaggageKey: "foo", MaxValueLength: 10}}, rest)
+}
+
+func TestSamplingManager_GetBaggageRestrictions_error(t *testing.T) {
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {})
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	defer close(t, conn)
+	require.NoError(t, err)
+	defer s.GracefulStop()
+	manager := NewConfigManager(conn)
 	rest, err := manager.GetBaggageRestrictions(context.Background(), "foo")
 	require.Nil(t, rest)
-	assert.EqualError(t, err, "baggage not implemented")
+	assert.Equal(t, errBaggageNotConfigured, err)
+}
+
+func TestSamplingManager_GetBaggageRestrictions_unavailable(t *testing.T) {
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		baggage_v1.RegisterBaggageRestrictionManagerServer(s, &mockBaggageHandler{err: status.Error(codes.Unavailable, "overloaded")})
+	})
+	conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+	defer close(t, conn)
+	require.NoError(t, err)
+	defer s.GracefulStop()
+	manager := NewConfigManager(conn)
+	rest, err := manager.GetBaggageRestrictions(context.Background(), "foo")
+	require.Nil(t, rest)
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+type mockBaggageHandler struct {
+	err error
+}
+
+func (h *mockBaggageHandler) GetBaggageRestrictions(_ context.Context, params *baggage_v1.GetBaggageRestrictionsRequest) (*baggage_v1.GetBaggageRestrictionsResponse, error) {
+	if h.err != nil {
+		return nil, h.err
+	}
+	return &baggage_v1.GetBaggageRestrictionsResponse{
+		Restrictions: []*baggage_v1.BaggageRestriction{{BaggageKey: params.ServiceName, MaxValueLength: 10}},
+	}, nil
 }
 
 type mockSamplingHandler struct {
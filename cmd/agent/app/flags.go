@@ -22,6 +22,7 @@ import (
 
 	"github.com/spf13/viper"
 
+	"github.com/jaegertracing/jaeger/cmd/agent/app/servers/thriftunix"
 	"github.com/jaegertracing/jaeger/ports"
 )
 
@@ -31,9 +32,17 @@ const (
 	suffixServerMaxPacketSize    = "server-max-packet-size"
 	suffixServerSocketBufferSize = "server-socket-buffer-size"
 	suffixServerHostPort         = "server-host-port"
+	suffixServerUnixSocket       = "server-unix-socket"
+	suffixServerUnixSocketType   = "server-unix-socket-type"
+	suffixServerUnixSocketMode   = "server-unix-socket-mode"
 
 	processorPrefixFmt = "processor.%s-%s."
 	httpServerHostPort = "http-server.host-port"
+
+	httpServerSpansEnabled     = "http-server.spans.enabled"
+	httpServerSpansAllowRemote = "http-server.spans.allow-remote"
+	httpServerSpansMaxBodySize = "http-server.spans.max-body-size-mib"
+	httpServerCreditsConfig    = "http-server.credits.config-file"
 )
 
 var defaultProcessors = []struct {
@@ -52,6 +61,22 @@ func AddFlags(flags *flag.FlagSet) {
 		httpServerHostPort,
 		defaultHTTPServerHostPort,
 		"host:port of the http server (e.g. for /sampling point and /baggageRestrictions endpoint)")
+	flags.Bool(
+		httpServerSpansEnabled,
+		false,
+		"Accept spans in Jaeger Thrift binary (/api/traces) and Zipkin JSON v2 (/api/v2/spans) formats on the http server, e.g. spans too large for UDP packets")
+	flags.Bool(
+		httpServerSpansAllowRemote,
+		false,
+		"Accept spans on the http server from non-loopback clients")
+	flags.Int(
+		httpServerSpansMaxBodySize,
+		defaultSpansMaxBodySizeMiB,
+		"The maximum size in MiB of a decompressed span submission on the http server (0 means unlimited)")
+	flags.String(
+		httpServerCreditsConfig,
+		"",
+		"Path to a JSON file configuring the per-service accrual of credits served on the /credits endpoint to throttle debug spans of clients; the endpoint is disabled if empty")
 
 	for _, p := range defaultProcessors {
 		prefix := fmt.Sprintf(processorPrefixFmt, p.model, p.protocol)
@@ -60,6 +85,9 @@ func AddFlags(flags *flag.FlagSet) {
 		flags.Int(prefix+suffixServerMaxPacketSize, defaultMaxPacketSize, "max packet size for the UDP server")
 		flags.Int(prefix+suffixServerSocketBufferSize, 0, "socket buffer size for UDP packets in bytes")
 		flags.String(prefix+suffixServerHostPort, ":"+strconv.Itoa(p.port), "host:port for the UDP server")
+		flags.String(prefix+suffixServerUnixSocket, "", "path of a Unix domain socket to receive spans on, in addition to the UDP server host:port")
+		flags.String(prefix+suffixServerUnixSocketType, thriftunix.Datagram, "type of the Unix domain socket, datagram or stream (messages prefixed with a 4-byte big-endian length)")
+		flags.String(prefix+suffixServerUnixSocketMode, defaultUnixSocketMode, "octal permissions of the Unix domain socket file")
 	}
 }
 
@@ -73,10 +101,17 @@ func (b *Builder) InitFromViper(v *viper.Viper) *Builder {
 		p.Server.MaxPacketSize = v.GetInt(prefix + suffixServerMaxPacketSize)
 		p.Server.SocketBufferSize = v.GetInt(prefix + suffixServerSocketBufferSize)
 		p.Server.HostPort = portNumToHostPort(v.GetString(prefix + suffixServerHostPort))
+		p.Server.UnixSocket = v.GetString(prefix + suffixServerUnixSocket)
+		p.Server.UnixSocketType = v.GetString(prefix + suffixServerUnixSocketType)
+		p.Server.UnixSocketMode = v.GetString(prefix + suffixServerUnixSocketMode)
 		b.Processors = append(b.Processors, *p)
 	}
 
 	b.HTTPServer.HostPort = portNumToHostPort(v.GetString(httpServerHostPort))
+	b.HTTPServer.SpansEnabled = v.GetBool(httpServerSpansEnabled)
+	b.HTTPServer.SpansAllowRemote = v.GetBool(httpServerSpansAllowRemote)
+	b.HTTPServer.SpansMaxBodySize = int64(v.GetInt(httpServerSpansMaxBodySize)) * 1024 * 1024
+	b.HTTPServer.CreditsConfigFile = v.GetString(httpServerCreditsConfig)
 	return b
 }
 
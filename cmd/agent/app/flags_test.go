@@ -40,14 +40,43 @@ func TestBindFlags(t *testing.T) {
 		"--processor.jaeger-binary.server-max-packet-size=4242",
 		"--processor.jaeger-binary.server-queue-size=42",
 		"--processor.jaeger-binary.workers=42",
+		"--processor.jaeger-compact.server-unix-socket=/var/run/jaeger/agent.sock",
+		"--processor.jaeger-compact.server-unix-socket-type=stream",
+		"--http-server.spans.enabled=true",
+		"--http-server.spans.allow-remote=true",
+		"--http-server.spans.max-body-size-mib=2",
+		"--http-server.credits.config-file=/etc/jaeger/credits.json",
 	})
 	require.NoError(t, err)
 
 	b.InitFromViper(v)
 	assert.Equal(t, 3, len(b.Processors))
 	assert.Equal(t, ":8080", b.HTTPServer.HostPort)
+	assert.True(t, b.HTTPServer.SpansEnabled)
+	assert.True(t, b.HTTPServer.SpansAllowRemote)
+	assert.Equal(t, int64(2*1024*1024), b.HTTPServer.SpansMaxBodySize)
+	assert.Equal(t, "/etc/jaeger/credits.json", b.HTTPServer.CreditsConfigFile)
 	assert.Equal(t, ":1111", b.Processors[2].Server.HostPort)
 	assert.Equal(t, 4242, b.Processors[2].Server.MaxPacketSize)
 	assert.Equal(t, 42, b.Processors[2].Server.QueueSize)
 	assert.Equal(t, 42, b.Processors[2].Workers)
+	assert.Equal(t, "/var/run/jaeger/agent.sock", b.Processors[1].Server.UnixSocket)
+	assert.Equal(t, "stream", b.Processors[1].Server.UnixSocketType)
+	assert.Equal(t, "0660", b.Processors[1].Server.UnixSocketMode)
+	assert.Equal(t, "", b.Processors[2].Server.UnixSocket)
+}
+
+func TestBindFlagsDefaults(t *testing.T) {
+	v := viper.New()
+	b := &Builder{}
+	command := cobra.Command{}
+	flags := &flag.FlagSet{}
+	AddFlags(flags)
+	command.PersistentFlags().AddGoFlagSet(flags)
+	v.BindPFlags(command.PersistentFlags())
+
+	require.NoError(t, command.ParseFlags(nil))
+	b.InitFromViper(v)
+	assert.False(t, b.HTTPServer.SpansEnabled)
+	assert.False(t, b.HTTPServer.SpansAllowRemote)
 }
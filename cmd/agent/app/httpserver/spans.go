@@ -0,0 +1,167 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"fmt"
+	"html"
+	"mime"
+	"net"
+	"net/http"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/gorilla/mux"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
+	"github.com/jaegertracing/jaeger/model/converter/thrift/zipkin"
+	"github.com/jaegertracing/jaeger/pkg/httpbody"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+)
+
+var acceptedThriftFormats = map[string]struct{}{
+	"application/x-thrift":                 {},
+	"application/vnd.apache.thrift.binary": {},
+}
+
+// SpansHandlerParams contains parameters that must be passed to NewSpansHandler.
+type SpansHandlerParams struct {
+	Reporter       reporter.Reporter // required
+	MetricsFactory metrics.Factory   // required
+	Logger         *zap.Logger       // required
+
+	// MaxBodySize is the maximum size in bytes of a decompressed request body, 0 means unlimited.
+	MaxBodySize int64
+
+	// AllowRemote accepts spans from non-loopback clients.
+	AllowRemote bool
+}
+
+// SpansHandler implements endpoints used by local clients to submit spans that do not fit
+// into a UDP packet, in Jaeger Thrift binary or Zipkin JSON v2 format.
+type SpansHandler struct {
+	params  SpansHandlerParams
+	metrics struct {
+		// Number of good Jaeger Thrift batch requests
+		JaegerRequestSuccess metrics.Counter `metric:"http-server.requests" tags:"type=spans-jaeger"`
+
+		// Number of good Zipkin JSON v2 requests
+		ZipkinRequestSuccess metrics.Counter `metric:"http-server.requests" tags:"type=spans-zipkin"`
+
+		// Number of bad span requests
+		BadRequest metrics.Counter `metric:"http-server.errors" tags:"status=4xx,source=spans"`
+
+		// Number of span requests rejected because they did not come from a loopback address
+		Forbidden metrics.Counter `metric:"http-server.errors" tags:"status=4xx,source=spans-remote"`
+
+		// Number of span requests the reporter failed to forward
+		ReporterFailures metrics.Counter `metric:"http-server.errors" tags:"status=5xx,source=reporter"`
+	}
+}
+
+// NewSpansHandler creates new SpansHandler.
+func NewSpansHandler(params SpansHandlerParams) *SpansHandler {
+	h := &SpansHandler{params: params}
+	metrics.MustInit(&h.metrics, params.MetricsFactory, nil)
+	return h
+}
+
+// RegisterRoutes registers span submission handlers with Gorilla Router.
+func (h *SpansHandler) RegisterRoutes(router *mux.Router) {
+	router.HandleFunc("/api/traces", h.saveJaegerBatch).Methods(http.MethodPost)
+	router.HandleFunc("/api/v2/spans", h.saveZipkinSpansV2).Methods(http.MethodPost)
+}
+
+func (h *SpansHandler) readBody(w http.ResponseWriter, r *http.Request, accepted map[string]struct{}) ([]byte, bool) {
+	if !h.params.AllowRemote && !isLoopback(r.RemoteAddr) {
+		h.metrics.Forbidden.Inc(1)
+		http.Error(w, "Spans are only accepted from localhost", http.StatusForbidden)
+		return nil, false
+	}
+	bodyBytes, err := httpbody.Read(r, h.params.MaxBodySize)
+	if err != nil {
+		h.metrics.BadRequest.Inc(1)
+		httpbody.WriteError(w, err)
+		return nil, false
+	}
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		h.metrics.BadRequest.Inc(1)
+		http.Error(w, fmt.Sprintf("Cannot parse content type: %v", err), http.StatusBadRequest)
+		return nil, false
+	}
+	if _, ok := accepted[contentType]; !ok {
+		h.metrics.BadRequest.Inc(1)
+		http.Error(w, fmt.Sprintf("Unsupported content type: %v", html.EscapeString(contentType)), http.StatusBadRequest)
+		return nil, false
+	}
+	return bodyBytes, true
+}
+
+func (h *SpansHandler) saveJaegerBatch(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, ok := h.readBody(w, r, acceptedThriftFormats)
+	if !ok {
+		return
+	}
+	batch := &jaeger.Batch{}
+	if err := thrift.NewTDeserializer().Read(r.Context(), batch, bodyBytes); err != nil {
+		h.metrics.BadRequest.Inc(1)
+		http.Error(w, fmt.Sprintf(httpbody.UnableToReadErrFormat, err), http.StatusBadRequest)
+		return
+	}
+	if err := h.params.Reporter.EmitBatch(r.Context(), batch); err != nil {
+		h.reporterError(w, err)
+		return
+	}
+	h.metrics.JaegerRequestSuccess.Inc(1)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *SpansHandler) saveZipkinSpansV2(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, ok := h.readBody(w, r, map[string]struct{}{"application/json": {}})
+	if !ok {
+		return
+	}
+	spans, err := zipkin.DeserializeJSONV2(bodyBytes)
+	if err != nil {
+		h.metrics.BadRequest.Inc(1)
+		http.Error(w, fmt.Sprintf(httpbody.UnableToReadErrFormat, html.EscapeString(err.Error())), http.StatusBadRequest)
+		return
+	}
+	if len(spans) > 0 {
+		if err := h.params.Reporter.EmitZipkinBatch(r.Context(), spans); err != nil {
+			h.reporterError(w, err)
+			return
+		}
+	}
+	h.metrics.ZipkinRequestSuccess.Inc(1)
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func (h *SpansHandler) reporterError(w http.ResponseWriter, err error) {
+	h.metrics.ReporterFailures.Inc(1)
+	h.params.Logger.Error("Could not forward spans received over HTTP", zap.Error(err))
+	http.Error(w, fmt.Sprintf("Cannot submit spans: %v", err), http.StatusInternalServerError)
+}
+
+func isLoopback(remoteAddr string) bool {
+	host, _, err := net.SplitHostPort(remoteAddr)
+	if err != nil {
+		host = remoteAddr
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,160 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpserver
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/testutils"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+type failingReporter struct{}
+
+func (failingReporter) EmitZipkinBatch(context.Context, []*zipkincore.Span) error {
+	return errors.New("no collector")
+}
+
+func (failingReporter) EmitBatch(context.Context, *jaeger.Batch) error {
+	return errors.New("no collector")
+}
+
+func newSpansRouter(params SpansHandlerParams) *mux.Router {
+	r := mux.NewRouter()
+	NewSpansHandler(params).RegisterRoutes(r)
+	return r
+}
+
+func serializeBatch(t *testing.T, batch *jaeger.Batch) []byte {
+	body, err := thrift.NewTSerializer().Write(context.Background(), batch)
+	require.NoError(t, err)
+	return body
+}
+
+func post(router http.Handler, remoteAddr, path, contentType string, body []byte) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, path, bytes.NewReader(body))
+	req.RemoteAddr = remoteAddr
+	req.Header.Set("Content-Type", contentType)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestSpansHandlerJaegerBatch(t *testing.T) {
+	rep := testutils.NewInMemoryReporter()
+	mf := metricstest.NewFactory(time.Hour)
+	router := newSpansRouter(SpansHandlerParams{Reporter: rep, MetricsFactory: mf, Logger: zap.NewNop()})
+
+	large := string(make([]byte, 100000))
+	body := serializeBatch(t, &jaeger.Batch{
+		Process: &jaeger.Process{ServiceName: "svc"},
+		Spans:   []*jaeger.Span{{OperationName: large}},
+	})
+	rec := post(router, "127.0.0.1:1234", "/api/traces", "application/x-thrift", body)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, rep.Spans(), 1)
+	assert.Equal(t, large, rep.Spans()[0].OperationName)
+
+	rec = post(router, "[::1]:1234", "/api/traces", "application/vnd.apache.thrift.binary", body)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+
+	rec = post(router, "127.0.0.1:1234", "/api/traces", "application/x-thrift", []byte("bad"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = post(router, "127.0.0.1:1234", "/api/traces", "application/json", body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "Unsupported content type: application/json\n", rec.Body.String())
+	rec = post(router, "127.0.0.1:1234", "/api/traces", "application/x-thrift; =bad;", body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "http-server.requests", Tags: map[string]string{"type": "spans-jaeger"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "http-server.errors", Tags: map[string]string{"status": "4xx", "source": "spans"}, Value: 3},
+	)
+}
+
+func TestSpansHandlerZipkinV2(t *testing.T) {
+	rep := testutils.NewInMemoryReporter()
+	mf := metricstest.NewFactory(time.Hour)
+	router := newSpansRouter(SpansHandlerParams{Reporter: rep, MetricsFactory: mf, Logger: zap.NewNop()})
+
+	body := []byte(`[{"id":"1111111111111111", "traceId":"1111111111111111", "name":"foo", "localEndpoint": {"serviceName": "bar"}}]`)
+	rec := post(router, "127.0.0.1:1234", "/api/v2/spans", "application/json", body)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, rep.ZipkinSpans(), 1)
+	assert.Equal(t, "foo", rep.ZipkinSpans()[0].Name)
+
+	rec = post(router, "127.0.0.1:1234", "/api/v2/spans", "application/json", []byte("[]"))
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	rec = post(router, "127.0.0.1:1234", "/api/v2/spans", "application/json", []byte("[{}]"))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = post(router, "127.0.0.1:1234", "/api/v2/spans", "application/x-protobuf", body)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "http-server.requests", Tags: map[string]string{"type": "spans-zipkin"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "http-server.errors", Tags: map[string]string{"status": "4xx", "source": "spans"}, Value: 2},
+	)
+}
+
+func TestSpansHandlerRemoteClients(t *testing.T) {
+	body := serializeBatch(t, &jaeger.Batch{Process: &jaeger.Process{ServiceName: "svc"}})
+
+	mf := metricstest.NewFactory(time.Hour)
+	router := newSpansRouter(SpansHandlerParams{Reporter: testutils.NewInMemoryReporter(), MetricsFactory: mf, Logger: zap.NewNop()})
+	rec := post(router, "10.0.0.1:1234", "/api/traces", "application/x-thrift", body)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = post(router, "10.0.0.1:1234", "/api/v2/spans", "application/json", []byte("[]"))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "http-server.errors", Tags: map[string]string{"status": "4xx", "source": "spans-remote"}, Value: 2})
+
+	router = newSpansRouter(SpansHandlerParams{Reporter: testutils.NewInMemoryReporter(), MetricsFactory: mf, Logger: zap.NewNop(), AllowRemote: true})
+	rec = post(router, "10.0.0.1:1234", "/api/traces", "application/x-thrift", body)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+}
+
+func TestSpansHandlerMaxBodySize(t *testing.T) {
+	router := newSpansRouter(SpansHandlerParams{Reporter: testutils.NewInMemoryReporter(), MetricsFactory: metricstest.NewFactory(time.Hour), Logger: zap.NewNop(), MaxBodySize: 10})
+	body := serializeBatch(t, &jaeger.Batch{Process: &jaeger.Process{ServiceName: "some-service-name"}})
+	rec := post(router, "127.0.0.1:1234", "/api/traces", "application/x-thrift", body)
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestSpansHandlerReporterFailure(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	router := newSpansRouter(SpansHandlerParams{Reporter: failingReporter{}, MetricsFactory: mf, Logger: zap.NewNop()})
+
+	body := serializeBatch(t, &jaeger.Batch{Process: &jaeger.Process{ServiceName: "svc"}})
+	rec := post(router, "127.0.0.1:1234", "/api/traces", "application/x-thrift", body)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.Equal(t, "Cannot submit spans: no collector\n", rec.Body.String())
+
+	rec = post(router, "127.0.0.1:1234", "/api/v2/spans", "application/json", []byte(`[{"id":"1111111111111111", "traceId":"1111111111111111"}]`))
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "http-server.errors", Tags: map[string]string{"status": "5xx", "source": "reporter"}, Value: 2})
+}
@@ -22,18 +22,30 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/throttling"
 	"github.com/jaegertracing/jaeger/pkg/clientcfg/clientcfghttp"
 )
 
 // NewHTTPServer creates a new server that hosts an HTTP/JSON endpoint for clients
-// to query for sampling strategies and baggage restrictions.
-func NewHTTPServer(hostPort string, manager configmanager.ClientConfigManager, mFactory metrics.Factory) *http.Server {
+// to query for sampling strategies and baggage restrictions, and optionally for throttling
+// credits and to submit spans.
+func NewHTTPServer(
+	hostPort string,
+	manager configmanager.ClientConfigManager,
+	credits throttling.CreditsManager,
+	mFactory metrics.Factory,
+	spans *SpansHandler,
+) *http.Server {
 	handler := clientcfghttp.NewHTTPHandler(clientcfghttp.HTTPHandlerParams{
 		ConfigManager:          manager,
+		CreditsManager:         credits,
 		MetricsFactory:         mFactory,
 		LegacySamplingEndpoint: true,
 	})
 	r := mux.NewRouter()
 	handler.RegisterRoutes(r)
+	if spans != nil {
+		spans.RegisterRoutes(r)
+	}
 	return &http.Server{Addr: hostPort, Handler: r}
 }
@@ -0,0 +1,184 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processors
+
+import (
+	"context"
+	"errors"
+
+	"github.com/apache/thrift/lib/go/thrift"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/customtransport"
+)
+
+var errNotStructList = errors.New("expecting a list of structs")
+
+// PeekBatch reads the service name and the number of spans of the emitBatch or emitZipkinBatch
+// message in the packet, without decoding the spans, so that the packet can be rate limited
+// before it is queued. ok is false if the packet holds another message or cannot be read.
+//
+// The service of a Jaeger batch is the service of its process, the service of a Zipkin batch
+// is the first service found in the endpoints of the annotations of its spans.
+func PeekBatch(factory thrift.TProtocolFactory, packet []byte) (serviceName string, spans int, ok bool) {
+	ctx := context.Background()
+	protocol := factory.GetProtocol(&customtransport.TBufferedReadTransport{})
+	protocol.Transport().Write(packet)
+	name, _, _, err := protocol.ReadMessageBegin(ctx)
+	if err != nil {
+		return "", 0, false
+	}
+	switch name {
+	case "emitBatch":
+		serviceName, spans, err = peekJaegerBatch(ctx, protocol)
+	case "emitZipkinBatch":
+		serviceName, spans, err = peekZipkinBatch(ctx, protocol)
+	default:
+		return "", 0, false
+	}
+	return serviceName, spans, err == nil
+}
+
+// peekJaegerBatch reads the arguments of emitBatch up to the service of the process and the size of the span list.
+func peekJaegerBatch(ctx context.Context, p thrift.TProtocol) (serviceName string, spans int, err error) {
+	var process, spanList bool
+	err = readStruct(ctx, p, func(id int16, fieldType thrift.TType) (bool, error) {
+		if id != 1 || fieldType != thrift.STRUCT {
+			return false, thrift.SkipDefaultDepth(ctx, p, fieldType)
+		}
+		return true, readStruct(ctx, p, func(id int16, fieldType thrift.TType) (bool, error) {
+			switch {
+			case id == 1 && fieldType == thrift.STRUCT:
+				process = true
+				return spanList, readStruct(ctx, p, func(id int16, fieldType thrift.TType) (bool, error) {
+					if id == 1 && fieldType == thrift.STRING {
+						var err error
+						serviceName, err = p.ReadString(ctx)
+						return false, err
+					}
+					return false, thrift.SkipDefaultDepth(ctx, p, fieldType)
+				})
+			case id == 2 && fieldType == thrift.LIST:
+				spanList = true
+				elemType, size, err := p.ReadListBegin(ctx)
+				spans = size
+				if err != nil || process {
+					// clients write the process first, so the spans usually do not need to be read
+					return true, err
+				}
+				for i := 0; i < size; i++ {
+					if err := thrift.SkipDefaultDepth(ctx, p, elemType); err != nil {
+						return true, err
+					}
+				}
+				return false, p.ReadListEnd(ctx)
+			default:
+				return false, thrift.SkipDefaultDepth(ctx, p, fieldType)
+			}
+		})
+	})
+	return serviceName, spans, err
+}
+
+// peekZipkinBatch reads the arguments of emitZipkinBatch up to the first span with a service.
+func peekZipkinBatch(ctx context.Context, p thrift.TProtocol) (serviceName string, spans int, err error) {
+	err = readStruct(ctx, p, func(id int16, fieldType thrift.TType) (bool, error) {
+		if id != 1 || fieldType != thrift.LIST {
+			return false, thrift.SkipDefaultDepth(ctx, p, fieldType)
+		}
+		elemType, size, err := p.ReadListBegin(ctx)
+		if err != nil {
+			return true, err
+		}
+		if elemType != thrift.STRUCT {
+			return true, errNotStructList
+		}
+		spans = size
+		for i := 0; i < size && serviceName == ""; i++ {
+			if serviceName, err = peekZipkinSpan(ctx, p); err != nil {
+				return true, err
+			}
+		}
+		return true, nil
+	})
+	return serviceName, spans, err
+}
+
+// peekZipkinSpan reads a Zipkin span and returns the first service found in the hosts of its annotations
+// and binary annotations.
+func peekZipkinSpan(ctx context.Context, p thrift.TProtocol) (serviceName string, err error) {
+	err = readStruct(ctx, p, func(id int16, fieldType thrift.TType) (bool, error) {
+		var hostID int16
+		switch id {
+		case 6: // annotations
+			hostID = 3
+		case 8: // binary_annotations
+			hostID = 4
+		}
+		if hostID == 0 || fieldType != thrift.LIST || serviceName != "" {
+			return false, thrift.SkipDefaultDepth(ctx, p, fieldType)
+		}
+		elemType, size, err := p.ReadListBegin(ctx)
+		if err != nil {
+			return true, err
+		}
+		if elemType != thrift.STRUCT {
+			return true, errNotStructList
+		}
+		for i := 0; i < size; i++ {
+			err := readStruct(ctx, p, func(id int16, fieldType thrift.TType) (bool, error) {
+				if id != hostID || fieldType != thrift.STRUCT || serviceName != "" {
+					return false, thrift.SkipDefaultDepth(ctx, p, fieldType)
+				}
+				return false, readStruct(ctx, p, func(id int16, fieldType thrift.TType) (bool, error) {
+					if id == 3 && fieldType == thrift.STRING { // service_name
+						var err error
+						serviceName, err = p.ReadString(ctx)
+						return false, err
+					}
+					return false, thrift.SkipDefaultDepth(ctx, p, fieldType)
+				})
+			})
+			if err != nil {
+				return true, err
+			}
+		}
+		return false, p.ReadListEnd(ctx)
+	})
+	return serviceName, err
+}
+
+// readStruct reads the fields of a struct with readField, which must read or skip the value of the field,
+// until the end of the struct or until readField returns true because the rest of the struct is not needed.
+func readStruct(ctx context.Context, p thrift.TProtocol, readField func(id int16, fieldType thrift.TType) (bool, error)) error {
+	if _, err := p.ReadStructBegin(ctx); err != nil {
+		return err
+	}
+	for {
+		_, fieldType, id, err := p.ReadFieldBegin(ctx)
+		if err != nil {
+			return err
+		}
+		if fieldType == thrift.STOP {
+			return p.ReadStructEnd(ctx)
+		}
+		done, err := readField(id, fieldType)
+		if err != nil || done {
+			return err
+		}
+		if err := p.ReadFieldEnd(ctx); err != nil {
+			return err
+		}
+	}
+}
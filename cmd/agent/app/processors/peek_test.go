@@ -0,0 +1,175 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package processors
+
+import (
+	"context"
+	"testing"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/agent"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+func emitPacket(t *testing.T, factory thrift.TProtocolFactory, emit func(context.Context, *agent.AgentClient) error) []byte {
+	buf := thrift.NewTMemoryBuffer()
+	require.NoError(t, emit(context.Background(), agent.NewAgentClientFactory(buf, factory)))
+	return buf.Bytes()
+}
+
+// spansFirstPacket writes an emitBatch message with the spans of the batch before its process.
+func spansFirstPacket(t *testing.T, factory thrift.TProtocolFactory, b *jaeger.Batch) []byte {
+	ctx := context.Background()
+	buf := thrift.NewTMemoryBuffer()
+	p := factory.GetProtocol(buf)
+	require.NoError(t, p.WriteMessageBegin(ctx, "emitBatch", thrift.ONEWAY, 1))
+	require.NoError(t, p.WriteStructBegin(ctx, "emitBatch_args"))
+	require.NoError(t, p.WriteFieldBegin(ctx, "batch", thrift.STRUCT, 1))
+	require.NoError(t, p.WriteStructBegin(ctx, "Batch"))
+	require.NoError(t, p.WriteFieldBegin(ctx, "spans", thrift.LIST, 2))
+	require.NoError(t, p.WriteListBegin(ctx, thrift.STRUCT, len(b.Spans)))
+	for _, span := range b.Spans {
+		require.NoError(t, span.Write(ctx, p))
+	}
+	require.NoError(t, p.WriteListEnd(ctx))
+	require.NoError(t, p.WriteFieldEnd(ctx))
+	require.NoError(t, p.WriteFieldBegin(ctx, "process", thrift.STRUCT, 1))
+	require.NoError(t, b.Process.Write(ctx, p))
+	require.NoError(t, p.WriteFieldEnd(ctx))
+	require.NoError(t, p.WriteFieldStop(ctx))
+	require.NoError(t, p.WriteStructEnd(ctx))
+	require.NoError(t, p.WriteFieldEnd(ctx))
+	require.NoError(t, p.WriteFieldStop(ctx))
+	require.NoError(t, p.WriteStructEnd(ctx))
+	require.NoError(t, p.WriteMessageEnd(ctx))
+	require.NoError(t, p.Flush(ctx))
+	return buf.Bytes()
+}
+
+func TestPeekBatch(t *testing.T) {
+	jaegerBatch := &jaeger.Batch{
+		Process: &jaeger.Process{
+			ServiceName: "jaeger-svc",
+			Tags:        []*jaeger.Tag{{Key: "hostname", VType: jaeger.TagType_STRING, VStr: stringPtr("host")}},
+		},
+		Spans: []*jaeger.Span{{OperationName: "op1"}, {OperationName: "op2", Flags: 2}},
+	}
+	zipkinSpans := []*zipkincore.Span{
+		{Name: "no-endpoint"},
+		{
+			Name:        "span",
+			Annotations: []*zipkincore.Annotation{{Value: "cs"}},
+			BinaryAnnotations: []*zipkincore.BinaryAnnotation{
+				{Key: "lc", Host: &zipkincore.Endpoint{ServiceName: "zipkin-svc"}},
+			},
+		},
+		{
+			Name:        "other",
+			Annotations: []*zipkincore.Annotation{{Value: "sr", Host: &zipkincore.Endpoint{ServiceName: "other-svc"}}},
+		},
+	}
+	for name, factory := range map[string]thrift.TProtocolFactory{"compact": compactFactory, "binary": binaryFactory} {
+		t.Run(name, func(t *testing.T) {
+			testCases := []struct {
+				name        string
+				packet      []byte
+				serviceName string
+				spans       int
+				ok          bool
+			}{
+				{
+					name: "jaeger",
+					packet: emitPacket(t, factory, func(ctx context.Context, c *agent.AgentClient) error {
+						return c.EmitBatch(ctx, jaegerBatch)
+					}),
+					serviceName: "jaeger-svc",
+					spans:       2,
+					ok:          true,
+				},
+				{
+					name: "jaeger without spans",
+					packet: emitPacket(t, factory, func(ctx context.Context, c *agent.AgentClient) error {
+						return c.EmitBatch(ctx, &jaeger.Batch{Process: jaegerBatch.Process})
+					}),
+					serviceName: "jaeger-svc",
+					ok:          true,
+				},
+				{
+					name:        "jaeger spans before process",
+					packet:      spansFirstPacket(t, factory, jaegerBatch),
+					serviceName: "jaeger-svc",
+					spans:       2,
+					ok:          true,
+				},
+				{
+					name: "zipkin",
+					packet: emitPacket(t, factory, func(ctx context.Context, c *agent.AgentClient) error {
+						return c.EmitZipkinBatch(ctx, zipkinSpans)
+					}),
+					serviceName: "zipkin-svc",
+					spans:       3,
+					ok:          true,
+				},
+				{
+					name: "zipkin without endpoint",
+					packet: emitPacket(t, factory, func(ctx context.Context, c *agent.AgentClient) error {
+						return c.EmitZipkinBatch(ctx, zipkinSpans[:1])
+					}),
+					spans: 1,
+					ok:    true,
+				},
+				{
+					name: "truncated",
+					packet: emitPacket(t, factory, func(ctx context.Context, c *agent.AgentClient) error {
+						return c.EmitBatch(ctx, &jaeger.Batch{Process: jaegerBatch.Process})
+					})[:20],
+				},
+				{
+					name:   "garbage",
+					packet: []byte("not a thrift message"),
+				},
+			}
+			for _, tc := range testCases {
+				t.Run(tc.name, func(t *testing.T) {
+					serviceName, spans, ok := PeekBatch(factory, tc.packet)
+					assert.Equal(t, tc.ok, ok)
+					if tc.ok {
+						assert.Equal(t, tc.serviceName, serviceName)
+						assert.Equal(t, tc.spans, spans)
+					}
+				})
+			}
+		})
+	}
+}
+
+func TestPeekBatchOtherMessage(t *testing.T) {
+	ctx := context.Background()
+	buf := thrift.NewTMemoryBuffer()
+	p := compactFactory.GetProtocol(buf)
+	require.NoError(t, p.WriteMessageBegin(ctx, "getSamplingStrategy", thrift.CALL, 1))
+	require.NoError(t, p.WriteMessageEnd(ctx))
+	require.NoError(t, p.Flush(ctx))
+	_, _, ok := PeekBatch(compactFactory, buf.Bytes())
+	assert.False(t, ok)
+}
+
+func stringPtr(s string) *string {
+	return &s
+}
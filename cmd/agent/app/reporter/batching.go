@@ -0,0 +1,285 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/multierror"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+const (
+	defaultBatchMaxSpans = 500
+	defaultBatchMaxBytes = 1024 * 1024
+)
+
+// BatchingOptions controls how small Jaeger batches are coalesced before being reported.
+type BatchingOptions struct {
+	// FlushInterval is the maximum time spans are held before being reported. Batching is disabled when zero.
+	FlushInterval time.Duration
+	// MaxSpans is the number of spans that triggers an immediate flush of a coalesced batch.
+	MaxSpans int
+	// MaxBytes is the approximate serialized size that triggers an immediate flush of a coalesced batch.
+	MaxBytes int
+}
+
+// Enabled returns true if incoming batches should be coalesced.
+func (o BatchingOptions) Enabled() bool {
+	return o.FlushInterval > 0
+}
+
+type batchingMetrics struct {
+	// Number of incoming batches merged into coalesced batches
+	BatchesReceived metrics.Counter `metric:"rebatch.batches_received"`
+
+	// Number of coalesced batches flushed because they reached max spans
+	FlushedMaxSpans metrics.Counter `metric:"rebatch.batches_flushed" tags:"reason=max-spans"`
+
+	// Number of coalesced batches flushed because they reached max bytes
+	FlushedMaxBytes metrics.Counter `metric:"rebatch.batches_flushed" tags:"reason=max-bytes"`
+
+	// Number of coalesced batches flushed because the flush interval elapsed
+	FlushedInterval metrics.Counter `metric:"rebatch.batches_flushed" tags:"reason=interval"`
+
+	// Number of coalesced batches flushed on shutdown
+	FlushedClose metrics.Counter `metric:"rebatch.batches_flushed" tags:"reason=close"`
+
+	// Number of coalesced batches the wrapped reporter failed to emit
+	FlushErrors metrics.Counter `metric:"rebatch.flush_errors"`
+
+	// Number of spans waiting to be flushed
+	PendingSpans metrics.Gauge `metric:"rebatch.pending_spans"`
+}
+
+type pendingBatch struct {
+	batch *jaeger.Batch
+	bytes int
+}
+
+// BatchingReporter is a decorator that coalesces Jaeger batches of the same process
+// into larger batches before passing them to the wrapped reporter.
+type BatchingReporter struct {
+	wrapped Reporter
+	options BatchingOptions
+	logger  *zap.Logger
+	metrics batchingMetrics
+
+	mux     sync.Mutex
+	pending map[string]*pendingBatch
+	spans   int
+
+	// sizers measures the serialized size of spans outside of mux
+	sizers sync.Pool
+	// onFlushFailure is called with the number of spans of each coalesced batch that failed to be emitted
+	onFlushFailure func(spans int64)
+
+	shutdown chan struct{}
+	wg       sync.WaitGroup
+}
+
+// WrapWithBatching creates BatchingReporter. Options must be enabled.
+func WrapWithBatching(reporter Reporter, options BatchingOptions, mFactory metrics.Factory, logger *zap.Logger) *BatchingReporter {
+	if options.MaxSpans <= 0 {
+		options.MaxSpans = defaultBatchMaxSpans
+	}
+	if options.MaxBytes <= 0 {
+		options.MaxBytes = defaultBatchMaxBytes
+	}
+	r := &BatchingReporter{
+		wrapped:        reporter,
+		options:        options,
+		logger:         logger,
+		pending:        make(map[string]*pendingBatch),
+		onFlushFailure: func(int64) {},
+		shutdown:       make(chan struct{}),
+	}
+	r.sizers.New = func() interface{} {
+		buffer := thrift.NewTMemoryBuffer()
+		return &spanSizer{buffer: buffer, proto: thrift.NewTCompactProtocolConf(buffer, &thrift.TConfiguration{})}
+	}
+	metrics.MustInit(&r.metrics, mFactory.Namespace(metrics.NSOptions{Name: "reporter"}), nil)
+	r.wg.Add(1)
+	go r.flushLoop()
+	return r
+}
+
+// OnFlushFailure sets the function called with the number of spans of each coalesced batch
+// that the wrapped reporter failed to emit, e.g. to count them as dropped spans.
+// It must be called before the reporter is used.
+func (r *BatchingReporter) OnFlushFailure(onFlushFailure func(spans int64)) {
+	r.onFlushFailure = onFlushFailure
+}
+
+// EmitZipkinBatch delegates to underlying Reporter, Zipkin spans are not coalesced.
+func (r *BatchingReporter) EmitZipkinBatch(ctx context.Context, spans []*zipkincore.Span) error {
+	return r.wrapped.EmitZipkinBatch(ctx, spans)
+}
+
+// EmitBatch merges the batch into the pending batch of the same process, flushing it
+// to the underlying reporter once it reaches max spans or max bytes. It returns the errors
+// of these flushes; the batches flushed later in the background are only logged and counted.
+func (r *BatchingReporter) EmitBatch(ctx context.Context, batch *jaeger.Batch) error {
+	if batch == nil || len(batch.Spans) == 0 {
+		return r.wrapped.EmitBatch(ctx, batch)
+	}
+	r.metrics.BatchesReceived.Inc(1)
+	key := processKey(batch.Process)
+	sizes := r.spanSizes(ctx, batch.Spans)
+
+	var toFlush []*jaeger.Batch
+	var reasons []metrics.Counter
+	r.mux.Lock()
+	for i, span := range batch.Spans {
+		size := sizes[i]
+		p, ok := r.pending[key]
+		if ok && p.bytes+size > r.options.MaxBytes {
+			toFlush = append(toFlush, r.take(key))
+			reasons = append(reasons, r.metrics.FlushedMaxBytes)
+			ok = false
+		}
+		if !ok {
+			p = &pendingBatch{batch: &jaeger.Batch{Process: batch.Process}}
+			r.pending[key] = p
+		}
+		p.batch.Spans = append(p.batch.Spans, span)
+		p.bytes += size
+		r.spans++
+		if len(p.batch.Spans) >= r.options.MaxSpans {
+			toFlush = append(toFlush, r.take(key))
+			reasons = append(reasons, r.metrics.FlushedMaxSpans)
+		}
+	}
+	// client stats are cumulative, so the latest values represent all merged batches
+	if p, ok := r.pending[key]; ok {
+		p.batch.SeqNo, p.batch.Stats = batch.SeqNo, batch.Stats
+	} else if n := len(toFlush); n > 0 {
+		toFlush[n-1].SeqNo, toFlush[n-1].Stats = batch.SeqNo, batch.Stats
+	}
+	r.metrics.PendingSpans.Update(int64(r.spans))
+	r.mux.Unlock()
+
+	var errors []error
+	for i, b := range toFlush {
+		if err := r.emit(ctx, b, reasons[i]); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return multierror.Wrap(errors)
+}
+
+// spanSizer serializes spans to measure their size.
+type spanSizer struct {
+	buffer *thrift.TMemoryBuffer
+	proto  thrift.TProtocol
+}
+
+// spanSizes returns the serialized size of each span.
+func (r *BatchingReporter) spanSizes(ctx context.Context, spans []*jaeger.Span) []int {
+	sizer := r.sizers.Get().(*spanSizer)
+	defer r.sizers.Put(sizer)
+	sizes := make([]int, len(spans))
+	for i, span := range spans {
+		sizer.buffer.Reset()
+		if err := span.Write(ctx, sizer.proto); err != nil {
+			continue
+		}
+		sizer.proto.Flush(ctx)
+		sizes[i] = sizer.buffer.Len()
+	}
+	return sizes
+}
+
+// take removes the pending batch for the key. Must be called with mux held.
+func (r *BatchingReporter) take(key string) *jaeger.Batch {
+	p := r.pending[key]
+	delete(r.pending, key)
+	r.spans -= len(p.batch.Spans)
+	return p.batch
+}
+
+func (r *BatchingReporter) takeAll() []*jaeger.Batch {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	batches := make([]*jaeger.Batch, 0, len(r.pending))
+	for key := range r.pending {
+		batches = append(batches, r.take(key))
+	}
+	r.metrics.PendingSpans.Update(int64(r.spans))
+	return batches
+}
+
+func (r *BatchingReporter) emit(ctx context.Context, batch *jaeger.Batch, reason metrics.Counter) error {
+	reason.Inc(1)
+	err := r.wrapped.EmitBatch(ctx, batch)
+	if err != nil {
+		r.metrics.FlushErrors.Inc(1)
+		r.onFlushFailure(int64(len(batch.Spans)))
+		r.logger.Error("Could not emit coalesced batch", zap.Int("spans", len(batch.Spans)), zap.Error(err))
+	}
+	return err
+}
+
+func (r *BatchingReporter) flushAll(reason metrics.Counter) {
+	for _, b := range r.takeAll() {
+		r.emit(context.Background(), b, reason)
+	}
+}
+
+func (r *BatchingReporter) flushLoop() {
+	defer r.wg.Done()
+	ticker := time.NewTicker(r.options.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			r.flushAll(r.metrics.FlushedInterval)
+		case <-r.shutdown:
+			r.flushAll(r.metrics.FlushedClose)
+			return
+		}
+	}
+}
+
+// Close flushes pending batches and stops the background flush goroutine.
+func (r *BatchingReporter) Close() error {
+	close(r.shutdown)
+	r.wg.Wait()
+	return nil
+}
+
+// processKey identifies the process of a batch, so that only spans from the same process are merged.
+func processKey(process *jaeger.Process) string {
+	if process == nil {
+		return ""
+	}
+	tags := make([]string, 0, len(process.Tags))
+	for _, tag := range process.Tags {
+		tags = append(tags, fmt.Sprintf("%s=%d:%s:%v:%v:%d:%x",
+			tag.Key, tag.VType, tag.GetVStr(), tag.GetVDouble(), tag.GetVBool(), tag.GetVLong(), tag.VBinary))
+	}
+	sort.Strings(tags)
+	return process.ServiceName + "\x00" + strings.Join(tags, "\x00")
+}
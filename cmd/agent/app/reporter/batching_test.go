@@ -0,0 +1,206 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+type batchRecorder struct {
+	mux     sync.Mutex
+	batches []*jaeger.Batch
+	err     error
+}
+
+func (r *batchRecorder) EmitZipkinBatch(_ context.Context, _ []*zipkincore.Span) error {
+	return r.err
+}
+
+func (r *batchRecorder) EmitBatch(_ context.Context, batch *jaeger.Batch) error {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.batches = append(r.batches, batch)
+	return r.err
+}
+
+func (r *batchRecorder) getBatches() []*jaeger.Batch {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return append([]*jaeger.Batch(nil), r.batches...)
+}
+
+func smallBatch(service, clientUUID string, seqNo int64, spans int) *jaeger.Batch {
+	uuid := clientUUID
+	b := &jaeger.Batch{
+		Process: &jaeger.Process{
+			ServiceName: service,
+			Tags:        []*jaeger.Tag{{Key: "client-uuid", VType: jaeger.TagType_STRING, VStr: &uuid}},
+		},
+		SeqNo: &seqNo,
+	}
+	for i := 0; i < spans; i++ {
+		b.Spans = append(b.Spans, &jaeger.Span{OperationName: "op", SpanId: int64(i)})
+	}
+	return b
+}
+
+func TestBatchingReporterMergesPerProcess(t *testing.T) {
+	rec := &batchRecorder{}
+	mf := metricstest.NewFactory(time.Hour)
+	r := WrapWithBatching(rec, BatchingOptions{FlushInterval: time.Hour}, mf, zap.NewNop())
+
+	ctx := context.Background()
+	require.NoError(t, r.EmitBatch(ctx, smallBatch("svc", "1", 1, 2)))
+	require.NoError(t, r.EmitBatch(ctx, smallBatch("svc", "2", 1, 1)))
+	require.NoError(t, r.EmitBatch(ctx, smallBatch("svc", "1", 2, 3)))
+	require.NoError(t, r.EmitZipkinBatch(ctx, []*zipkincore.Span{{}}))
+	assert.Empty(t, rec.getBatches())
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "reporter.rebatch.pending_spans", Value: 6})
+
+	require.NoError(t, r.Close())
+	batches := rec.getBatches()
+	require.Len(t, batches, 2)
+	spans := map[string]int{}
+	for _, b := range batches {
+		assert.Equal(t, "svc", b.Process.ServiceName)
+		uuid := b.Process.Tags[0].GetVStr()
+		spans[uuid] = len(b.Spans)
+		if uuid == "1" {
+			assert.Equal(t, int64(2), b.GetSeqNo())
+		}
+	}
+	assert.Equal(t, map[string]int{"1": 5, "2": 1}, spans)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "reporter.rebatch.batches_received", Value: 3},
+		metricstest.ExpectedMetric{Name: "reporter.rebatch.batches_flushed", Tags: map[string]string{"reason": "close"}, Value: 2},
+	)
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "reporter.rebatch.pending_spans", Value: 0})
+}
+
+func TestBatchingReporterFlushesOnMaxSpans(t *testing.T) {
+	rec := &batchRecorder{}
+	mf := metricstest.NewFactory(time.Hour)
+	r := WrapWithBatching(rec, BatchingOptions{FlushInterval: time.Hour, MaxSpans: 4}, mf, zap.NewNop())
+	defer func() { assert.NoError(t, r.Close()) }()
+
+	require.NoError(t, r.EmitBatch(context.Background(), smallBatch("svc", "1", 1, 3)))
+	require.NoError(t, r.EmitBatch(context.Background(), smallBatch("svc", "1", 2, 3)))
+	batches := rec.getBatches()
+	require.Len(t, batches, 1)
+	assert.Len(t, batches[0].Spans, 4)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.rebatch.batches_flushed", Tags: map[string]string{"reason": "max-spans"}, Value: 1})
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "reporter.rebatch.pending_spans", Value: 2})
+}
+
+func TestBatchingReporterFlushesOnMaxBytes(t *testing.T) {
+	rec := &batchRecorder{}
+	mf := metricstest.NewFactory(time.Hour)
+	r := WrapWithBatching(rec, BatchingOptions{FlushInterval: time.Hour, MaxBytes: 30}, mf, zap.NewNop())
+	defer func() { assert.NoError(t, r.Close()) }()
+
+	require.NoError(t, r.EmitBatch(context.Background(), smallBatch("svc", "1", 1, 5)))
+	batches := rec.getBatches()
+	require.NotEmpty(t, batches)
+	for _, b := range batches {
+		assert.Less(t, len(b.Spans), 5)
+	}
+	counters, _ := mf.Snapshot()
+	assert.Equal(t, int64(len(batches)), counters["reporter.rebatch.batches_flushed|reason=max-bytes"])
+}
+
+func TestBatchingReporterFlushesOnInterval(t *testing.T) {
+	rec := &batchRecorder{err: errors.New("boom")}
+	mf := metricstest.NewFactory(time.Hour)
+	r := WrapWithBatching(rec, BatchingOptions{FlushInterval: 10 * time.Millisecond}, mf, zap.NewNop())
+	failedSpans := atomic.NewInt64(0)
+	r.OnFlushFailure(func(spans int64) { failedSpans.Add(spans) })
+	defer func() { assert.NoError(t, r.Close()) }()
+
+	require.NoError(t, r.EmitBatch(context.Background(), smallBatch("svc", "1", 1, 2)))
+	assert.Eventually(t, func() bool { return len(rec.getBatches()) == 1 }, time.Second, time.Millisecond)
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["reporter.rebatch.flush_errors"] == 1 &&
+			counters["reporter.rebatch.batches_flushed|reason=interval"] == 1
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, int64(2), failedSpans.Load())
+	assert.Error(t, r.EmitZipkinBatch(context.Background(), nil))
+}
+
+func TestBatchingReporterReturnsFlushErrors(t *testing.T) {
+	rec := &batchRecorder{err: errors.New("boom")}
+	r := WrapWithBatching(rec, BatchingOptions{FlushInterval: time.Hour, MaxSpans: 2}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	var failedSpans int64
+	r.OnFlushFailure(func(spans int64) { failedSpans += spans })
+	defer func() { assert.NoError(t, r.Close()) }()
+
+	require.NoError(t, r.EmitBatch(context.Background(), smallBatch("svc", "1", 1, 1)))
+	assert.EqualError(t, r.EmitBatch(context.Background(), smallBatch("svc", "1", 2, 4)), "[boom, boom]")
+	assert.Equal(t, int64(4), failedSpans)
+}
+
+func TestBatchingReporterConcurrentEmits(t *testing.T) {
+	rec := &batchRecorder{}
+	r := WrapWithBatching(rec, BatchingOptions{FlushInterval: time.Hour, MaxSpans: 10}, metricstest.NewFactory(time.Hour), zap.NewNop())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				assert.NoError(t, r.EmitBatch(context.Background(), smallBatch("svc", "1", int64(j), 3)))
+			}
+		}()
+	}
+	wg.Wait()
+	require.NoError(t, r.Close())
+	spans := 0
+	for _, b := range rec.getBatches() {
+		spans += len(b.Spans)
+	}
+	assert.Equal(t, 300, spans)
+}
+
+func TestBatchingReporterPassesEmptyBatches(t *testing.T) {
+	rec := &batchRecorder{}
+	r := WrapWithBatching(rec, BatchingOptions{FlushInterval: time.Hour}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	defer func() { assert.NoError(t, r.Close()) }()
+	require.NoError(t, r.EmitBatch(context.Background(), &jaeger.Batch{}))
+	assert.Len(t, rec.getBatches(), 1)
+}
+
+func TestProcessKey(t *testing.T) {
+	a, b := "a", "b"
+	p1 := &jaeger.Process{ServiceName: "svc", Tags: []*jaeger.Tag{{Key: "k1", VStr: &a}, {Key: "k2", VStr: &b}}}
+	a2, b2 := "a", "b"
+	p2 := &jaeger.Process{ServiceName: "svc", Tags: []*jaeger.Tag{{Key: "k2", VStr: &b2}, {Key: "k1", VStr: &a2}}}
+	assert.Equal(t, processKey(p1), processKey(p2))
+	assert.NotEqual(t, processKey(p1), processKey(&jaeger.Process{ServiceName: "svc"}))
+	assert.Equal(t, "", processKey(nil))
+}
@@ -54,7 +54,8 @@ type clientMetrics struct {
 	// Total count of spans dropped by clients because they were larger than max packet size.
 	TooLargeDroppedSpans metrics.Counter `metric:"spans_dropped" tags:"cause=too-large"`
 
-	// Total count of spans dropped by clients because they failed Thrift encoding or submission.
+	// Total count of spans dropped by clients because they failed Thrift encoding or submission,
+	// and of spans dropped by the agent because it failed to send the batches it coalesced.
 	FailedToEmitSpans metrics.Counter `metric:"spans_dropped" tags:"cause=send-failure"`
 }
 
@@ -121,6 +122,12 @@ func (r *ClientMetricsReporter) EmitBatch(ctx context.Context, batch *jaeger.Bat
 	return r.params.Reporter.EmitBatch(ctx, batch)
 }
 
+// CountFailedSpans counts spans accepted from the clients that the agent failed to send,
+// together with the spans the clients report as dropped because of send failures.
+func (r *ClientMetricsReporter) CountFailedSpans(spans int64) {
+	r.clientMetrics.FailedToEmitSpans.Inc(spans)
+}
+
 // Close stops background gc goroutine for client stats map.
 func (r *ClientMetricsReporter) Close() error {
 	if r.closed.CAS(false, true) {
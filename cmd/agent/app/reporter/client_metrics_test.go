@@ -203,6 +203,17 @@ func TestClientMetricsReporter_Jaeger(t *testing.T) {
 	})
 }
 
+func TestClientMetricsReporter_CountFailedSpans(t *testing.T) {
+	testClientMetrics(func(tr *clientMetricsTest) {
+		tr.r.CountFailedSpans(3)
+		tr.mb.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+			Name:  "client_stats.spans_dropped",
+			Tags:  map[string]string{"cause": "send-failure"},
+			Value: 3,
+		})
+	})
+}
+
 func TestClientMetricsReporter_ClientUUID(t *testing.T) {
 	id := "my-client-id"
 	tests := []struct {
@@ -17,6 +17,8 @@ package reporter
 import (
 	"flag"
 	"fmt"
+	"strconv"
+	"strings"
 
 	"github.com/spf13/viper"
 	"go.uber.org/zap"
@@ -30,8 +32,18 @@ const (
 	reporterType = "reporter.type"
 	// GRPC is name of gRPC reporter.
 	GRPC Type = "grpc"
+	// OTLP is name of the reporter exporting spans over OTLP/gRPC.
+	OTLP Type = "otlp"
 
 	agentTags = "agent.tags"
+
+	batchFlushInterval = "reporter.batch.flush-interval"
+	batchMaxSpans      = "reporter.batch.max-spans"
+	batchMaxBytes      = "reporter.batch.max-bytes"
+
+	rateLimitSpansPerSecond = "reporter.rate-limit.spans-per-second"
+	rateLimitBurst          = "reporter.rate-limit.burst"
+	rateLimitServices       = "reporter.rate-limit.services"
 )
 
 // Type defines type of reporter.
@@ -41,11 +53,19 @@ type Type string
 type Options struct {
 	ReporterType Type
 	AgentTags    map[string]string
+	Batching     BatchingOptions
+	RateLimits   RateLimitOptions
 }
 
 // AddFlags adds flags for Options.
 func AddFlags(flags *flag.FlagSet) {
-	flags.String(reporterType, string(GRPC), fmt.Sprintf("Reporter type to use e.g. %s", string(GRPC)))
+	flags.String(reporterType, string(GRPC), fmt.Sprintf("Reporter type to use e.g. %s, %s", string(GRPC), string(OTLP)))
+	flags.Duration(batchFlushInterval, 0, "The maximum time spans from small Jaeger batches are held to be coalesced per process into larger batches before being reported (0 disables re-batching)")
+	flags.Int(batchMaxSpans, defaultBatchMaxSpans, "The number of spans at which a coalesced batch is reported immediately")
+	flags.Int(batchMaxBytes, defaultBatchMaxBytes, "The approximate size in bytes at which a coalesced batch is reported immediately")
+	flags.Float64(rateLimitSpansPerSecond, 0, "The maximum number of spans per second accepted from each client service over UDP and Unix sockets. The packets of a service above its limit are dropped before they are queued (0 disables rate limiting)")
+	flags.Float64(rateLimitBurst, 0, "The maximum number of spans a client service can send at once, at least the number of spans per second of the service. It should be larger than the number of spans in the packets of the clients, a larger packet takes the whole burst")
+	flags.String(rateLimitServices, "", "Comma-separated service=spans-per-second overrides of the rate limit of specific client services, 0 exempts a service. Ex: frontend=1000,batch-job=10")
 	if !setupcontext.IsAllInOne() {
 		flags.String(agentTags, "", "One or more tags to be added to the Process tags of all spans passing through this agent. Ex: key1=value1,key2=${envVar:defaultValue}")
 	}
@@ -54,6 +74,16 @@ func AddFlags(flags *flag.FlagSet) {
 // InitFromViper initializes Options with properties retrieved from Viper.
 func (b *Options) InitFromViper(v *viper.Viper, logger *zap.Logger) *Options {
 	b.ReporterType = Type(v.GetString(reporterType))
+	b.Batching = BatchingOptions{
+		FlushInterval: v.GetDuration(batchFlushInterval),
+		MaxSpans:      v.GetInt(batchMaxSpans),
+		MaxBytes:      v.GetInt(batchMaxBytes),
+	}
+	b.RateLimits = RateLimitOptions{
+		SpansPerSecond: v.GetFloat64(rateLimitSpansPerSecond),
+		Burst:          v.GetFloat64(rateLimitBurst),
+		Services:       parseServiceRateLimits(v.GetString(rateLimitServices), logger),
+	}
 	if !setupcontext.IsAllInOne() {
 		if len(v.GetString(agentTags)) > 0 {
 			b.AgentTags = flags.ParseJaegerTags(v.GetString(agentTags))
@@ -61,3 +91,22 @@ func (b *Options) InitFromViper(v *viper.Viper, logger *zap.Logger) *Options {
 	}
 	return b
 }
+
+// parseServiceRateLimits parses comma-separated service=spans-per-second pairs, skipping invalid ones.
+func parseServiceRateLimits(value string, logger *zap.Logger) map[string]float64 {
+	if value == "" {
+		return nil
+	}
+	limits := make(map[string]float64)
+	for _, pair := range strings.Split(value, ",") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) == 2 && kv[0] != "" {
+			if rate, err := strconv.ParseFloat(kv[1], 64); err == nil && rate >= 0 {
+				limits[kv[0]] = rate
+				continue
+			}
+		}
+		logger.Warn("Ignoring invalid service rate limit, expecting service=spans-per-second", zap.String("value", pair))
+	}
+	return limits
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -46,6 +47,51 @@ func TestBindFlags_NoJaegerTags(t *testing.T) {
 	b.InitFromViper(v, zap.NewNop())
 	assert.Equal(t, Type("grpc"), b.ReporterType)
 	assert.Len(t, b.AgentTags, 0)
+	assert.False(t, b.Batching.Enabled())
+}
+
+func TestBindFlags_Batching(t *testing.T) {
+	v := viper.New()
+	command := cobra.Command{}
+	flags := &flag.FlagSet{}
+	AddFlags(flags)
+	command.PersistentFlags().AddGoFlagSet(flags)
+	v.BindPFlags(command.PersistentFlags())
+
+	err := command.ParseFlags([]string{
+		"--reporter.batch.flush-interval=200ms",
+		"--reporter.batch.max-spans=100",
+		"--reporter.batch.max-bytes=65536",
+	})
+	require.NoError(t, err)
+
+	b := new(Options).InitFromViper(v, zap.NewNop())
+	assert.Equal(t, BatchingOptions{FlushInterval: 200 * time.Millisecond, MaxSpans: 100, MaxBytes: 65536}, b.Batching)
+	assert.True(t, b.Batching.Enabled())
+}
+
+func TestBindFlags_RateLimits(t *testing.T) {
+	v := viper.New()
+	command := cobra.Command{}
+	flags := &flag.FlagSet{}
+	AddFlags(flags)
+	command.PersistentFlags().AddGoFlagSet(flags)
+	v.BindPFlags(command.PersistentFlags())
+
+	err := command.ParseFlags([]string{
+		"--reporter.rate-limit.spans-per-second=100",
+		"--reporter.rate-limit.burst=500",
+		"--reporter.rate-limit.services=frontend=1000, batch-job=0.5,invalid,bad=rate,=1,neg=-1",
+	})
+	require.NoError(t, err)
+
+	b := new(Options).InitFromViper(v, zap.NewNop())
+	assert.Equal(t, RateLimitOptions{
+		SpansPerSecond: 100,
+		Burst:          500,
+		Services:       map[string]float64{"frontend": 1000, "batch-job": 0.5},
+	}, b.RateLimits)
+	assert.True(t, b.RateLimits.Enabled())
 }
 
 func TestBindFlags(t *testing.T) {
@@ -18,7 +18,9 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"strings"
+	"time"
 
 	grpc_retry "github.com/grpc-ecosystem/go-grpc-middleware/retry"
 	"github.com/uber/jaeger-lib/metrics"
@@ -29,6 +31,7 @@ import (
 	"google.golang.org/grpc/resolver"
 	"google.golang.org/grpc/resolver/manual"
 
+	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/pkg/discovery"
@@ -46,6 +49,20 @@ type ConnBuilder struct {
 	DiscoveryMinPeers int
 	Notifier          discovery.Notifier
 	Discoverer        discovery.Discoverer
+
+	// DiscoveryFile is a file listing the collectors, watched for changes.
+	DiscoveryFile string
+	// DiscoveryDNS is a host:port resolved with A records, or a name resolved with SRV records.
+	DiscoveryDNS         string
+	DiscoveryDNSInterval time.Duration
+
+	discoveryCloser io.Closer
+
+	// Spool configures the optional on-disk spool of batches that could not be sent.
+	Spool SpoolOptions
+
+	// SamplingCache configures the cache of sampling strategies served when the collector is unreachable.
+	SamplingCache configmanager.CacheOptions
 }
 
 // NewConnBuilder creates a new grpc connection builder.
@@ -71,6 +88,9 @@ func (b *ConnBuilder) CreateConnection(logger *zap.Logger, mFactory metrics.Fact
 		dialOptions = append(dialOptions, grpc.WithInsecure())
 	}
 
+	if err := b.initDiscovery(logger); err != nil {
+		return nil, err
+	}
 	if b.Notifier != nil && b.Discoverer != nil {
 		logger.Info("Using external discovery service with roundrobin load balancer")
 		grpcResolver := grpcresolver.New(b.Notifier, b.Discoverer, logger, b.DiscoveryMinPeers)
@@ -97,6 +117,7 @@ func (b *ConnBuilder) CreateConnection(logger *zap.Logger, mFactory metrics.Fact
 	conn, err := grpc.Dial(dialTarget, dialOptions...)
 
 	if err != nil {
+		b.closeDiscovery()
 		return nil, err
 	}
 
@@ -123,3 +144,38 @@ func (b *ConnBuilder) CreateConnection(logger *zap.Logger, mFactory metrics.Fact
 
 	return conn, nil
 }
+
+// initDiscovery creates the file or DNS based discoverer, unless an external discovery service is provided.
+// closeDiscovery stops the discovery created by initDiscovery, if any.
+func (b *ConnBuilder) closeDiscovery() {
+	if b.discoveryCloser == nil {
+		return
+	}
+	b.discoveryCloser.Close()
+	b.Notifier, b.Discoverer, b.discoveryCloser = nil, nil, nil
+}
+
+func (b *ConnBuilder) initDiscovery(logger *zap.Logger) error {
+	if b.Notifier != nil && b.Discoverer != nil {
+		return nil
+	}
+	switch {
+	case b.DiscoveryFile != "" && b.DiscoveryDNS != "":
+		return errors.New("only one of file and DNS based collector discovery can be used")
+	case b.DiscoveryFile != "":
+		d, err := discovery.NewFileDiscoverer(b.DiscoveryFile, logger)
+		if err != nil {
+			return fmt.Errorf("failed to create file based collector discovery: %w", err)
+		}
+		logger.Info("Discovering collectors from file", zap.String("file", b.DiscoveryFile))
+		b.Notifier, b.Discoverer, b.discoveryCloser = d, d, d
+	case b.DiscoveryDNS != "":
+		d, err := discovery.NewDNSDiscoverer(b.DiscoveryDNS, b.DiscoveryDNSInterval, logger)
+		if err != nil {
+			return fmt.Errorf("failed to create DNS based collector discovery: %w", err)
+		}
+		logger.Info("Discovering collectors from DNS", zap.String("target", b.DiscoveryDNS), zap.Duration("interval", b.DiscoveryDNSInterval))
+		b.Notifier, b.Discoverer, b.discoveryCloser = d, d, d
+	}
+	return nil
+}
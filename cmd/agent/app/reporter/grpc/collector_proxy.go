@@ -30,30 +30,63 @@ import (
 // ProxyBuilder holds objects communicating with collector
 type ProxyBuilder struct {
 	reporter  *reporter.ClientMetricsReporter
-	manager   configmanager.ClientConfigManager
+	manager   *configmanager.CachingManager
 	conn      *grpc.ClientConn
 	tlsCloser io.Closer
+	spool     io.Closer
+	batching  io.Closer
+	discovery io.Closer
 }
 
 // NewCollectorProxy creates ProxyBuilder
-func NewCollectorProxy(builder *ConnBuilder, agentTags map[string]string, mFactory metrics.Factory, logger *zap.Logger) (*ProxyBuilder, error) {
+func NewCollectorProxy(builder *ConnBuilder, opts reporter.Options, mFactory metrics.Factory, logger *zap.Logger) (*ProxyBuilder, error) {
 	conn, err := builder.CreateConnection(logger, mFactory)
 	if err != nil {
 		return nil, err
 	}
 	grpcMetrics := mFactory.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{"protocol": "grpc"}})
-	r1 := NewReporter(conn, agentTags, logger)
-	r2 := reporter.WrapWithMetrics(r1, grpcMetrics)
+	r1 := NewReporter(conn, opts.AgentTags, logger)
+	var spoolCloser io.Closer
+	if builder.Spool.Dir != "" {
+		sp, err := newSpool(builder.Spool, r1.collector, grpcMetrics, logger)
+		if err != nil {
+			multicloser.Wrap(conn, builder.discoveryCloser).Close()
+			return nil, err
+		}
+		r1.spool = sp
+		spoolCloser = sp
+	}
+	var r2 reporter.Reporter = reporter.WrapWithMetrics(r1, grpcMetrics)
+	var batchingCloser io.Closer
+	var br *reporter.BatchingReporter
+	if opts.Batching.Enabled() {
+		br = reporter.WrapWithBatching(r2, opts.Batching, grpcMetrics, logger)
+		r2 = br
+		batchingCloser = br
+	}
+	manager, err := configmanager.WrapWithCache(
+		configmanager.WrapWithMetrics(grpcManager.NewConfigManager(conn), grpcMetrics),
+		builder.SamplingCache, grpcMetrics, logger)
+	if err != nil {
+		multicloser.Wrap(batchingCloser, spoolCloser, conn, builder.discoveryCloser).Close()
+		return nil, err
+	}
 	r3 := reporter.WrapWithClientMetrics(reporter.ClientMetricsReporterParams{
 		Reporter:       r2,
 		Logger:         logger,
 		MetricsFactory: mFactory,
 	})
+	if br != nil {
+		br.OnFlushFailure(r3.CountFailedSpans)
+	}
 	return &ProxyBuilder{
 		conn:      conn,
 		reporter:  r3,
-		manager:   configmanager.WrapWithMetrics(grpcManager.NewConfigManager(conn), grpcMetrics),
+		manager:   manager,
 		tlsCloser: &builder.TLS,
+		discovery: builder.discoveryCloser,
+		spool:     spoolCloser,
+		batching:  batchingCloser,
 	}, nil
 }
 
@@ -74,5 +107,5 @@ func (b ProxyBuilder) GetManager() configmanager.ClientConfigManager {
 
 // Close closes connections used by proxy.
 func (b ProxyBuilder) Close() error {
-	return multicloser.Wrap(b.reporter, b.tlsCloser, b.GetConn()).Close()
+	return multicloser.Wrap(b.manager, b.reporter, b.batching, b.spool, b.tlsCloser, b.GetConn(), b.discovery).Close()
 }
@@ -17,7 +17,10 @@ package grpc
 import (
 	"context"
 	"io"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -27,8 +30,10 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
 var _ io.Closer = (*ProxyBuilder)(nil)
@@ -46,7 +51,7 @@ func TestMultipleCollectors(t *testing.T) {
 	defer s2.Stop()
 
 	mFactory := metricstest.NewFactory(time.Microsecond)
-	proxy, err := NewCollectorProxy(&ConnBuilder{CollectorHostPorts: []string{addr1.String(), addr2.String()}}, nil, mFactory, zap.NewNop())
+	proxy, err := NewCollectorProxy(&ConnBuilder{CollectorHostPorts: []string{addr1.String(), addr2.String()}}, reporter.Options{}, mFactory, zap.NewNop())
 	require.NoError(t, err)
 	require.NotNil(t, proxy)
 	assert.NotNil(t, proxy.GetReporter())
@@ -81,3 +86,121 @@ func initializeGRPCTestServer(t *testing.T, beforeServe func(server *grpc.Server
 	}()
 	return server, lis.Addr()
 }
+
+func TestCollectorProxyWithSpool(t *testing.T) {
+	mFactory := metricstest.NewFactory(time.Hour)
+	proxy, err := NewCollectorProxy(&ConnBuilder{CollectorHostPorts: []string{"localhost:1"}, Spool: SpoolOptions{Dir: tempSpoolDir(t)}}, reporter.Options{}, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	err = proxy.GetReporter().EmitBatch(context.Background(), &jaeger.Batch{Spans: []*jaeger.Span{{OperationName: "op"}}, Process: &jaeger.Process{ServiceName: "service"}})
+	require.NoError(t, err)
+	mFactory.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spool.depth", Tags: map[string]string{"protocol": "grpc"}, Value: 1})
+	require.NoError(t, proxy.Close())
+}
+
+func TestCollectorProxyWithInvalidSpool(t *testing.T) {
+	dir := tempSpoolDir(t)
+	file := dir + "/file"
+	require.NoError(t, ioutil.WriteFile(file, nil, 0o600))
+	_, err := NewCollectorProxy(&ConnBuilder{CollectorHostPorts: []string{"localhost:1"}, Spool: SpoolOptions{Dir: file}}, reporter.Options{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	assert.Error(t, err)
+}
+
+func TestCollectorProxyWithBatching(t *testing.T) {
+	handler := &mockSpanHandler{}
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer s.Stop()
+	opts := reporter.Options{Batching: reporter.BatchingOptions{FlushInterval: time.Hour}}
+	proxy, err := NewCollectorProxy(&ConnBuilder{CollectorHostPorts: []string{addr.String()}}, opts, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		err = proxy.GetReporter().EmitBatch(context.Background(), &jaeger.Batch{Spans: []*jaeger.Span{{OperationName: "op"}}, Process: &jaeger.Process{ServiceName: "service"}})
+		require.NoError(t, err)
+	}
+	assert.Empty(t, handler.getRequests())
+	require.NoError(t, proxy.Close())
+	require.Len(t, handler.getRequests(), 1)
+	assert.Len(t, handler.getRequests()[0].Batch.Spans, 3)
+}
+
+func TestCollectorProxyWithFileDiscovery(t *testing.T) {
+	handler1 := &mockSpanHandler{}
+	s1, addr1 := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterCollectorServiceServer(s, handler1)
+	})
+	defer s1.Stop()
+	handler2 := &mockSpanHandler{}
+	s2, addr2 := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterCollectorServiceServer(s, handler2)
+	})
+	defer s2.Stop()
+
+	dir, err := ioutil.TempDir("", "discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	file := filepath.Join(dir, "collectors")
+	require.NoError(t, ioutil.WriteFile(file, []byte(addr1.String()), 0o600))
+
+	proxy, err := NewCollectorProxy(&ConnBuilder{DiscoveryFile: file, DiscoveryMinPeers: 1}, reporter.Options{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, proxy.Close()) }()
+
+	batch := &jaeger.Batch{Spans: []*jaeger.Span{{OperationName: "op"}}, Process: &jaeger.Process{ServiceName: "service"}}
+	require.NoError(t, proxy.GetReporter().EmitBatch(context.Background(), batch))
+	assert.Len(t, handler1.getRequests(), 1)
+
+	// the agent follows the collectors listed in the file without a restart
+	require.NoError(t, ioutil.WriteFile(file, []byte(addr2.String()), 0o600))
+	assert.Eventually(t, func() bool {
+		require.NoError(t, proxy.GetReporter().EmitBatch(context.Background(), batch))
+		return len(handler2.getRequests()) > 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCollectorProxyWithInvalidDiscovery(t *testing.T) {
+	_, err := NewCollectorProxy(&ConnBuilder{DiscoveryFile: "/does/not/exist"}, reporter.Options{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to create file based collector discovery")
+	_, err = NewCollectorProxy(&ConnBuilder{DiscoveryDNS: "jaeger-collector:14250"}, reporter.Options{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to create DNS based collector discovery")
+	_, err = NewCollectorProxy(&ConnBuilder{DiscoveryFile: "collectors", DiscoveryDNS: "jaeger-collector:14250"}, reporter.Options{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	assert.EqualError(t, err, "only one of file and DNS based collector discovery can be used")
+}
+
+func TestCollectorProxyStartsWhileDNSIsDown(t *testing.T) {
+	// names under .invalid never resolve
+	builder := &ConnBuilder{DiscoveryDNS: "jaeger-collector.invalid:14250", DiscoveryDNSInterval: time.Hour, DiscoveryMinPeers: 1}
+	proxy, err := NewCollectorProxy(builder, reporter.Options{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	assert.NoError(t, proxy.Close())
+}
+
+type mockSamplingHandler struct{}
+
+func (*mockSamplingHandler) GetSamplingStrategy(context.Context, *api_v2.SamplingStrategyParameters) (*api_v2.SamplingStrategyResponse, error) {
+	return &api_v2.SamplingStrategyResponse{StrategyType: api_v2.SamplingStrategyType_RATE_LIMITING}, nil
+}
+
+func TestCollectorProxyWithSamplingCache(t *testing.T) {
+	s, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		api_v2.RegisterSamplingManagerServer(s, &mockSamplingHandler{})
+	})
+	mFactory := metricstest.NewFactory(time.Hour)
+	proxy, err := NewCollectorProxy(&ConnBuilder{CollectorHostPorts: []string{addr.String()}}, reporter.Options{}, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, proxy.Close()) }()
+
+	r, err := proxy.GetManager().GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Equal(t, sampling.SamplingStrategyType_RATE_LIMITING, r.StrategyType)
+
+	// the last known strategy is served while the collector is down
+	s.Stop()
+	r, err = proxy.GetManager().GetSamplingStrategy(context.Background(), "svc")
+	require.NoError(t, err)
+	assert.Equal(t, sampling.SamplingStrategyType_RATE_LIMITING, r.StrategyType)
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "collector-proxy", Tags: map[string]string{"protocol": "grpc", "result": "err", "endpoint": "sampling"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "sampling.cache.served", Tags: map[string]string{"protocol": "grpc"}, Value: 1},
+	)
+}
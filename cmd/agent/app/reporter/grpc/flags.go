@@ -17,18 +17,36 @@ package grpc
 import (
 	"flag"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
+	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 )
 
 const (
-	gRPCPrefix        = "reporter.grpc"
-	collectorHostPort = gRPCPrefix + ".host-port"
-	retry             = gRPCPrefix + ".retry.max"
-	defaultMaxRetry   = 3
-	discoveryMinPeers = gRPCPrefix + ".discovery.min-peers"
+	gRPCPrefix         = "reporter.grpc"
+	collectorHostPort  = gRPCPrefix + ".host-port"
+	retry              = gRPCPrefix + ".retry.max"
+	defaultMaxRetry    = 3
+	defaultDNSInterval = 30 * time.Second
+	discoveryMinPeers  = gRPCPrefix + ".discovery.min-peers"
+	discoveryFile      = gRPCPrefix + ".discovery.file"
+	discoveryDNS       = gRPCPrefix + ".discovery.dns"
+	discoveryDNSInt    = gRPCPrefix + ".discovery.dns-interval"
+	spoolDir           = gRPCPrefix + ".spool.dir"
+	spoolMaxSize       = gRPCPrefix + ".spool.max-size-mib"
+	spoolMaxAge        = gRPCPrefix + ".spool.max-age"
+	spoolMaxBackoff    = gRPCPrefix + ".spool.max-backoff"
+	samplingCacheDir   = gRPCPrefix + ".sampling.cache-dir"
+	samplingRefresh    = gRPCPrefix + ".sampling.refresh-interval"
+	samplingMaxEntries = gRPCPrefix + ".sampling.cache-max-entries"
+	samplingTTL        = gRPCPrefix + ".sampling.cache-ttl"
+
+	defaultSamplingRefresh    = time.Minute
+	defaultSamplingMaxEntries = 1000
+	defaultSamplingTTL        = 24 * time.Hour
 )
 
 var tlsFlagsConfig = tlscfg.ClientFlagsConfig{
@@ -42,6 +60,17 @@ func AddFlags(flags *flag.FlagSet) {
 	flags.Uint(retry, defaultMaxRetry, "Sets the maximum number of retries for a call")
 	flags.Int(discoveryMinPeers, 3, "Max number of collectors to which the agent will try to connect at any given time")
 	flags.String(collectorHostPort, "", "Comma-separated string representing host:port of a static list of collectors to connect to directly")
+	flags.String(discoveryFile, "", "Path to a file listing host:port of collectors (one per line or comma-separated), reloaded when it changes; takes precedence over the static list")
+	flags.String(discoveryDNS, "", "DNS name of the collectors, either host:port resolved with A records or a name like _grpc._tcp.jaeger-collector resolved with SRV records; takes precedence over the static list")
+	flags.Duration(discoveryDNSInt, defaultDNSInterval, "How often the collectors DNS name is resolved again")
+	flags.String(spoolDir, "", "Directory where batches that could not be sent to the collector because it was unavailable, overloaded or too slow are spooled and retried later; spooling is disabled if empty")
+	flags.Int(spoolMaxSize, defaultSpoolSize/1024/1024, "The maximum total size in MiB of spooled batches; the oldest batches are discarded first")
+	flags.Duration(spoolMaxAge, defaultSpoolAge, "The maximum age of a spooled batch before it is discarded (0 keeps batches until sent)")
+	flags.Duration(spoolMaxBackoff, defaultMaxBackoff, "The maximum delay between attempts to re-send spooled batches")
+	flags.String(samplingCacheDir, "", "Directory where the last known sampling strategies are persisted, so they can be served after a restart while the collector is unreachable; strategies are only cached in memory if empty")
+	flags.Duration(samplingRefresh, defaultSamplingRefresh, "How often the cached sampling strategies are fetched from the collector in the background (0 disables background refreshes)")
+	flags.Int(samplingMaxEntries, defaultSamplingMaxEntries, "The maximum number of cached sampling strategies; the strategy of the service requested least recently is evicted first (0 means unlimited)")
+	flags.Duration(samplingTTL, defaultSamplingTTL, "How long the cached sampling strategy of a service that clients no longer request is kept and refreshed (0 keeps strategies forever)")
 	tlsFlagsConfig.AddFlags(flags)
 }
 
@@ -54,5 +83,20 @@ func (b *ConnBuilder) InitFromViper(v *viper.Viper) *ConnBuilder {
 	b.MaxRetry = uint(v.GetInt(retry))
 	b.TLS = tlsFlagsConfig.InitFromViper(v)
 	b.DiscoveryMinPeers = v.GetInt(discoveryMinPeers)
+	b.DiscoveryFile = v.GetString(discoveryFile)
+	b.DiscoveryDNS = v.GetString(discoveryDNS)
+	b.DiscoveryDNSInterval = v.GetDuration(discoveryDNSInt)
+	b.Spool = SpoolOptions{
+		Dir:        v.GetString(spoolDir),
+		MaxSize:    int64(v.GetInt(spoolMaxSize)) * 1024 * 1024,
+		MaxAge:     v.GetDuration(spoolMaxAge),
+		MaxBackoff: v.GetDuration(spoolMaxBackoff),
+	}
+	b.SamplingCache = configmanager.CacheOptions{
+		Dir:             v.GetString(samplingCacheDir),
+		RefreshInterval: v.GetDuration(samplingRefresh),
+		MaxEntries:      v.GetInt(samplingMaxEntries),
+		TTL:             v.GetDuration(samplingTTL),
+	}
 	return b
 }
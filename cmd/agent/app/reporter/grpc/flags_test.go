@@ -17,24 +17,40 @@ package grpc
 import (
 	"flag"
 	"testing"
+	"time"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
 )
 
 func TestBindFlags(t *testing.T) {
+	defaultSpool := SpoolOptions{MaxSize: defaultSpoolSize, MaxAge: defaultSpoolAge, MaxBackoff: defaultMaxBackoff}
+	defaultCache := configmanager.CacheOptions{RefreshInterval: defaultSamplingRefresh, MaxEntries: defaultSamplingMaxEntries, TTL: defaultSamplingTTL}
 	tests := []struct {
 		cOpts    []string
 		expected *ConnBuilder
 	}{
 		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111", "--reporter.grpc.retry.max=15"},
-			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: 15, DiscoveryMinPeers: 3}},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: 15, DiscoveryMinPeers: 3, DiscoveryDNSInterval: defaultDNSInterval, Spool: defaultSpool, SamplingCache: defaultCache}},
 		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111,localhost:2222"},
-			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111", "localhost:2222"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3}},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111", "localhost:2222"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNSInterval: defaultDNSInterval, Spool: defaultSpool, SamplingCache: defaultCache}},
 		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111,localhost:2222", "--reporter.grpc.discovery.min-peers=5"},
-			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111", "localhost:2222"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 5}},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111", "localhost:2222"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 5, DiscoveryDNSInterval: defaultDNSInterval, Spool: defaultSpool, SamplingCache: defaultCache}},
+		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111", "--reporter.grpc.spool.dir=/tmp/spool", "--reporter.grpc.spool.max-size-mib=10", "--reporter.grpc.spool.max-age=1h", "--reporter.grpc.spool.max-backoff=5s"},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNSInterval: defaultDNSInterval,
+				Spool: SpoolOptions{Dir: "/tmp/spool", MaxSize: 10 * 1024 * 1024, MaxAge: time.Hour, MaxBackoff: 5 * time.Second}, SamplingCache: defaultCache}},
+		{cOpts: []string{"--reporter.grpc.discovery.file=/etc/jaeger/collectors"},
+			expected: &ConnBuilder{MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryFile: "/etc/jaeger/collectors", DiscoveryDNSInterval: defaultDNSInterval, Spool: defaultSpool, SamplingCache: defaultCache}},
+		{cOpts: []string{"--reporter.grpc.discovery.dns=_grpc._tcp.jaeger-collector", "--reporter.grpc.discovery.dns-interval=1m"},
+			expected: &ConnBuilder{MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNS: "_grpc._tcp.jaeger-collector", DiscoveryDNSInterval: time.Minute, Spool: defaultSpool, SamplingCache: defaultCache}},
+		{cOpts: []string{"--reporter.grpc.host-port=localhost:1111", "--reporter.grpc.sampling.cache-dir=/tmp/sampling", "--reporter.grpc.sampling.refresh-interval=0",
+			"--reporter.grpc.sampling.cache-max-entries=10", "--reporter.grpc.sampling.cache-ttl=1h"},
+			expected: &ConnBuilder{CollectorHostPorts: []string{"localhost:1111"}, MaxRetry: defaultMaxRetry, DiscoveryMinPeers: 3, DiscoveryDNSInterval: defaultDNSInterval, Spool: defaultSpool,
+				SamplingCache: configmanager.CacheOptions{Dir: "/tmp/sampling", MaxEntries: 10, TTL: time.Hour}}},
 	}
 	for _, test := range tests {
 		v := viper.New()
@@ -36,6 +36,7 @@ type Reporter struct {
 	agentTags []model.KeyValue
 	logger    *zap.Logger
 	sanitizer zipkin2.Sanitizer
+	spool     *spool
 }
 
 // NewReporter creates gRPC reporter.
@@ -68,14 +69,41 @@ func (r *Reporter) EmitZipkinBatch(ctx context.Context, zSpans []*zipkincore.Spa
 func (r *Reporter) send(ctx context.Context, spans []*model.Span, process *model.Process) error {
 	spans, process = addProcessTags(spans, process, r.agentTags)
 	batch := model.Batch{Spans: spans, Process: process}
+	if r.spool != nil && !r.spool.empty() {
+		// the batches are sent in order, so new batches wait behind the spooled ones
+		return r.spoolBatch(batch, nil)
+	}
 	req := &api_v2.PostSpansRequest{Batch: batch}
 	_, err := r.collector.PostSpans(ctx, req)
 	if err != nil {
+		if r.spool != nil {
+			if !isRetryable(err) {
+				r.spool.reject(err)
+				return err
+			}
+			return r.spoolBatch(batch, err)
+		}
 		r.logger.Error("Could not send spans over gRPC", zap.Error(err))
 	}
 	return err
 }
 
+// spoolBatch stores the batch in the spool, and returns the send error if the batch cannot be spooled.
+func (r *Reporter) spoolBatch(batch model.Batch, sendErr error) error {
+	if err := r.spool.put(batch); err != nil {
+		r.logger.Error("Could not spool batch to disk", zap.Error(err))
+		if sendErr == nil {
+			return err
+		}
+		r.logger.Error("Could not send spans over gRPC", zap.Error(sendErr))
+		return sendErr
+	}
+	if sendErr != nil {
+		r.logger.Warn("Could not send spans over gRPC, batch spooled to disk", zap.Error(sendErr))
+	}
+	return nil
+}
+
 // addTags appends jaeger tags for the agent to every span it sends to the collector.
 func addProcessTags(spans []*model.Span, process *model.Process, agentTags []model.KeyValue) ([]*model.Span, *model.Process) {
 	if len(agentTags) == 0 {
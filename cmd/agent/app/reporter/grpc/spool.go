@@ -0,0 +1,356 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+)
+
+const (
+	spoolFileSuffix   = ".batch"
+	spoolTmpSuffix    = ".tmp"
+	spoolSendTimeout  = 5 * time.Second
+	spoolMinBackoff   = time.Second
+	defaultSpoolSize  = 100 * 1024 * 1024
+	defaultSpoolAge   = 24 * time.Hour
+	defaultMaxBackoff = 30 * time.Second
+)
+
+// SpoolOptions configures the on-disk spool of batches that could not be sent to the collector.
+type SpoolOptions struct {
+	// Dir is the directory where failed batches are stored. The spool is disabled when empty.
+	Dir string
+	// MaxSize is the maximum total size of spooled batches in bytes; the oldest batches are evicted first.
+	MaxSize int64
+	// MaxAge is the maximum age of a spooled batch; older batches are discarded instead of being retried.
+	MaxAge time.Duration
+	// MaxBackoff is the maximum delay between retries while the collector is unreachable.
+	MaxBackoff time.Duration
+}
+
+type spoolMetrics struct {
+	// Number of batches currently stored in the spool
+	Depth metrics.Gauge `metric:"spool.depth"`
+
+	// Total size in bytes of batches currently stored in the spool
+	Bytes metrics.Gauge `metric:"spool.bytes"`
+
+	// Number of batches written to the spool
+	Spooled metrics.Counter `metric:"spool.batches" tags:"result=spooled"`
+
+	// Number of spooled batches successfully re-sent to collector
+	Sent metrics.Counter `metric:"spool.batches" tags:"result=sent"`
+
+	// Number of spooled batches discarded because they exceeded max age
+	Expired metrics.Counter `metric:"spool.batches" tags:"result=expired"`
+
+	// Number of spooled batches discarded to stay within max size
+	Evicted metrics.Counter `metric:"spool.batches" tags:"result=evicted"`
+
+	// Number of batches that could not be written to or read from the spool
+	Errors metrics.Counter `metric:"spool.batches" tags:"result=err"`
+
+	// Number of batches discarded because the collector rejected them with an error that retrying cannot fix
+	Rejected metrics.Counter `metric:"spool.batches" tags:"result=rejected"`
+
+	// Number of failed attempts to re-send spooled batches
+	Retries metrics.Counter `metric:"spool.retries"`
+}
+
+type spoolEntry struct {
+	seq     uint64
+	size    int64
+	created time.Time
+}
+
+// spool stores batches on disk and re-sends them to the collector in the order they were stored.
+// Only the batches that failed with a retryable error are spooled, see isRetryable.
+type spool struct {
+	options   SpoolOptions
+	collector api_v2.CollectorServiceClient
+	logger    *zap.Logger
+	metrics   spoolMetrics
+
+	mux     sync.Mutex
+	entries []spoolEntry
+	bytes   int64
+	nextSeq uint64
+
+	notify chan struct{}
+	done   chan struct{}
+	wg     sync.WaitGroup
+}
+
+func newSpool(options SpoolOptions, collector api_v2.CollectorServiceClient, mFactory metrics.Factory, logger *zap.Logger) (*spool, error) {
+	if options.MaxSize <= 0 {
+		options.MaxSize = defaultSpoolSize
+	}
+	if options.MaxBackoff < spoolMinBackoff {
+		options.MaxBackoff = spoolMinBackoff
+	}
+	if err := os.MkdirAll(options.Dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create spool directory: %w", err)
+	}
+	s := &spool{
+		options:   options,
+		collector: collector,
+		logger:    logger,
+		notify:    make(chan struct{}, 1),
+		done:      make(chan struct{}),
+	}
+	metrics.MustInit(&s.metrics, mFactory.Namespace(metrics.NSOptions{Name: "reporter"}), nil)
+	if err := s.restore(); err != nil {
+		return nil, err
+	}
+	logger.Info("Spooling failed batches to disk",
+		zap.String("dir", options.Dir),
+		zap.Int("batches", len(s.entries)),
+		zap.Int64("bytes", s.bytes))
+	s.wg.Add(1)
+	go s.retryLoop()
+	return s, nil
+}
+
+// restore loads the batches left over by a previous run of the agent.
+func (s *spool) restore() error {
+	files, err := ioutil.ReadDir(s.options.Dir)
+	if err != nil {
+		return fmt.Errorf("failed to read spool directory: %w", err)
+	}
+	for _, f := range files {
+		name := f.Name()
+		if strings.HasSuffix(name, spoolTmpSuffix) {
+			os.Remove(filepath.Join(s.options.Dir, name))
+			continue
+		}
+		if !strings.HasSuffix(name, spoolFileSuffix) {
+			continue
+		}
+		seq, err := strconv.ParseUint(strings.TrimSuffix(name, spoolFileSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		s.entries = append(s.entries, spoolEntry{seq: seq, size: f.Size(), created: f.ModTime()})
+		s.bytes += f.Size()
+		if seq >= s.nextSeq {
+			s.nextSeq = seq + 1
+		}
+	}
+	sort.Slice(s.entries, func(i, j int) bool { return s.entries[i].seq < s.entries[j].seq })
+	s.evict(0)
+	s.updateGauges()
+	return nil
+}
+
+func (s *spool) path(seq uint64) string {
+	return filepath.Join(s.options.Dir, fmt.Sprintf("%020d%s", seq, spoolFileSuffix))
+}
+
+// isRetryable returns true if the error sending a batch is transient, e.g. the collector is unreachable,
+// overloaded or too slow. Other errors, such as an invalid batch, fail again on every retry.
+func isRetryable(err error) bool {
+	switch status.Code(err) {
+	case codes.Unavailable, codes.DeadlineExceeded, codes.ResourceExhausted:
+		return true
+	default:
+		return false
+	}
+}
+
+// empty returns true if no batch is waiting to be re-sent.
+func (s *spool) empty() bool {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return len(s.entries) == 0
+}
+
+// reject counts a batch discarded because of a non-retryable error.
+func (s *spool) reject(err error) {
+	s.metrics.Rejected.Inc(1)
+	s.logger.Error("Discarding batch rejected by the collector", zap.Error(err))
+}
+
+// put stores the batch at the end of the spool, evicting the oldest batches if it would exceed max size.
+func (s *spool) put(batch model.Batch) error {
+	data, err := batch.Marshal()
+	if err != nil {
+		s.metrics.Errors.Inc(1)
+		return err
+	}
+	size := int64(len(data))
+	if size > s.options.MaxSize {
+		s.metrics.Evicted.Inc(1)
+		return fmt.Errorf("batch of %d bytes exceeds spool max size", size)
+	}
+
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	seq := s.nextSeq
+	s.nextSeq++
+	path := s.path(seq)
+	tmp := path + spoolTmpSuffix
+	if err := ioutil.WriteFile(tmp, data, 0o600); err != nil {
+		s.metrics.Errors.Inc(1)
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		s.metrics.Errors.Inc(1)
+		return fmt.Errorf("failed to write spool file: %w", err)
+	}
+	s.evict(size)
+	s.entries = append(s.entries, spoolEntry{seq: seq, size: size, created: time.Now()})
+	s.bytes += size
+	s.metrics.Spooled.Inc(1)
+	s.updateGauges()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return nil
+}
+
+// evict removes the oldest batches until extra more bytes fit within max size. Must be called with mux held.
+func (s *spool) evict(extra int64) {
+	for len(s.entries) > 0 && s.bytes+extra > s.options.MaxSize {
+		s.removeFirst()
+		s.metrics.Evicted.Inc(1)
+	}
+}
+
+// removeFirst deletes the oldest batch. Must be called with mux held.
+func (s *spool) removeFirst() {
+	e := s.entries[0]
+	if err := os.Remove(s.path(e.seq)); err != nil && !os.IsNotExist(err) {
+		s.logger.Warn("Failed to remove spool file", zap.Error(err))
+	}
+	s.entries = s.entries[1:]
+	s.bytes -= e.size
+}
+
+func (s *spool) updateGauges() {
+	s.metrics.Depth.Update(int64(len(s.entries)))
+	s.metrics.Bytes.Update(s.bytes)
+}
+
+// first returns the oldest batch in the spool.
+func (s *spool) first() (spoolEntry, bool) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if len(s.entries) == 0 {
+		return spoolEntry{}, false
+	}
+	return s.entries[0], true
+}
+
+// remove deletes the entry if it is still the oldest one in the spool, i.e. it was not evicted meanwhile.
+func (s *spool) remove(e spoolEntry) {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	if len(s.entries) > 0 && s.entries[0].seq == e.seq {
+		s.removeFirst()
+		s.updateGauges()
+	}
+}
+
+func (s *spool) retryLoop() {
+	defer s.wg.Done()
+	backoff := spoolMinBackoff
+	for {
+		e, ok := s.first()
+		if !ok {
+			select {
+			case <-s.notify:
+				continue
+			case <-s.done:
+				return
+			}
+		}
+		if s.options.MaxAge > 0 && time.Since(e.created) > s.options.MaxAge {
+			s.remove(e)
+			s.metrics.Expired.Inc(1)
+			continue
+		}
+		if err := s.send(e); err != nil {
+			if !isRetryable(err) {
+				// the batch would fail forever and block the batches behind it
+				s.remove(e)
+				s.reject(err)
+				continue
+			}
+			s.metrics.Retries.Inc(1)
+			s.logger.Debug("Could not re-send spooled batch", zap.Duration("backoff", backoff), zap.Error(err))
+			select {
+			case <-time.After(backoff):
+			case <-s.done:
+				return
+			}
+			backoff *= 2
+			if backoff > s.options.MaxBackoff {
+				backoff = s.options.MaxBackoff
+			}
+			continue
+		}
+		backoff = spoolMinBackoff
+	}
+}
+
+// send re-sends a spooled batch, removing it from the spool once the collector accepted it
+// or if it cannot be read back from disk.
+func (s *spool) send(e spoolEntry) error {
+	data, err := ioutil.ReadFile(s.path(e.seq))
+	if err == nil {
+		var batch model.Batch
+		if err = batch.Unmarshal(data); err == nil {
+			ctx, cancel := context.WithTimeout(context.Background(), spoolSendTimeout)
+			defer cancel()
+			if _, err := s.collector.PostSpans(ctx, &api_v2.PostSpansRequest{Batch: batch}); err != nil {
+				return err
+			}
+			s.remove(e)
+			s.metrics.Sent.Inc(1)
+			return nil
+		}
+	}
+	s.logger.Error("Discarding unreadable spool file", zap.String("file", s.path(e.seq)), zap.Error(err))
+	s.remove(e)
+	s.metrics.Errors.Inc(1)
+	return nil
+}
+
+// Close stops retrying; batches remaining in the spool are kept on disk for the next run.
+func (s *spool) Close() error {
+	close(s.done)
+	s.wg.Wait()
+	return nil
+}
@@ -0,0 +1,262 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+)
+
+type flakyCollector struct {
+	mux  sync.Mutex
+	down bool
+	// reject is the service whose batches are rejected as invalid
+	reject   string
+	received []model.Batch
+}
+
+func (c *flakyCollector) setDown(down bool) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	c.down = down
+}
+
+func (c *flakyCollector) batches() []model.Batch {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return append([]model.Batch(nil), c.received...)
+}
+
+func (c *flakyCollector) PostSpans(ctx context.Context, r *api_v2.PostSpansRequest, opts ...grpc.CallOption) (*api_v2.PostSpansResponse, error) {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if c.down {
+		return nil, status.Error(codes.Unavailable, "collector unavailable")
+	}
+	if r.Batch.Process != nil && r.Batch.Process.ServiceName == c.reject {
+		return nil, status.Error(codes.InvalidArgument, "invalid batch")
+	}
+	c.received = append(c.received, r.Batch)
+	return &api_v2.PostSpansResponse{}, nil
+}
+
+func testBatch(service string) model.Batch {
+	return model.Batch{
+		Spans:   []*model.Span{{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(2), OperationName: "op"}},
+		Process: &model.Process{ServiceName: service},
+	}
+}
+
+func tempSpoolDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "spool")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return dir
+}
+
+func TestReporterSpoolsAndRetriesInOrder(t *testing.T) {
+	collector := &flakyCollector{down: true}
+	mf := metricstest.NewFactory(time.Hour)
+	sp, err := newSpool(SpoolOptions{Dir: tempSpoolDir(t), MaxAge: time.Hour}, collector, mf, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, sp.Close()) }()
+
+	rep := &Reporter{collector: collector, logger: zap.NewNop(), spool: sp}
+	for _, svc := range []string{"a", "b", "c"} {
+		b := testBatch(svc)
+		require.NoError(t, rep.send(context.Background(), b.Spans, b.Process))
+	}
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spool.depth", Value: 3})
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spool.batches", Tags: map[string]string{"result": "spooled"}, Value: 3})
+
+	collector.setDown(false)
+	assert.Eventually(t, func() bool { return len(collector.batches()) == 3 }, 5*time.Second, 10*time.Millisecond)
+	for i, svc := range []string{"a", "b", "c"} {
+		assert.Equal(t, svc, collector.batches()[i].Process.ServiceName)
+	}
+	assert.Eventually(t, func() bool {
+		_, gauges := mf.Snapshot()
+		return gauges["reporter.spool.depth"] == 0 && gauges["reporter.spool.bytes"] == 0
+	}, time.Second, 10*time.Millisecond)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spool.batches", Tags: map[string]string{"result": "sent"}, Value: 3})
+}
+
+func TestReporterSendsThroughNonEmptySpool(t *testing.T) {
+	collector := &flakyCollector{down: true}
+	mf := metricstest.NewFactory(time.Hour)
+	sp, err := newSpool(SpoolOptions{Dir: tempSpoolDir(t)}, collector, mf, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, sp.Close()) }()
+
+	rep := &Reporter{collector: collector, logger: zap.NewNop(), spool: sp}
+	a := testBatch("a")
+	require.NoError(t, rep.send(context.Background(), a.Spans, a.Process))
+	// wait for the retry loop to fail and back off
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["reporter.spool.retries"] > 0
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the collector is back, but the new batch must not overtake the spooled one
+	collector.setDown(false)
+	b := testBatch("b")
+	require.NoError(t, rep.send(context.Background(), b.Spans, b.Process))
+	assert.Eventually(t, func() bool { return len(collector.batches()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "a", collector.batches()[0].Process.ServiceName)
+	assert.Equal(t, "b", collector.batches()[1].Process.ServiceName)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spool.batches", Tags: map[string]string{"result": "spooled"}, Value: 2})
+}
+
+func TestReporterDoesNotSpoolRejectedBatches(t *testing.T) {
+	collector := &flakyCollector{reject: "bad"}
+	mf := metricstest.NewFactory(time.Hour)
+	sp, err := newSpool(SpoolOptions{Dir: tempSpoolDir(t)}, collector, mf, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, sp.Close()) }()
+
+	rep := &Reporter{collector: collector, logger: zap.NewNop(), spool: sp}
+	b := testBatch("bad")
+	err = rep.send(context.Background(), b.Spans, b.Process)
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	assert.True(t, sp.empty())
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "reporter.spool.batches", Tags: map[string]string{"result": "spooled"}, Value: 0},
+		metricstest.ExpectedMetric{Name: "reporter.spool.batches", Tags: map[string]string{"result": "rejected"}, Value: 1},
+	)
+}
+
+func TestSpoolDiscardsRejectedBatches(t *testing.T) {
+	collector := &flakyCollector{down: true, reject: "bad"}
+	mf := metricstest.NewFactory(time.Hour)
+	sp, err := newSpool(SpoolOptions{Dir: tempSpoolDir(t)}, collector, mf, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, sp.Close()) }()
+
+	for _, svc := range []string{"a", "bad", "c"} {
+		require.NoError(t, sp.put(testBatch(svc)))
+	}
+	collector.setDown(false)
+	// the rejected batch does not block the batches behind it
+	assert.Eventually(t, func() bool { return len(collector.batches()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "a", collector.batches()[0].Process.ServiceName)
+	assert.Equal(t, "c", collector.batches()[1].Process.ServiceName)
+	assert.Eventually(t, sp.empty, time.Second, 10*time.Millisecond)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spool.batches", Tags: map[string]string{"result": "rejected"}, Value: 1})
+}
+
+func TestIsRetryable(t *testing.T) {
+	assert.True(t, isRetryable(status.Error(codes.Unavailable, "")))
+	assert.True(t, isRetryable(status.Error(codes.DeadlineExceeded, "")))
+	assert.True(t, isRetryable(status.Error(codes.ResourceExhausted, "")))
+	assert.False(t, isRetryable(status.Error(codes.InvalidArgument, "")))
+	assert.False(t, isRetryable(status.Error(codes.Internal, "")))
+	assert.False(t, isRetryable(nil))
+}
+
+func TestSpoolEvictsOldestWhenFull(t *testing.T) {
+	b := testBatch("a")
+	size, err := b.Marshal()
+	require.NoError(t, err)
+	collector := &flakyCollector{down: true}
+	mf := metricstest.NewFactory(time.Hour)
+	sp, err := newSpool(SpoolOptions{Dir: tempSpoolDir(t), MaxSize: int64(2 * len(size))}, collector, mf, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, sp.Close()) }()
+
+	for _, svc := range []string{"a", "b", "c"} {
+		require.NoError(t, sp.put(testBatch(svc)))
+	}
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spool.depth", Value: 2})
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.spool.batches", Tags: map[string]string{"result": "evicted"}, Value: 1})
+
+	big := testBatch("big")
+	big.Spans[0].OperationName = string(make([]byte, 3*len(size)))
+	assert.Error(t, sp.put(big))
+
+	collector.setDown(false)
+	assert.Eventually(t, func() bool { return len(collector.batches()) == 2 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "b", collector.batches()[0].Process.ServiceName)
+	assert.Equal(t, "c", collector.batches()[1].Process.ServiceName)
+}
+
+func TestSpoolDiscardsExpiredBatches(t *testing.T) {
+	collector := &flakyCollector{down: true}
+	mf := metricstest.NewFactory(time.Hour)
+	sp, err := newSpool(SpoolOptions{Dir: tempSpoolDir(t), MaxAge: time.Nanosecond}, collector, mf, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, sp.Close()) }()
+
+	require.NoError(t, sp.put(testBatch("a")))
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["reporter.spool.batches|result=expired"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Empty(t, collector.batches())
+}
+
+func TestSpoolRestoresBatchesFromDisk(t *testing.T) {
+	dir := tempSpoolDir(t)
+	collector := &flakyCollector{down: true}
+	sp, err := newSpool(SpoolOptions{Dir: dir}, collector, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	require.NoError(t, sp.put(testBatch("a")))
+	require.NoError(t, sp.put(testBatch("b")))
+	require.NoError(t, sp.Close())
+
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "00000000000000000007.batch"), []byte("garbage"), 0o600))
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "00000000000000000008.batch.tmp"), []byte("partial"), 0o600))
+
+	collector.setDown(false)
+	mf := metricstest.NewFactory(time.Hour)
+	sp, err = newSpool(SpoolOptions{Dir: dir}, collector, mf, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, sp.Close()) }()
+
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["reporter.spool.batches|result=err"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	require.Len(t, collector.batches(), 2)
+	assert.Equal(t, "a", collector.batches()[0].Process.ServiceName)
+	assert.Equal(t, "b", collector.batches()[1].Process.ServiceName)
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Empty(t, files)
+	assert.Equal(t, uint64(8), sp.nextSeq)
+}
+
+func TestSpoolInvalidDir(t *testing.T) {
+	dir := tempSpoolDir(t)
+	file := filepath.Join(dir, "file")
+	require.NoError(t, ioutil.WriteFile(file, nil, 0o600))
+	_, err := newSpool(SpoolOptions{Dir: file}, &flakyCollector{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	assert.Error(t, err)
+}
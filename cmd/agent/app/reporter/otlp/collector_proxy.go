@@ -0,0 +1,130 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
+	"github.com/jaegertracing/jaeger/pkg/multicloser"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
+)
+
+// errNotSupported is returned to clients asking for configuration, which OTLP backends do not provide.
+var errNotSupported = errors.New("sampling strategies and baggage restrictions are not supported by the OTLP reporter")
+
+// ProxyBuilder holds objects communicating with the OTLP backend
+type ProxyBuilder struct {
+	reporter  *reporter.ClientMetricsReporter
+	manager   configmanager.ClientConfigManager
+	conn      *grpc.ClientConn
+	closers   []io.Closer
+	tlsCloser io.Closer
+}
+
+// NewCollectorProxy creates ProxyBuilder
+func NewCollectorProxy(options *Options, opts reporter.Options, mFactory metrics.Factory, logger *zap.Logger) (*ProxyBuilder, error) {
+	conn, err := createConnection(options, logger)
+	if err != nil {
+		return nil, err
+	}
+	otlpMetrics := mFactory.Namespace(metrics.NSOptions{Name: "", Tags: map[string]string{"protocol": "otlp"}})
+	r1 := NewReporter(conn, *options, opts.AgentTags, otlpMetrics, logger)
+	closers := []io.Closer{r1}
+	var r2 reporter.Reporter = reporter.WrapWithMetrics(r1, otlpMetrics)
+	var br *reporter.BatchingReporter
+	if opts.Batching.Enabled() {
+		br = reporter.WrapWithBatching(r2, opts.Batching, otlpMetrics, logger)
+		r2 = br
+		// the batching reporter flushes into the OTLP reporter, so it must be closed first
+		closers = []io.Closer{br, r1}
+	}
+	r3 := reporter.WrapWithClientMetrics(reporter.ClientMetricsReporterParams{
+		Reporter:       r2,
+		Logger:         logger,
+		MetricsFactory: mFactory,
+	})
+	if br != nil {
+		br.OnFlushFailure(r3.CountFailedSpans)
+	}
+	return &ProxyBuilder{
+		conn:      conn,
+		reporter:  r3,
+		manager:   configmanager.WrapWithMetrics(unsupportedManager{}, otlpMetrics),
+		closers:   closers,
+		tlsCloser: &options.TLS,
+	}, nil
+}
+
+func createConnection(options *Options, logger *zap.Logger) (*grpc.ClientConn, error) {
+	var dialOptions []grpc.DialOption
+	if options.TLS.Enabled {
+		logger.Info("Agent requested secure OTLP connection", zap.String("endpoint", options.Endpoint))
+		tlsConf, err := options.TLS.Config(logger)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load TLS config: %w", err)
+		}
+		dialOptions = append(dialOptions, grpc.WithTransportCredentials(credentials.NewTLS(tlsConf)))
+	} else {
+		logger.Info("Agent requested insecure OTLP connection", zap.String("endpoint", options.Endpoint))
+		dialOptions = append(dialOptions, grpc.WithInsecure())
+	}
+	endpoint := options.Endpoint
+	if endpoint == "" {
+		endpoint = defaultEndpoint
+	}
+	return grpc.Dial(endpoint, dialOptions...)
+}
+
+// GetConn returns grpc conn
+func (b ProxyBuilder) GetConn() *grpc.ClientConn {
+	return b.conn
+}
+
+// GetReporter returns Reporter
+func (b ProxyBuilder) GetReporter() reporter.Reporter {
+	return b.reporter
+}
+
+// GetManager returns manager
+func (b ProxyBuilder) GetManager() configmanager.ClientConfigManager {
+	return b.manager
+}
+
+// Close closes connections used by proxy.
+func (b ProxyBuilder) Close() error {
+	closers := append([]io.Closer{b.reporter}, b.closers...)
+	return multicloser.Wrap(append(closers, b.tlsCloser, b.conn)...).Close()
+}
+
+type unsupportedManager struct{}
+
+func (unsupportedManager) GetSamplingStrategy(_ context.Context, _ string) (*sampling.SamplingStrategyResponse, error) {
+	return nil, errNotSupported
+}
+
+func (unsupportedManager) GetBaggageRestrictions(_ context.Context, _ string) ([]*baggage.BaggageRestriction, error) {
+	return nil, errNotSupported
+}
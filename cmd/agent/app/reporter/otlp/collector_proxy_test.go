@@ -0,0 +1,125 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
+	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+)
+
+var _ io.Closer = (*ProxyBuilder)(nil)
+
+type traceService struct {
+	mux      sync.Mutex
+	requests []*exportTraceServiceRequest
+}
+
+func (s *traceService) getRequests() []*exportTraceServiceRequest {
+	s.mux.Lock()
+	defer s.mux.Unlock()
+	return s.requests
+}
+
+func initializeOTLPTestServer(t *testing.T, service *traceService) (*grpc.Server, net.Addr) {
+	server := grpc.NewServer()
+	server.RegisterService(&grpc.ServiceDesc{
+		ServiceName: "opentelemetry.proto.collector.trace.v1.TraceService",
+		HandlerType: (*interface{})(nil),
+		Methods: []grpc.MethodDesc{{
+			MethodName: "Export",
+			Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, _ grpc.UnaryServerInterceptor) (interface{}, error) {
+				req := &exportTraceServiceRequest{}
+				if err := dec(req); err != nil {
+					return nil, err
+				}
+				service.mux.Lock()
+				defer service.mux.Unlock()
+				service.requests = append(service.requests, req)
+				return &exportTraceServiceResponse{}, nil
+			},
+		}},
+	}, service)
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go func() {
+		require.NoError(t, server.Serve(lis))
+	}()
+	return server, lis.Addr()
+}
+
+func TestCollectorProxy(t *testing.T) {
+	service := &traceService{}
+	s, addr := initializeOTLPTestServer(t, service)
+	defer s.Stop()
+
+	mFactory := metricstest.NewFactory(time.Hour)
+	opts := reporter.Options{
+		AgentTags: map[string]string{"agent": "a"},
+		Batching:  reporter.BatchingOptions{FlushInterval: time.Hour},
+	}
+	proxy, err := NewCollectorProxy(&Options{Endpoint: addr.String()}, opts, mFactory, zap.NewNop())
+	require.NoError(t, err)
+	assert.NotNil(t, proxy.GetConn())
+
+	for i := 0; i < 2; i++ {
+		err = proxy.GetReporter().EmitBatch(context.Background(), &jaeger.Batch{
+			Process: &jaeger.Process{ServiceName: "svc"},
+			Spans:   []*jaeger.Span{{TraceIdLow: 1, SpanId: int64(i + 1), OperationName: "op"}},
+		})
+		require.NoError(t, err)
+	}
+	_, err = proxy.GetManager().GetSamplingStrategy(context.Background(), "svc")
+	assert.Equal(t, errNotSupported, err)
+	_, err = proxy.GetManager().GetBaggageRestrictions(context.Background(), "svc")
+	assert.Equal(t, errNotSupported, err)
+
+	require.NoError(t, proxy.Close())
+	requests := service.getRequests()
+	require.Len(t, requests, 1)
+	spans := requests[0].ResourceSpans[0].InstrumentationLibrarySpans[0].Spans
+	require.Len(t, spans, 2)
+	assert.Equal(t, "op", spans[0].Name)
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "reporter.otlp.exports", Tags: map[string]string{"protocol": "otlp", "result": "ok"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "reporter.batches.submitted", Tags: map[string]string{"protocol": "otlp", "format": "jaeger"}, Value: 1},
+	)
+}
+
+func TestCollectorProxyInvalidTLS(t *testing.T) {
+	_, err := NewCollectorProxy(&Options{TLS: tlscfg.Options{Enabled: true, CAPath: "/not/a/file"}}, reporter.Options{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "failed to load TLS config")
+}
+
+func TestCollectorProxyTLS(t *testing.T) {
+	proxy, err := NewCollectorProxy(&Options{TLS: tlscfg.Options{Enabled: true}}, reporter.Options{}, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	assert.Equal(t, defaultEndpoint, proxy.GetConn().Target())
+	require.NoError(t, proxy.Close())
+}
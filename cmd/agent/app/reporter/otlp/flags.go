@@ -0,0 +1,114 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"flag"
+	"time"
+
+	"github.com/spf13/viper"
+
+	"github.com/jaegertracing/jaeger/cmd/flags"
+	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+)
+
+const (
+	otlpPrefix  = "reporter.otlp"
+	endpoint    = otlpPrefix + ".endpoint"
+	timeout     = otlpPrefix + ".timeout"
+	retry       = otlpPrefix + ".retry.max"
+	maxBackoff  = otlpPrefix + ".retry.max-backoff"
+	queueSize   = otlpPrefix + ".queue-size"
+	workers     = otlpPrefix + ".workers"
+	headersFlag = otlpPrefix + ".headers"
+
+	defaultEndpoint   = "localhost:4317"
+	defaultTimeout    = 5 * time.Second
+	defaultMaxRetry   = 5
+	defaultMaxBackoff = 30 * time.Second
+	defaultQueueSize  = 1000
+	defaultWorkers    = 2
+)
+
+var tlsFlagsConfig = tlscfg.ClientFlagsConfig{
+	Prefix:         otlpPrefix,
+	ShowEnabled:    true,
+	ShowServerName: true,
+}
+
+// Options holds the configuration of the OTLP reporter.
+type Options struct {
+	// Endpoint is the host:port of the OTLP/gRPC receiver.
+	Endpoint string
+	// Headers are sent as gRPC metadata with every export request.
+	Headers map[string]string
+	// Timeout is the deadline of a single export request.
+	Timeout time.Duration
+	// MaxRetry is the maximum number of retries of a failed export request.
+	MaxRetry uint
+	// MaxBackoff is the maximum delay between retries.
+	MaxBackoff time.Duration
+	// QueueSize is the maximum number of batches waiting to be exported.
+	QueueSize int
+	// Workers is the number of concurrent export requests.
+	Workers int
+	TLS     tlscfg.Options
+}
+
+// AddFlags adds flags for Options.
+func AddFlags(flagSet *flag.FlagSet) {
+	flagSet.String(endpoint, defaultEndpoint, "host:port of the OTLP/gRPC receiver used when --reporter.type=otlp")
+	flagSet.String(headersFlag, "", "Comma-separated key=value headers sent with every OTLP export request, e.g. for authentication")
+	flagSet.Duration(timeout, defaultTimeout, "The timeout of a single OTLP export request")
+	flagSet.Uint(retry, defaultMaxRetry, "Sets the maximum number of retries of a failed OTLP export request")
+	flagSet.Duration(maxBackoff, defaultMaxBackoff, "The maximum delay between retries of a failed OTLP export request")
+	flagSet.Int(queueSize, defaultQueueSize, "The maximum number of batches waiting to be exported over OTLP")
+	flagSet.Int(workers, defaultWorkers, "The number of concurrent OTLP export requests")
+	tlsFlagsConfig.AddFlags(flagSet)
+}
+
+// InitFromViper initializes Options with properties retrieved from Viper.
+func (o *Options) InitFromViper(v *viper.Viper) *Options {
+	o.Endpoint = v.GetString(endpoint)
+	if headers := v.GetString(headersFlag); headers != "" {
+		o.Headers = flags.ParseJaegerTags(headers)
+	}
+	o.Timeout = v.GetDuration(timeout)
+	o.MaxRetry = uint(v.GetInt(retry))
+	o.MaxBackoff = v.GetDuration(maxBackoff)
+	o.QueueSize = v.GetInt(queueSize)
+	o.Workers = v.GetInt(workers)
+	o.TLS = tlsFlagsConfig.InitFromViper(v)
+	return o
+}
+
+func (o Options) withDefaults() Options {
+	if o.Endpoint == "" {
+		o.Endpoint = defaultEndpoint
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = defaultTimeout
+	}
+	if o.MaxBackoff < minBackoff {
+		o.MaxBackoff = minBackoff
+	}
+	if o.QueueSize <= 0 {
+		o.QueueSize = defaultQueueSize
+	}
+	if o.Workers <= 0 {
+		o.Workers = defaultWorkers
+	}
+	return o
+}
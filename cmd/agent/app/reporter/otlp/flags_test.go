@@ -0,0 +1,74 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBindFlags(t *testing.T) {
+	tests := []struct {
+		cOpts    []string
+		expected Options
+	}{
+		{
+			expected: Options{Endpoint: defaultEndpoint, Timeout: defaultTimeout, MaxRetry: defaultMaxRetry, MaxBackoff: defaultMaxBackoff, QueueSize: defaultQueueSize, Workers: defaultWorkers},
+		},
+		{
+			cOpts: []string{
+				"--reporter.otlp.endpoint=otel:4317",
+				"--reporter.otlp.headers=authorization=token,tenant=acme",
+				"--reporter.otlp.timeout=2s",
+				"--reporter.otlp.retry.max=1",
+				"--reporter.otlp.retry.max-backoff=5s",
+				"--reporter.otlp.queue-size=10",
+				"--reporter.otlp.workers=4",
+			},
+			expected: Options{
+				Endpoint:   "otel:4317",
+				Headers:    map[string]string{"authorization": "token", "tenant": "acme"},
+				Timeout:    2 * time.Second,
+				MaxRetry:   1,
+				MaxBackoff: 5 * time.Second,
+				QueueSize:  10,
+				Workers:    4,
+			},
+		},
+	}
+	for _, test := range tests {
+		v := viper.New()
+		command := cobra.Command{}
+		flags := &flag.FlagSet{}
+		AddFlags(flags)
+		command.PersistentFlags().AddGoFlagSet(flags)
+		v.BindPFlags(command.PersistentFlags())
+
+		require.NoError(t, command.ParseFlags(test.cOpts))
+		o := new(Options).InitFromViper(v)
+		assert.Equal(t, test.expected, *o)
+	}
+}
+
+func TestOptionsWithDefaults(t *testing.T) {
+	o := Options{}.withDefaults()
+	assert.Equal(t, Options{Endpoint: defaultEndpoint, Timeout: defaultTimeout, MaxBackoff: minBackoff, QueueSize: defaultQueueSize, Workers: defaultWorkers}, o)
+}
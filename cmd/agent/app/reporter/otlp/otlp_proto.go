@@ -0,0 +1,197 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"github.com/golang/protobuf/proto"
+)
+
+// The types below mirror the subset of the OpenTelemetry protocol (opentelemetry-proto v0.9.0)
+// messages used to export traces. They rely on the reflection based marshaling of golang/protobuf,
+// which keeps the agent independent from the OTLP generated code and its gRPC requirements.
+// Their wire format is checked against golden bytes of the generated OTLP types in tests.
+
+const exportMethod = "/opentelemetry.proto.collector.trace.v1.TraceService/Export"
+
+// OTLP span kinds.
+const (
+	spanKindUnspecified int32 = 0
+	spanKindInternal    int32 = 1
+	spanKindServer      int32 = 2
+	spanKindClient      int32 = 3
+	spanKindProducer    int32 = 4
+	spanKindConsumer    int32 = 5
+)
+
+// OTLP status codes.
+const (
+	statusCodeUnset int32 = 0
+	statusCodeError int32 = 2
+)
+
+type exportTraceServiceRequest struct {
+	ResourceSpans []*resourceSpans `protobuf:"bytes,1,rep,name=resource_spans,json=resourceSpans,proto3"`
+}
+
+func (m *exportTraceServiceRequest) Reset()         { *m = exportTraceServiceRequest{} }
+func (m *exportTraceServiceRequest) String() string { return proto.CompactTextString(m) }
+func (*exportTraceServiceRequest) ProtoMessage()    {}
+
+type exportTraceServiceResponse struct{}
+
+func (m *exportTraceServiceResponse) Reset()         { *m = exportTraceServiceResponse{} }
+func (m *exportTraceServiceResponse) String() string { return proto.CompactTextString(m) }
+func (*exportTraceServiceResponse) ProtoMessage()    {}
+
+type resourceSpans struct {
+	Resource                    *resource                      `protobuf:"bytes,1,opt,name=resource,proto3"`
+	InstrumentationLibrarySpans []*instrumentationLibrarySpans `protobuf:"bytes,2,rep,name=instrumentation_library_spans,json=instrumentationLibrarySpans,proto3"`
+}
+
+func (m *resourceSpans) Reset()         { *m = resourceSpans{} }
+func (m *resourceSpans) String() string { return proto.CompactTextString(m) }
+func (*resourceSpans) ProtoMessage()    {}
+
+type resource struct {
+	Attributes []*keyValue `protobuf:"bytes,1,rep,name=attributes,proto3"`
+}
+
+func (m *resource) Reset()         { *m = resource{} }
+func (m *resource) String() string { return proto.CompactTextString(m) }
+func (*resource) ProtoMessage()    {}
+
+type instrumentationLibrarySpans struct {
+	InstrumentationLibrary *instrumentationLibrary `protobuf:"bytes,1,opt,name=instrumentation_library,json=instrumentationLibrary,proto3"`
+	Spans                  []*span                 `protobuf:"bytes,2,rep,name=spans,proto3"`
+}
+
+func (m *instrumentationLibrarySpans) Reset()         { *m = instrumentationLibrarySpans{} }
+func (m *instrumentationLibrarySpans) String() string { return proto.CompactTextString(m) }
+func (*instrumentationLibrarySpans) ProtoMessage()    {}
+
+type instrumentationLibrary struct {
+	Name    string `protobuf:"bytes,1,opt,name=name,proto3"`
+	Version string `protobuf:"bytes,2,opt,name=version,proto3"`
+}
+
+func (m *instrumentationLibrary) Reset()         { *m = instrumentationLibrary{} }
+func (m *instrumentationLibrary) String() string { return proto.CompactTextString(m) }
+func (*instrumentationLibrary) ProtoMessage()    {}
+
+type span struct {
+	TraceID           []byte      `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3"`
+	SpanID            []byte      `protobuf:"bytes,2,opt,name=span_id,json=spanId,proto3"`
+	TraceState        string      `protobuf:"bytes,3,opt,name=trace_state,json=traceState,proto3"`
+	ParentSpanID      []byte      `protobuf:"bytes,4,opt,name=parent_span_id,json=parentSpanId,proto3"`
+	Name              string      `protobuf:"bytes,5,opt,name=name,proto3"`
+	Kind              int32       `protobuf:"varint,6,opt,name=kind,proto3"`
+	StartTimeUnixNano uint64      `protobuf:"fixed64,7,opt,name=start_time_unix_nano,json=startTimeUnixNano,proto3"`
+	EndTimeUnixNano   uint64      `protobuf:"fixed64,8,opt,name=end_time_unix_nano,json=endTimeUnixNano,proto3"`
+	Attributes        []*keyValue `protobuf:"bytes,9,rep,name=attributes,proto3"`
+	Events            []*event    `protobuf:"bytes,11,rep,name=events,proto3"`
+	Links             []*link     `protobuf:"bytes,13,rep,name=links,proto3"`
+	Status            *spanStatus `protobuf:"bytes,15,opt,name=status,proto3"`
+}
+
+func (m *span) Reset()         { *m = span{} }
+func (m *span) String() string { return proto.CompactTextString(m) }
+func (*span) ProtoMessage()    {}
+
+type event struct {
+	TimeUnixNano uint64      `protobuf:"fixed64,1,opt,name=time_unix_nano,json=timeUnixNano,proto3"`
+	Name         string      `protobuf:"bytes,2,opt,name=name,proto3"`
+	Attributes   []*keyValue `protobuf:"bytes,3,rep,name=attributes,proto3"`
+}
+
+func (m *event) Reset()         { *m = event{} }
+func (m *event) String() string { return proto.CompactTextString(m) }
+func (*event) ProtoMessage()    {}
+
+type link struct {
+	TraceID    []byte      `protobuf:"bytes,1,opt,name=trace_id,json=traceId,proto3"`
+	SpanID     []byte      `protobuf:"bytes,2,opt,name=span_id,json=spanId,proto3"`
+	Attributes []*keyValue `protobuf:"bytes,4,rep,name=attributes,proto3"`
+}
+
+func (m *link) Reset()         { *m = link{} }
+func (m *link) String() string { return proto.CompactTextString(m) }
+func (*link) ProtoMessage()    {}
+
+type spanStatus struct {
+	Message string `protobuf:"bytes,2,opt,name=message,proto3"`
+	Code    int32  `protobuf:"varint,3,opt,name=code,proto3"`
+}
+
+func (m *spanStatus) Reset()         { *m = spanStatus{} }
+func (m *spanStatus) String() string { return proto.CompactTextString(m) }
+func (*spanStatus) ProtoMessage()    {}
+
+type keyValue struct {
+	Key   string    `protobuf:"bytes,1,opt,name=key,proto3"`
+	Value *anyValue `protobuf:"bytes,2,opt,name=value,proto3"`
+}
+
+func (m *keyValue) Reset()         { *m = keyValue{} }
+func (m *keyValue) String() string { return proto.CompactTextString(m) }
+func (*keyValue) ProtoMessage()    {}
+
+type anyValue struct {
+	Value isAnyValue `protobuf_oneof:"value"`
+}
+
+func (m *anyValue) Reset()         { *m = anyValue{} }
+func (m *anyValue) String() string { return proto.CompactTextString(m) }
+func (*anyValue) ProtoMessage()    {}
+
+// XXX_OneofWrappers is used by golang/protobuf to marshal the oneof value.
+func (*anyValue) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*stringValue)(nil),
+		(*boolValue)(nil),
+		(*intValue)(nil),
+		(*doubleValue)(nil),
+		(*bytesValue)(nil),
+	}
+}
+
+type isAnyValue interface {
+	isAnyValue()
+}
+
+type stringValue struct {
+	StringValue string `protobuf:"bytes,1,opt,name=string_value,json=stringValue,proto3,oneof"`
+}
+
+type boolValue struct {
+	BoolValue bool `protobuf:"varint,2,opt,name=bool_value,json=boolValue,proto3,oneof"`
+}
+
+type intValue struct {
+	IntValue int64 `protobuf:"varint,3,opt,name=int_value,json=intValue,proto3,oneof"`
+}
+
+type doubleValue struct {
+	DoubleValue float64 `protobuf:"fixed64,4,opt,name=double_value,json=doubleValue,proto3,oneof"`
+}
+
+type bytesValue struct {
+	BytesValue []byte `protobuf:"bytes,7,opt,name=bytes_value,json=bytesValue,proto3,oneof"`
+}
+
+func (*stringValue) isAnyValue() {}
+func (*boolValue) isAnyValue()   {}
+func (*intValue) isAnyValue()    {}
+func (*doubleValue) isAnyValue() {}
+func (*bytesValue) isAnyValue()  {}
@@ -0,0 +1,82 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"io/ioutil"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fixtures/export_trace_service_request.hex holds the encoding of goldenRequest produced by
+// proto.Marshal of the equivalent go.opentelemetry.io/proto/otlp v0.9.0 (opentelemetry-proto
+// v0.9.0) generated types, so that the hand-written messages stay wire compatible with OTLP.
+func goldenRequest() *exportTraceServiceRequest {
+	traceID := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+	str := func(k, v string) *keyValue {
+		return &keyValue{Key: k, Value: &anyValue{Value: &stringValue{StringValue: v}}}
+	}
+	return &exportTraceServiceRequest{ResourceSpans: []*resourceSpans{{
+		Resource: &resource{Attributes: []*keyValue{str("service.name", "svc")}},
+		InstrumentationLibrarySpans: []*instrumentationLibrarySpans{{
+			InstrumentationLibrary: &instrumentationLibrary{Name: "lib", Version: "1.0"},
+			Spans: []*span{{
+				TraceID:           traceID,
+				SpanID:            []byte{1, 1, 1, 1, 1, 1, 1, 1},
+				TraceState:        "a=b",
+				ParentSpanID:      []byte{2, 2, 2, 2, 2, 2, 2, 2},
+				Name:              "op",
+				Kind:              spanKindServer,
+				StartTimeUnixNano: 1000,
+				EndTimeUnixNano:   2000,
+				Attributes: []*keyValue{
+					str("s", "v"),
+					{Key: "b", Value: &anyValue{Value: &boolValue{BoolValue: true}}},
+					{Key: "i", Value: &anyValue{Value: &intValue{IntValue: -1}}},
+					{Key: "d", Value: &anyValue{Value: &doubleValue{DoubleValue: 1.5}}},
+					{Key: "y", Value: &anyValue{Value: &bytesValue{BytesValue: []byte{0xff}}}},
+				},
+				Events: []*event{{TimeUnixNano: 1500, Name: "ev", Attributes: []*keyValue{str("k", "v")}}},
+				Links:  []*link{{TraceID: traceID, SpanID: []byte{3, 3, 3, 3, 3, 3, 3, 3}, Attributes: []*keyValue{str("k", "v")}}},
+				Status: &spanStatus{Message: "boom", Code: statusCodeError},
+			}},
+		}},
+	}}}
+}
+
+func loadGolden(t *testing.T) []byte {
+	b, err := ioutil.ReadFile("fixtures/export_trace_service_request.hex")
+	require.NoError(t, err)
+	golden, err := hex.DecodeString(string(bytes.TrimSpace(b)))
+	require.NoError(t, err)
+	return golden
+}
+
+func TestMarshalMatchesOTLP(t *testing.T) {
+	b, err := proto.Marshal(goldenRequest())
+	require.NoError(t, err)
+	assert.Equal(t, hex.EncodeToString(loadGolden(t)), hex.EncodeToString(b))
+}
+
+func TestUnmarshalOTLP(t *testing.T) {
+	req := &exportTraceServiceRequest{}
+	require.NoError(t, proto.Unmarshal(loadGolden(t), req))
+	assert.True(t, proto.Equal(goldenRequest(), req), "decoded %v", req)
+}
@@ -0,0 +1,199 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	zipkin2 "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
+	"github.com/jaegertracing/jaeger/model"
+	jConverter "github.com/jaegertracing/jaeger/model/converter/thrift/jaeger"
+	"github.com/jaegertracing/jaeger/model/converter/thrift/zipkin"
+	"github.com/jaegertracing/jaeger/pkg/queue"
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+const (
+	minBackoff   = 100 * time.Millisecond
+	drainTimeout = 5 * time.Second
+)
+
+// errQueueFull is returned when a batch cannot be queued for export.
+var errQueueFull = errors.New("OTLP export queue is full")
+
+// retryableCodes are the gRPC codes after which the OTLP specification allows retrying an export.
+var retryableCodes = map[codes.Code]bool{
+	codes.Canceled:          true,
+	codes.DeadlineExceeded:  true,
+	codes.ResourceExhausted: true,
+	codes.Aborted:           true,
+	codes.OutOfRange:        true,
+	codes.Unavailable:       true,
+	codes.DataLoss:          true,
+}
+
+type exportMetrics struct {
+	// Number of export requests accepted by the OTLP backend
+	ExportsOK metrics.Counter `metric:"otlp.exports" tags:"result=ok"`
+
+	// Number of export requests that failed after all retries
+	ExportsErr metrics.Counter `metric:"otlp.exports" tags:"result=err"`
+
+	// Number of retried export requests
+	Retries metrics.Counter `metric:"otlp.retries"`
+
+	// Number of spans dropped because the export queue was full
+	QueueFullDroppedSpans metrics.Counter `metric:"otlp.spans_dropped" tags:"cause=full-queue"`
+
+	// Number of spans dropped because their export failed
+	FailedDroppedSpans metrics.Counter `metric:"otlp.spans_dropped" tags:"cause=export-failure"`
+
+	// Number of batches waiting to be exported
+	QueueLength metrics.Gauge `metric:"otlp.queue_length"`
+}
+
+type queuedRequest struct {
+	request *exportTraceServiceRequest
+	spans   int64
+}
+
+// Reporter converts batches to OTLP and exports them over gRPC from a bounded queue.
+type Reporter struct {
+	conn      grpc.ClientConnInterface
+	options   Options
+	agentTags []model.KeyValue
+	logger    *zap.Logger
+	sanitizer zipkin2.Sanitizer
+	metrics   exportMetrics
+	queue     *queue.BoundedQueue
+	done      chan struct{}
+}
+
+// NewReporter creates OTLP reporter and starts its export workers.
+func NewReporter(conn grpc.ClientConnInterface, options Options, agentTags map[string]string, mFactory metrics.Factory, logger *zap.Logger) *Reporter {
+	r := &Reporter{
+		conn:      conn,
+		options:   options.withDefaults(),
+		agentTags: makeModelKeyValue(agentTags),
+		logger:    logger,
+		sanitizer: zipkin2.NewChainedSanitizer(zipkin2.StandardSanitizers...),
+		done:      make(chan struct{}),
+	}
+	metrics.MustInit(&r.metrics, mFactory.Namespace(metrics.NSOptions{Name: "reporter"}), nil)
+	r.queue = queue.NewBoundedQueue(r.options.QueueSize, func(item interface{}) {
+		r.metrics.QueueFullDroppedSpans.Inc(item.(*queuedRequest).spans)
+	})
+	r.queue.StartConsumers(r.options.Workers, func(item interface{}) {
+		r.export(item.(*queuedRequest))
+	})
+	r.queue.StartLengthReporting(time.Second, r.metrics.QueueLength)
+	return r
+}
+
+// EmitBatch implements EmitBatch() of Reporter
+func (r *Reporter) EmitBatch(ctx context.Context, b *jaeger.Batch) error {
+	return r.enqueue(jConverter.ToDomain(b.Spans, nil), jConverter.ToDomainProcess(b.Process))
+}
+
+// EmitZipkinBatch implements EmitZipkinBatch() of Reporter
+func (r *Reporter) EmitZipkinBatch(ctx context.Context, zSpans []*zipkincore.Span) error {
+	for i := range zSpans {
+		zSpans[i] = r.sanitizer.Sanitize(zSpans[i])
+	}
+	trace, err := zipkin.ToDomain(zSpans)
+	if err != nil {
+		return err
+	}
+	return r.enqueue(trace.Spans, nil)
+}
+
+func (r *Reporter) enqueue(spans []*model.Span, process *model.Process) error {
+	if len(spans) == 0 {
+		return nil
+	}
+	item := &queuedRequest{request: toOTLP(spans, process, r.agentTags), spans: int64(len(spans))}
+	if !r.queue.Produce(item) {
+		return errQueueFull
+	}
+	return nil
+}
+
+func (r *Reporter) export(item *queuedRequest) {
+	backoff := minBackoff
+	for attempt := uint(0); ; attempt++ {
+		err := r.send(item.request)
+		if err == nil {
+			r.metrics.ExportsOK.Inc(1)
+			return
+		}
+		if attempt >= r.options.MaxRetry || !retryableCodes[status.Code(err)] {
+			r.metrics.ExportsErr.Inc(1)
+			r.metrics.FailedDroppedSpans.Inc(item.spans)
+			r.logger.Error("Could not export spans over OTLP", zap.Int64("spans", item.spans), zap.Error(err))
+			return
+		}
+		r.metrics.Retries.Inc(1)
+		select {
+		case <-time.After(backoff):
+		case <-r.done:
+			r.metrics.ExportsErr.Inc(1)
+			r.metrics.FailedDroppedSpans.Inc(item.spans)
+			return
+		}
+		backoff *= 2
+		if backoff > r.options.MaxBackoff {
+			backoff = r.options.MaxBackoff
+		}
+	}
+}
+
+func (r *Reporter) send(req *exportTraceServiceRequest) error {
+	ctx, cancel := context.WithTimeout(context.Background(), r.options.Timeout)
+	defer cancel()
+	if len(r.options.Headers) > 0 {
+		ctx = metadata.NewOutgoingContext(ctx, metadata.New(r.options.Headers))
+	}
+	return r.conn.Invoke(ctx, exportMethod, req, &exportTraceServiceResponse{})
+}
+
+// Close exports the queued batches, waiting up to a few seconds, and stops the export workers.
+func (r *Reporter) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), drainTimeout)
+	defer cancel()
+	if n := r.queue.Drain(ctx); n > 0 {
+		r.logger.Warn("Dropping batches still queued for OTLP export", zap.Int("batches", n))
+	}
+	close(r.done)
+	r.queue.Stop()
+	return nil
+}
+
+func makeModelKeyValue(agentTags map[string]string) []model.KeyValue {
+	tags := make([]model.KeyValue, 0, len(agentTags))
+	for k, v := range agentTags {
+		tags = append(tags, model.String(k, v))
+	}
+	return tags
+}
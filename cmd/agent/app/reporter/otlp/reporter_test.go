@@ -0,0 +1,179 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+// fakeConn fails the first len(errs) export requests with the given errors.
+type fakeConn struct {
+	mux      sync.Mutex
+	errs     []error
+	block    chan struct{}
+	requests []*exportTraceServiceRequest
+	headers  []metadata.MD
+}
+
+func (c *fakeConn) Invoke(ctx context.Context, method string, args interface{}, reply interface{}, opts ...grpc.CallOption) error {
+	if c.block != nil {
+		<-c.block
+	}
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	if method != exportMethod {
+		return errors.New("unexpected method")
+	}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	c.headers = append(c.headers, md)
+	if len(c.errs) > 0 {
+		err := c.errs[0]
+		c.errs = c.errs[1:]
+		return err
+	}
+	c.requests = append(c.requests, args.(*exportTraceServiceRequest))
+	return nil
+}
+
+func (c *fakeConn) NewStream(ctx context.Context, desc *grpc.StreamDesc, method string, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	return nil, errors.New("not implemented")
+}
+
+func (c *fakeConn) getRequests() []*exportTraceServiceRequest {
+	c.mux.Lock()
+	defer c.mux.Unlock()
+	return append([]*exportTraceServiceRequest(nil), c.requests...)
+}
+
+func testJaegerBatch() *jaeger.Batch {
+	return &jaeger.Batch{
+		Process: &jaeger.Process{ServiceName: "svc"},
+		Spans:   []*jaeger.Span{{TraceIdLow: 1, SpanId: 2, OperationName: "op"}, {TraceIdLow: 1, SpanId: 3, OperationName: "op"}},
+	}
+}
+
+func TestReporterExportsBatches(t *testing.T) {
+	conn := &fakeConn{}
+	mf := metricstest.NewFactory(time.Hour)
+	r := NewReporter(conn, Options{Headers: map[string]string{"authorization": "token"}}, map[string]string{"agent": "a"}, mf, zap.NewNop())
+
+	require.NoError(t, r.EmitBatch(context.Background(), testJaegerBatch()))
+	require.NoError(t, r.EmitZipkinBatch(context.Background(), []*zipkincore.Span{{
+		TraceID: 1, ID: 4, Name: "zop",
+		Annotations: []*zipkincore.Annotation{{Value: zipkincore.SERVER_RECV, Host: &zipkincore.Endpoint{ServiceName: "zsvc"}}},
+	}}))
+	require.NoError(t, r.EmitBatch(context.Background(), &jaeger.Batch{Process: &jaeger.Process{ServiceName: "svc"}}))
+	require.NoError(t, r.Close())
+
+	requests := conn.getRequests()
+	require.Len(t, requests, 2)
+	services := map[string]int{}
+	for _, req := range requests {
+		require.Len(t, req.ResourceSpans, 1)
+		attrs := req.ResourceSpans[0].Resource.Attributes
+		services[attrs[0].Value.Value.(*stringValue).StringValue] = len(req.ResourceSpans[0].InstrumentationLibrarySpans[0].Spans)
+		assert.Equal(t, "agent", attrs[len(attrs)-1].Key)
+	}
+	assert.Equal(t, map[string]int{"svc": 2, "zsvc": 1}, services)
+	assert.Equal(t, []string{"token"}, conn.headers[0].Get("authorization"))
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.otlp.exports", Tags: map[string]string{"result": "ok"}, Value: 2})
+}
+
+func TestReporterRetriesRetryableErrors(t *testing.T) {
+	conn := &fakeConn{errs: []error{
+		status.Error(codes.Unavailable, "down"),
+		status.Error(codes.ResourceExhausted, "slow down"),
+	}}
+	mf := metricstest.NewFactory(time.Hour)
+	r := NewReporter(conn, Options{MaxRetry: 3}, nil, mf, zap.NewNop())
+	defer func() { assert.NoError(t, r.Close()) }()
+
+	require.NoError(t, r.EmitBatch(context.Background(), testJaegerBatch()))
+	assert.Eventually(t, func() bool { return len(conn.getRequests()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "reporter.otlp.retries", Value: 2},
+		metricstest.ExpectedMetric{Name: "reporter.otlp.exports", Tags: map[string]string{"result": "ok"}, Value: 1},
+	)
+}
+
+func TestReporterDropsAfterFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		errs    []error
+		retries int
+	}{
+		{name: "non-retryable", errs: []error{status.Error(codes.InvalidArgument, "bad")}, retries: 0},
+		{name: "retries exhausted", errs: []error{status.Error(codes.Unavailable, "down"), status.Error(codes.Unavailable, "down")}, retries: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			conn := &fakeConn{errs: test.errs}
+			mf := metricstest.NewFactory(time.Hour)
+			r := NewReporter(conn, Options{MaxRetry: 1}, nil, mf, zap.NewNop())
+			defer func() { assert.NoError(t, r.Close()) }()
+
+			require.NoError(t, r.EmitBatch(context.Background(), testJaegerBatch()))
+			assert.Eventually(t, func() bool {
+				counters, _ := mf.Snapshot()
+				return counters["reporter.otlp.spans_dropped|cause=export-failure"] == 2
+			}, 5*time.Second, 10*time.Millisecond)
+			mf.AssertCounterMetrics(t,
+				metricstest.ExpectedMetric{Name: "reporter.otlp.retries", Value: test.retries},
+				metricstest.ExpectedMetric{Name: "reporter.otlp.exports", Tags: map[string]string{"result": "err"}, Value: 1},
+			)
+			assert.Empty(t, conn.getRequests())
+		})
+	}
+}
+
+func TestReporterQueueFull(t *testing.T) {
+	conn := &fakeConn{block: make(chan struct{})}
+	mf := metricstest.NewFactory(time.Hour)
+	r := NewReporter(conn, Options{QueueSize: 1, Workers: 1}, nil, mf, zap.NewNop())
+
+	// the first batch is taken by the worker, the second one fills the queue
+	require.NoError(t, r.EmitBatch(context.Background(), testJaegerBatch()))
+	assert.Eventually(t, func() bool { return r.queue.Size() == 0 }, time.Second, time.Millisecond)
+	require.NoError(t, r.EmitBatch(context.Background(), testJaegerBatch()))
+	assert.Equal(t, errQueueFull, r.EmitBatch(context.Background(), testJaegerBatch()))
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "reporter.otlp.spans_dropped", Tags: map[string]string{"cause": "full-queue"}, Value: 2})
+
+	close(conn.block)
+	require.NoError(t, r.Close())
+	assert.Len(t, conn.getRequests(), 2)
+}
+
+func TestReporterInvalidZipkinSpans(t *testing.T) {
+	r := NewReporter(&fakeConn{}, Options{}, nil, metricstest.NewFactory(time.Hour), zap.NewNop())
+	defer func() { assert.NoError(t, r.Close()) }()
+	err := r.EmitZipkinBatch(context.Background(), []*zipkincore.Span{{}})
+	assert.EqualError(t, err, "cannot find service name in Zipkin span [traceID=0, spanID=0]")
+}
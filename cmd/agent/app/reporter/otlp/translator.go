@@ -0,0 +1,188 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"encoding/binary"
+	"strings"
+
+	"github.com/opentracing/opentracing-go/ext"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	serviceNameAttribute = "service.name"
+	eventNameField       = "event"
+)
+
+var spanKinds = map[string]int32{
+	string(ext.SpanKindRPCClientEnum): spanKindClient,
+	string(ext.SpanKindRPCServerEnum): spanKindServer,
+	string(ext.SpanKindProducerEnum):  spanKindProducer,
+	string(ext.SpanKindConsumerEnum):  spanKindConsumer,
+	"internal":                        spanKindInternal,
+}
+
+// toOTLP converts spans to an OTLP export request, with one resource per distinct process.
+// The process of a span is used if set, otherwise the batch process.
+func toOTLP(spans []*model.Span, batchProcess *model.Process, agentTags []model.KeyValue) *exportTraceServiceRequest {
+	var processes []*model.Process
+	var groups []*instrumentationLibrarySpans
+	for _, s := range spans {
+		process := s.Process
+		if process == nil {
+			process = batchProcess
+		}
+		idx := -1
+		for i, p := range processes {
+			if p == process || (p != nil && process != nil && p.Equal(process)) {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			processes = append(processes, process)
+			groups = append(groups, &instrumentationLibrarySpans{})
+			idx = len(groups) - 1
+		}
+		groups[idx].Spans = append(groups[idx].Spans, toOTLPSpan(s))
+	}
+	req := &exportTraceServiceRequest{ResourceSpans: make([]*resourceSpans, len(groups))}
+	for i, g := range groups {
+		req.ResourceSpans[i] = &resourceSpans{
+			Resource:                    toOTLPResource(processes[i], agentTags),
+			InstrumentationLibrarySpans: []*instrumentationLibrarySpans{g},
+		}
+	}
+	return req
+}
+
+func toOTLPResource(process *model.Process, agentTags []model.KeyValue) *resource {
+	r := &resource{}
+	if process != nil {
+		r.Attributes = append(r.Attributes, &keyValue{
+			Key:   serviceNameAttribute,
+			Value: &anyValue{Value: &stringValue{StringValue: process.ServiceName}},
+		})
+		r.Attributes = appendAttributes(r.Attributes, process.Tags)
+	}
+	r.Attributes = appendAttributes(r.Attributes, agentTags)
+	return r
+}
+
+func toOTLPSpan(s *model.Span) *span {
+	start := s.StartTime.UnixNano()
+	out := &span{
+		TraceID:           traceIDBytes(s.TraceID),
+		SpanID:            spanIDBytes(s.SpanID),
+		Name:              s.OperationName,
+		Kind:              spanKindUnspecified,
+		StartTimeUnixNano: uint64(start),
+		EndTimeUnixNano:   uint64(start + s.Duration.Nanoseconds()),
+		Status:            &spanStatus{Code: statusCodeUnset},
+	}
+	parentID := s.ParentSpanID()
+	if parentID != 0 {
+		out.ParentSpanID = spanIDBytes(parentID)
+	}
+	for _, ref := range s.References {
+		if ref.TraceID == s.TraceID && ref.SpanID == parentID && ref.RefType == model.ChildOf {
+			continue
+		}
+		out.Links = append(out.Links, &link{
+			TraceID: traceIDBytes(ref.TraceID),
+			SpanID:  spanIDBytes(ref.SpanID),
+		})
+	}
+	tags := make([]model.KeyValue, 0, len(s.Tags))
+	for _, tag := range s.Tags {
+		switch tag.Key {
+		case string(ext.SpanKind):
+			if kind, ok := spanKinds[tag.AsString()]; ok {
+				out.Kind = kind
+				continue
+			}
+		case string(ext.Error):
+			if isErrorTag(tag) {
+				out.Status.Code = statusCodeError
+				continue
+			}
+		}
+		tags = append(tags, tag)
+	}
+	out.Attributes = appendAttributes(nil, tags)
+	for _, log := range s.Logs {
+		e := &event{TimeUnixNano: uint64(log.Timestamp.UnixNano())}
+		fields := make([]model.KeyValue, 0, len(log.Fields))
+		for _, f := range log.Fields {
+			if f.Key == eventNameField && e.Name == "" {
+				e.Name = f.AsString()
+				continue
+			}
+			fields = append(fields, f)
+		}
+		e.Attributes = appendAttributes(nil, fields)
+		out.Events = append(out.Events, e)
+	}
+	return out
+}
+
+// isErrorTag reports whether an error tag marks the span as failed. Some clients,
+// e.g. those going through Zipkin, send the tag as the string "true".
+func isErrorTag(tag model.KeyValue) bool {
+	switch tag.VType {
+	case model.BoolType:
+		return tag.Bool()
+	case model.StringType:
+		return strings.EqualFold(tag.VStr, "true")
+	}
+	return false
+}
+
+func appendAttributes(attrs []*keyValue, tags []model.KeyValue) []*keyValue {
+	for _, tag := range tags {
+		attrs = append(attrs, &keyValue{Key: tag.Key, Value: toAnyValue(tag)})
+	}
+	return attrs
+}
+
+func toAnyValue(tag model.KeyValue) *anyValue {
+	switch tag.VType {
+	case model.BoolType:
+		return &anyValue{Value: &boolValue{BoolValue: tag.Bool()}}
+	case model.Int64Type:
+		return &anyValue{Value: &intValue{IntValue: tag.Int64()}}
+	case model.Float64Type:
+		return &anyValue{Value: &doubleValue{DoubleValue: tag.Float64()}}
+	case model.BinaryType:
+		return &anyValue{Value: &bytesValue{BytesValue: tag.Binary()}}
+	default:
+		return &anyValue{Value: &stringValue{StringValue: tag.VStr}}
+	}
+}
+
+func traceIDBytes(id model.TraceID) []byte {
+	b := make([]byte, 16)
+	binary.BigEndian.PutUint64(b[:8], id.High)
+	binary.BigEndian.PutUint64(b[8:], id.Low)
+	return b
+}
+
+func spanIDBytes(id model.SpanID) []byte {
+	b := make([]byte, 8)
+	binary.BigEndian.PutUint64(b, uint64(id))
+	return b
+}
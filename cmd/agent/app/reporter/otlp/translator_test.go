@@ -0,0 +1,141 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package otlp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestToOTLPSpan(t *testing.T) {
+	traceID := model.NewTraceID(1, 2)
+	s := &model.Span{
+		TraceID:       traceID,
+		SpanID:        3,
+		OperationName: "op",
+		References: []model.SpanRef{
+			model.NewChildOfRef(traceID, 4),
+			model.NewFollowsFromRef(model.NewTraceID(5, 6), 7),
+		},
+		StartTime: time.Unix(100, 5),
+		Duration:  time.Second,
+		Tags: model.KeyValues{
+			model.String("span.kind", "client"),
+			model.Bool("error", true),
+			model.Int64("int", -5),
+			model.Float64("float", 1.5),
+			model.Binary("bin", []byte{1, 2}),
+			model.String("str", "x"),
+			model.Bool("bool", true),
+		},
+		Logs: []model.Log{{
+			Timestamp: time.Unix(100, 7),
+			Fields:    model.KeyValues{model.String("event", "retry"), model.String("k", "v")},
+		}},
+	}
+	out := toOTLPSpan(s)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0, 0, 2}, out.TraceID)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 3}, out.SpanID)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 4}, out.ParentSpanID)
+	assert.Equal(t, "op", out.Name)
+	assert.Equal(t, spanKindClient, out.Kind)
+	assert.Equal(t, uint64(100000000005), out.StartTimeUnixNano)
+	assert.Equal(t, uint64(101000000005), out.EndTimeUnixNano)
+	assert.Equal(t, statusCodeError, out.Status.Code)
+	assert.Equal(t, []*keyValue{
+		{Key: "int", Value: &anyValue{Value: &intValue{IntValue: -5}}},
+		{Key: "float", Value: &anyValue{Value: &doubleValue{DoubleValue: 1.5}}},
+		{Key: "bin", Value: &anyValue{Value: &bytesValue{BytesValue: []byte{1, 2}}}},
+		{Key: "str", Value: &anyValue{Value: &stringValue{StringValue: "x"}}},
+		{Key: "bool", Value: &anyValue{Value: &boolValue{BoolValue: true}}},
+	}, out.Attributes)
+	require.Len(t, out.Links, 1)
+	assert.Equal(t, []byte{0, 0, 0, 0, 0, 0, 0, 7}, out.Links[0].SpanID)
+	require.Len(t, out.Events, 1)
+	assert.Equal(t, "retry", out.Events[0].Name)
+	assert.Equal(t, uint64(100000000007), out.Events[0].TimeUnixNano)
+	assert.Equal(t, []*keyValue{{Key: "k", Value: &anyValue{Value: &stringValue{StringValue: "v"}}}}, out.Events[0].Attributes)
+}
+
+func TestToOTLPSpanKeepsUnknownKindAndErrorTags(t *testing.T) {
+	out := toOTLPSpan(&model.Span{
+		Tags: model.KeyValues{model.String("span.kind", "weird"), model.Bool("error", false)},
+	})
+	assert.Equal(t, spanKindUnspecified, out.Kind)
+	assert.Equal(t, statusCodeUnset, out.Status.Code)
+	assert.Len(t, out.Attributes, 2)
+	assert.Nil(t, out.ParentSpanID)
+}
+
+func TestToOTLPSpanStringErrorTag(t *testing.T) {
+	for _, tag := range []model.KeyValue{model.String("error", "true"), model.String("error", "TRUE")} {
+		out := toOTLPSpan(&model.Span{Tags: model.KeyValues{tag}})
+		assert.Equal(t, statusCodeError, out.Status.Code, tag.VStr)
+		assert.Empty(t, out.Attributes, tag.VStr)
+	}
+	out := toOTLPSpan(&model.Span{Tags: model.KeyValues{model.String("error", "false")}})
+	assert.Equal(t, statusCodeUnset, out.Status.Code)
+	assert.Len(t, out.Attributes, 1)
+}
+
+func TestToOTLPGroupsSpansByProcess(t *testing.T) {
+	p1 := &model.Process{ServiceName: "a", Tags: model.KeyValues{model.String("host", "h")}}
+	p1Copy := &model.Process{ServiceName: "a", Tags: model.KeyValues{model.String("host", "h")}}
+	p2 := &model.Process{ServiceName: "b"}
+	spans := []*model.Span{
+		{SpanID: 1, Process: p1},
+		{SpanID: 2, Process: p2},
+		{SpanID: 3, Process: p1Copy},
+		{SpanID: 4},
+	}
+	batchProcess := &model.Process{ServiceName: "batch"}
+	req := toOTLP(spans, batchProcess, []model.KeyValue{model.String("agent", "x")})
+	require.Len(t, req.ResourceSpans, 3)
+
+	services := make([]string, 0, 3)
+	counts := make([]int, 0, 3)
+	for _, rs := range req.ResourceSpans {
+		attrs := rs.Resource.Attributes
+		assert.Equal(t, serviceNameAttribute, attrs[0].Key)
+		assert.Equal(t, "agent", attrs[len(attrs)-1].Key)
+		services = append(services, attrs[0].Value.Value.(*stringValue).StringValue)
+		counts = append(counts, len(rs.InstrumentationLibrarySpans[0].Spans))
+	}
+	assert.Equal(t, []string{"a", "b", "batch"}, services)
+	assert.Equal(t, []int{2, 1, 1}, counts)
+	assert.Len(t, req.ResourceSpans[0].Resource.Attributes, 3)
+}
+
+func TestExportRequestRoundTrip(t *testing.T) {
+	req := toOTLP([]*model.Span{{
+		TraceID:       model.NewTraceID(1, 2),
+		SpanID:        3,
+		OperationName: "op",
+		Tags:          model.KeyValues{model.String("str", "x"), model.Int64("int", 5), model.Binary("bin", []byte{1})},
+	}}, &model.Process{ServiceName: "svc"}, nil)
+	data, err := proto.Marshal(req)
+	require.NoError(t, err)
+
+	var decoded exportTraceServiceRequest
+	require.NoError(t, proto.Unmarshal(data, &decoded))
+	assert.True(t, proto.Equal(req, &decoded))
+	assert.Contains(t, decoded.String(), `name:"op"`)
+}
@@ -0,0 +1,157 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"math"
+	"sync"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/utils"
+
+	"github.com/jaegertracing/jaeger/pkg/cache"
+)
+
+const (
+	// maxRateLimitedServices limits the number of token buckets created from the default rate limit,
+	// and the number of services tagged on the spans_rate_limited counter
+	maxRateLimitedServices = 1000
+
+	// otherServices tags the dropped spans of services above maxRateLimitedServices
+	otherServices = "other-services"
+)
+
+// RateLimitOptions controls the per-service rate limits applied to spans received from clients.
+type RateLimitOptions struct {
+	// SpansPerSecond is the rate limit of every service without an override. Disabled when zero.
+	SpansPerSecond float64
+	// Burst is the number of spans a service can send at once, never less than one second worth of spans.
+	Burst float64
+	// Services maps service names to the rate limit overriding SpansPerSecond, zero exempts the service.
+	Services map[string]float64
+}
+
+// Enabled returns true if spans of at least one service are rate limited.
+func (o RateLimitOptions) Enabled() bool {
+	return o.SpansPerSecond > 0 || len(o.Services) > 0
+}
+
+// serviceRateLimiter is the token bucket of a client service and the counter of its dropped spans.
+type serviceRateLimiter struct {
+	limiter utils.RateLimiter
+	burst   float64
+	// Total count of spans of the service dropped by the agent because they exceeded its rate limit,
+	// emitted as client_stats.spans_rate_limited{service=...}.
+	dropped metrics.Counter
+}
+
+// ServiceRateLimiter rate limits the spans received from each client service. It is meant to be
+// called by the servers before the packets are queued, so that a noisy service cannot fill the
+// queues and cause the packets of the other services to be dropped. It is safe for concurrent use.
+type ServiceRateLimiter struct {
+	options        RateLimitOptions
+	metricsFactory metrics.Factory
+
+	lock sync.Mutex
+	// overrides holds the buckets of the services in options.Services, which are never evicted
+	overrides map[string]*serviceRateLimiter
+	// buckets holds the buckets of the other services. Every service gets its own bucket,
+	// the least recently used ones are evicted once there are maxRateLimitedServices of them.
+	buckets *cache.LRU
+	// counters holds the spans_rate_limited counters by service tag
+	counters map[string]metrics.Counter
+}
+
+// NewServiceRateLimiter creates ServiceRateLimiter.
+func NewServiceRateLimiter(options RateLimitOptions, mFactory metrics.Factory) *ServiceRateLimiter {
+	return &ServiceRateLimiter{
+		options:        options,
+		metricsFactory: mFactory.Namespace(metrics.NSOptions{Name: "client_stats"}),
+		overrides:      make(map[string]*serviceRateLimiter),
+		buckets:        cache.NewLRU(maxRateLimitedServices),
+		counters:       make(map[string]metrics.Counter),
+	}
+}
+
+// Allow returns true if the service has the credits to send a packet of the given number of spans,
+// otherwise it counts the spans as dropped. Packets are accepted or dropped as a whole, and a packet
+// with more spans than the burst of the service only needs, and consumes, a full bucket.
+func (r *ServiceRateLimiter) Allow(serviceName string, spans int) bool {
+	limiter := r.get(serviceName)
+	if limiter == nil || limiter.limiter.CheckCredit(math.Min(float64(spans), limiter.burst)) {
+		return true
+	}
+	limiter.dropped.Inc(int64(spans))
+	return false
+}
+
+// get returns the token bucket of the service, or nil if the service is not rate limited.
+func (r *ServiceRateLimiter) get(serviceName string) *serviceRateLimiter {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if rate, ok := r.options.Services[serviceName]; ok {
+		if rate <= 0 {
+			return nil
+		}
+		limiter, ok := r.overrides[serviceName]
+		if !ok {
+			limiter = r.newLimiter(serviceName, rate)
+			r.overrides[serviceName] = limiter
+		}
+		return limiter
+	}
+	if r.options.SpansPerSecond <= 0 {
+		return nil
+	}
+	if limiter, ok := r.buckets.Get(serviceName).(*serviceRateLimiter); ok {
+		return limiter
+	}
+	// An evicted service starts again from a full bucket, which only matters if more than
+	// maxRateLimitedServices services are active at once.
+	limiter := r.newLimiter(serviceName, r.options.SpansPerSecond)
+	r.buckets.Put(serviceName, limiter)
+	return limiter
+}
+
+func (r *ServiceRateLimiter) newLimiter(serviceName string, rate float64) *serviceRateLimiter {
+	burst := r.options.Burst
+	if burst < rate {
+		burst = rate
+	}
+	return &serviceRateLimiter{
+		limiter: utils.NewRateLimiter(rate, burst),
+		burst:   burst,
+		dropped: r.counter(serviceName),
+	}
+}
+
+// counter returns the spans_rate_limited counter of the service, shared by all services
+// above maxRateLimitedServices to bound the cardinality of the metric.
+func (r *ServiceRateLimiter) counter(serviceName string) metrics.Counter {
+	key := serviceName
+	if _, ok := r.counters[key]; !ok && len(r.counters) >= maxRateLimitedServices {
+		key = otherServices
+	}
+	if counter, ok := r.counters[key]; ok {
+		return counter
+	}
+	counter := r.metricsFactory.Counter(metrics.Options{
+		Name: "spans_rate_limited",
+		Tags: map[string]string{"service": key},
+		Help: "Total count of spans dropped by the agent because their client service exceeded its rate limit",
+	})
+	r.counters[key] = counter
+	return counter
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reporter
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+)
+
+func TestServiceRateLimiter(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	r := NewServiceRateLimiter(RateLimitOptions{
+		SpansPerSecond: 0.001,
+		Burst:          3,
+		Services:       map[string]float64{"exempt": 0},
+	}, mb)
+	assert.True(t, r.Allow("noisy", 2))
+	assert.False(t, r.Allow("noisy", 2))
+	assert.True(t, r.Allow("noisy", 1))
+	assert.False(t, r.Allow("noisy", 1))
+	// packets without spans, e.g. with client stats only, are never dropped
+	assert.True(t, r.Allow("noisy", 0))
+	assert.True(t, r.Allow("quiet", 3))
+	assert.True(t, r.Allow("exempt", 10))
+	assert.True(t, r.Allow("exempt", 10))
+
+	counters, _ := mb.Snapshot()
+	assert.EqualValues(t, 3, counters["client_stats.spans_rate_limited|service=noisy"])
+	assert.EqualValues(t, 0, counters["client_stats.spans_rate_limited|service=quiet"])
+	_, ok := counters["client_stats.spans_rate_limited|service=exempt"]
+	assert.False(t, ok)
+}
+
+func TestServiceRateLimiter_LargePackets(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	r := NewServiceRateLimiter(RateLimitOptions{
+		SpansPerSecond: 0.001,
+		Burst:          3,
+		Services:       map[string]float64{"override": 0.001},
+	}, mb)
+	// a packet with more spans than the burst takes the full bucket
+	assert.True(t, r.Allow("svc", 10))
+	assert.False(t, r.Allow("svc", 1))
+	assert.True(t, r.Allow("override", 10))
+	assert.False(t, r.Allow("override", 10))
+
+	counters, _ := mb.Snapshot()
+	assert.EqualValues(t, 1, counters["client_stats.spans_rate_limited|service=svc"])
+	assert.EqualValues(t, 10, counters["client_stats.spans_rate_limited|service=override"])
+}
+
+func TestServiceRateLimiter_ManyServices(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	r := NewServiceRateLimiter(RateLimitOptions{SpansPerSecond: 0.001, Burst: 1}, mb)
+	for i := 0; i < maxRateLimitedServices+2; i++ {
+		assert.True(t, r.Allow(fmt.Sprintf("svc-%d", i), 1))
+	}
+
+	// a noisy service above the limit does not consume the credits of the other services
+	assert.True(t, r.Allow("noisy", 1))
+	assert.False(t, r.Allow("noisy", 9))
+	assert.True(t, r.Allow("quiet", 1))
+
+	// the services above the limit share the counter of dropped spans
+	mb.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "client_stats.spans_rate_limited",
+		Tags:  map[string]string{"service": otherServices},
+		Value: 9,
+	})
+}
+
+func TestRateLimitOptions(t *testing.T) {
+	assert.False(t, RateLimitOptions{}.Enabled())
+	assert.True(t, RateLimitOptions{SpansPerSecond: 10}.Enabled())
+	assert.True(t, RateLimitOptions{Services: map[string]float64{"svc": 10}}.Enabled())
+}
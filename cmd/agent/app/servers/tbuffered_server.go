@@ -29,6 +29,9 @@ type ThriftTransport interface {
 	io.Closer
 }
 
+// PacketFilter returns false for the packets the server must drop instead of queueing them.
+type PacketFilter func(packet []byte) bool
+
 // TBufferedServer is a custom thrift server that reads traffic using the transport provided
 // and places messages into a buffered channel to be processed by the processor provided
 type TBufferedServer struct {
@@ -41,6 +44,7 @@ type TBufferedServer struct {
 	serving       uint32
 	transport     ThriftTransport
 	readBufPool   *sync.Pool
+	filter        PacketFilter
 	metrics       struct {
 		// Size of the current server queue
 		QueueSize metrics.Gauge `metric:"thrift.udp.server.queue_size"`
@@ -51,6 +55,9 @@ type TBufferedServer struct {
 		// Number of packets dropped by server
 		PacketsDropped metrics.Counter `metric:"thrift.udp.server.packets.dropped"`
 
+		// Number of packets dropped by the packet filter of the server before they were queued
+		PacketsFiltered metrics.Counter `metric:"thrift.udp.server.packets.filtered"`
+
 		// Number of packets processed by server
 		PacketsProcessed metrics.Counter `metric:"thrift.udp.server.packets.processed"`
 
@@ -96,6 +103,12 @@ func NewTBufferedServer(
 	return res, nil
 }
 
+// SetPacketFilter sets the filter of the packets read by the server, which is called from the Serve
+// goroutine before the packets are queued. It must be called before Serve.
+func (s *TBufferedServer) SetPacketFilter(filter PacketFilter) {
+	s.filter = filter
+}
+
 // Serve initiates the readers and starts serving traffic
 func (s *TBufferedServer) Serve() {
 	defer close(s.dataChan)
@@ -109,6 +122,11 @@ func (s *TBufferedServer) Serve() {
 		if err == nil {
 			readBuf.n = n
 			s.metrics.PacketSize.Update(int64(n))
+			if s.filter != nil && !s.filter(readBuf.GetBytes()) {
+				s.readBufPool.Put(readBuf)
+				s.metrics.PacketsFiltered.Inc(1)
+				continue
+			}
 			select {
 			case s.dataChan <- readBuf:
 				s.metrics.PacketsProcessed.Inc(1)
@@ -174,3 +174,40 @@ func TestTBufferedServer_Metrics(t *testing.T) {
 		metricstest.ExpectedMetric{Name: "thrift.udp.server.queue_size", Value: 0},
 	)
 }
+
+func TestTBufferedServer_PacketFilter(t *testing.T) {
+	metricsFactory := metricstest.NewFactory(0)
+
+	transport := new(fakeTransport)
+	transport.wg.Add(1)
+	defer transport.wg.Done()
+
+	server, err := NewTBufferedServer(transport, 1, 65000, metricsFactory)
+	require.NoError(t, err)
+	// the first packet is filtered, so the queue of size 1 has room for the second valid packet
+	server.SetPacketFilter(func(packet []byte) bool {
+		return packet[0] != 1
+	})
+	go server.Serve()
+	defer server.Stop()
+
+	var readBuf *ReadBuf
+	select {
+	case readBuf = <-server.DataChan():
+		assert.EqualValues(t, 3, readBuf.GetBytes()[0], "third packet must be all 0x03's")
+	case <-time.After(5 * time.Second):
+		t.Fatal("expecting a packet in the channel")
+	}
+	server.DataRecd(readBuf)
+
+	// the counter is incremented after the packet is queued
+	assert.Eventually(t, func() bool {
+		c, _ := metricsFactory.Snapshot()
+		return c["thrift.udp.server.packets.processed"] == 1
+	}, 5*time.Second, time.Millisecond)
+	metricsFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "thrift.udp.server.packets.filtered", Value: 1},
+		metricstest.ExpectedMetric{Name: "thrift.udp.server.packets.processed", Value: 1},
+		metricstest.ExpectedMetric{Name: "thrift.udp.server.packets.dropped", Value: 0},
+	)
+}
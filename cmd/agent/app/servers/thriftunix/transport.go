@@ -0,0 +1,246 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package thriftunix
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/apache/thrift/lib/go/thrift"
+)
+
+// Socket types of the server transports.
+const (
+	Datagram = "datagram"
+	Stream   = "stream"
+)
+
+// frameHeaderSize is the size of the big-endian length prefixing every message sent over a
+// stream socket, the same framing as Thrift's TFramedTransport.
+const frameHeaderSize = 4
+
+var (
+	errConnAlreadyClosed = errors.New("connection already closed")
+	errFrameTooLarge     = errors.New("frame larger than the max packet size")
+)
+
+// listen removes a stale socket file left by a previous process, then binds the socket
+// and sets the permissions of the socket file.
+func listen(path string, mode os.FileMode, bind func(*net.UnixAddr) (io.Closer, error)) (io.Closer, error) {
+	if fi, err := os.Lstat(path); err == nil {
+		if fi.Mode()&os.ModeSocket == 0 {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, err
+		}
+	}
+	closer, err := bind(&net.UnixAddr{Name: path})
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(path, mode); err != nil {
+		closer.Close()
+		return nil, err
+	}
+	return closer, nil
+}
+
+// TUnixgramTransport reads messages from a Unix datagram socket, one message per datagram.
+type TUnixgramTransport struct {
+	conn   *net.UnixConn
+	path   string
+	closed uint32 // atomic flag
+}
+
+// NewTUnixgramServerTransport creates a Unix datagram socket at the given path, with the given permissions.
+func NewTUnixgramServerTransport(path string, mode os.FileMode) (*TUnixgramTransport, error) {
+	closer, err := listen(path, mode, func(addr *net.UnixAddr) (io.Closer, error) {
+		return net.ListenUnixgram("unixgram", addr)
+	})
+	if err != nil {
+		return nil, thrift.NewTTransportException(thrift.NOT_OPEN, err.Error())
+	}
+	return &TUnixgramTransport{conn: closer.(*net.UnixConn), path: path}, nil
+}
+
+// Read reads one datagram and puts it in the specified buf.
+func (p *TUnixgramTransport) Read(buf []byte) (int, error) {
+	if atomic.LoadUint32(&p.closed) != 0 {
+		return 0, thrift.NewTTransportException(thrift.NOT_OPEN, "Connection not open")
+	}
+	n, err := p.conn.Read(buf)
+	return n, thrift.NewTTransportExceptionFromError(err)
+}
+
+// SetSocketBufferSize sets the receive buffer size of the socket.
+func (p *TUnixgramTransport) SetSocketBufferSize(bufferSize int) error {
+	return p.conn.SetReadBuffer(bufferSize)
+}
+
+// Addr returns the address of the socket.
+func (p *TUnixgramTransport) Addr() net.Addr {
+	return p.conn.LocalAddr()
+}
+
+// Close closes the socket and removes the socket file.
+func (p *TUnixgramTransport) Close() error {
+	if !atomic.CompareAndSwapUint32(&p.closed, 0, 1) {
+		return errConnAlreadyClosed
+	}
+	err := p.conn.Close()
+	os.Remove(p.path)
+	return err
+}
+
+// TUnixStreamTransport accepts connections on a Unix stream socket and reads the messages
+// sent by the clients, each one prefixed by its length as a 4 bytes big-endian integer.
+// Unlike datagrams, messages are not lost in the socket buffer when the agent reads slower than
+// the clients write, the clients block instead. They can still be dropped by the server reading
+// from the transport, e.g. TBufferedServer drops messages when its queue is full.
+type TUnixStreamTransport struct {
+	listener      *net.UnixListener
+	maxPacketSize int
+	frames        chan []byte
+	done          chan struct{}
+	closeOnce     sync.Once
+
+	mux   sync.Mutex
+	conns map[net.Conn]struct{}
+	wg    sync.WaitGroup
+}
+
+// NewTUnixStreamServerTransport creates a Unix stream socket at the given path, with the given permissions.
+// Connections sending messages larger than maxPacketSize are closed.
+func NewTUnixStreamServerTransport(path string, mode os.FileMode, maxPacketSize int) (*TUnixStreamTransport, error) {
+	closer, err := listen(path, mode, func(addr *net.UnixAddr) (io.Closer, error) {
+		return net.ListenUnix("unix", addr)
+	})
+	if err != nil {
+		return nil, thrift.NewTTransportException(thrift.NOT_OPEN, err.Error())
+	}
+	p := &TUnixStreamTransport{
+		listener:      closer.(*net.UnixListener),
+		maxPacketSize: maxPacketSize,
+		frames:        make(chan []byte),
+		done:          make(chan struct{}),
+		conns:         make(map[net.Conn]struct{}),
+	}
+	p.wg.Add(1)
+	go p.acceptLoop()
+	return p, nil
+}
+
+func (p *TUnixStreamTransport) acceptLoop() {
+	defer p.wg.Done()
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return // the listener is closed
+		}
+		p.mux.Lock()
+		select {
+		case <-p.done:
+			p.mux.Unlock()
+			conn.Close()
+			return
+		default:
+		}
+		p.conns[conn] = struct{}{}
+		p.mux.Unlock()
+		p.wg.Add(1)
+		go p.readLoop(conn)
+	}
+}
+
+func (p *TUnixStreamTransport) readLoop(conn net.Conn) {
+	defer func() {
+		p.mux.Lock()
+		delete(p.conns, conn)
+		p.mux.Unlock()
+		conn.Close()
+		p.wg.Done()
+	}()
+	var header [frameHeaderSize]byte
+	for {
+		if _, err := io.ReadFull(conn, header[:]); err != nil {
+			return
+		}
+		size := binary.BigEndian.Uint32(header[:])
+		if size > uint32(p.maxPacketSize) {
+			// the stream cannot be resynchronized, the client has to reconnect
+			p.send(nil)
+			return
+		}
+		frame := make([]byte, size)
+		if _, err := io.ReadFull(conn, frame); err != nil {
+			return
+		}
+		if !p.send(frame) {
+			return
+		}
+	}
+}
+
+// send passes the frame to Read, a nil frame reports an invalid message.
+func (p *TUnixStreamTransport) send(frame []byte) bool {
+	select {
+	case p.frames <- frame:
+		return true
+	case <-p.done:
+		return false
+	}
+}
+
+// Read reads one message sent by any of the clients and puts it in the specified buf.
+func (p *TUnixStreamTransport) Read(buf []byte) (int, error) {
+	select {
+	case frame := <-p.frames:
+		if frame == nil || len(frame) > len(buf) {
+			return 0, thrift.NewTTransportExceptionFromError(errFrameTooLarge)
+		}
+		return copy(buf, frame), nil
+	case <-p.done:
+		return 0, thrift.NewTTransportException(thrift.NOT_OPEN, "Connection not open")
+	}
+}
+
+// Addr returns the address of the socket.
+func (p *TUnixStreamTransport) Addr() net.Addr {
+	return p.listener.Addr()
+}
+
+// Close closes the socket and the client connections, and removes the socket file.
+func (p *TUnixStreamTransport) Close() error {
+	err := errConnAlreadyClosed
+	p.closeOnce.Do(func() {
+		p.mux.Lock()
+		close(p.done)
+		for conn := range p.conns {
+			conn.Close()
+		}
+		p.mux.Unlock()
+		// closing the listener also removes the socket file
+		err = p.listener.Close()
+		p.wg.Wait()
+	})
+	return err
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package thriftunix
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func socketPath(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "thriftunix")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	return filepath.Join(dir, "agent.sock")
+}
+
+func writeFrame(t *testing.T, conn net.Conn, msg []byte) {
+	var header [frameHeaderSize]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(msg)))
+	_, err := conn.Write(append(header[:], msg...))
+	require.NoError(t, err)
+}
+
+func TestUnixgramTransport(t *testing.T) {
+	path := socketPath(t)
+	trans, err := NewTUnixgramServerTransport(path, 0o660)
+	require.NoError(t, err)
+	assert.Equal(t, path, trans.Addr().String())
+	require.NoError(t, trans.SetSocketBufferSize(1024*1024))
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o660), fi.Mode().Perm())
+
+	conn, err := net.Dial("unixgram", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Write([]byte("first"))
+	require.NoError(t, err)
+	_, err = conn.Write([]byte("second"))
+	require.NoError(t, err)
+
+	buf := make([]byte, 100)
+	n, err := trans.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(buf[:n]))
+	n, err = trans.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(buf[:n]))
+
+	require.NoError(t, trans.Close())
+	assert.Error(t, trans.Close())
+	_, err = trans.Read(buf)
+	assert.Error(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUnixgramTransportStaleSocket(t *testing.T) {
+	path := socketPath(t)
+	// a socket file left behind by a crashed agent
+	stale, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path})
+	require.NoError(t, err)
+	stale.Close()
+
+	trans, err := NewTUnixgramServerTransport(path, 0o600)
+	require.NoError(t, err)
+	require.NoError(t, trans.Close())
+}
+
+func TestTransportNotASocket(t *testing.T) {
+	path := socketPath(t)
+	require.NoError(t, ioutil.WriteFile(path, nil, 0o600))
+	_, err := NewTUnixgramServerTransport(path, 0o600)
+	assert.Contains(t, err.Error(), "is not a socket")
+	_, err = NewTUnixStreamServerTransport(path, 0o600, 100)
+	assert.Contains(t, err.Error(), "is not a socket")
+
+	_, err = NewTUnixgramServerTransport("/does/not/exist/agent.sock", 0o600)
+	assert.Error(t, err)
+}
+
+func TestUnixStreamTransport(t *testing.T) {
+	path := socketPath(t)
+	trans, err := NewTUnixStreamServerTransport(path, 0o666, 10)
+	require.NoError(t, err)
+	assert.Equal(t, path, trans.Addr().String())
+
+	fi, err := os.Stat(path)
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o666), fi.Mode().Perm())
+
+	conn1, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn1.Close()
+	conn2, err := net.Dial("unix", path)
+	require.NoError(t, err)
+	defer conn2.Close()
+
+	buf := make([]byte, 10)
+	writeFrame(t, conn1, []byte("first"))
+	n, err := trans.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "first", string(buf[:n]))
+	writeFrame(t, conn2, []byte("second"))
+	n, err = trans.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "second", string(buf[:n]))
+
+	// messages over the max packet size are rejected and the connection is closed
+	writeFrame(t, conn1, []byte("too large message"))
+	_, err = trans.Read(buf)
+	assert.Error(t, err)
+	_, err = conn1.Read(buf)
+	assert.Error(t, err)
+
+	writeFrame(t, conn2, []byte("third"))
+	n, err = trans.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, "third", string(buf[:n]))
+
+	require.NoError(t, trans.Close())
+	assert.Error(t, trans.Close())
+	_, err = trans.Read(buf)
+	assert.Error(t, err)
+	_, err = os.Stat(path)
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,236 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttling
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/cache"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+// maxAccounts limits the number of balances of services and operations kept by the Throttler.
+const maxAccounts = 10000
+
+// defaultAccrual is used for services that are not configured when the file has no default.
+var defaultAccrual = Accrual{CreditsPerSecond: 1, MaxBalance: 10}
+
+// CreditsManager grants credits to clients, allowing them to emit debug spans.
+type CreditsManager interface {
+	GetCredits(ctx context.Context, serviceName, clientID string, operations []string) (*CreditsResponse, error)
+}
+
+// CreditsResponse is the response to a client's request for credits, in the format
+// expected by the remote throttler of Jaeger clients.
+type CreditsResponse struct {
+	Balances []OperationBalance `json:"balances"`
+}
+
+// OperationBalance is the number of credits granted for an operation.
+type OperationBalance struct {
+	Operation string  `json:"operation"`
+	Balance   float64 `json:"balance"`
+}
+
+// Accrual defines how fast credits accumulate for every operation of a service.
+type Accrual struct {
+	CreditsPerSecond float64 `json:"creditsPerSecond"`
+	MaxBalance       float64 `json:"maxBalance"`
+}
+
+// Config is the content of the throttling configuration file.
+type Config struct {
+	Default  *Accrual           `json:"default"`
+	Services map[string]Accrual `json:"services"`
+}
+
+func (c *Config) accrual(serviceName string) Accrual {
+	if a, ok := c.Services[serviceName]; ok {
+		return a
+	}
+	if c.Default != nil {
+		return *c.Default
+	}
+	return defaultAccrual
+}
+
+type account struct {
+	balance float64
+	updated time.Time
+}
+
+// withdraw returns the balance accrued since the last withdrawal, capped by the accrual, and empties it.
+func (a *account) withdraw(accrual Accrual, now time.Time) float64 {
+	balance := a.balance + now.Sub(a.updated).Seconds()*accrual.CreditsPerSecond
+	balance = math.Min(balance, accrual.MaxBalance)
+	a.balance, a.updated = 0, now
+	return balance
+}
+
+// serviceKey returns the key of the balance of the service from which its new operations are credited.
+func serviceKey(serviceName string) string {
+	return "s\x00" + serviceName
+}
+
+// operationKey returns the key of the balance of the operation of the service.
+func operationKey(serviceName, operation string) string {
+	return "o\x00" + serviceName + "\x00" + operation
+}
+
+type throttlerMetrics struct {
+	// Number of operations for which credits were requested
+	Requests metrics.Counter `metric:"throttling.operations"`
+
+	// Number of operations that were granted less than one credit, i.e. throttled
+	Throttled metrics.Counter `metric:"throttling.throttled"`
+
+	// Number of balances evicted because more than maxAccounts services and operations were requested
+	Evictions metrics.Counter `metric:"throttling.evictions"`
+}
+
+// Throttler grants credits from a per-service and per-operation balance accruing at the
+// rate configured in a file, which is reloaded when it changes. The balance is shared by all
+// the clients of a service, so the debug spans of a service are capped regardless of how many
+// instances report to the agent. The balances of the least recently requested operations are
+// evicted once there are maxAccounts of them.
+type Throttler struct {
+	logger  *zap.Logger
+	metrics throttlerMetrics
+	timeNow func() time.Time
+	watcher *fswatcher.FileWatcher
+
+	mux      sync.Mutex
+	config   *Config
+	accounts *cache.LRU // *account by serviceKey and operationKey
+}
+
+// NewThrottler creates a Throttler configured by the given file.
+func NewThrottler(path string, mFactory metrics.Factory, logger *zap.Logger) (*Throttler, error) {
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	t := &Throttler{
+		logger:  logger,
+		timeNow: time.Now,
+		config:  config,
+	}
+	metrics.MustInit(&t.metrics, mFactory, nil)
+	t.accounts = t.newAccounts(maxAccounts)
+	t.watcher, err = fswatcher.WatchFile(path, func() { t.reload(path) }, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch throttling config: %w", err)
+	}
+	return t, nil
+}
+
+func (t *Throttler) newAccounts(size int) *cache.LRU {
+	return cache.NewLRUWithOptions(size, &cache.Options{
+		OnEvict: func(string, interface{}) { t.metrics.Evictions.Inc(1) },
+	})
+}
+
+// GetCredits implements CreditsManager. The credits accrued since the last request for an
+// operation are withdrawn from its balance and returned to the client.
+func (t *Throttler) GetCredits(_ context.Context, serviceName, _ string, operations []string) (*CreditsResponse, error) {
+	t.mux.Lock()
+	defer t.mux.Unlock()
+	now := t.timeNow()
+	accrual := t.config.accrual(serviceName)
+	resp := &CreditsResponse{Balances: make([]OperationBalance, 0, len(operations))}
+	for _, operation := range operations {
+		key := operationKey(serviceName, operation)
+		acc, ok := t.accounts.Get(key).(*account)
+		if !ok {
+			// New operations, and those whose balance was evicted, start with the credits withdrawn
+			// from the balance of the service, so that requesting made-up operations yields no credits.
+			acc = &account{balance: t.account(serviceKey(serviceName), accrual, now).withdraw(accrual, now), updated: now}
+			t.accounts.Put(key, acc)
+		}
+		balance := acc.withdraw(accrual, now)
+		t.metrics.Requests.Inc(1)
+		if balance < 1 {
+			t.metrics.Throttled.Inc(1)
+		}
+		resp.Balances = append(resp.Balances, OperationBalance{Operation: operation, Balance: balance})
+	}
+	return resp, nil
+}
+
+// account returns the account with the given key, created with a full balance if it does not exist.
+func (t *Throttler) account(key string, accrual Accrual, now time.Time) *account {
+	if acc, ok := t.accounts.Get(key).(*account); ok {
+		return acc
+	}
+	acc := &account{balance: accrual.MaxBalance, updated: now}
+	t.accounts.Put(key, acc)
+	return acc
+}
+
+// Close stops watching the configuration file.
+func (t *Throttler) Close() error {
+	return t.watcher.Close()
+}
+
+func (t *Throttler) reload(path string) {
+	config, err := loadConfig(path)
+	if err != nil {
+		t.logger.Error("Failed to reload throttling config, using the last known version", zap.Error(err))
+		return
+	}
+	t.mux.Lock()
+	t.config = config
+	t.mux.Unlock()
+	t.logger.Info("Throttling config reloaded")
+}
+
+func loadConfig(path string) (*Config, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read throttling config: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(content, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse throttling config: %w", err)
+	}
+	if config.Default != nil {
+		if err := validate(*config.Default); err != nil {
+			return nil, fmt.Errorf("invalid default throttling config: %w", err)
+		}
+	}
+	for service, accrual := range config.Services {
+		if err := validate(accrual); err != nil {
+			return nil, fmt.Errorf("invalid throttling config for service %s: %w", service, err)
+		}
+	}
+	return &config, nil
+}
+
+func validate(accrual Accrual) error {
+	if accrual.CreditsPerSecond < 0 || accrual.MaxBalance < 0 {
+		return errors.New("creditsPerSecond and maxBalance must not be negative")
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package throttling
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+)
+
+const testConfig = `{
+	"default": {"creditsPerSecond": 1, "maxBalance": 5},
+	"services": {"noisy": {"creditsPerSecond": 0.5, "maxBalance": 1}}
+}`
+
+func writeConfig(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "throttling")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "throttling.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestThrottlerCredits(t *testing.T) {
+	mFactory := metricstest.NewFactory(time.Hour)
+	throttler, err := NewThrottler(writeConfig(t, testConfig), mFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, throttler.Close()) }()
+	now := time.Now()
+	throttler.timeNow = func() time.Time { return now }
+
+	resp, err := throttler.GetCredits(context.Background(), "svc", "client-1", []string{"op1", "op2"})
+	require.NoError(t, err)
+	// new operations share the balance of the service
+	assert.Equal(t, &CreditsResponse{Balances: []OperationBalance{{"op1", 5}, {"op2", 0}}}, resp)
+
+	// the balance is shared by all the clients of the service
+	resp, err = throttler.GetCredits(context.Background(), "svc", "client-2", []string{"op1"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op1", 0}}, resp.Balances)
+
+	now = now.Add(2 * time.Second)
+	resp, err = throttler.GetCredits(context.Background(), "svc", "client-2", []string{"op1"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op1", 2}}, resp.Balances)
+
+	// the balance is capped
+	now = now.Add(time.Minute)
+	resp, err = throttler.GetCredits(context.Background(), "noisy", "client-3", []string{"op1"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op1", 1}}, resp.Balances)
+	now = now.Add(time.Second)
+	resp, err = throttler.GetCredits(context.Background(), "noisy", "client-3", []string{"op1"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op1", 0.5}}, resp.Balances)
+
+	resp, err = throttler.GetCredits(context.Background(), "svc", "client-1", nil)
+	require.NoError(t, err)
+	assert.Empty(t, resp.Balances)
+
+	mFactory.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "throttling.operations", Value: 6},
+		metricstest.ExpectedMetric{Name: "throttling.throttled", Value: 3},
+	)
+}
+
+func TestThrottlerNewOperations(t *testing.T) {
+	throttler, err := NewThrottler(writeConfig(t, testConfig), metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, throttler.Close()) }()
+	now := time.Now()
+	throttler.timeNow = func() time.Time { return now }
+
+	resp, err := throttler.GetCredits(context.Background(), "svc", "client", []string{"op1"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op1", 5}}, resp.Balances)
+
+	// made-up operations do not yield more credits than the service accrues
+	resp, err = throttler.GetCredits(context.Background(), "svc", "client", []string{"op2", "op3"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op2", 0}, {"op3", 0}}, resp.Balances)
+
+	now = now.Add(3 * time.Second)
+	resp, err = throttler.GetCredits(context.Background(), "svc", "client", []string{"op1", "op4"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op1", 3}, {"op4", 3}}, resp.Balances)
+}
+
+func TestThrottlerEvictions(t *testing.T) {
+	mFactory := metricstest.NewFactory(time.Hour)
+	throttler, err := NewThrottler(writeConfig(t, testConfig), mFactory, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, throttler.Close()) }()
+	now := time.Now()
+	throttler.timeNow = func() time.Time { return now }
+	throttler.accounts = throttler.newAccounts(3)
+
+	// the balances of the service and of op1 and op2 fill the accounts, op3 evicts op1
+	resp, err := throttler.GetCredits(context.Background(), "svc", "client", []string{"op1", "op2", "op3"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op1", 5}, {"op2", 0}, {"op3", 0}}, resp.Balances)
+	assert.Equal(t, 3, throttler.accounts.Size())
+
+	// an evicted operation starts again from the balance of the service, not from a full balance
+	resp, err = throttler.GetCredits(context.Background(), "svc", "client", []string{"op1"})
+	require.NoError(t, err)
+	assert.Equal(t, []OperationBalance{{"op1", 0}}, resp.Balances)
+
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "throttling.evictions", Value: 2})
+}
+
+func TestThrottlerReload(t *testing.T) {
+	path := writeConfig(t, `{}`)
+	throttler, err := NewThrottler(path, metricstest.NewFactory(time.Hour), zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, throttler.Close()) }()
+
+	resp, err := throttler.GetCredits(context.Background(), "svc", "client", []string{"op"})
+	require.NoError(t, err)
+	assert.Equal(t, defaultAccrual.MaxBalance, resp.Balances[0].Balance)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(testConfig), 0o600))
+	assert.Eventually(t, func() bool {
+		throttler.mux.Lock()
+		defer throttler.mux.Unlock()
+		return throttler.config.Default != nil
+	}, time.Second, 10*time.Millisecond)
+
+	// invalid changes keep the last known config
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"default": {"maxBalance": -1}}`), 0o600))
+	time.Sleep(50 * time.Millisecond)
+	throttler.mux.Lock()
+	assert.Equal(t, 5.0, throttler.config.Default.MaxBalance)
+	throttler.mux.Unlock()
+}
+
+func TestThrottlerConfigErrors(t *testing.T) {
+	tests := []struct {
+		config string
+		err    string
+	}{
+		{config: `{`, err: "failed to parse throttling config"},
+		{config: `{"default": {"creditsPerSecond": -1}}`, err: "invalid default throttling config"},
+		{config: `{"services": {"svc": {"maxBalance": -1}}}`, err: "invalid throttling config for service svc"},
+	}
+	for _, test := range tests {
+		_, err := NewThrottler(writeConfig(t, test.config), metricstest.NewFactory(time.Hour), zap.NewNop())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+	_, err := NewThrottler("/does/not/exist", metricstest.NewFactory(time.Hour), zap.NewNop())
+	assert.Contains(t, err.Error(), "failed to read throttling config")
+}
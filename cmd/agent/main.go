@@ -30,6 +30,7 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/agent/app"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter"
 	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter/grpc"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/reporter/otlp"
 	"github.com/jaegertracing/jaeger/cmd/docs"
 	"github.com/jaegertracing/jaeger/cmd/flags"
 	"github.com/jaegertracing/jaeger/cmd/status"
@@ -61,8 +62,10 @@ func main() {
 
 			rOpts := new(reporter.Options).InitFromViper(v, logger)
 			grpcBuilder := grpc.NewConnBuilder().InitFromViper(v)
+			otlpOptions := new(otlp.Options).InitFromViper(v)
 			builders := map[reporter.Type]app.CollectorProxyBuilder{
 				reporter.GRPC: app.GRPCCollectorProxyBuilder(grpcBuilder),
+				reporter.OTLP: app.OTLPCollectorProxyBuilder(otlpOptions),
 			}
 			cp, err := app.CreateCollectorProxy(app.ProxyBuilderOptions{
 				Options: *rOpts,
@@ -75,7 +78,7 @@ func main() {
 
 			// TODO illustrate discovery service wiring
 
-			builder := new(app.Builder).InitFromViper(v)
+			builder := new(app.Builder).InitFromViper(v).WithRateLimits(rOpts.RateLimits)
 			agent, err := builder.CreateAgent(cp, logger, mFactory)
 			if err != nil {
 				return fmt.Errorf("unable to initialize Jaeger Agent: %w", err)
@@ -105,6 +108,7 @@ func main() {
 		app.AddFlags,
 		reporter.AddFlags,
 		grpc.AddFlags,
+		otlp.AddFlags,
 	)
 
 	if err := command.Execute(); err != nil {
@@ -0,0 +1,50 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+
+	"github.com/jaegertracing/jaeger/proto-gen/baggage_v1"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+// GRPCHandler serves baggage restrictions to the agents over gRPC.
+type GRPCHandler struct {
+	manager baggage.BaggageRestrictionManager
+}
+
+// NewGRPCHandler creates a handler serving the restrictions of the given manager.
+func NewGRPCHandler(manager baggage.BaggageRestrictionManager) GRPCHandler {
+	return GRPCHandler{
+		manager: manager,
+	}
+}
+
+// GetBaggageRestrictions returns the baggage restrictions of a service.
+func (h GRPCHandler) GetBaggageRestrictions(ctx context.Context, param *baggage_v1.GetBaggageRestrictionsRequest) (*baggage_v1.GetBaggageRestrictionsResponse, error) {
+	r, err := h.manager.GetBaggageRestrictions(ctx, param.ServiceName)
+	if err != nil {
+		return nil, err
+	}
+	resp := &baggage_v1.GetBaggageRestrictionsResponse{Restrictions: make([]*baggage_v1.BaggageRestriction, len(r))}
+	for i, restriction := range r {
+		resp.Restrictions[i] = &baggage_v1.BaggageRestriction{
+			BaggageKey:     restriction.BaggageKey,
+			MaxValueLength: restriction.MaxValueLength,
+		}
+	}
+	return resp, nil
+}
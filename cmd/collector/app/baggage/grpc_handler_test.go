@@ -0,0 +1,59 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+
+	"github.com/jaegertracing/jaeger/proto-gen/baggage_v1"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+type mockManager struct{}
+
+func (mockManager) GetBaggageRestrictions(_ context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	if serviceName == "error" {
+		return nil, errors.New("no restrictions")
+	}
+	return []*baggage.BaggageRestriction{{BaggageKey: "key", MaxValueLength: 10}}, nil
+}
+
+func TestGRPCHandler(t *testing.T) {
+	server := grpc.NewServer()
+	baggage_v1.RegisterBaggageRestrictionManagerServer(server, NewGRPCHandler(mockManager{}))
+	lis, err := net.Listen("tcp", "localhost:0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+	defer server.Stop()
+
+	conn, err := grpc.Dial(lis.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+	client := baggage_v1.NewBaggageRestrictionManagerClient(conn)
+
+	resp, err := client.GetBaggageRestrictions(context.Background(), &baggage_v1.GetBaggageRestrictionsRequest{ServiceName: "svc"})
+	require.NoError(t, err)
+	assert.Equal(t, []*baggage_v1.BaggageRestriction{{BaggageKey: "key", MaxValueLength: 10}}, resp.Restrictions)
+
+	_, err = client.GetBaggageRestrictions(context.Background(), &baggage_v1.GetBaggageRestrictionsRequest{ServiceName: "error"})
+	assert.Contains(t, err.Error(), "no restrictions")
+}
@@ -0,0 +1,170 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync/atomic"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+// restrictionsFile is the content of the baggage restrictions file.
+type restrictionsFile struct {
+	DefaultRestrictions []*baggage.BaggageRestriction `json:"default_restrictions"`
+	ServiceRestrictions []serviceRestrictions         `json:"service_restrictions"`
+}
+
+type serviceRestrictions struct {
+	Service      string                        `json:"service"`
+	Restrictions []*baggage.BaggageRestriction `json:"restrictions"`
+}
+
+// restrictions holds the baggage restrictions loaded from the file.
+type restrictions struct {
+	defaults []*baggage.BaggageRestriction
+	services map[string][]*baggage.BaggageRestriction
+}
+
+type storeMetrics struct {
+	// ReloadSuccess is the number of successful reloads of the baggage restrictions file
+	ReloadSuccess metrics.Counter `metric:"reloads" tags:"result=ok"`
+	// ReloadFailure is the number of failed reloads of the baggage restrictions file
+	ReloadFailure metrics.Counter `metric:"reloads" tags:"result=err"`
+}
+
+// Store serves the baggage keys each service is allowed to use and the maximum length of their
+// values, loaded from a JSON file. Services that are not listed in the file get the default
+// restrictions. The file is watched and reloaded whenever it changes.
+type Store struct {
+	path         string
+	logger       *zap.Logger
+	metrics      storeMetrics
+	restrictions atomic.Value // *restrictions
+	watcher      io.Closer
+}
+
+var _ baggage.BaggageRestrictionManager = (*Store)(nil)
+
+// NewStore creates a Store from the baggage restrictions file at the given path and starts watching the file for changes.
+func NewStore(path string, logger *zap.Logger, metricsFactory metrics.Factory) (*Store, error) {
+	r, err := loadRestrictions(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Store{
+		path:   path,
+		logger: logger,
+	}
+	metrics.MustInit(&s.metrics, metricsFactory.Namespace(metrics.NSOptions{Name: "baggage_restrictions"}), nil)
+	s.restrictions.Store(r)
+	watcher, err := fswatcher.WatchFile(path, s.reload, logger)
+	if err != nil {
+		return nil, err
+	}
+	s.watcher = watcher
+	logger.Info("Loaded baggage restrictions", zap.String("file", path), zap.Int("services", len(r.services)))
+	return s, nil
+}
+
+// GetBaggageRestrictions implements baggage.BaggageRestrictionManager.
+func (s *Store) GetBaggageRestrictions(_ context.Context, serviceName string) ([]*baggage.BaggageRestriction, error) {
+	r := s.restrictions.Load().(*restrictions)
+	if restrictions, ok := r.services[serviceName]; ok {
+		return restrictions, nil
+	}
+	return r.defaults, nil
+}
+
+// Close stops watching the baggage restrictions file.
+func (s *Store) Close() error {
+	return s.watcher.Close()
+}
+
+func (s *Store) reload() {
+	r, err := loadRestrictions(s.path)
+	if err != nil {
+		s.metrics.ReloadFailure.Inc(1)
+		s.logger.Error("Failed to reload baggage restrictions, using the last known version", zap.String("file", s.path), zap.Error(err))
+		return
+	}
+	s.restrictions.Store(r)
+	s.metrics.ReloadSuccess.Inc(1)
+	s.logger.Info("Reloaded baggage restrictions", zap.String("file", s.path), zap.Int("services", len(r.services)))
+}
+
+func loadRestrictions(path string) (*restrictions, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baggage restrictions file: %w", err)
+	}
+	var file restrictionsFile
+	if err := json.Unmarshal(content, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse baggage restrictions file: %w", err)
+	}
+	if err := validateRestrictions(file.DefaultRestrictions); err != nil {
+		return nil, fmt.Errorf("invalid default baggage restrictions: %w", err)
+	}
+	r := &restrictions{
+		defaults: file.DefaultRestrictions,
+		services: make(map[string][]*baggage.BaggageRestriction, len(file.ServiceRestrictions)),
+	}
+	if r.defaults == nil {
+		// clients expect a list, an empty one disallows all baggage keys
+		r.defaults = []*baggage.BaggageRestriction{}
+	}
+	for _, service := range file.ServiceRestrictions {
+		if service.Service == "" {
+			return nil, errors.New("invalid baggage restrictions: service name is required")
+		}
+		if _, ok := r.services[service.Service]; ok {
+			return nil, fmt.Errorf("invalid baggage restrictions: duplicate service %s", service.Service)
+		}
+		if err := validateRestrictions(service.Restrictions); err != nil {
+			return nil, fmt.Errorf("invalid baggage restrictions for service %s: %w", service.Service, err)
+		}
+		if service.Restrictions == nil {
+			service.Restrictions = []*baggage.BaggageRestriction{}
+		}
+		r.services[service.Service] = service.Restrictions
+	}
+	return r, nil
+}
+
+func validateRestrictions(restrictions []*baggage.BaggageRestriction) error {
+	keys := make(map[string]struct{}, len(restrictions))
+	for _, restriction := range restrictions {
+		if restriction == nil || restriction.BaggageKey == "" {
+			return errors.New("baggage key is required")
+		}
+		if restriction.MaxValueLength <= 0 {
+			return fmt.Errorf("max value length of baggage key %s must be positive", restriction.BaggageKey)
+		}
+		if _, ok := keys[restriction.BaggageKey]; ok {
+			return fmt.Errorf("duplicate baggage key %s", restriction.BaggageKey)
+		}
+		keys[restriction.BaggageKey] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package baggage
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
+)
+
+const testRestrictions = `{
+	"default_restrictions": [{"baggageKey": "request-id", "maxValueLength": 36}],
+	"service_restrictions": [
+		{"service": "frontend", "restrictions": [{"baggageKey": "customer", "maxValueLength": 64}]},
+		{"service": "locked"}
+	]
+}`
+
+func writeRestrictions(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "baggage")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "baggage.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestStore(t *testing.T) {
+	path := writeRestrictions(t, testRestrictions)
+	mFactory := metricstest.NewFactory(time.Hour)
+	store, err := NewStore(path, zap.NewNop(), mFactory)
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, store.Close()) }()
+
+	tests := []struct {
+		service  string
+		expected []*baggage.BaggageRestriction
+	}{
+		{service: "frontend", expected: []*baggage.BaggageRestriction{{BaggageKey: "customer", MaxValueLength: 64}}},
+		{service: "locked", expected: []*baggage.BaggageRestriction{}},
+		{service: "other", expected: []*baggage.BaggageRestriction{{BaggageKey: "request-id", MaxValueLength: 36}}},
+	}
+	for _, test := range tests {
+		r, err := store.GetBaggageRestrictions(context.Background(), test.service)
+		require.NoError(t, err)
+		assert.Equal(t, test.expected, r, test.service)
+	}
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{}`), 0o600))
+	assert.Eventually(t, func() bool {
+		r, _ := store.GetBaggageRestrictions(context.Background(), "frontend")
+		return len(r) == 0
+	}, time.Second, 10*time.Millisecond)
+
+	// invalid changes keep the last known version
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{`), 0o600))
+	assert.Eventually(t, func() bool {
+		counters, _ := mFactory.Snapshot()
+		return counters["baggage_restrictions.reloads|result=err"] == 1
+	}, time.Second, 10*time.Millisecond)
+	r, err := store.GetBaggageRestrictions(context.Background(), "frontend")
+	require.NoError(t, err)
+	assert.Equal(t, []*baggage.BaggageRestriction{}, r)
+	mFactory.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "baggage_restrictions.reloads", Tags: map[string]string{"result": "ok"}, Value: 1})
+}
+
+func TestStoreErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{content: `{`, err: "failed to parse baggage restrictions file"},
+		{content: `{"default_restrictions": [{"maxValueLength": 1}]}`, err: "invalid default baggage restrictions: baggage key is required"},
+		{content: `{"default_restrictions": [{"baggageKey": "k"}]}`, err: "max value length of baggage key k must be positive"},
+		{content: `{"default_restrictions": [{"baggageKey": "k", "maxValueLength": 1}, {"baggageKey": "k", "maxValueLength": 2}]}`, err: "duplicate baggage key k"},
+		{content: `{"service_restrictions": [{"restrictions": []}]}`, err: "service name is required"},
+		{content: `{"service_restrictions": [{"service": "s"}, {"service": "s"}]}`, err: "duplicate service s"},
+		{content: `{"service_restrictions": [{"service": "s", "restrictions": [{"baggageKey": "k"}]}]}`, err: "invalid baggage restrictions for service s"},
+	}
+	for _, test := range tests {
+		_, err := NewStore(writeRestrictions(t, test.content), zap.NewNop(), metricstest.NewFactory(time.Hour))
+		require.Error(t, err, test.content)
+		assert.Contains(t, err.Error(), test.err)
+	}
+	_, err := NewStore("/does/not/exist", zap.NewNop(), metricstest.NewFactory(time.Hour))
+	assert.Contains(t, err.Error(), "failed to read baggage restrictions file")
+}
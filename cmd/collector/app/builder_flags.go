@@ -17,21 +17,54 @@ package app
 
 import (
 	"flag"
+	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/memorylimiter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/traceevents"
 	"github.com/jaegertracing/jaeger/cmd/flags"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
+	"github.com/jaegertracing/jaeger/pkg/httpbody"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/ports"
 )
 
 const (
+	collectorAttributeRulesFile   = "collector.attribute-rules.file"
+	collectorBaggageFile          = "collector.baggage-restrictions.file"
+	collectorDedupMaxKeys         = "collector.dedup.max-keys"
+	collectorDedupWindow          = "collector.dedup.window"
+	collectorDrainTimeout         = "collector.shutdown.drain-timeout"
 	collectorDynQueueSizeMemory   = "collector.queue-size-memory"
 	collectorGRPCHostPort         = "collector.grpc-server.host-port"
+	collectorHTTPAllowedHeaders   = "collector.http-server.allowed-headers"
+	collectorHTTPAllowedOrigins   = "collector.http-server.allowed-origins"
 	collectorHTTPHostPort         = "collector.http-server.host-port"
+	collectorHostMetadataFile     = "collector.host-metadata.file"
+	collectorMaxBodySize          = "collector.max-body-size-mib"
+	collectorMemoryCheckInterval  = "collector.memory-limiter.check-interval"
+	collectorMemoryHardLimit      = "collector.memory-limiter.hard-limit-mib"
+	collectorMemorySoftLimit      = "collector.memory-limiter.soft-limit-mib"
 	collectorNumWorkers           = "collector.num-workers"
 	collectorQueueSize            = "collector.queue-size"
+	collectorQuotasFile           = "collector.quotas.file"
+	collectorRedactionDetectors   = "collector.redaction.detectors"
+	collectorRedactionFile        = "collector.redaction.detectors-file"
+	collectorRedactionMask        = "collector.redaction.mask"
 	collectorTags                 = "collector.tags"
+	collectorTraceEventsFile      = "collector.trace-events.file"
+	collectorTraceEventsIdle      = "collector.trace-events.idle-timeout"
+	collectorTraceEventsMaxTraces = "collector.trace-events.max-traces"
+	collectorSpanPipelineFile     = "collector.span-pipeline.file"
+	collectorSpanMaxLogs          = "collector.span-limits.max-logs"
+	collectorSpanMaxSize          = "collector.span-limits.max-span-size"
+	collectorSpanMaxTags          = "collector.span-limits.max-tags"
+	collectorSpanMaxTagValueLen   = "collector.span-limits.max-tag-value-length"
 	collectorZipkinAllowedHeaders = "collector.zipkin.allowed-headers"
 	collectorZipkinAllowedOrigins = "collector.zipkin.allowed-origins"
 	collectorZipkinHTTPHostPort   = "collector.zipkin.host-port"
@@ -51,8 +84,20 @@ var tlsHTTPFlagsConfig = tlscfg.ServerFlagsConfig{
 
 // CollectorOptions holds configuration for collector
 type CollectorOptions struct {
+	// AttributeRulesFile is the path to the file with the rules applied to span and process tags
+	AttributeRulesFile string
+	// BaggageRestrictionsFile is the path to the file with the baggage keys allowed for each service
+	BaggageRestrictionsFile string
+	// Dedup configures the detection of the spans received more than once
+	Dedup dedup.Options
 	// DynQueueSizeMemory determines how much memory to use for the queue
 	DynQueueSizeMemory uint
+	// DrainTimeout is how long the collector waits on shutdown for the queued spans to be saved
+	DrainTimeout time.Duration
+	// MaxBodySize is the maximum size in bytes of the decompressed bodies of the requests received by the HTTP and Zipkin servers
+	MaxBodySize int64
+	// MemoryLimiter configures the rejection of incoming spans when the heap is too big
+	MemoryLimiter memorylimiter.Options
 	// QueueSize is the size of collector's queue
 	QueueSize int
 	// NumWorkers is the number of internal workers in a collector
@@ -67,6 +112,30 @@ type CollectorOptions struct {
 	TLSHTTP tlscfg.Options
 	// CollectorTags is the string representing collector tags to append to each and every span
 	CollectorTags map[string]string
+	// HostMetadataFile is the path to the file with the metadata of the hosts, added to the process tags of their spans
+	HostMetadataFile string
+	// QuotasFile is the path to the file with per-service span rate limits
+	QuotasFile string
+	// RedactionDetectors is the list of built-in detectors of sensitive values to mask in span tags and logs
+	RedactionDetectors []string
+	// RedactionDetectorsFile is the path to a file with custom detectors of sensitive values
+	RedactionDetectorsFile string
+	// RedactionMask is the string replacing sensitive values
+	RedactionMask string
+	// Tenancy configures the tenant header required on incoming spans
+	Tenancy tenancy.Options
+	// SpanPipelineFile is the path to the YAML file with the span processors applied to spans before they are queued
+	SpanPipelineFile string
+	// SpanSizeLimits are the limits on tag values, tags, logs and size of the spans passing through this collector
+	SpanSizeLimits sanitizer.SpanSizeLimits
+	// TraceEventsFile is the path to the file with the rules and webhooks notified of completed traces
+	TraceEventsFile string
+	// TraceEvents configures when traces are considered complete
+	TraceEvents traceevents.Options
+	// CollectorHTTPAllowedOrigins is a list of origins a cross-domain request to the proto endpoint of the HTTP server can be executed from
+	CollectorHTTPAllowedOrigins string
+	// CollectorHTTPAllowedHeaders is a list of headers that the proto endpoint of the HTTP server allows the client to use with cross-domain requests
+	CollectorHTTPAllowedHeaders string
 	// CollectorZipkinHTTPHostPort is the host:port address that the Zipkin collector service listens in on for http requests
 	CollectorZipkinHTTPHostPort string
 	// CollectorZipkinAllowedOrigins is a list of origins a cross-domain request to the Zipkin collector service can be executed from
@@ -79,12 +148,36 @@ type CollectorOptions struct {
 func AddFlags(flags *flag.FlagSet) {
 	flags.Int(collectorNumWorkers, DefaultNumWorkers, "The number of workers pulling items from the queue")
 	flags.Int(collectorQueueSize, DefaultQueueSize, "The queue size of the collector")
+	flags.Duration(collectorDrainTimeout, DefaultDrainTimeout, "How long the collector waits on shutdown for the spans already in its queue to be saved; spans still queued after this timeout are lost")
 	flags.String(collectorGRPCHostPort, ports.PortToHostPort(ports.CollectorGRPC), "The host:port (e.g. 127.0.0.1:14250 or :14250) of the collector's GRPC server")
 	flags.String(collectorHTTPHostPort, ports.PortToHostPort(ports.CollectorHTTP), "The host:port (e.g. 127.0.0.1:14268 or :14268) of the collector's HTTP server")
+	flags.String(collectorHTTPAllowedHeaders, "content-type", "Comma separated list of allowed headers for the proto endpoint ("+handler.ProtoSpansPath+") of the collector's HTTP server, default content-type")
+	flags.String(collectorHTTPAllowedOrigins, "*", "Comma separated list of allowed origins for the proto endpoint ("+handler.ProtoSpansPath+") of the collector's HTTP server, default accepts all")
 	flags.String(collectorTags, "", "One or more tags to be added to the Process tags of all spans passing through this collector. Ex: key1=value1,key2=${envVar:defaultValue}")
 	flags.String(collectorZipkinAllowedHeaders, "content-type", "Comma separated list of allowed headers for the Zipkin collector service, default content-type")
 	flags.String(collectorZipkinAllowedOrigins, "*", "Comma separated list of allowed origins for the Zipkin collector service, default accepts all")
 	flags.String(collectorZipkinHTTPHostPort, "", "The host:port (e.g. 127.0.0.1:9411 or :9411) of the collector's Zipkin server (disabled by default)")
+	flags.String(collectorAttributeRulesFile, "", "The path to a JSON file with rules to insert, rename, hash, truncate or delete span and process tags, or drop spans. The file is reloaded when it changes")
+	flags.String(collectorBaggageFile, "", "The path to a JSON file with the baggage keys each service is allowed to use and the maximum length of their values, served to the agents and clients. The file is reloaded when it changes")
+	flags.String(collectorHostMetadataFile, "", "The path to a JSON or YAML file mapping host IP addresses and hostnames to tags (e.g. pod, node, zone) added to the Process tags of the spans coming from these hosts. The file is reloaded when it changes")
+	flags.String(collectorQuotasFile, "", "The path to a JSON file with per-service and per-tenant span rate limits (spans per second and burst). Batches with spans over the limit are rejected as a whole, spans with the debug flag are not limited. The file is reloaded when it changes")
+	flags.String(collectorRedactionDetectors, "", "Comma separated list of built-in detectors of sensitive values to mask in span tags and log fields (email, credit-card, bearer-token)")
+	flags.String(collectorRedactionFile, "", "The path to a JSON file with custom regex detectors of sensitive values to mask in span tags and log fields")
+	flags.String(collectorRedactionMask, sanitizer.DefaultRedactionMask, "The string that replaces sensitive values found by the redaction detectors")
+	flags.String(collectorSpanPipelineFile, "", "The path to a YAML file with the ordered list of span processors (e.g. filter, redact, add-tags, sample) applied to spans before they are queued")
+	flags.String(collectorTraceEventsFile, "", "The path to a JSON file with rules (error, minimum duration, services) selecting completed traces and the webhooks their JSON summaries are posted to (disabled by default)")
+	flags.Duration(collectorTraceEventsIdle, traceevents.DefaultIdleTimeout, "The time without new spans after which a trace is considered complete and evaluated against the trace events rules")
+	flags.Int(collectorTraceEventsMaxTraces, traceevents.DefaultMaxTraces, "The maximum number of traces waiting for completion; spans of new traces are ignored by the trace events rules above it")
+	flags.Int(collectorSpanMaxTagValueLen, 0, "The maximum length in bytes of span tag and log field values; longer values are truncated (0 = unlimited)")
+	flags.Int(collectorSpanMaxTags, 0, "The maximum number of tags per span; extra tags are removed (0 = unlimited)")
+	flags.Int(collectorSpanMaxLogs, 0, "The maximum number of logs per span; extra logs are removed (0 = unlimited)")
+	flags.Int(collectorSpanMaxSize, 0, "The maximum size in bytes of a span; logs and then tags of bigger spans are removed, and spans that are still too big are dropped (0 = unlimited)")
+	flags.Uint(collectorMaxBodySize, 64, "The maximum size in MiB of the request bodies received by the collector HTTP and Zipkin servers, after decompression ("+httpbody.SupportedEncodings+"); bigger requests are rejected (0 = unlimited)")
+	flags.Uint(collectorMemorySoftLimit, 0, "The heap size in MiB above which the collector rejects incoming spans with retryable errors and reports itself as unavailable (0 = disabled)")
+	flags.Uint(collectorMemoryHardLimit, 0, "The heap size in MiB above which the collector forces a garbage collection (0 = disabled)")
+	flags.Duration(collectorMemoryCheckInterval, time.Second, "How often the memory limiter checks the heap size")
+	flags.Duration(collectorDedupWindow, 0, "The minimum time the (trace ID, span ID, start time) keys of received spans are remembered to drop the spans received again, e.g. after client retries (0 = disabled)")
+	flags.Int(collectorDedupMaxKeys, dedup.DefaultMaxKeys, "The maximum number of span keys remembered to detect duplicate spans; the oldest keys are forgotten early when it is reached")
 	flags.Uint(collectorDynQueueSizeMemory, 0, "(experimental) The max memory size in MiB to use for the dynamic queue.")
 
 	tlsGRPCFlagsConfig.AddFlags(flags)
@@ -93,17 +186,60 @@ func AddFlags(flags *flag.FlagSet) {
 
 // InitFromViper initializes CollectorOptions with properties from viper
 func (cOpts *CollectorOptions) InitFromViper(v *viper.Viper) *CollectorOptions {
+	cOpts.AttributeRulesFile = v.GetString(collectorAttributeRulesFile)
+	cOpts.BaggageRestrictionsFile = v.GetString(collectorBaggageFile)
 	cOpts.CollectorGRPCHostPort = ports.FormatHostPort(v.GetString(collectorGRPCHostPort))
 	cOpts.CollectorHTTPHostPort = ports.FormatHostPort(v.GetString(collectorHTTPHostPort))
+	cOpts.CollectorHTTPAllowedHeaders = v.GetString(collectorHTTPAllowedHeaders)
+	cOpts.CollectorHTTPAllowedOrigins = v.GetString(collectorHTTPAllowedOrigins)
 	cOpts.CollectorTags = flags.ParseJaegerTags(v.GetString(collectorTags))
 	cOpts.CollectorZipkinAllowedHeaders = v.GetString(collectorZipkinAllowedHeaders)
 	cOpts.CollectorZipkinAllowedOrigins = v.GetString(collectorZipkinAllowedOrigins)
 	cOpts.CollectorZipkinHTTPHostPort = ports.FormatHostPort(v.GetString(collectorZipkinHTTPHostPort))
+	cOpts.Dedup = dedup.Options{
+		Window:  v.GetDuration(collectorDedupWindow),
+		MaxKeys: v.GetInt(collectorDedupMaxKeys),
+	}
+	cOpts.DrainTimeout = v.GetDuration(collectorDrainTimeout)
 	cOpts.DynQueueSizeMemory = v.GetUint(collectorDynQueueSizeMemory) * 1024 * 1024 // we receive in MiB and store in bytes
+	cOpts.HostMetadataFile = v.GetString(collectorHostMetadataFile)
+	cOpts.MaxBodySize = int64(v.GetUint(collectorMaxBodySize)) * 1024 * 1024 // we receive in MiB and store in bytes
+	cOpts.MemoryLimiter = memorylimiter.Options{
+		SoftLimitBytes: uint64(v.GetUint(collectorMemorySoftLimit)) * 1024 * 1024, // we receive in MiB and store in bytes
+		HardLimitBytes: uint64(v.GetUint(collectorMemoryHardLimit)) * 1024 * 1024,
+		CheckInterval:  v.GetDuration(collectorMemoryCheckInterval),
+	}
 	cOpts.NumWorkers = v.GetInt(collectorNumWorkers)
 	cOpts.QueueSize = v.GetInt(collectorQueueSize)
+	cOpts.QuotasFile = v.GetString(collectorQuotasFile)
+	cOpts.RedactionDetectors = splitList(v.GetString(collectorRedactionDetectors))
+	cOpts.RedactionDetectorsFile = v.GetString(collectorRedactionFile)
+	cOpts.RedactionMask = v.GetString(collectorRedactionMask)
+	cOpts.SpanPipelineFile = v.GetString(collectorSpanPipelineFile)
+	cOpts.SpanSizeLimits = sanitizer.SpanSizeLimits{
+		MaxTagValueLength: v.GetInt(collectorSpanMaxTagValueLen),
+		MaxTags:           v.GetInt(collectorSpanMaxTags),
+		MaxLogs:           v.GetInt(collectorSpanMaxLogs),
+		MaxSpanSize:       v.GetInt(collectorSpanMaxSize),
+	}
+	cOpts.Tenancy = tenancy.InitFromViper(v)
+	cOpts.TraceEventsFile = v.GetString(collectorTraceEventsFile)
+	cOpts.TraceEvents = traceevents.Options{
+		IdleTimeout: v.GetDuration(collectorTraceEventsIdle),
+		MaxTraces:   v.GetInt(collectorTraceEventsMaxTraces),
+	}
 	cOpts.TLSGRPC = tlsGRPCFlagsConfig.InitFromViper(v)
 	cOpts.TLSHTTP = tlsHTTPFlagsConfig.InitFromViper(v)
 
 	return cOpts
 }
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
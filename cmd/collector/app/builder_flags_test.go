@@ -16,10 +16,16 @@ package app
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/memorylimiter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/traceevents"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 )
 
 func TestCollectorOptionsWithFlags_CheckHostPort(t *testing.T) {
@@ -51,3 +57,153 @@ func TestCollectorOptionsWithFlags_CheckFullHostPort(t *testing.T) {
 	assert.Equal(t, "127.0.0.1:1234", c.CollectorGRPCHostPort)
 	assert.Equal(t, "0.0.0.0:3456", c.CollectorZipkinHTTPHostPort)
 }
+
+func TestCollectorOptionsWithFlags_CheckRedaction(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.redaction.detectors=email, credit-card,",
+		"--collector.redaction.detectors-file=/etc/jaeger/detectors.json",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, []string{"email", "credit-card"}, c.RedactionDetectors)
+	assert.Equal(t, "/etc/jaeger/detectors.json", c.RedactionDetectorsFile)
+	assert.Equal(t, "[REDACTED]", c.RedactionMask)
+}
+
+func TestCollectorOptionsWithFlags_CheckSpanSizeLimits(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.span-limits.max-tag-value-length=1024",
+		"--collector.span-limits.max-tags=100",
+		"--collector.span-limits.max-logs=200",
+		"--collector.span-limits.max-span-size=65536",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, sanitizer.SpanSizeLimits{
+		MaxTagValueLength: 1024,
+		MaxTags:           100,
+		MaxLogs:           200,
+		MaxSpanSize:       65536,
+	}, c.SpanSizeLimits)
+}
+
+func TestCollectorOptionsWithFlags_CheckMemoryLimiter(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.memory-limiter.soft-limit-mib=512",
+		"--collector.memory-limiter.hard-limit-mib=1024",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, memorylimiter.Options{
+		SoftLimitBytes: 512 * 1024 * 1024,
+		HardLimitBytes: 1024 * 1024 * 1024,
+		CheckInterval:  time.Second,
+	}, c.MemoryLimiter)
+}
+
+func TestCollectorOptionsWithFlags_CheckTenancy(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags, tenancy.AddFlags)
+	command.ParseFlags([]string{
+		"--multi-tenancy.enabled=true",
+		"--multi-tenancy.tenants=acme,globex",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, tenancy.Options{
+		Enabled: true,
+		Header:  tenancy.DefaultHeader,
+		Tenants: []string{"acme", "globex"},
+	}, c.Tenancy)
+}
+
+func TestCollectorOptionsWithFlags_CheckDedup(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{})
+	c.InitFromViper(v)
+	assert.Equal(t, dedup.Options{MaxKeys: dedup.DefaultMaxKeys}, c.Dedup)
+
+	command.ParseFlags([]string{
+		"--collector.dedup.window=5m",
+		"--collector.dedup.max-keys=1000",
+	})
+	c.InitFromViper(v)
+	assert.Equal(t, dedup.Options{Window: 5 * time.Minute, MaxKeys: 1000}, c.Dedup)
+}
+
+func TestCollectorOptionsWithFlags_CheckTraceEvents(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.trace-events.file=/etc/jaeger/events.json",
+		"--collector.trace-events.idle-timeout=1m",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, "/etc/jaeger/events.json", c.TraceEventsFile)
+	assert.Equal(t, traceevents.Options{IdleTimeout: time.Minute, MaxTraces: traceevents.DefaultMaxTraces}, c.TraceEvents)
+}
+
+func TestCollectorOptionsWithFlags_CheckHostMetadata(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.host-metadata.file=/etc/jaeger/hosts.yaml",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, "/etc/jaeger/hosts.yaml", c.HostMetadataFile)
+}
+
+func TestCollectorOptionsWithFlags_CheckBaggageRestrictions(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.baggage-restrictions.file=/etc/jaeger/baggage.json",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, "/etc/jaeger/baggage.json", c.BaggageRestrictionsFile)
+}
+
+func TestCollectorOptionsWithFlags_CheckSpanPipeline(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.span-pipeline.file=/etc/jaeger/pipeline.yaml",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, "/etc/jaeger/pipeline.yaml", c.SpanPipelineFile)
+}
+
+func TestCollectorOptionsWithFlags_CheckHTTPAllowedOrigins(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--collector.http-server.allowed-origins=http://example.com",
+	})
+	c.InitFromViper(v)
+
+	assert.Equal(t, "http://example.com", c.CollectorHTTPAllowedOrigins)
+	assert.Equal(t, "content-type", c.CollectorHTTPAllowedHeaders)
+}
+
+func TestCollectorOptionsWithFlags_CheckMaxBodySize(t *testing.T) {
+	c := &CollectorOptions{}
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{})
+	c.InitFromViper(v)
+	assert.Equal(t, int64(64*1024*1024), c.MaxBodySize)
+
+	command.ParseFlags([]string{"--collector.max-body-size-mib=0"})
+	c.InitFromViper(v)
+	assert.Equal(t, int64(0), c.MaxBodySize)
+}
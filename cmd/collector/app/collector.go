@@ -25,11 +25,21 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 
+	baggageStore "github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/hostmetadata"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/memorylimiter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/pipeline"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/quota"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/rules"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/server"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/traceevents"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
 // Collector returns the collector as a manageable unit of work
@@ -50,6 +60,8 @@ type Collector struct {
 	grpcServer               *grpc.Server
 	tlsGRPCCertWatcherCloser io.Closer
 	tlsHTTPCertWatcherCloser io.Closer
+	closers                  []io.Closer
+	memoryLimiter            *memorylimiter.Limiter
 }
 
 // CollectorParams to construct a new Jaeger Collector.
@@ -76,22 +88,82 @@ func New(params *CollectorParams) *Collector {
 
 // Start the component and underlying dependencies
 func (c *Collector) Start(builderOpts *CollectorOptions) error {
+	sanitizers, err := c.buildSanitizers(builderOpts)
+	if err != nil {
+		return err
+	}
 	handlerBuilder := &SpanHandlerBuilder{
 		SpanWriter:     c.spanWriter,
 		CollectorOpts:  *builderOpts,
 		Logger:         c.logger,
 		MetricsFactory: c.metricsFactory,
+		Sanitizers:     sanitizers,
+	}
+	if builderOpts.Dedup.Enabled() {
+		handlerBuilder.Deduper = dedup.New(builderOpts.Dedup, c.metricsFactory)
+	}
+	if builderOpts.SpanPipelineFile != "" {
+		spanPipeline, err := pipeline.LoadFile(builderOpts.SpanPipelineFile, pipeline.Params{
+			Logger:         c.logger,
+			MetricsFactory: c.metricsFactory,
+		})
+		if err != nil {
+			return fmt.Errorf("could not load span pipeline %w", err)
+		}
+		handlerBuilder.SpanPipeline = spanPipeline.Process
+	}
+	if builderOpts.QuotasFile != "" {
+		limiter, err := quota.NewLimiter(builderOpts.QuotasFile, c.logger, c.metricsFactory)
+		if err != nil {
+			return fmt.Errorf("could not load span quotas %w", err)
+		}
+		c.closers = append(c.closers, limiter)
+		handlerBuilder.SpanQuota = limiter.Allow
+	}
+	if builderOpts.HostMetadataFile != "" {
+		enricher, err := hostmetadata.NewEnricher(builderOpts.HostMetadataFile, c.logger, c.metricsFactory)
+		if err != nil {
+			return fmt.Errorf("could not load host metadata %w", err)
+		}
+		c.closers = append(c.closers, enricher)
+		handlerBuilder.HostTags = enricher.ProcessTags
+	}
+	if builderOpts.TraceEventsFile != "" {
+		tracker, err := traceevents.New(builderOpts.TraceEventsFile, builderOpts.TraceEvents, c.logger, c.metricsFactory)
+		if err != nil {
+			return fmt.Errorf("could not load trace events %w", err)
+		}
+		c.closers = append(c.closers, tracker)
+		handlerBuilder.PreSave = tracker.Add
+	}
+	var baggageManager baggage.BaggageRestrictionManager
+	if builderOpts.BaggageRestrictionsFile != "" {
+		store, err := baggageStore.NewStore(builderOpts.BaggageRestrictionsFile, c.logger, c.metricsFactory)
+		if err != nil {
+			return fmt.Errorf("could not load baggage restrictions %w", err)
+		}
+		c.closers = append(c.closers, store)
+		baggageManager = store
+	}
+	if builderOpts.MemoryLimiter.Enabled() {
+		if err := builderOpts.MemoryLimiter.Validate(); err != nil {
+			return fmt.Errorf("invalid memory limiter options: %w", err)
+		}
+		c.memoryLimiter = memorylimiter.New(builderOpts.MemoryLimiter, c.hCheck, c.logger, c.metricsFactory)
+		c.memoryLimiter.Start()
+		handlerBuilder.MemoryLimiter = c.memoryLimiter.RejectBatch
 	}
 
 	c.spanProcessor = handlerBuilder.BuildSpanProcessor()
 	c.spanHandlers = handlerBuilder.BuildHandlers(c.spanProcessor)
 
 	grpcServer, err := server.StartGRPCServer(&server.GRPCServerParams{
-		HostPort:      builderOpts.CollectorGRPCHostPort,
-		Handler:       c.spanHandlers.GRPCHandler,
-		TLSConfig:     builderOpts.TLSGRPC,
-		SamplingStore: c.strategyStore,
-		Logger:        c.logger,
+		HostPort:       builderOpts.CollectorGRPCHostPort,
+		Handler:        c.spanHandlers.GRPCHandler,
+		TLSConfig:      builderOpts.TLSGRPC,
+		SamplingStore:  c.strategyStore,
+		BaggageManager: baggageManager,
+		Logger:         c.logger,
 	})
 	if err != nil {
 		return fmt.Errorf("could not start gRPC collector %w", err)
@@ -101,10 +173,16 @@ func (c *Collector) Start(builderOpts *CollectorOptions) error {
 	httpServer, err := server.StartHTTPServer(&server.HTTPServerParams{
 		HostPort:       builderOpts.CollectorHTTPHostPort,
 		Handler:        c.spanHandlers.JaegerBatchesHandler,
+		GRPCHandler:    c.spanHandlers.GRPCHandler,
+		AllowedOrigins: builderOpts.CollectorHTTPAllowedOrigins,
+		AllowedHeaders: builderOpts.CollectorHTTPAllowedHeaders,
+		MaxBodySize:    builderOpts.MaxBodySize,
 		TLSConfig:      builderOpts.TLSHTTP,
+		Tenancy:        builderOpts.Tenancy,
 		HealthCheck:    c.hCheck,
 		MetricsFactory: c.metricsFactory,
 		SamplingStore:  c.strategyStore,
+		BaggageManager: baggageManager,
 		Logger:         c.logger,
 	})
 	if err != nil {
@@ -119,7 +197,9 @@ func (c *Collector) Start(builderOpts *CollectorOptions) error {
 		Handler:        c.spanHandlers.ZipkinSpansHandler,
 		HealthCheck:    c.hCheck,
 		AllowedHeaders: builderOpts.CollectorZipkinAllowedHeaders,
+		MaxBodySize:    builderOpts.MaxBodySize,
 		AllowedOrigins: builderOpts.CollectorZipkinAllowedOrigins,
+		Tenancy:        builderOpts.Tenancy,
 		Logger:         c.logger,
 		MetricsFactory: c.metricsFactory,
 	})
@@ -133,6 +213,49 @@ func (c *Collector) Start(builderOpts *CollectorOptions) error {
 	return nil
 }
 
+func (c *Collector) buildSanitizers(cOpts *CollectorOptions) ([]sanitizer.SanitizeSpan, error) {
+	var sanitizers []sanitizer.SanitizeSpan
+	if cOpts.AttributeRulesFile != "" {
+		attributeRules, err := rules.New(cOpts.AttributeRulesFile, c.logger, c.metricsFactory)
+		if err != nil {
+			return nil, fmt.Errorf("could not load attribute rules %w", err)
+		}
+		c.closers = append(c.closers, attributeRules)
+		sanitizers = append(sanitizers, attributeRules.Sanitize)
+	}
+	if len(cOpts.RedactionDetectors) > 0 || cOpts.RedactionDetectorsFile != "" {
+		detectors, err := redactionDetectors(cOpts)
+		if err != nil {
+			return nil, err
+		}
+		sanitizers = append(sanitizers, sanitizer.NewRedactionSanitizer(detectors, cOpts.RedactionMask, c.metricsFactory))
+	}
+	// size limits are enforced last, so that tags added by other sanitizers are accounted for
+	if cOpts.SpanSizeLimits.Enabled() {
+		sanitizers = append(sanitizers, sanitizer.NewSpanSizeSanitizer(cOpts.SpanSizeLimits, c.metricsFactory))
+	}
+	return sanitizers, nil
+}
+
+func redactionDetectors(cOpts *CollectorOptions) ([]sanitizer.RedactionDetector, error) {
+	var detectors []sanitizer.RedactionDetector
+	for _, name := range cOpts.RedactionDetectors {
+		d, ok := sanitizer.BuiltInRedactionDetectors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction detector %q", name)
+		}
+		detectors = append(detectors, d)
+	}
+	if cOpts.RedactionDetectorsFile != "" {
+		custom, err := sanitizer.LoadRedactionDetectors(cOpts.RedactionDetectorsFile)
+		if err != nil {
+			return nil, err
+		}
+		detectors = append(detectors, custom...)
+	}
+	return detectors, nil
+}
+
 func (c *Collector) publishOpts(cOpts *CollectorOptions) {
 	internalFactory := c.metricsFactory.Namespace(metrics.NSOptions{Name: "internal"})
 	internalFactory.Gauge(metrics.Options{Name: collectorNumWorkers}).Update(int64(cOpts.NumWorkers))
@@ -141,6 +264,16 @@ func (c *Collector) publishOpts(cOpts *CollectorOptions) {
 
 // Close the component and all its underlying dependencies
 func (c *Collector) Close() error {
+	// the memory limiter must not report the collector as ready again
+	if c.memoryLimiter != nil {
+		c.memoryLimiter.Close()
+	}
+
+	// stop receiving traffic from load balancers while the servers and the queue are drained
+	if c.hCheck != nil {
+		c.hCheck.Set(healthcheck.Unavailable)
+	}
+
 	// gRPC server
 	if c.grpcServer != nil {
 		c.grpcServer.GracefulStop()
@@ -164,6 +297,7 @@ func (c *Collector) Close() error {
 		defer cancel()
 	}
 
+	// the servers no longer accept spans, save the ones already queued
 	if err := c.spanProcessor.Close(); err != nil {
 		c.logger.Error("failed to close span processor.", zap.Error(err))
 	}
@@ -171,6 +305,9 @@ func (c *Collector) Close() error {
 	// watchers actually never return errors from Close
 	_ = c.tlsGRPCCertWatcherCloser.Close()
 	_ = c.tlsHTTPCertWatcherCloser.Close()
+	for _, closer := range c.closers {
+		_ = closer.Close()
+	}
 
 	return nil
 }
@@ -21,10 +21,15 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics/fork"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 	"go.uber.org/zap"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/memorylimiter"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
@@ -51,9 +56,68 @@ func TestNewCollector(t *testing.T) {
 
 	// test
 	c.Start(collectorOpts)
+	hc.Ready()
 
 	// verify
 	assert.NoError(t, c.Close())
+	assert.Equal(t, healthcheck.Unavailable, hc.Get())
+}
+
+func TestCollectorStartWithDedup(t *testing.T) {
+	baseMetrics := metricstest.NewFactory(time.Hour)
+	c := New(&CollectorParams{
+		ServiceName:    "collector",
+		Logger:         zap.NewNop(),
+		MetricsFactory: baseMetrics,
+		SpanWriter:     &fakeSpanWriter{},
+		StrategyStore:  &mockStrategyStore{},
+		HealthCheck:    healthcheck.New(),
+	})
+	require.NoError(t, c.Start(&CollectorOptions{QueueSize: 10, Dedup: dedup.Options{Window: time.Minute}}))
+	defer func() { assert.NoError(t, c.Close()) }()
+
+	span := &model.Span{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(1), Process: model.NewProcess("svc", nil)}
+	for i := 0; i < 2; i++ {
+		_, err := c.spanProcessor.ProcessSpans([]*model.Span{span}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+		require.NoError(t, err)
+	}
+	baseMetrics.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "dedup.spans", Tags: map[string]string{"result": "unique"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "dedup.spans", Tags: map[string]string{"result": "duplicate"}, Value: 1},
+	)
+}
+
+func TestCollectorStartWithInvalidSanitizers(t *testing.T) {
+	tests := []struct {
+		name string
+		opts CollectorOptions
+	}{
+		{name: "attribute rules", opts: CollectorOptions{AttributeRulesFile: "fixture/does-not-exist.json"}},
+		{name: "redaction detector", opts: CollectorOptions{RedactionDetectors: []string{"phone"}}},
+		{name: "redaction file", opts: CollectorOptions{RedactionDetectorsFile: "fixture/does-not-exist.json"}},
+		{name: "quotas file", opts: CollectorOptions{QuotasFile: "fixture/does-not-exist.json"}},
+		{name: "host metadata file", opts: CollectorOptions{HostMetadataFile: "fixture/does-not-exist.json"}},
+		{name: "span pipeline file", opts: CollectorOptions{SpanPipelineFile: "fixture/does-not-exist.yaml"}},
+		{name: "trace events file", opts: CollectorOptions{TraceEventsFile: "fixture/does-not-exist.json"}},
+		{name: "baggage restrictions file", opts: CollectorOptions{BaggageRestrictionsFile: "fixture/does-not-exist.json"}},
+		{name: "memory limiter check interval", opts: CollectorOptions{MemoryLimiter: memorylimiter.Options{HardLimitBytes: 100}}},
+		{name: "memory limiter limits", opts: CollectorOptions{MemoryLimiter: memorylimiter.Options{
+			SoftLimitBytes: 200, HardLimitBytes: 100, CheckInterval: time.Second,
+		}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := New(&CollectorParams{
+				ServiceName:    "collector",
+				Logger:         zap.NewNop(),
+				MetricsFactory: metricstest.NewFactory(time.Hour),
+				SpanWriter:     &fakeSpanWriter{},
+				StrategyStore:  &mockStrategyStore{},
+				HealthCheck:    healthcheck.New(),
+			})
+			assert.Error(t, c.Start(&test.opts))
+		})
+	}
 }
 
 type mockStrategyStore struct {
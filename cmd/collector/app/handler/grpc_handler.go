@@ -22,6 +22,7 @@ import (
 	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 )
 
@@ -29,33 +30,49 @@ import (
 type GRPCHandler struct {
 	logger        *zap.Logger
 	spanProcessor processor.SpanProcessor
+	tenancy       tenancy.Options
 }
 
 // NewGRPCHandler registers routes for this handler on the given router.
-func NewGRPCHandler(logger *zap.Logger, spanProcessor processor.SpanProcessor) *GRPCHandler {
+func NewGRPCHandler(logger *zap.Logger, spanProcessor processor.SpanProcessor, tenancyOpts tenancy.Options) *GRPCHandler {
 	return &GRPCHandler{
 		logger:        logger,
 		spanProcessor: spanProcessor,
+		tenancy:       tenancyOpts,
 	}
 }
 
 // PostSpans implements gRPC CollectorService.
 func (g *GRPCHandler) PostSpans(ctx context.Context, r *api_v2.PostSpansRequest) (*api_v2.PostSpansResponse, error) {
+	ctx, err := tenancy.TenantFromIncomingContext(ctx, g.tenancy)
+	if err != nil {
+		return nil, err
+	}
+	if err := g.postSpans(ctx, r, processor.GRPCTransport); err != nil {
+		if err == processor.ErrBusy || err == processor.ErrQuotaExceeded {
+			return nil, status.Errorf(codes.ResourceExhausted, err.Error())
+		}
+		if err == processor.ErrMemoryLimitExceeded {
+			return nil, status.Errorf(codes.Unavailable, err.Error())
+		}
+		g.logger.Error("cannot process spans", zap.Error(err))
+		return nil, err
+	}
+	return &api_v2.PostSpansResponse{}, nil
+}
+
+// postSpans submits the spans of the request to the span processor, on behalf of the tenant in the context.
+// It is shared by the gRPC and the HTTP endpoints accepting api_v2.PostSpansRequest.
+func (g *GRPCHandler) postSpans(ctx context.Context, r *api_v2.PostSpansRequest, transport processor.InboundTransport) error {
 	for _, span := range r.GetBatch().Spans {
 		if span.GetProcess() == nil {
 			span.Process = r.Batch.Process
 		}
 	}
 	_, err := g.spanProcessor.ProcessSpans(r.GetBatch().Spans, processor.SpansOptions{
-		InboundTransport: processor.GRPCTransport,
+		InboundTransport: transport,
 		SpanFormat:       processor.ProtoSpanFormat,
+		Tenant:           tenancy.GetTenant(ctx),
 	})
-	if err != nil {
-		if err == processor.ErrBusy {
-			return nil, status.Errorf(codes.ResourceExhausted, err.Error())
-		}
-		g.logger.Error("cannot process spans", zap.Error(err))
-		return nil, err
-	}
-	return &api_v2.PostSpansResponse{}, nil
+	return err
 }
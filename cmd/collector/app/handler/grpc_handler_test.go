@@ -25,9 +25,13 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 )
 
@@ -35,12 +39,14 @@ type mockSpanProcessor struct {
 	expectedError error
 	mux           sync.Mutex
 	spans         []*model.Span
+	tenants       []string
 }
 
 func (p *mockSpanProcessor) ProcessSpans(spans []*model.Span, opts processor.SpansOptions) ([]bool, error) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 	p.spans = append(p.spans, spans...)
+	p.tenants = append(p.tenants, opts.Tenant)
 	oks := make([]bool, len(spans))
 	return oks, p.expectedError
 }
@@ -51,6 +57,12 @@ func (p *mockSpanProcessor) getSpans() []*model.Span {
 	return p.spans
 }
 
+func (p *mockSpanProcessor) getTenants() []string {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	return p.tenants
+}
+
 func (p *mockSpanProcessor) reset() {
 	p.mux.Lock()
 	defer p.mux.Unlock()
@@ -82,7 +94,7 @@ func newClient(t *testing.T, addr net.Addr) (api_v2.CollectorServiceClient, *grp
 func TestPostSpans(t *testing.T) {
 	processor := &mockSpanProcessor{}
 	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
-		handler := NewGRPCHandler(zap.NewNop(), processor)
+		handler := NewGRPCHandler(zap.NewNop(), processor, tenancy.Options{})
 		api_v2.RegisterCollectorServiceServer(s, handler)
 	})
 	defer server.Stop()
@@ -114,7 +126,7 @@ func TestPostSpansWithError(t *testing.T) {
 	expectedError := errors.New("test-error")
 	processor := &mockSpanProcessor{expectedError: expectedError}
 	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
-		handler := NewGRPCHandler(zap.NewNop(), processor)
+		handler := NewGRPCHandler(zap.NewNop(), processor, tenancy.Options{})
 		api_v2.RegisterCollectorServiceServer(s, handler)
 	})
 	defer server.Stop()
@@ -134,3 +146,65 @@ func TestPostSpansWithError(t *testing.T) {
 	require.Contains(t, err.Error(), expectedError.Error())
 	require.Len(t, processor.getSpans(), 1)
 }
+
+func TestPostSpansQuotaExceeded(t *testing.T) {
+	spanProcessor := &mockSpanProcessor{expectedError: processor.ErrQuotaExceeded}
+	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		handler := NewGRPCHandler(zap.NewNop(), spanProcessor, tenancy.Options{})
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer server.Stop()
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+	_, err := client.PostSpans(context.Background(), &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Spans: []*model.Span{{OperationName: "fake-operation"}},
+		},
+	})
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+}
+
+func TestPostSpansMemoryLimitExceeded(t *testing.T) {
+	spanProcessor := &mockSpanProcessor{expectedError: processor.ErrMemoryLimitExceeded}
+	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		handler := NewGRPCHandler(zap.NewNop(), spanProcessor, tenancy.Options{})
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer server.Stop()
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+	_, err := client.PostSpans(context.Background(), &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Spans: []*model.Span{{OperationName: "fake-operation"}},
+		},
+	})
+	assert.Equal(t, codes.Unavailable, status.Code(err))
+}
+
+func TestPostSpansTenancy(t *testing.T) {
+	spanProcessor := &mockSpanProcessor{}
+	server, addr := initializeGRPCTestServer(t, func(s *grpc.Server) {
+		handler := NewGRPCHandler(zap.NewNop(), spanProcessor, tenancy.Options{Enabled: true, Tenants: []string{"acme"}})
+		api_v2.RegisterCollectorServiceServer(s, handler)
+	})
+	defer server.Stop()
+	client, conn := newClient(t, addr)
+	defer conn.Close()
+	request := &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Spans: []*model.Span{{OperationName: "fake-operation"}},
+		},
+	}
+
+	_, err := client.PostSpans(context.Background(), request)
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), tenancy.DefaultHeader, "globex")
+	_, err = client.PostSpans(ctx, request)
+	assert.Equal(t, codes.PermissionDenied, status.Code(err))
+
+	ctx = metadata.AppendToOutgoingContext(context.Background(), tenancy.DefaultHeader, "acme")
+	_, err = client.PostSpans(ctx, request)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"acme"}, spanProcessor.getTenants())
+}
@@ -18,7 +18,6 @@ package handler
 import (
 	"fmt"
 	"html"
-	"io/ioutil"
 	"mime"
 	"net/http"
 
@@ -26,12 +25,14 @@ import (
 	"github.com/gorilla/mux"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/pkg/httpbody"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	tJaeger "github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 )
 
 const (
 	// UnableToReadBodyErrFormat is an error message for invalid requests
-	UnableToReadBodyErrFormat = "Unable to process request body: %v"
+	UnableToReadBodyErrFormat = httpbody.UnableToReadErrFormat
 )
 
 var (
@@ -44,14 +45,17 @@ var (
 // APIHandler handles all HTTP calls to the collector
 type APIHandler struct {
 	jaegerBatchesHandler JaegerBatchesHandler
+	maxBodySize          int64
 }
 
-// NewAPIHandler returns a new APIHandler
+// NewAPIHandler returns a new APIHandler, which rejects request bodies bigger than maxBodySize bytes once decompressed
 func NewAPIHandler(
 	jaegerBatchesHandler JaegerBatchesHandler,
+	maxBodySize int64,
 ) *APIHandler {
 	return &APIHandler{
 		jaegerBatchesHandler: jaegerBatchesHandler,
+		maxBodySize:          maxBodySize,
 	}
 }
 
@@ -62,10 +66,9 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
 
 // SaveSpan submits the span provided in the request body to the JaegerBatchesHandler
 func (aH *APIHandler) SaveSpan(w http.ResponseWriter, r *http.Request) {
-	bodyBytes, err := ioutil.ReadAll(r.Body)
-	r.Body.Close()
+	bodyBytes, err := httpbody.Read(r, aH.maxBodySize)
 	if err != nil {
-		http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusInternalServerError)
+		httpbody.WriteError(w, err)
 		return
 	}
 
@@ -88,11 +91,26 @@ func (aH *APIHandler) SaveSpan(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	batches := []*tJaeger.Batch{batch}
-	opts := SubmitBatchOptions{InboundTransport: processor.HTTPTransport}
+	opts := SubmitBatchOptions{
+		InboundTransport: processor.HTTPTransport,
+		Tenant:           tenancy.GetTenant(r.Context()),
+	}
 	if _, err = aH.jaegerBatchesHandler.SubmitBatches(batches, opts); err != nil {
-		http.Error(w, fmt.Sprintf("Cannot submit Jaeger batch: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Cannot submit Jaeger batch: %v", err), SubmitErrorStatusCode(err))
 		return
 	}
 
 	w.WriteHeader(http.StatusAccepted)
 }
+
+// SubmitErrorStatusCode returns the HTTP status code for an error returned when submitting spans,
+// so that clients can tell retryable rejections from internal failures.
+func SubmitErrorStatusCode(err error) int {
+	switch err {
+	case processor.ErrQuotaExceeded:
+		return http.StatusTooManyRequests
+	case processor.ErrMemoryLimitExceeded:
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusInternalServerError
+}
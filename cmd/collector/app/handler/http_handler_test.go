@@ -27,11 +27,15 @@ import (
 	"time"
 
 	"github.com/apache/thrift/lib/go/thrift"
+	"github.com/golang/snappy"
 	"github.com/gorilla/mux"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	jaegerClient "github.com/uber/jaeger-client-go"
 	"github.com/uber/jaeger-client-go/transport"
 
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 )
 
@@ -41,12 +45,14 @@ type mockJaegerHandler struct {
 	err     error
 	mux     sync.Mutex
 	batches []*jaeger.Batch
+	tenants []string
 }
 
-func (p *mockJaegerHandler) SubmitBatches(batches []*jaeger.Batch, _ SubmitBatchOptions) ([]*jaeger.BatchSubmitResponse, error) {
+func (p *mockJaegerHandler) SubmitBatches(batches []*jaeger.Batch, opts SubmitBatchOptions) ([]*jaeger.BatchSubmitResponse, error) {
 	p.mux.Lock()
 	defer p.mux.Unlock()
 	p.batches = append(p.batches, batches...)
+	p.tenants = append(p.tenants, opts.Tenant)
 	return nil, p.err
 }
 
@@ -58,7 +64,7 @@ func (p *mockJaegerHandler) getBatches() []*jaeger.Batch {
 
 func initializeTestServer(err error) (*httptest.Server, *APIHandler) {
 	r := mux.NewRouter()
-	handler := NewAPIHandler(&mockJaegerHandler{err: err})
+	handler := NewAPIHandler(&mockJaegerHandler{err: err}, 0)
 	handler.RegisterRoutes(r)
 	return httptest.NewServer(r), handler
 }
@@ -96,6 +102,48 @@ func TestThriftFormat(t *testing.T) {
 	assert.EqualValues(t, "Cannot submit Jaeger batch: Bad times ahead\n", resBodyStr)
 }
 
+func TestThriftFormatTenant(t *testing.T) {
+	tser := thrift.NewTSerializer()
+	someBytes, err := tser.Write(context.Background(), &jaeger.Batch{Process: &jaeger.Process{ServiceName: "serviceName"}})
+	require.NoError(t, err)
+	jaegerHandler := &mockJaegerHandler{}
+	handler := NewAPIHandler(jaegerHandler, 0)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/traces", bytes.NewReader(someBytes))
+	req.Header.Set("Content-Type", "application/x-thrift")
+	rec := httptest.NewRecorder()
+	handler.SaveSpan(rec, req.WithContext(tenancy.WithTenant(req.Context(), "acme")))
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, []string{"acme"}, jaegerHandler.tenants)
+}
+
+func TestThriftFormatSnappy(t *testing.T) {
+	jaegerHandler := &mockJaegerHandler{}
+	handler := NewAPIHandler(jaegerHandler, 1024*1024)
+	someBytes, err := thrift.NewTSerializer().Write(context.Background(), &jaeger.Batch{Process: &jaeger.Process{ServiceName: "svc"}})
+	require.NoError(t, err)
+	var body bytes.Buffer
+	w := snappy.NewBufferedWriter(&body)
+	_, err = w.Write(someBytes)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	req := httptest.NewRequest(http.MethodPost, "/api/traces", &body)
+	req.Header.Set("Content-Type", "application/x-thrift")
+	req.Header.Set("Content-Encoding", "snappy")
+	rec := httptest.NewRecorder()
+	handler.SaveSpan(rec, req)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	require.Len(t, jaegerHandler.getBatches(), 1)
+	assert.Equal(t, "svc", jaegerHandler.getBatches()[0].Process.ServiceName)
+}
+
+func TestSubmitErrorStatusCode(t *testing.T) {
+	assert.Equal(t, http.StatusTooManyRequests, SubmitErrorStatusCode(processor.ErrQuotaExceeded))
+	assert.Equal(t, http.StatusServiceUnavailable, SubmitErrorStatusCode(processor.ErrMemoryLimitExceeded))
+	assert.Equal(t, http.StatusInternalServerError, SubmitErrorStatusCode(fmt.Errorf("Bad times ahead")))
+}
+
 func TestViaClient(t *testing.T) {
 	server, handler := initializeTestServer(nil)
 	defer server.Close()
@@ -159,7 +207,7 @@ func TestMalformedFormat(t *testing.T) {
 }
 
 func TestCannotReadBodyFromRequest(t *testing.T) {
-	handler := NewAPIHandler(&mockJaegerHandler{})
+	handler := NewAPIHandler(&mockJaegerHandler{}, 0)
 	req, err := http.NewRequest(http.MethodPost, "whatever", &errReader{})
 	assert.NoError(t, err)
 	rw := dummyResponseWriter{}
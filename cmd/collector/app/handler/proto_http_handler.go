@@ -0,0 +1,94 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"fmt"
+	"html"
+	"mime"
+	"net/http"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/pkg/httpbody"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+)
+
+// ProtoSpansPath is the path of the endpoint accepting api_v2.PostSpansRequest over HTTP
+const ProtoSpansPath = "/api/v2/traces"
+
+var (
+	acceptedProtoFormats = map[string]struct{}{
+		"application/x-protobuf": {},
+		"application/protobuf":   {},
+	}
+	acceptedProtoJSONFormats = map[string]struct{}{
+		"application/json": {},
+	}
+)
+
+// ProtoAPIHandler handles api_v2.PostSpansRequest sent over HTTP, encoded as protobuf or proto-JSON,
+// for clients that cannot use gRPC. It is served on ProtoSpansPath, behind the CORS handler of the server. The spans are submitted like the ones received by the GRPCHandler.
+type ProtoAPIHandler struct {
+	grpcHandler *GRPCHandler
+	maxBodySize int64
+}
+
+// NewProtoAPIHandler returns a new ProtoAPIHandler, which rejects request bodies bigger than maxBodySize bytes once decompressed
+func NewProtoAPIHandler(grpcHandler *GRPCHandler, maxBodySize int64) *ProtoAPIHandler {
+	return &ProtoAPIHandler{
+		grpcHandler: grpcHandler,
+		maxBodySize: maxBodySize,
+	}
+}
+
+// SaveSpans submits the spans of the api_v2.PostSpansRequest provided in the request body
+func (aH *ProtoAPIHandler) SaveSpans(w http.ResponseWriter, r *http.Request) {
+	bodyBytes, err := httpbody.Read(r, aH.maxBodySize)
+	if err != nil {
+		httpbody.WriteError(w, err)
+		return
+	}
+
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		http.Error(w, fmt.Sprintf("Cannot parse content type: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	request := &api_v2.PostSpansRequest{}
+	if _, ok := acceptedProtoFormats[contentType]; ok {
+		err = proto.Unmarshal(bodyBytes, request)
+	} else if _, ok := acceptedProtoJSONFormats[contentType]; ok {
+		err = jsonpb.Unmarshal(bytes.NewReader(bodyBytes), request)
+	} else {
+		http.Error(w, fmt.Sprintf("Unsupported content type: %v", html.EscapeString(contentType)), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf(UnableToReadBodyErrFormat, err), http.StatusBadRequest)
+		return
+	}
+
+	if err := aH.grpcHandler.postSpans(r.Context(), request, processor.HTTPTransport); err != nil {
+		http.Error(w, fmt.Sprintf("Cannot submit spans: %v", err), SubmitErrorStatusCode(err))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
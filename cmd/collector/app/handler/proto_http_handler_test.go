@@ -0,0 +1,145 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gogo/protobuf/jsonpb"
+	"github.com/gogo/protobuf/proto"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+)
+
+func newPostSpansRequest() *api_v2.PostSpansRequest {
+	return &api_v2.PostSpansRequest{
+		Batch: model.Batch{
+			Spans: []*model.Span{
+				{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(2), OperationName: "fake-operation"},
+			},
+			Process: &model.Process{ServiceName: "fake-service"},
+		},
+	}
+}
+
+func postProto(t *testing.T, aH *ProtoAPIHandler, contentType string, body []byte, headers map[string]string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, ProtoSpansPath, bytes.NewReader(body))
+	req.Header.Set("Content-Type", contentType)
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+	rec := httptest.NewRecorder()
+	aH.SaveSpans(rec, req)
+	return rec
+}
+
+func TestProtoAPIHandler(t *testing.T) {
+	protoBytes, err := proto.Marshal(newPostSpansRequest())
+	require.NoError(t, err)
+	jsonString, err := new(jsonpb.Marshaler).MarshalToString(newPostSpansRequest())
+	require.NoError(t, err)
+	var gzipped bytes.Buffer
+	gz := gzip.NewWriter(&gzipped)
+	_, err = gz.Write(protoBytes)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+
+	tests := []struct {
+		name        string
+		contentType string
+		body        []byte
+		headers     map[string]string
+	}{
+		{name: "protobuf", contentType: "application/x-protobuf", body: protoBytes},
+		{name: "protobuf alias", contentType: "application/protobuf", body: protoBytes},
+		{name: "proto-JSON", contentType: "application/json; charset=utf-8", body: []byte(jsonString)},
+		{name: "gzip", contentType: "application/x-protobuf", body: gzipped.Bytes(), headers: map[string]string{"Content-Encoding": "gzip"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spanProcessor := &mockSpanProcessor{}
+			aH := NewProtoAPIHandler(NewGRPCHandler(zap.NewNop(), spanProcessor, tenancy.Options{}), 0)
+			rec := postProto(t, aH, test.contentType, test.body, test.headers)
+			assert.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+			spans := spanProcessor.getSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, "fake-operation", spans[0].OperationName)
+			assert.Equal(t, "fake-service", spans[0].Process.ServiceName)
+		})
+	}
+}
+
+func TestProtoAPIHandlerTenant(t *testing.T) {
+	protoBytes, err := proto.Marshal(newPostSpansRequest())
+	require.NoError(t, err)
+	spanProcessor := &mockSpanProcessor{}
+	aH := NewProtoAPIHandler(NewGRPCHandler(zap.NewNop(), spanProcessor, tenancy.Options{Enabled: true}), 0)
+
+	req := httptest.NewRequest(http.MethodPost, ProtoSpansPath, bytes.NewReader(protoBytes))
+	req.Header.Set("Content-Type", "application/x-protobuf")
+	req = req.WithContext(tenancy.WithTenant(req.Context(), "acme"))
+	rec := httptest.NewRecorder()
+	aH.SaveSpans(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	assert.Equal(t, []string{"acme"}, spanProcessor.getTenants())
+}
+
+func TestProtoAPIHandlerErrors(t *testing.T) {
+	protoBytes, err := proto.Marshal(newPostSpansRequest())
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		contentType   string
+		body          []byte
+		headers       map[string]string
+		expectedError error
+		status        int
+		message       string
+	}{
+		{name: "bad content type", contentType: "application/x-thrift", body: protoBytes,
+			status: http.StatusBadRequest, message: "Unsupported content type: application/x-thrift"},
+		{name: "malformed content type", contentType: "application/x-protobuf; =", body: protoBytes,
+			status: http.StatusBadRequest, message: "Cannot parse content type"},
+		{name: "bad protobuf", contentType: "application/x-protobuf", body: []byte("not protobuf"),
+			status: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "bad JSON", contentType: "application/json", body: []byte("{"),
+			status: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "bad gzip", contentType: "application/x-protobuf", body: protoBytes, headers: map[string]string{"Content-Encoding": "gzip"},
+			status: http.StatusBadRequest, message: "Unable to process request body"},
+		{name: "quota exceeded", contentType: "application/x-protobuf", body: protoBytes, expectedError: processor.ErrQuotaExceeded,
+			status: http.StatusTooManyRequests, message: "Cannot submit spans: " + processor.ErrQuotaExceeded.Error()},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			spanProcessor := &mockSpanProcessor{expectedError: test.expectedError}
+			aH := NewProtoAPIHandler(NewGRPCHandler(zap.NewNop(), spanProcessor, tenancy.Options{}), 0)
+			rec := postProto(t, aH, test.contentType, test.body, test.headers)
+			assert.Equal(t, test.status, rec.Code)
+			assert.Contains(t, rec.Body.String(), test.message)
+		})
+	}
+}
@@ -0,0 +1,166 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostmetadata
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/cache"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+const (
+	// ipTagKey and hostnameTagKey are the process tags set by the Jaeger clients to identify the host
+	ipTagKey       = "ip"
+	hostnameTagKey = "hostname"
+
+	// cacheSize is the number of IP address and hostname pairs whose lookup result is cached
+	cacheSize = 10000
+)
+
+type enricherMetrics struct {
+	// ReloadSuccess is the number of successful reloads of the host metadata file
+	ReloadSuccess metrics.Counter `metric:"reloads" tags:"result=ok"`
+	// ReloadFailure is the number of failed reloads of the host metadata file
+	ReloadFailure metrics.Counter `metric:"reloads" tags:"result=err"`
+	// Enriched is the number of processes that were enriched with host metadata
+	Enriched metrics.Counter `metric:"lookups" tags:"result=found"`
+	// Unknown is the number of processes whose host is not in the host metadata file
+	Unknown metrics.Counter `metric:"lookups" tags:"result=unknown"`
+	// CacheHits is the number of lookups served from the cache
+	CacheHits metrics.Counter `metric:"cache" tags:"result=hit"`
+	// CacheMisses is the number of lookups not found in the cache
+	CacheMisses metrics.Counter `metric:"cache" tags:"result=miss"`
+}
+
+// Enricher maps the ip and hostname process tags of the spans to the metadata of their host,
+// loaded from a JSON or YAML file. The file is watched and reloaded whenever it changes.
+type Enricher struct {
+	path    string
+	logger  *zap.Logger
+	metrics enricherMetrics
+	state   atomic.Value // *state
+	watcher io.Closer
+}
+
+// state holds the hosts loaded from the file and the lookups cached since it was loaded.
+type state struct {
+	hosts *hosts
+	cache cache.Cache
+}
+
+// NewEnricher creates an Enricher from the host metadata file at the given path and starts watching the file for changes.
+func NewEnricher(path string, logger *zap.Logger, metricsFactory metrics.Factory) (*Enricher, error) {
+	h, err := loadHosts(path)
+	if err != nil {
+		return nil, err
+	}
+	e := &Enricher{
+		path:   path,
+		logger: logger,
+	}
+	metrics.MustInit(&e.metrics, metricsFactory.Namespace(metrics.NSOptions{Name: "host_metadata"}), nil)
+	e.state.Store(newState(h))
+	watcher, err := fswatcher.WatchFile(path, e.reload, logger)
+	if err != nil {
+		return nil, err
+	}
+	e.watcher = watcher
+	logger.Info("Loaded host metadata", zap.String("file", path), zap.Int("hosts", h.count))
+	return e, nil
+}
+
+func newState(h *hosts) *state {
+	return &state{
+		hosts: h,
+		cache: cache.NewLRU(cacheSize),
+	}
+}
+
+// Close stops watching the host metadata file.
+func (e *Enricher) Close() error {
+	return e.watcher.Close()
+}
+
+func (e *Enricher) reload() {
+	h, err := loadHosts(e.path)
+	if err != nil {
+		e.metrics.ReloadFailure.Inc(1)
+		e.logger.Error("Failed to reload host metadata, using the last known version", zap.String("file", e.path), zap.Error(err))
+		return
+	}
+	e.state.Store(newState(h))
+	e.metrics.ReloadSuccess.Inc(1)
+	e.logger.Info("Reloaded host metadata", zap.String("file", e.path), zap.Int("hosts", h.count))
+}
+
+// ProcessTags returns the tags to add to the process, based on the metadata of the host identified
+// by its ip and hostname tags. It returns nil if the host is unknown.
+func (e *Enricher) ProcessTags(process *model.Process) map[string]string {
+	if process == nil {
+		return nil
+	}
+	ip, hostname := hostOf(process)
+	if ip == "" && hostname == "" {
+		e.metrics.Unknown.Inc(1)
+		return nil
+	}
+	s := e.state.Load().(*state)
+	key := ip + "|" + hostname
+	var tags map[string]string
+	if cached := s.cache.Get(key); cached != nil {
+		e.metrics.CacheHits.Inc(1)
+		tags = cached.(map[string]string)
+	} else {
+		e.metrics.CacheMisses.Inc(1)
+		tags, _ = s.hosts.lookup(ip, hostname)
+		if tags == nil {
+			// cache unknown hosts too, with an empty map since the cache cannot hold nil
+			tags = map[string]string{}
+		}
+		s.cache.Put(key, tags)
+	}
+	if len(tags) == 0 {
+		e.metrics.Unknown.Inc(1)
+		return nil
+	}
+	e.metrics.Enriched.Inc(1)
+	return tags
+}
+
+// hostOf returns the values of the ip and hostname tags of the process.
+// Older clients report the IPv4 address as an integer, which is converted to its dotted representation.
+func hostOf(process *model.Process) (ip string, hostname string) {
+	for _, tag := range process.Tags {
+		switch tag.Key {
+		case ipTagKey:
+			if tag.VType == model.Int64Type {
+				v := uint32(tag.Int64())
+				ip = net.IPv4(byte(v>>24), byte(v>>16), byte(v>>8), byte(v)).String()
+			} else {
+				ip = tag.AsString()
+			}
+		case hostnameTagKey:
+			hostname = tag.AsString()
+		}
+	}
+	return ip, hostname
+}
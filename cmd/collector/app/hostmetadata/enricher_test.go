@@ -0,0 +1,137 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostmetadata
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const hostsJSON = `{"hosts": [
+	{"ip": "10.0.0.1", "tags": {"pod": "web-1", "zone": "us-east-1a"}},
+	{"hostname": "worker-7", "tags": {"pod": "worker-7", "node": "node-3"}}
+]}`
+
+const hostsYAML = `
+hosts:
+  - ip: 10.0.0.1
+    hostname: web-1
+    tags:
+      pod: web-1
+      deployment: web
+`
+
+func writeHosts(t *testing.T, name, content string) string {
+	dir, err := ioutil.TempDir("", "hostmetadata")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, name)
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func newTestEnricher(t *testing.T, name, content string) (*Enricher, *metricstest.Factory, string) {
+	path := writeHosts(t, name, content)
+	mf := metricstest.NewFactory(time.Hour)
+	e, err := NewEnricher(path, zap.NewNop(), mf)
+	require.NoError(t, err)
+	t.Cleanup(func() { e.Close() })
+	return e, mf, path
+}
+
+func process(tags ...model.KeyValue) *model.Process {
+	return model.NewProcess("svc", tags)
+}
+
+func TestEnricherProcessTags(t *testing.T) {
+	e, mf, _ := newTestEnricher(t, "hosts.json", hostsJSON)
+
+	assert.Equal(t, map[string]string{"pod": "web-1", "zone": "us-east-1a"},
+		e.ProcessTags(process(model.String("ip", "10.0.0.1"), model.String("hostname", "whatever"))))
+	// older clients report the IP address as an integer
+	assert.Equal(t, map[string]string{"pod": "web-1", "zone": "us-east-1a"},
+		e.ProcessTags(process(model.Int64("ip", 10<<24|1))))
+	// the hostname is used when the IP address is unknown
+	assert.Equal(t, map[string]string{"pod": "worker-7", "node": "node-3"},
+		e.ProcessTags(process(model.String("ip", "10.0.0.9"), model.String("hostname", "worker-7"))))
+	assert.Nil(t, e.ProcessTags(process(model.String("hostname", "unknown"))))
+	assert.Nil(t, e.ProcessTags(process()))
+	assert.Nil(t, e.ProcessTags(nil))
+	// cached lookup
+	assert.Nil(t, e.ProcessTags(process(model.String("hostname", "unknown"))))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "host_metadata.lookups", Tags: map[string]string{"result": "found"}, Value: 3},
+		metricstest.ExpectedMetric{Name: "host_metadata.lookups", Tags: map[string]string{"result": "unknown"}, Value: 3},
+		metricstest.ExpectedMetric{Name: "host_metadata.cache", Tags: map[string]string{"result": "hit"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "host_metadata.cache", Tags: map[string]string{"result": "miss"}, Value: 4},
+	)
+}
+
+func TestEnricherYAML(t *testing.T) {
+	e, _, _ := newTestEnricher(t, "hosts.yaml", hostsYAML)
+
+	assert.Equal(t, map[string]string{"pod": "web-1", "deployment": "web"},
+		e.ProcessTags(process(model.String("hostname", "web-1"))))
+}
+
+func TestEnricherInvalidFile(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "hosts.json", content: `{`, err: "failed to unmarshal host metadata"},
+		{name: "hosts.yml", content: `unknown: field`, err: "failed to unmarshal host metadata"},
+		{name: "hosts.json", content: `{"hosts": [{"tags": {"pod": "web-1"}}]}`, err: "host #0 has neither ip nor hostname"},
+	} {
+		_, err := NewEnricher(writeHosts(t, test.name, test.content), zap.NewNop(), metricstest.NewFactory(time.Hour))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), test.err)
+	}
+	_, err := NewEnricher("/does/not/exist.json", zap.NewNop(), metricstest.NewFactory(time.Hour))
+	assert.Contains(t, err.Error(), "failed to read host metadata file")
+}
+
+func TestEnricherReload(t *testing.T) {
+	e, mf, path := newTestEnricher(t, "hosts.json", hostsJSON)
+	p := process(model.String("ip", "10.0.0.1"))
+	assert.Equal(t, "web-1", e.ProcessTags(p)["pod"])
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"hosts": [{}]}`), 0600))
+	assert.Eventually(t, func() bool {
+		c, _ := mf.Snapshot()
+		return c["host_metadata.reloads|result=err"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "web-1", e.ProcessTags(p)["pod"])
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"hosts": [{"ip": "10.0.0.1", "tags": {"pod": "web-2"}}]}`), 0600))
+	assert.Eventually(t, func() bool {
+		c, _ := mf.Snapshot()
+		return c["host_metadata.reloads|result=ok"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// the cache is reset with the new metadata
+	assert.Equal(t, "web-2", e.ProcessTags(p)["pod"])
+}
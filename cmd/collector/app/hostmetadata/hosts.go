@@ -0,0 +1,91 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostmetadata
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// host defines the metadata of a host, matched by IP address or by hostname.
+// The tags are added to the process of the spans coming from the host, e.g. pod, node, zone and deployment.
+type host struct {
+	IP       string            `json:"ip" yaml:"ip"`
+	Hostname string            `json:"hostname" yaml:"hostname"`
+	Tags     map[string]string `json:"tags" yaml:"tags"`
+}
+
+// hostsFile is the content of the host metadata file.
+type hostsFile struct {
+	Hosts []*host `json:"hosts" yaml:"hosts"`
+}
+
+// hosts indexes the metadata of the hosts by IP address and by hostname.
+type hosts struct {
+	count      int
+	byIP       map[string]map[string]string
+	byHostname map[string]map[string]string
+}
+
+// loadHosts reads the host metadata file, which is parsed as YAML if it has a .yaml or .yml extension and as JSON otherwise.
+func loadHosts(path string) (*hosts, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read host metadata file: %w", err)
+	}
+	var f hostsFile
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.UnmarshalStrict(bytes, &f)
+	default:
+		err = json.Unmarshal(bytes, &f)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal host metadata: %w", err)
+	}
+	h := &hosts{
+		count:      len(f.Hosts),
+		byIP:       make(map[string]map[string]string),
+		byHostname: make(map[string]map[string]string),
+	}
+	for i, entry := range f.Hosts {
+		if entry.IP == "" && entry.Hostname == "" {
+			return nil, fmt.Errorf("host #%d has neither ip nor hostname", i)
+		}
+		if entry.IP != "" {
+			h.byIP[entry.IP] = entry.Tags
+		}
+		if entry.Hostname != "" {
+			h.byHostname[entry.Hostname] = entry.Tags
+		}
+	}
+	return h, nil
+}
+
+// lookup returns the tags of the host with the given IP address or, if the IP is unknown, with the given hostname.
+func (h *hosts) lookup(ip, hostname string) (map[string]string, bool) {
+	if tags, ok := h.byIP[ip]; ok && ip != "" {
+		return tags, true
+	}
+	if tags, ok := h.byHostname[hostname]; ok && hostname != "" {
+		return tags, true
+	}
+	return nil, false
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hostmetadata
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadHostsJSON(t *testing.T) {
+	h, err := loadHosts(writeHosts(t, "hosts.json", hostsJSON))
+	require.NoError(t, err)
+
+	assert.Equal(t, 2, h.count)
+	assert.Equal(t, map[string]map[string]string{
+		"10.0.0.1": {"pod": "web-1", "zone": "us-east-1a"},
+	}, h.byIP)
+	assert.Equal(t, map[string]map[string]string{
+		"worker-7": {"pod": "worker-7", "node": "node-3"},
+	}, h.byHostname)
+}
+
+func TestLoadHostsYAML(t *testing.T) {
+	for _, name := range []string{"hosts.yaml", "hosts.yml", "HOSTS.YAML"} {
+		t.Run(name, func(t *testing.T) {
+			h, err := loadHosts(writeHosts(t, name, hostsYAML))
+			require.NoError(t, err)
+
+			tags := map[string]string{"pod": "web-1", "deployment": "web"}
+			assert.Equal(t, 1, h.count)
+			assert.Equal(t, map[string]map[string]string{"10.0.0.1": tags}, h.byIP)
+			assert.Equal(t, map[string]map[string]string{"web-1": tags}, h.byHostname)
+		})
+	}
+}
+
+func TestLoadHostsErrors(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{name: "hosts.json", content: `{"hosts": [{"ip": "10.0.0.1"}, {"tags": {"pod": "web-1"}}]}`, err: "host #1 has neither ip nor hostname"},
+		{name: "hosts.yaml", content: "hosts:\n  - tags:\n      pod: web-1\n", err: "host #0 has neither ip nor hostname"},
+		// files without a YAML extension are parsed as JSON
+		{name: "hosts.txt", content: hostsYAML, err: "failed to unmarshal host metadata"},
+		{name: "hosts.yaml", content: "hosts:\n  - address: 10.0.0.1\n", err: "failed to unmarshal host metadata"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := loadHosts(writeHosts(t, test.name, test.content))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+	_, err := loadHosts("/does/not/exist.json")
+	assert.Contains(t, err.Error(), "failed to read host metadata file")
+}
+
+func TestHostsLookup(t *testing.T) {
+	h, err := loadHosts(writeHosts(t, "hosts.json", `{"hosts": [
+		{"ip": "10.0.0.1", "tags": {"pod": "by-ip"}},
+		{"hostname": "web-1", "tags": {"pod": "by-hostname"}},
+		{"ip": "10.0.0.2", "hostname": "web-2", "tags": {"pod": "both"}}
+	]}`))
+	require.NoError(t, err)
+
+	for _, test := range []struct {
+		ip, hostname string
+		expected     map[string]string
+	}{
+		// the IP address takes precedence over the hostname
+		{ip: "10.0.0.1", hostname: "web-1", expected: map[string]string{"pod": "by-ip"}},
+		{ip: "10.0.0.1", hostname: "unknown", expected: map[string]string{"pod": "by-ip"}},
+		// the hostname is used when the IP address is unknown or missing
+		{ip: "10.0.0.9", hostname: "web-1", expected: map[string]string{"pod": "by-hostname"}},
+		{ip: "", hostname: "web-1", expected: map[string]string{"pod": "by-hostname"}},
+		// an entry with both is found by either
+		{ip: "10.0.0.2", expected: map[string]string{"pod": "both"}},
+		{hostname: "web-2", expected: map[string]string{"pod": "both"}},
+		{ip: "10.0.0.9", hostname: "unknown"},
+		{},
+	} {
+		tags, ok := h.lookup(test.ip, test.hostname)
+		assert.Equal(t, test.expected != nil, ok, "ip=%q hostname=%q", test.ip, test.hostname)
+		assert.Equal(t, test.expected, tags, "ip=%q hostname=%q", test.ip, test.hostname)
+	}
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorylimiter
+
+import (
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+)
+
+// State describes the heap usage relative to the limits.
+type State int32
+
+const (
+	// Normal means the heap is below the soft limit
+	Normal State = iota
+	// SoftLimit means the heap is above the soft limit, incoming spans are rejected
+	SoftLimit
+	// HardLimit means the heap is still above the hard limit after a forced garbage collection
+	HardLimit
+)
+
+// Options configures the memory limiter.
+type Options struct {
+	// SoftLimitBytes is the heap size above which incoming spans are rejected
+	SoftLimitBytes uint64
+	// HardLimitBytes is the heap size above which a garbage collection is forced
+	HardLimitBytes uint64
+	// CheckInterval is how often the heap size is checked
+	CheckInterval time.Duration
+}
+
+// Enabled returns true if at least one limit is set.
+func (o Options) Enabled() bool {
+	return o.SoftLimitBytes > 0 || o.HardLimitBytes > 0
+}
+
+// Validate returns an error if the soft limit is above the hard limit or the check interval is not positive.
+func (o Options) Validate() error {
+	if o.HardLimitBytes > 0 && o.SoftLimitBytes > o.HardLimitBytes {
+		return fmt.Errorf("memory limiter soft limit (%d bytes) must not be above the hard limit (%d bytes)", o.SoftLimitBytes, o.HardLimitBytes)
+	}
+	if o.CheckInterval <= 0 {
+		return fmt.Errorf("memory limiter check interval must be positive, got %v", o.CheckInterval)
+	}
+	return nil
+}
+
+type limiterMetrics struct {
+	// HeapBytes is the heap size measured by the last check
+	HeapBytes metrics.Gauge `metric:"heap-bytes"`
+	// State is the state of the limiter: 0 for normal, 1 above the soft limit, 2 above the hard limit
+	State metrics.Gauge `metric:"state"`
+	// RejectedBatches is the number of span batches rejected because of the memory usage
+	RejectedBatches metrics.Counter `metric:"rejected-batches"`
+	// ForcedGCs is the number of garbage collections forced by the hard limit
+	ForcedGCs metrics.Counter `metric:"forced-gcs"`
+}
+
+// Limiter periodically measures the heap size and tells the span processor to reject incoming spans
+// when it is above the soft limit. While rejecting, the collector is reported as unavailable by the health check,
+// so that load balancers send traffic to other instances.
+type Limiter struct {
+	options Options
+	logger  *zap.Logger
+	hCheck  *healthcheck.HealthCheck
+	metrics limiterMetrics
+	state   *atomic.Int32
+
+	readHeapSize func() uint64
+	forceGC      func()
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+var _ io.Closer = (*Limiter)(nil)
+
+// New creates a memory limiter. Start must be called to start checking the heap size.
+// If only the hard limit is set, it is also used as the soft limit.
+func New(options Options, hCheck *healthcheck.HealthCheck, logger *zap.Logger, metricsFactory metrics.Factory) *Limiter {
+	if options.SoftLimitBytes == 0 {
+		options.SoftLimitBytes = options.HardLimitBytes
+	}
+	l := &Limiter{
+		options:      options,
+		logger:       logger,
+		hCheck:       hCheck,
+		state:        atomic.NewInt32(int32(Normal)),
+		readHeapSize: readHeapSize,
+		forceGC:      runtime.GC,
+		stopCh:       make(chan struct{}),
+	}
+	metrics.MustInit(&l.metrics, metricsFactory.Namespace(metrics.NSOptions{Name: "memory_limiter"}), nil)
+	return l
+}
+
+// Start checks the heap size periodically in a background goroutine.
+func (l *Limiter) Start() {
+	l.wg.Add(1)
+	go func() {
+		defer l.wg.Done()
+		ticker := time.NewTicker(l.options.CheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.check()
+			case <-l.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Close stops checking the heap size.
+func (l *Limiter) Close() error {
+	close(l.stopCh)
+	l.wg.Wait()
+	return nil
+}
+
+// State returns the state measured by the last check.
+func (l *Limiter) State() State {
+	return State(l.state.Load())
+}
+
+// RejectBatch returns true if incoming spans must be rejected because the heap is above the soft limit.
+func (l *Limiter) RejectBatch() bool {
+	if l.State() == Normal {
+		return false
+	}
+	l.metrics.RejectedBatches.Inc(1)
+	return true
+}
+
+func (l *Limiter) check() {
+	heapSize := l.readHeapSize()
+	if l.options.HardLimitBytes > 0 && heapSize >= l.options.HardLimitBytes {
+		l.logger.Warn("Heap size is above the hard limit, forcing garbage collection",
+			zap.Uint64("heap-bytes", heapSize),
+			zap.Uint64("hard-limit-bytes", l.options.HardLimitBytes))
+		l.forceGC()
+		l.metrics.ForcedGCs.Inc(1)
+		heapSize = l.readHeapSize()
+	}
+
+	newState := Normal
+	if l.options.HardLimitBytes > 0 && heapSize >= l.options.HardLimitBytes {
+		newState = HardLimit
+	} else if heapSize >= l.options.SoftLimitBytes {
+		newState = SoftLimit
+	}
+	l.metrics.HeapBytes.Update(int64(heapSize))
+	l.metrics.State.Update(int64(newState))
+
+	oldState := State(l.state.Swap(int32(newState)))
+	if oldState == Normal && newState != Normal {
+		l.logger.Warn("Heap size is above the soft limit, rejecting incoming spans",
+			zap.Uint64("heap-bytes", heapSize),
+			zap.Uint64("soft-limit-bytes", l.options.SoftLimitBytes))
+		l.hCheck.Set(healthcheck.Unavailable)
+	} else if oldState != Normal && newState == Normal {
+		l.logger.Info("Heap size is back below the soft limit, accepting incoming spans",
+			zap.Uint64("heap-bytes", heapSize))
+		l.hCheck.Set(healthcheck.Ready)
+	}
+}
+
+func readHeapSize() uint64 {
+	var ms runtime.MemStats
+	runtime.ReadMemStats(&ms)
+	return ms.HeapAlloc
+}
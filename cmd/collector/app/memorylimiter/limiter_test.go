@@ -0,0 +1,127 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package memorylimiter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+)
+
+type fakeHeap struct {
+	size      *atomic.Uint64
+	afterGC   *atomic.Uint64
+	forcedGCs *atomic.Int32
+}
+
+func newTestLimiter(options Options) (*Limiter, *fakeHeap, *healthcheck.HealthCheck, *metricstest.Factory) {
+	heap := &fakeHeap{size: atomic.NewUint64(0), afterGC: atomic.NewUint64(0), forcedGCs: atomic.NewInt32(0)}
+	hc := healthcheck.New()
+	hc.Ready()
+	mf := metricstest.NewFactory(time.Hour)
+	l := New(options, hc, zap.NewNop(), mf)
+	l.readHeapSize = heap.size.Load
+	l.forceGC = func() {
+		heap.forcedGCs.Inc()
+		heap.size.Store(heap.afterGC.Load())
+	}
+	return l, heap, hc, mf
+}
+
+func TestLimiterStates(t *testing.T) {
+	l, heap, hc, mf := newTestLimiter(Options{SoftLimitBytes: 100, HardLimitBytes: 200})
+
+	heap.size.Store(50)
+	l.check()
+	assert.Equal(t, Normal, l.State())
+	assert.False(t, l.RejectBatch())
+	assert.Equal(t, healthcheck.Ready, hc.Get())
+
+	heap.size.Store(150)
+	l.check()
+	assert.Equal(t, SoftLimit, l.State())
+	assert.True(t, l.RejectBatch())
+	assert.Equal(t, healthcheck.Unavailable, hc.Get())
+	assert.Equal(t, int32(0), heap.forcedGCs.Load())
+
+	// the forced garbage collection frees enough memory to go below the hard limit
+	heap.size.Store(250)
+	heap.afterGC.Store(120)
+	l.check()
+	assert.Equal(t, SoftLimit, l.State())
+	assert.Equal(t, int32(1), heap.forcedGCs.Load())
+
+	heap.size.Store(250)
+	heap.afterGC.Store(220)
+	l.check()
+	assert.Equal(t, HardLimit, l.State())
+	assert.True(t, l.RejectBatch())
+
+	heap.size.Store(10)
+	l.check()
+	assert.Equal(t, Normal, l.State())
+	assert.False(t, l.RejectBatch())
+	assert.Equal(t, healthcheck.Ready, hc.Get())
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "memory_limiter.rejected-batches", Value: 2},
+		metricstest.ExpectedMetric{Name: "memory_limiter.forced-gcs", Value: 2},
+	)
+	mf.AssertGaugeMetrics(t,
+		metricstest.ExpectedMetric{Name: "memory_limiter.heap-bytes", Value: 10},
+		metricstest.ExpectedMetric{Name: "memory_limiter.state", Value: int(Normal)},
+	)
+}
+
+func TestLimiterHardLimitOnly(t *testing.T) {
+	l, heap, _, _ := newTestLimiter(Options{HardLimitBytes: 100})
+
+	heap.size.Store(99)
+	l.check()
+	assert.Equal(t, Normal, l.State())
+
+	heap.size.Store(150)
+	heap.afterGC.Store(100)
+	l.check()
+	assert.Equal(t, HardLimit, l.State())
+}
+
+func TestLimiterStartClose(t *testing.T) {
+	l, heap, hc, _ := newTestLimiter(Options{SoftLimitBytes: 100, CheckInterval: time.Millisecond})
+	heap.size.Store(150)
+	l.Start()
+	assert.Eventually(t, func() bool {
+		return l.State() == SoftLimit && hc.Get() == healthcheck.Unavailable
+	}, 5*time.Second, time.Millisecond)
+	assert.NoError(t, l.Close())
+}
+
+func TestOptionsValidate(t *testing.T) {
+	assert.False(t, Options{CheckInterval: time.Second}.Enabled())
+	assert.True(t, Options{HardLimitBytes: 100}.Enabled())
+
+	assert.NoError(t, Options{SoftLimitBytes: 100, CheckInterval: time.Second}.Validate())
+	assert.NoError(t, Options{SoftLimitBytes: 100, HardLimitBytes: 100, CheckInterval: time.Second}.Validate())
+	assert.EqualError(t, Options{SoftLimitBytes: 200, HardLimitBytes: 100, CheckInterval: time.Second}.Validate(),
+		"memory limiter soft limit (200 bytes) must not be above the hard limit (100 bytes)")
+	assert.EqualError(t, Options{HardLimitBytes: 100}.Validate(), "memory limiter check interval must be positive, got 0s")
+	assert.EqualError(t, Options{HardLimitBytes: 100, CheckInterval: -time.Second}.Validate(), "memory limiter check interval must be positive, got -1s")
+}
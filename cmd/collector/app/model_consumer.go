@@ -28,6 +28,9 @@ type ProcessSpans func(spans []*model.Span)
 // FilterSpan decides whether to allow or disallow a span
 type FilterSpan func(span *model.Span) bool
 
+// AllowSpans decides whether to allow or disallow a batch of spans of a tenant as a whole
+type AllowSpans func(spans []*model.Span, tenant string) bool
+
 // ChainedProcessSpan chains spanProcessors as a single ProcessSpan call
 func ChainedProcessSpan(spanProcessors ...ProcessSpan) ProcessSpan {
 	return func(span *model.Span) {
@@ -16,12 +16,15 @@
 package app
 
 import (
+	"time"
+
 	"github.com/uber/jaeger-lib/metrics"
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
 )
 
 const (
@@ -29,6 +32,8 @@ const (
 	DefaultNumWorkers = 50
 	// DefaultQueueSize is the size of the processor's queue
 	DefaultQueueSize = 2000
+	// DefaultDrainTimeout is how long the processor waits on Close for the queue to be drained
+	DefaultDrainTimeout = 10 * time.Second
 )
 
 type options struct {
@@ -39,14 +44,20 @@ type options struct {
 	sanitizer          sanitizer.SanitizeSpan
 	preSave            ProcessSpan
 	spanFilter         FilterSpan
+	spanPipeline       sanitizer.SanitizeSpan
+	spanQuota          AllowSpans
+	deduper            *dedup.Deduper
+	memoryLimiter      func() bool
 	numWorkers         int
 	blockingSubmit     bool
 	queueSize          int
+	drainTimeout       time.Duration
 	dynQueueSizeWarmup uint
 	dynQueueSizeMemory uint
 	reportBusy         bool
 	extraFormatTypes   []processor.SpanFormat
 	collectorTags      map[string]string
+	hostTags           func(process *model.Process) map[string]string
 }
 
 // Option is a function that sets some option on StorageBuilder.
@@ -104,6 +115,36 @@ func (options) SpanFilter(spanFilter FilterSpan) Option {
 	}
 }
 
+// SpanPipeline creates an Option that initializes the spanPipeline function, applied to spans before they are queued
+func (options) SpanPipeline(spanPipeline sanitizer.SanitizeSpan) Option {
+	return func(b *options) {
+		b.spanPipeline = spanPipeline
+	}
+}
+
+// SpanQuota creates an Option that initializes the spanQuota function, which rejects batches over the quota
+// of their services or tenant
+func (options) SpanQuota(spanQuota AllowSpans) Option {
+	return func(b *options) {
+		b.spanQuota = spanQuota
+	}
+}
+
+// Deduper creates an Option that initializes the deduper, which drops the spans already enqueued recently
+func (options) Deduper(deduper *dedup.Deduper) Option {
+	return func(b *options) {
+		b.deduper = deduper
+	}
+}
+
+// MemoryLimiter creates an Option that initializes the memoryLimiter function, which returns true when incoming
+// spans must be rejected because the memory usage is too high
+func (options) MemoryLimiter(memoryLimiter func() bool) Option {
+	return func(b *options) {
+		b.memoryLimiter = memoryLimiter
+	}
+}
+
 // NumWorkers creates an Option that initializes the number of queue consumers AKA workers
 func (options) NumWorkers(numWorkers int) Option {
 	return func(b *options) {
@@ -125,6 +166,13 @@ func (options) QueueSize(queueSize int) Option {
 	}
 }
 
+// DrainTimeout creates an Option that initializes how long Close waits for the queued spans to be saved
+func (options) DrainTimeout(drainTimeout time.Duration) Option {
+	return func(b *options) {
+		b.drainTimeout = drainTimeout
+	}
+}
+
 // DynQueueSize creates an Option that initializes the queue size
 func (options) DynQueueSizeWarmup(dynQueueSizeWarmup uint) Option {
 	return func(b *options) {
@@ -160,6 +208,14 @@ func (options) CollectorTags(extraTags map[string]string) Option {
 	}
 }
 
+// HostTags creates an Option that initializes the function returning the extra tags to append to the process of a span,
+// based on the host the span comes from
+func (options) HostTags(hostTags func(process *model.Process) map[string]string) Option {
+	return func(b *options) {
+		b.hostTags = hostTags
+	}
+}
+
 func (o options) apply(opts ...Option) options {
 	ret := options{}
 	for _, opt := range opts {
@@ -186,6 +242,18 @@ func (o options) apply(opts ...Option) options {
 	if ret.spanFilter == nil {
 		ret.spanFilter = func(span *model.Span) bool { return true }
 	}
+	if ret.spanPipeline == nil {
+		ret.spanPipeline = func(span *model.Span) *model.Span { return span }
+	}
+	if ret.spanQuota == nil {
+		ret.spanQuota = func(spans []*model.Span, tenant string) bool { return true }
+	}
+	if ret.memoryLimiter == nil {
+		ret.memoryLimiter = func() bool { return false }
+	}
+	if ret.hostTags == nil {
+		ret.hostTags = func(process *model.Process) map[string]string { return nil }
+	}
 	if ret.numWorkers == 0 {
 		ret.numWorkers = DefaultNumWorkers
 	}
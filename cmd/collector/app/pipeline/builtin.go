@@ -0,0 +1,240 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+// builtIns are the processors available in every registry.
+var builtIns = map[string]registration{
+	"filter":    {kind: KindFilter, factory: newFilter},
+	"utf8":      {kind: KindSanitize, factory: newUTF8},
+	"redact":    {kind: KindSanitize, factory: newRedact},
+	"span-size": {kind: KindSanitize, factory: newSpanSize},
+	"add-tags":  {kind: KindEnrich, factory: newAddTags},
+	"sample":    {kind: KindSample, factory: newSample},
+}
+
+// filterConfig selects the spans dropped by the filter processor. All values are regular expressions
+// that must match the whole string; a span is dropped when it matches every non-empty field.
+type filterConfig struct {
+	Service   string            `yaml:"service"`
+	Operation string            `yaml:"operation"`
+	Tags      map[string]string `yaml:"tags"`
+}
+
+func newFilter(config Config, _ Params) (Processor, error) {
+	var c filterConfig
+	if err := config.Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Service == "" && c.Operation == "" && len(c.Tags) == 0 {
+		return nil, fmt.Errorf("filter must define at least one of service, operation or tags")
+	}
+	service, err := compile(c.Service)
+	if err != nil {
+		return nil, err
+	}
+	operation, err := compile(c.Operation)
+	if err != nil {
+		return nil, err
+	}
+	tags := make(map[string]*regexp.Regexp, len(c.Tags))
+	for key, value := range c.Tags {
+		if tags[key], err = compile(value); err != nil {
+			return nil, err
+		}
+	}
+	return func(span *model.Span) *model.Span {
+		if service != nil && (span.Process == nil || !service.MatchString(span.Process.ServiceName)) {
+			return span
+		}
+		if operation != nil && !operation.MatchString(span.OperationName) {
+			return span
+		}
+		for key, value := range tags {
+			tag, ok := model.KeyValues(span.Tags).FindByKey(key)
+			if !ok || (value != nil && !value.MatchString(tag.AsString())) {
+				return span
+			}
+		}
+		return nil
+	}, nil
+}
+
+// compile returns nil for empty expressions, which match everything.
+func compile(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	re, err := regexp.Compile("^(?:" + expr + ")$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %q: %w", expr, err)
+	}
+	return re, nil
+}
+
+func newUTF8(config Config, params Params) (Processor, error) {
+	if err := config.Decode(&struct{}{}); err != nil {
+		return nil, err
+	}
+	return Processor(sanitizer.NewUTF8Sanitizer(params.Logger)), nil
+}
+
+type redactConfig struct {
+	Detectors []string `yaml:"detectors"`
+	Mask      string   `yaml:"mask"`
+}
+
+func newRedact(config Config, params Params) (Processor, error) {
+	c := redactConfig{Mask: sanitizer.DefaultRedactionMask}
+	if err := config.Decode(&c); err != nil {
+		return nil, err
+	}
+	if len(c.Detectors) == 0 {
+		return nil, fmt.Errorf("redact must define at least one detector")
+	}
+	var detectors []sanitizer.RedactionDetector
+	for _, name := range c.Detectors {
+		d, ok := sanitizer.BuiltInRedactionDetectors[name]
+		if !ok {
+			return nil, fmt.Errorf("unknown redaction detector %q", name)
+		}
+		detectors = append(detectors, d)
+	}
+	return Processor(sanitizer.NewRedactionSanitizer(detectors, c.Mask, params.MetricsFactory)), nil
+}
+
+type spanSizeConfig struct {
+	MaxTagValueLength int `yaml:"max_tag_value_length"`
+	MaxTags           int `yaml:"max_tags"`
+	MaxLogs           int `yaml:"max_logs"`
+	MaxSpanSize       int `yaml:"max_span_size"`
+}
+
+func newSpanSize(config Config, params Params) (Processor, error) {
+	var c spanSizeConfig
+	if err := config.Decode(&c); err != nil {
+		return nil, err
+	}
+	limits := sanitizer.SpanSizeLimits(c)
+	if !limits.Enabled() {
+		return nil, fmt.Errorf("span-size must define at least one limit")
+	}
+	return Processor(sanitizer.NewSpanSizeSanitizer(limits, params.MetricsFactory)), nil
+}
+
+// addTagsConfig defines the tags added to the span or, when Scope is process, to its process.
+type addTagsConfig struct {
+	Tags  map[string]string `yaml:"tags"`
+	Scope string            `yaml:"scope"`
+}
+
+func newAddTags(config Config, _ Params) (Processor, error) {
+	var c addTagsConfig
+	if err := config.Decode(&c); err != nil {
+		return nil, err
+	}
+	if len(c.Tags) == 0 {
+		return nil, fmt.Errorf("add-tags must define at least one tag")
+	}
+	if c.Scope != "" && c.Scope != "span" && c.Scope != "process" {
+		return nil, fmt.Errorf("unknown scope %q, must be span or process", c.Scope)
+	}
+	tags := make([]model.KeyValue, 0, len(c.Tags))
+	for key, value := range c.Tags {
+		tags = append(tags, model.String(key, value))
+	}
+	model.KeyValues(tags).Sort()
+	return func(span *model.Span) *model.Span {
+		if c.Scope == "process" {
+			if span.Process != nil {
+				span.Process.Tags = appendMissing(span.Process.Tags, tags)
+			}
+		} else {
+			span.Tags = appendMissing(span.Tags, tags)
+		}
+		return span
+	}, nil
+}
+
+// appendMissing appends the tags that are not already present with the same value,
+// since the spans of a batch may share their process.
+func appendMissing(kvs []model.KeyValue, tags []model.KeyValue) []model.KeyValue {
+	for _, tag := range tags {
+		if kv, ok := model.KeyValues(kvs).FindByKey(tag.Key); ok && kv.Equal(&tag) {
+			continue
+		}
+		kvs = append(kvs, tag)
+	}
+	return kvs
+}
+
+// sampleConfig defines the ratio of traces kept by the sample processor, and service specific ratios.
+type sampleConfig struct {
+	Ratio         *float64           `yaml:"ratio"`
+	HashSalt      string             `yaml:"hash_salt"`
+	ServiceRatios map[string]float64 `yaml:"service_ratios"`
+}
+
+func newSample(config Config, _ Params) (Processor, error) {
+	var c sampleConfig
+	if err := config.Decode(&c); err != nil {
+		return nil, err
+	}
+	if c.Ratio == nil {
+		return nil, fmt.Errorf("sample must define a ratio")
+	}
+	if err := validateRatio(*c.Ratio); err != nil {
+		return nil, err
+	}
+	defaultSampler := spanstore.NewSampler(*c.Ratio, c.HashSalt)
+	samplers := make(map[string]*spanstore.Sampler, len(c.ServiceRatios))
+	for service, ratio := range c.ServiceRatios {
+		if err := validateRatio(ratio); err != nil {
+			return nil, fmt.Errorf("invalid ratio for service %s: %w", service, err)
+		}
+		samplers[service] = spanstore.NewSampler(ratio, c.HashSalt)
+	}
+	return func(span *model.Span) *model.Span {
+		// debug spans are never dropped
+		if span.Flags.IsDebug() {
+			return span
+		}
+		sampler := defaultSampler
+		if span.Process != nil {
+			if s, ok := samplers[span.Process.ServiceName]; ok {
+				sampler = s
+			}
+		}
+		if !sampler.ShouldSample(span) {
+			return nil
+		}
+		return span
+	}, nil
+}
+
+func validateRatio(ratio float64) error {
+	if ratio < 0 || ratio > 1 {
+		return fmt.Errorf("ratio must be between 0 and 1, got %v", ratio)
+	}
+	return nil
+}
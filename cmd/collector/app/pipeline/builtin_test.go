@@ -0,0 +1,156 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+var testParams = Params{Logger: zap.NewNop(), MetricsFactory: metrics.NullFactory}
+
+func newBuiltIn(t *testing.T, name string, config Config) Processor {
+	p, err := builtIns[name].factory(config, testParams)
+	require.NoError(t, err)
+	return p
+}
+
+func TestFilter(t *testing.T) {
+	p := newBuiltIn(t, "filter", Config{
+		"service":   "front.*",
+		"operation": "/health",
+		"tags":      map[interface{}]interface{}{"http.method": "GET"},
+	})
+	match := func() *model.Span {
+		return &model.Span{
+			OperationName: "/health",
+			Process:       model.NewProcess("frontend", nil),
+			Tags:          []model.KeyValue{model.String("http.method", "GET")},
+		}
+	}
+	assert.Nil(t, p(match()))
+
+	other := match()
+	other.Process.ServiceName = "backend"
+	assert.Equal(t, other, p(other))
+	other = match()
+	other.OperationName = "/health/live"
+	assert.Equal(t, other, p(other))
+	other = match()
+	other.Tags = []model.KeyValue{model.String("http.method", "POST")}
+	assert.Equal(t, other, p(other))
+	other = match()
+	other.Tags = nil
+	assert.Equal(t, other, p(other))
+	assert.NotNil(t, p(&model.Span{OperationName: "/health"}))
+}
+
+func TestFilterErrors(t *testing.T) {
+	for _, config := range []Config{
+		{},
+		{"service": "("},
+		{"operation": "("},
+		{"tags": map[interface{}]interface{}{"k": "("}},
+		{"unknown": "x"},
+	} {
+		_, err := newFilter(config, testParams)
+		assert.Error(t, err, "%v", config)
+	}
+}
+
+func TestUTF8(t *testing.T) {
+	p := newBuiltIn(t, "utf8", nil)
+	span := p(&model.Span{Process: model.NewProcess("svc", nil), Tags: []model.KeyValue{model.String("k", "\xff")}})
+	assert.NotEqual(t, "\xff", span.Tags[0].VStr)
+
+	_, err := newUTF8(Config{"unknown": true}, testParams)
+	assert.Error(t, err)
+}
+
+func TestRedact(t *testing.T) {
+	p := newBuiltIn(t, "redact", Config{"detectors": []interface{}{"email"}, "mask": "***"})
+	span := p(&model.Span{Process: model.NewProcess("svc", nil), Tags: []model.KeyValue{model.String("user", "jane@example.com")}})
+	assert.Equal(t, "***", span.Tags[0].VStr)
+
+	_, err := newRedact(Config{}, testParams)
+	assert.EqualError(t, err, "redact must define at least one detector")
+	_, err = newRedact(Config{"detectors": []interface{}{"phone"}}, testParams)
+	assert.EqualError(t, err, `unknown redaction detector "phone"`)
+	_, err = newRedact(Config{"detectors": "email"}, testParams)
+	assert.Error(t, err)
+}
+
+func TestSpanSize(t *testing.T) {
+	p := newBuiltIn(t, "span-size", Config{"max_tags": 1})
+	span := p(&model.Span{Process: model.NewProcess("svc", nil), Tags: []model.KeyValue{model.String("a", "1"), model.String("b", "2")}})
+	kv, ok := model.KeyValues(span.Tags).FindByKey("b")
+	assert.False(t, ok, "%v", kv)
+
+	_, err := newSpanSize(Config{}, testParams)
+	assert.EqualError(t, err, "span-size must define at least one limit")
+	_, err = newSpanSize(Config{"max_tags": "many"}, testParams)
+	assert.Error(t, err)
+}
+
+func TestAddTags(t *testing.T) {
+	p := newBuiltIn(t, "add-tags", Config{"tags": map[interface{}]interface{}{"b": "2", "a": "1"}})
+	span := p(&model.Span{Tags: []model.KeyValue{model.String("a", "1")}})
+	assert.Equal(t, []model.KeyValue{model.String("a", "1"), model.String("b", "2")}, span.Tags)
+
+	p = newBuiltIn(t, "add-tags", Config{"tags": map[interface{}]interface{}{"zone": "us-east-1a"}, "scope": "process"})
+	process := model.NewProcess("svc", nil)
+	// spans of the same batch share the process, which gets the tags once
+	p(&model.Span{Process: process})
+	p(&model.Span{Process: process})
+	assert.Equal(t, []model.KeyValue{model.String("zone", "us-east-1a")}, process.Tags)
+	assert.NotNil(t, p(&model.Span{}))
+
+	_, err := newAddTags(Config{}, testParams)
+	assert.EqualError(t, err, "add-tags must define at least one tag")
+	_, err = newAddTags(Config{"tags": map[interface{}]interface{}{"a": "1"}, "scope": "trace"}, testParams)
+	assert.EqualError(t, err, `unknown scope "trace", must be span or process`)
+	_, err = newAddTags(Config{"tags": "a"}, testParams)
+	assert.Error(t, err)
+}
+
+func TestSample(t *testing.T) {
+	p := newBuiltIn(t, "sample", Config{
+		"ratio":          0.0,
+		"service_ratios": map[interface{}]interface{}{"important": 1.0},
+	})
+	span := &model.Span{TraceID: model.NewTraceID(1, 2), Process: model.NewProcess("svc", nil)}
+	assert.Nil(t, p(span))
+	assert.Nil(t, p(&model.Span{TraceID: model.NewTraceID(1, 2)}))
+	span.Flags.SetDebug()
+	assert.Equal(t, span, p(span), "debug spans are never dropped")
+	important := &model.Span{TraceID: model.NewTraceID(1, 2), Process: model.NewProcess("important", nil)}
+	assert.Equal(t, important, p(important))
+
+	for _, config := range []Config{
+		{},
+		{"ratio": 2.0},
+		{"ratio": 0.5, "service_ratios": map[interface{}]interface{}{"svc": -1.0}},
+		{"ratio": "half"},
+	} {
+		_, err := newSample(config, testParams)
+		assert.Error(t, err, "%v", config)
+	}
+}
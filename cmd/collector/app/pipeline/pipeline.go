@@ -0,0 +1,131 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// File is the YAML representation of the pipeline file.
+type File struct {
+	Processors []ProcessorConfig `yaml:"processors"`
+}
+
+// ProcessorConfig describes a single step of the pipeline.
+type ProcessorConfig struct {
+	// Type is the name the processor factory is registered with.
+	Type string `yaml:"type"`
+	// Name identifies the step in logs and metrics, and defaults to Type. Names must be unique.
+	Name string `yaml:"name"`
+	// Config is passed to the processor factory.
+	Config Config `yaml:"config"`
+}
+
+type stage struct {
+	name    string
+	process Processor
+	dropped metrics.Counter
+}
+
+// Pipeline applies span processors in order.
+type Pipeline struct {
+	stages []stage
+}
+
+// LoadFile reads the pipeline file at the given path and builds the pipeline with the processors of the registry.
+func (r *Registry) LoadFile(path string, params Params) (*Pipeline, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read span pipeline file: %w", err)
+	}
+	var f File
+	if err := yaml.UnmarshalStrict(bytes, &f); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal span pipeline: %w", err)
+	}
+	return r.Build(f.Processors, params)
+}
+
+// Build creates the pipeline applying the given processors in order.
+func (r *Registry) Build(configs []ProcessorConfig, params Params) (*Pipeline, error) {
+	if params.Logger == nil {
+		params.Logger = zap.NewNop()
+	}
+	if params.MetricsFactory == nil {
+		params.MetricsFactory = metrics.NullFactory
+	}
+	metricsFactory := params.MetricsFactory.Namespace(metrics.NSOptions{Name: "span_pipeline"})
+	p := &Pipeline{}
+	names := make(map[string]struct{}, len(configs))
+	for _, c := range configs {
+		reg, ok := r.get(c.Type)
+		if !ok {
+			return nil, fmt.Errorf("unknown span processor type %q, registered types are %v", c.Type, r.Names())
+		}
+		name := c.Name
+		if name == "" {
+			name = c.Type
+		}
+		if _, ok := names[name]; ok {
+			return nil, fmt.Errorf("duplicate span processor name %q", name)
+		}
+		names[name] = struct{}{}
+		process, err := reg.factory(c.Config, Params{
+			Logger:         params.Logger.With(zap.String("span-processor", name)),
+			MetricsFactory: metricsFactory.Namespace(metrics.NSOptions{Tags: map[string]string{"processor": name}}),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("invalid span processor %s: %w", name, err)
+		}
+		p.stages = append(p.stages, stage{
+			name:    name,
+			process: process,
+			dropped: metricsFactory.Counter(metrics.Options{
+				Name: "spans_dropped",
+				Tags: map[string]string{"processor": name, "kind": string(reg.kind)},
+			}),
+		})
+		params.Logger.Info("Added span processor to the pipeline", zap.String("name", name), zap.String("type", c.Type), zap.String("kind", string(reg.kind)))
+	}
+	return p, nil
+}
+
+// Process applies the processors in order. It returns nil as soon as a processor drops the span.
+func (p *Pipeline) Process(span *model.Span) *model.Span {
+	for _, s := range p.stages {
+		if span = s.process(span); span == nil {
+			s.dropped.Inc(1)
+			return nil
+		}
+	}
+	return span
+}
+
+// Len returns the number of processors in the pipeline.
+func (p *Pipeline) Len() int {
+	return len(p.stages)
+}
+
+// LoadFile reads the pipeline file at the given path and builds the pipeline with the processors of the DefaultRegistry.
+func LoadFile(path string, params Params) (*Pipeline, error) {
+	return DefaultRegistry.LoadFile(path, params)
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func writePipeline(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "pipeline")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "pipeline.yaml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func TestRegistryRegister(t *testing.T) {
+	r := NewRegistry()
+	keep := func(Config, Params) (Processor, error) {
+		return func(span *model.Span) *model.Span { return span }, nil
+	}
+	require.NoError(t, r.Register("keep", KindFilter, keep))
+	assert.Contains(t, r.Names(), "keep")
+	assert.Contains(t, r.Names(), "filter")
+	// registries are independent
+	assert.NotContains(t, NewRegistry().Names(), "keep")
+
+	assert.EqualError(t, r.Register("keep", KindFilter, keep), "span processor keep is already registered")
+	assert.EqualError(t, r.Register("filter", KindFilter, keep), "span processor filter is already registered")
+	assert.EqualError(t, r.Register("", KindFilter, keep), "span processor name must not be empty")
+	assert.EqualError(t, r.Register("nil", KindFilter, nil), "span processor nil has no factory")
+}
+
+func TestRegisterDefault(t *testing.T) {
+	require.NoError(t, Register("test-default", KindEnrich, newAddTags))
+	assert.Contains(t, DefaultRegistry.Names(), "test-default")
+}
+
+func TestLoadFile(t *testing.T) {
+	r := NewRegistry()
+	type customConfig struct {
+		Operation string `yaml:"operation"`
+	}
+	require.NoError(t, r.Register("rename", KindSanitize, func(config Config, _ Params) (Processor, error) {
+		var c customConfig
+		if err := config.Decode(&c); err != nil {
+			return nil, err
+		}
+		return func(span *model.Span) *model.Span {
+			span.OperationName = c.Operation
+			return span
+		}, nil
+	}))
+	path := writePipeline(t, `
+processors:
+  - type: filter
+    name: drop-health-checks
+    config:
+      operation: /health.*
+  - type: rename
+    config:
+      operation: renamed
+  - type: add-tags
+    config:
+      tags:
+        env: prod
+`)
+	mf := metricstest.NewFactory(time.Hour)
+	p, err := r.LoadFile(path, Params{MetricsFactory: mf})
+	require.NoError(t, err)
+	assert.Equal(t, 3, p.Len())
+
+	assert.Nil(t, p.Process(&model.Span{OperationName: "/healthz"}))
+	span := p.Process(&model.Span{OperationName: "get"})
+	require.NotNil(t, span)
+	assert.Equal(t, "renamed", span.OperationName)
+	assert.Equal(t, []model.KeyValue{model.String("env", "prod")}, span.Tags)
+
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name:  "span_pipeline.spans_dropped",
+		Tags:  map[string]string{"processor": "drop-health-checks", "kind": "filter"},
+		Value: 1,
+	})
+}
+
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{content: `processors: {`, err: "failed to unmarshal span pipeline"},
+		{content: `unknown: true`, err: "failed to unmarshal span pipeline"},
+		{content: "processors:\n  - type: unknown", err: `unknown span processor type "unknown"`},
+		{content: "processors:\n  - type: utf8\n  - type: utf8", err: `duplicate span processor name "utf8"`},
+		{content: "processors:\n  - type: filter", err: "invalid span processor filter"},
+	}
+	for _, test := range tests {
+		t.Run(test.err, func(t *testing.T) {
+			_, err := LoadFile(writePipeline(t, test.content), Params{})
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	_, err := LoadFile("/does/not/exist.yaml", Params{})
+	assert.Contains(t, err.Error(), "failed to read span pipeline file")
+}
+
+func TestEmptyPipeline(t *testing.T) {
+	p, err := LoadFile(writePipeline(t, ``), Params{})
+	require.NoError(t, err)
+	span := &model.Span{}
+	assert.Equal(t, span, p.Process(span))
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pipeline
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+	"gopkg.in/yaml.v2"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+// Kind describes what a span processor does with the spans.
+type Kind string
+
+// Supported processor kinds.
+const (
+	// KindFilter processors drop spans matching some criteria
+	KindFilter Kind = "filter"
+	// KindSanitize processors normalize or remove the contents of spans, and may drop spans
+	KindSanitize Kind = "sanitize"
+	// KindEnrich processors add information to spans
+	KindEnrich Kind = "enrich"
+	// KindSample processors keep a fraction of the traces
+	KindSample Kind = "sample"
+)
+
+// Processor processes a span before it is queued. It may modify the span or return a new one,
+// and returns nil to indicate that the span must be dropped.
+type Processor func(span *model.Span) *model.Span
+
+// Params are the dependencies passed to the factories.
+type Params struct {
+	Logger         *zap.Logger
+	MetricsFactory metrics.Factory
+}
+
+// Config is the configuration of a processor, as read from the pipeline file.
+type Config map[string]interface{}
+
+// Decode unmarshals the configuration into v, which must be a pointer to a struct with yaml tags.
+// Unknown fields are reported as errors.
+func (c Config) Decode(v interface{}) error {
+	bytes, err := yaml.Marshal(map[string]interface{}(c))
+	if err != nil {
+		return err
+	}
+	return yaml.UnmarshalStrict(bytes, v)
+}
+
+// Factory creates a processor from its configuration.
+type Factory func(config Config, params Params) (Processor, error)
+
+type registration struct {
+	kind    Kind
+	factory Factory
+}
+
+// Registry holds the span processor factories by name.
+type Registry struct {
+	lock      sync.RWMutex
+	factories map[string]registration
+}
+
+// NewRegistry creates a Registry with the built-in processors.
+func NewRegistry() *Registry {
+	r := &Registry{factories: make(map[string]registration)}
+	for name, b := range builtIns {
+		r.factories[name] = b
+	}
+	return r
+}
+
+// Register adds a named processor factory to the registry.
+// It returns an error if a factory is already registered with the same name.
+func (r *Registry) Register(name string, kind Kind, factory Factory) error {
+	if name == "" {
+		return fmt.Errorf("span processor name must not be empty")
+	}
+	if factory == nil {
+		return fmt.Errorf("span processor %s has no factory", name)
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if _, ok := r.factories[name]; ok {
+		return fmt.Errorf("span processor %s is already registered", name)
+	}
+	r.factories[name] = registration{kind: kind, factory: factory}
+	return nil
+}
+
+// Names returns the sorted names of the registered processors.
+func (r *Registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := make([]string, 0, len(r.factories))
+	for name := range r.factories {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *Registry) get(name string) (registration, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	reg, ok := r.factories[name]
+	return reg, ok
+}
+
+// DefaultRegistry is the registry used by the collector. Custom collector binaries
+// register their own processors in it before the collector is started.
+var DefaultRegistry = NewRegistry()
+
+// Register adds a named processor factory to the DefaultRegistry.
+func Register(name string, kind Kind, factory Factory) error {
+	return DefaultRegistry.Register(name, kind, factory)
+}
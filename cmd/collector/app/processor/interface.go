@@ -24,10 +24,18 @@ import (
 // ErrBusy signalizes that processor cannot process incoming data
 var ErrBusy = errors.New("server busy")
 
+// ErrQuotaExceeded signalizes that some spans were rejected because their service exceeded its quota
+var ErrQuotaExceeded = errors.New("span quota exceeded")
+
+// ErrMemoryLimitExceeded signalizes that the processor rejected the spans because its memory usage is too high
+var ErrMemoryLimitExceeded = errors.New("memory limit exceeded")
+
 // SpansOptions additional options passed to processor along with the spans.
 type SpansOptions struct {
 	SpanFormat       SpanFormat
 	InboundTransport InboundTransport
+	// Tenant is the tenant the spans belong to, empty when multi-tenancy is not enabled
+	Tenant string
 }
 
 // SpanProcessor handles model spans
@@ -0,0 +1,84 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"sync"
+	"time"
+)
+
+// tokenBucket is a rate limiter like utils.RateLimiter that can also return unused tokens
+// and be reconfigured without losing its balance.
+type tokenBucket struct {
+	lock           sync.Mutex
+	spansPerSecond float64
+	burst          float64
+	balance        float64
+	lastTick       time.Time
+	timeNow        func() time.Time
+}
+
+func newTokenBucket(q *quota) *tokenBucket {
+	return &tokenBucket{
+		spansPerSecond: q.SpansPerSecond,
+		burst:          q.Burst,
+		balance:        q.Burst,
+		lastTick:       time.Now(),
+		timeNow:        time.Now,
+	}
+}
+
+// take consumes n tokens and returns true if the bucket holds at least n tokens.
+func (b *tokenBucket) take(n float64) bool {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill()
+	if b.balance < n {
+		return false
+	}
+	b.balance -= n
+	return true
+}
+
+// refund returns n tokens taken for spans that were rejected because of another bucket.
+func (b *tokenBucket) refund(n float64) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.balance += n
+	if b.balance > b.burst {
+		b.balance = b.burst
+	}
+}
+
+// update changes the rate and burst of the bucket, keeping the tokens it holds up to the new burst.
+func (b *tokenBucket) update(q *quota) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.refill()
+	b.spansPerSecond = q.SpansPerSecond
+	b.burst = q.Burst
+	if b.balance > b.burst {
+		b.balance = b.burst
+	}
+}
+
+func (b *tokenBucket) refill() {
+	now := b.timeNow()
+	b.balance += now.Sub(b.lastTick).Seconds() * b.spansPerSecond
+	b.lastTick = now
+	if b.balance > b.burst {
+		b.balance = b.burst
+	}
+}
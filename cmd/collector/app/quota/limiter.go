@@ -0,0 +1,215 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"io"
+	"sync"
+	"sync/atomic"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+const (
+	// maxServices limits the number of token buckets created from the default quota
+	maxServices = 4000
+
+	// otherServices is the key of the bucket shared by services above maxServices
+	otherServices = "other-services"
+)
+
+type limiterMetrics struct {
+	// ReloadSuccess is the number of successful reloads of the quotas file
+	ReloadSuccess metrics.Counter `metric:"reloads" tags:"result=ok"`
+	// ReloadFailure is the number of failed reloads of the quotas file
+	ReloadFailure metrics.Counter `metric:"reloads" tags:"result=err"`
+}
+
+// Limiter enforces per-service and per-tenant span rate limits with token buckets.
+// The quotas file is watched and the buckets are reconfigured whenever it changes.
+type Limiter struct {
+	path    string
+	logger  *zap.Logger
+	metrics limiterMetrics
+	buckets atomic.Value // *buckets
+	watcher io.Closer
+}
+
+type buckets struct {
+	quotas   *quotas
+	lock     sync.Mutex
+	byKey    map[string]*tokenBucket
+	byTenant map[string]*tokenBucket
+}
+
+// NewLimiter creates a Limiter from the quotas file at the given path and starts watching the file for changes.
+func NewLimiter(path string, logger *zap.Logger, metricsFactory metrics.Factory) (*Limiter, error) {
+	q, err := loadQuotas(path)
+	if err != nil {
+		return nil, err
+	}
+	l := &Limiter{
+		path:   path,
+		logger: logger,
+	}
+	metrics.MustInit(&l.metrics, metricsFactory.Namespace(metrics.NSOptions{Name: "quotas"}), nil)
+	l.buckets.Store(newBuckets(q, nil))
+	watcher, err := fswatcher.WatchFile(path, l.reload, logger)
+	if err != nil {
+		return nil, err
+	}
+	l.watcher = watcher
+	logger.Info("Loaded span quotas", zap.String("file", path), zap.Int("services", len(q.ServiceQuotas)), zap.Int("tenants", len(q.TenantQuotas)))
+	return l, nil
+}
+
+// Close stops watching the quotas file.
+func (l *Limiter) Close() error {
+	return l.watcher.Close()
+}
+
+func (l *Limiter) reload() {
+	q, err := loadQuotas(l.path)
+	if err != nil {
+		l.metrics.ReloadFailure.Inc(1)
+		l.logger.Error("Failed to reload span quotas, using the last known version", zap.String("file", l.path), zap.Error(err))
+		return
+	}
+	l.buckets.Store(newBuckets(q, l.buckets.Load().(*buckets)))
+	l.metrics.ReloadSuccess.Inc(1)
+	l.logger.Info("Reloaded span quotas", zap.String("file", l.path), zap.Int("services", len(q.ServiceQuotas)), zap.Int("tenants", len(q.TenantQuotas)))
+}
+
+// Allow consumes one token per span from the buckets of the spans' services and of the tenant,
+// and returns false if any of the buckets does not hold enough tokens. The batch is accepted or
+// rejected as a whole: when it is rejected, the tokens taken from the other buckets are returned.
+// Spans with the debug flag are always allowed and do not consume tokens.
+func (l *Limiter) Allow(spans []*model.Span, tenant string) bool {
+	return l.buckets.Load().(*buckets).allow(spans, tenant)
+}
+
+// newBuckets creates the buckets of the quotas. The buckets of the previous quotas are reused,
+// keeping their tokens, so that reloading the quotas file does not refill every bucket.
+func newBuckets(q *quotas, previous *buckets) *buckets {
+	b := &buckets{
+		quotas:   q,
+		byKey:    make(map[string]*tokenBucket),
+		byTenant: make(map[string]*tokenBucket),
+	}
+	var previousByKey, previousByTenant map[string]*tokenBucket
+	if previous != nil {
+		previous.lock.Lock()
+		defer previous.lock.Unlock()
+		previousByKey, previousByTenant = previous.byKey, previous.byTenant
+	}
+	for _, sq := range q.ServiceQuotas {
+		b.byKey[sq.Service] = reuseBucket(previousByKey[sq.Service], &sq.quota)
+	}
+	for _, tq := range q.TenantQuotas {
+		b.byTenant[tq.Tenant] = reuseBucket(previousByTenant[tq.Tenant], &tq.quota)
+	}
+	if q.DefaultQuota != nil {
+		for key, bucket := range previousByKey {
+			if _, ok := b.byKey[key]; !ok {
+				b.byKey[key] = reuseBucket(bucket, q.DefaultQuota)
+			}
+		}
+	}
+	return b
+}
+
+func reuseBucket(bucket *tokenBucket, q *quota) *tokenBucket {
+	if bucket == nil {
+		return newTokenBucket(q)
+	}
+	bucket.update(q)
+	return bucket
+}
+
+type takenTokens struct {
+	bucket *tokenBucket
+	tokens float64
+}
+
+func (b *buckets) allow(spans []*model.Span, tenant string) bool {
+	total := 0.0
+	byService := make(map[string]float64)
+	for _, span := range spans {
+		if span.Flags.IsDebug() {
+			continue
+		}
+		serviceName := ""
+		if span.Process != nil {
+			serviceName = span.Process.ServiceName
+		}
+		byService[serviceName]++
+		total++
+	}
+	if total == 0 {
+		return true
+	}
+	var taken []takenTokens
+	if bucket := b.tenantBucket(tenant); bucket != nil {
+		if !bucket.take(total) {
+			return false
+		}
+		taken = append(taken, takenTokens{bucket: bucket, tokens: total})
+	}
+	for serviceName, tokens := range byService {
+		bucket := b.serviceBucket(serviceName)
+		if bucket == nil {
+			continue
+		}
+		if !bucket.take(tokens) {
+			for _, t := range taken {
+				t.bucket.refund(t.tokens)
+			}
+			return false
+		}
+		taken = append(taken, takenTokens{bucket: bucket, tokens: tokens})
+	}
+	return true
+}
+
+func (b *buckets) tenantBucket(tenant string) *tokenBucket {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.byTenant[tenant]
+}
+
+func (b *buckets) serviceBucket(serviceName string) *tokenBucket {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if bucket, ok := b.byKey[serviceName]; ok {
+		return bucket
+	}
+	if b.quotas.DefaultQuota == nil {
+		return nil
+	}
+	key := serviceName
+	if len(b.byKey) >= maxServices+len(b.quotas.ServiceQuotas) {
+		key = otherServices
+	}
+	bucket, ok := b.byKey[key]
+	if !ok {
+		bucket = newTokenBucket(b.quotas.DefaultQuota)
+		b.byKey[key] = bucket
+	}
+	return bucket
+}
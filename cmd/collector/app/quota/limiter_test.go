@@ -0,0 +1,196 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func writeQuotas(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "quota")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "quotas.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func newTestLimiter(t *testing.T, content string) (*Limiter, *metricstest.Factory, string) {
+	path := writeQuotas(t, content)
+	mf := metricstest.NewFactory(time.Hour)
+	l, err := NewLimiter(path, zap.NewNop(), mf)
+	require.NoError(t, err)
+	t.Cleanup(func() { l.Close() })
+	return l, mf, path
+}
+
+func span(service string, debug bool) *model.Span {
+	s := &model.Span{Process: model.NewProcess(service, nil)}
+	if debug {
+		s.Flags.SetDebug()
+	}
+	return s
+}
+
+func allowed(l *Limiter, s *model.Span, n int) int {
+	count := 0
+	for i := 0; i < n; i++ {
+		if l.Allow([]*model.Span{s}, "") {
+			count++
+		}
+	}
+	return count
+}
+
+func TestLimiterAllow(t *testing.T) {
+	l, _, _ := newTestLimiter(t, `{
+		"default_quota": {"spans_per_second": 0.001, "burst": 5},
+		"service_quotas": [{"service": "noisy", "spans_per_second": 0.001, "burst": 2}]
+	}`)
+
+	assert.Equal(t, 2, allowed(l, span("noisy", false), 10))
+	assert.Equal(t, 5, allowed(l, span("other", false), 10))
+	// buckets are per service
+	assert.Equal(t, 5, allowed(l, span("another", false), 10))
+	// debug spans bypass the quota
+	assert.Equal(t, 10, allowed(l, span("noisy", true), 10))
+	// spans without process use the default quota
+	assert.Equal(t, 5, allowed(l, &model.Span{}, 10))
+}
+
+func TestLimiterWithoutDefaultQuota(t *testing.T) {
+	l, _, _ := newTestLimiter(t, `{"service_quotas": [{"service": "noisy", "spans_per_second": 0, "burst": 0}]}`)
+
+	assert.Equal(t, 0, allowed(l, span("noisy", false), 10))
+	assert.Equal(t, 10, allowed(l, span("other", false), 10))
+}
+
+func TestLimiterMaxServices(t *testing.T) {
+	l, _, _ := newTestLimiter(t, `{"default_quota": {"spans_per_second": 0.001, "burst": 1}}`)
+	for i := 0; i < maxServices; i++ {
+		require.Equal(t, 1, allowed(l, span(fmt.Sprintf("svc-%d", i), false), 1))
+	}
+	// services above the limit share a single bucket
+	assert.Equal(t, 1, allowed(l, span("one-too-many", false), 1))
+	assert.Equal(t, 0, allowed(l, span("two-too-many", false), 1))
+}
+
+func TestLimiterReload(t *testing.T) {
+	l, mf, path := newTestLimiter(t, `{"service_quotas": [{"service": "noisy", "spans_per_second": 0.001, "burst": 1}]}`)
+	assert.Equal(t, 1, allowed(l, span("noisy", false), 10))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"service_quotas": [{"service": ""}]}`), 0600))
+	assert.Eventually(t, func() bool {
+		c, _ := mf.Snapshot()
+		return c["quotas.reloads|result=err"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0, allowed(l, span("noisy", false), 10))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"default_quota": {"spans_per_second": 0.001, "burst": 3},
+		"service_quotas": [{"service": "noisy", "spans_per_second": 0.001, "burst": 3}]
+	}`), 0600))
+	assert.Eventually(t, func() bool {
+		c, _ := mf.Snapshot()
+		return c["quotas.reloads|result=ok"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// the bucket keeps its tokens across reloads instead of being refilled
+	assert.Equal(t, 0, allowed(l, span("noisy", false), 10))
+	assert.Equal(t, 3, allowed(l, span("other", false), 10))
+}
+
+func TestLimiterBatch(t *testing.T) {
+	l, _, _ := newTestLimiter(t, `{
+		"default_quota": {"spans_per_second": 0.001, "burst": 3},
+		"service_quotas": [{"service": "noisy", "spans_per_second": 0.001, "burst": 1}]
+	}`)
+
+	// the batch is rejected as a whole and the tokens taken for the quiet service are returned
+	assert.False(t, l.Allow([]*model.Span{span("quiet", false), span("noisy", false), span("noisy", false)}, ""))
+	assert.True(t, l.Allow([]*model.Span{span("quiet", false), span("quiet", false), span("quiet", false), span("noisy", false)}, ""))
+	assert.False(t, l.Allow([]*model.Span{span("quiet", false)}, ""))
+	// debug spans do not count
+	assert.True(t, l.Allow([]*model.Span{span("quiet", true), span("noisy", true)}, ""))
+	assert.True(t, l.Allow(nil, ""))
+}
+
+func TestLimiterTenants(t *testing.T) {
+	l, _, _ := newTestLimiter(t, `{
+		"default_quota": {"spans_per_second": 0.001, "burst": 3},
+		"tenant_quotas": [{"tenant": "acme", "spans_per_second": 0.001, "burst": 4}]
+	}`)
+
+	// the tenant quota is shared by all services of the tenant
+	assert.True(t, l.Allow([]*model.Span{span("a", false), span("a", false), span("b", false)}, "acme"))
+	assert.False(t, l.Allow([]*model.Span{span("b", false), span("c", false)}, "acme"))
+	// the service buckets were refunded
+	assert.True(t, l.Allow([]*model.Span{span("c", false)}, "acme"))
+	assert.False(t, l.Allow([]*model.Span{span("d", false)}, "acme"))
+	// other tenants are only limited by the service quotas
+	assert.True(t, l.Allow([]*model.Span{span("d", false), span("d", false)}, "other"))
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	b := newTokenBucket(&quota{SpansPerSecond: 1, Burst: 2})
+	b.timeNow = func() time.Time { return now }
+	b.lastTick = now
+	assert.True(t, b.take(2))
+	assert.False(t, b.take(1))
+	now = now.Add(time.Second)
+	assert.True(t, b.take(1))
+	b.refund(5)
+	assert.Equal(t, 2.0, b.balance)
+	b.update(&quota{SpansPerSecond: 1, Burst: 1})
+	assert.Equal(t, 1.0, b.balance)
+}
+
+func TestLoadQuotasErrors(t *testing.T) {
+	tests := []struct {
+		content string
+		errMsg  string
+	}{
+		{content: `{`, errMsg: "failed to unmarshal quotas: unexpected end of JSON input"},
+		{content: `{"default_quota": {"spans_per_second": -1}}`, errMsg: "invalid default quota: spans_per_second must not be negative"},
+		{content: `{"service_quotas": [{"spans_per_second": 1}]}`, errMsg: "service quota without service name"},
+		{content: `{"service_quotas": [{"service": "a", "spans_per_second": 1, "burst": 0.5}]}`, errMsg: "invalid quota for service a: burst must be at least 1"},
+		{content: `{"tenant_quotas": [{"spans_per_second": 1}]}`, errMsg: "tenant quota without tenant name"},
+		{content: `{"tenant_quotas": [{"tenant": "a", "spans_per_second": -1}]}`, errMsg: "invalid quota for tenant a: spans_per_second must not be negative"},
+	}
+	for _, test := range tests {
+		_, err := loadQuotas(writeQuotas(t, test.content))
+		assert.EqualError(t, err, test.errMsg)
+	}
+	_, err := NewLimiter("/does/not/exist.json", zap.NewNop(), metricstest.NewFactory(time.Hour))
+	assert.Error(t, err)
+}
+
+func TestLoadQuotasDefaultBurst(t *testing.T) {
+	q, err := loadQuotas(writeQuotas(t, `{"default_quota": {"spans_per_second": 100}}`))
+	require.NoError(t, err)
+	assert.Equal(t, 100.0, q.DefaultQuota.Burst)
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package quota
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// quota defines the token bucket of a service: the bucket is refilled with SpansPerSecond tokens
+// every second and holds at most Burst tokens. Each span consumes one token.
+type quota struct {
+	SpansPerSecond float64 `json:"spans_per_second"`
+	Burst          float64 `json:"burst"`
+}
+
+// serviceQuota defines a service specific quota.
+type serviceQuota struct {
+	Service string `json:"service"`
+	quota
+}
+
+// tenantQuota defines the quota shared by all services of a tenant, enforced in addition to the service quotas.
+type tenantQuota struct {
+	Tenant string `json:"tenant"`
+	quota
+}
+
+// quotas holds a default quota, applied to every service without a specific one, service specific quotas
+// and tenant quotas.
+type quotas struct {
+	DefaultQuota  *quota          `json:"default_quota"`
+	ServiceQuotas []*serviceQuota `json:"service_quotas"`
+	TenantQuotas  []*tenantQuota  `json:"tenant_quotas"`
+}
+
+func loadQuotas(path string) (*quotas, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read quotas file: %w", err)
+	}
+	var q quotas
+	if err := json.Unmarshal(bytes, &q); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal quotas: %w", err)
+	}
+	if q.DefaultQuota != nil {
+		if err := q.DefaultQuota.validate(); err != nil {
+			return nil, fmt.Errorf("invalid default quota: %w", err)
+		}
+	}
+	for _, sq := range q.ServiceQuotas {
+		if sq.Service == "" {
+			return nil, fmt.Errorf("service quota without service name")
+		}
+		if err := sq.validate(); err != nil {
+			return nil, fmt.Errorf("invalid quota for service %s: %w", sq.Service, err)
+		}
+	}
+	for _, tq := range q.TenantQuotas {
+		if tq.Tenant == "" {
+			return nil, fmt.Errorf("tenant quota without tenant name")
+		}
+		if err := tq.validate(); err != nil {
+			return nil, fmt.Errorf("invalid quota for tenant %s: %w", tq.Tenant, err)
+		}
+	}
+	return &q, nil
+}
+
+func (q *quota) validate() error {
+	if q.SpansPerSecond < 0 {
+		return fmt.Errorf("spans_per_second must not be negative")
+	}
+	if q.Burst == 0 {
+		// allow one second worth of spans by default
+		q.Burst = q.SpansPerSecond
+	}
+	if q.Burst < 1 && q.SpansPerSecond > 0 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+	return nil
+}
@@ -0,0 +1,204 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sync"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/normalizer"
+)
+
+const (
+	// DefaultRedactionMask is the string that replaces sensitive values by default
+	DefaultRedactionMask = "[REDACTED]"
+
+	// maxRedactionServices limits the cardinality of the per-service redaction counters
+	maxRedactionServices = 4000
+	otherServices        = "other-services"
+)
+
+// RedactionDetector finds sensitive values in strings.
+type RedactionDetector struct {
+	// Name identifies the detector in metrics
+	Name string
+	// Pattern matches candidate values
+	Pattern *regexp.Regexp
+	// Validate, if not nil, filters out false positives among the matches of Pattern
+	Validate func(match string) bool
+}
+
+// BuiltInRedactionDetectors are the detectors that can be enabled by name.
+var BuiltInRedactionDetectors = map[string]RedactionDetector{
+	"email": {
+		Name:    "email",
+		Pattern: regexp.MustCompile(`[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}`),
+	},
+	"credit-card": {
+		Name:     "credit-card",
+		Pattern:  regexp.MustCompile(`\b(?:\d[ \-]?){12,18}\d\b`),
+		Validate: luhnValid,
+	},
+	"bearer-token": {
+		Name:    "bearer-token",
+		Pattern: regexp.MustCompile(`(?i)\bbearer\s+[a-z0-9\-._~+/]+=*`),
+	},
+}
+
+type redactionDetectorConfig struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+type redactionDetectorsConfig struct {
+	Detectors []redactionDetectorConfig `json:"detectors"`
+}
+
+// LoadRedactionDetectors reads custom detectors from a JSON file of the form
+// {"detectors": [{"name": "ssn", "pattern": "\\d{3}-\\d{2}-\\d{4}"}]}.
+func LoadRedactionDetectors(path string) ([]RedactionDetector, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read redaction detectors file: %w", err)
+	}
+	var config redactionDetectorsConfig
+	if err := json.Unmarshal(bytes, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal redaction detectors: %w", err)
+	}
+	detectors := make([]RedactionDetector, 0, len(config.Detectors))
+	for _, d := range config.Detectors {
+		if d.Name == "" {
+			return nil, fmt.Errorf("redaction detector with pattern %q has no name", d.Pattern)
+		}
+		pattern, err := regexp.Compile(d.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid pattern for redaction detector %q: %w", d.Name, err)
+		}
+		detectors = append(detectors, RedactionDetector{Name: d.Name, Pattern: pattern})
+	}
+	return detectors, nil
+}
+
+// redactionSanitizer masks values matched by detectors in span tags and log fields
+type redactionSanitizer struct {
+	detectors []RedactionDetector
+	mask      string
+	factory   metrics.Factory
+
+	lock     sync.Mutex
+	services map[string]struct{}
+	counters map[string]metrics.Counter // by service and detector
+}
+
+// NewRedactionSanitizer creates a sanitizer that replaces the values found by the detectors with the mask.
+// The number of redactions is counted per service and detector.
+func NewRedactionSanitizer(detectors []RedactionDetector, mask string, metricsFactory metrics.Factory) SanitizeSpan {
+	s := &redactionSanitizer{
+		detectors: detectors,
+		mask:      mask,
+		factory:   metricsFactory.Namespace(metrics.NSOptions{Name: "redaction"}),
+		services:  make(map[string]struct{}),
+		counters:  make(map[string]metrics.Counter),
+	}
+	return s.Sanitize
+}
+
+// Sanitize masks sensitive values in string tags and log fields of the span.
+func (s *redactionSanitizer) Sanitize(span *model.Span) *model.Span {
+	serviceName := ""
+	if span.Process != nil {
+		serviceName = span.Process.ServiceName
+	}
+	s.redactKV(serviceName, span.Tags)
+	for _, log := range span.Logs {
+		s.redactKV(serviceName, log.Fields)
+	}
+	return span
+}
+
+func (s *redactionSanitizer) redactKV(serviceName string, keyValues model.KeyValues) {
+	for i := range keyValues {
+		if keyValues[i].VType != model.StringType {
+			continue
+		}
+		for _, d := range s.detectors {
+			count := 0
+			keyValues[i].VStr = d.Pattern.ReplaceAllStringFunc(keyValues[i].VStr, func(match string) string {
+				if d.Validate != nil && !d.Validate(match) {
+					return match
+				}
+				count++
+				return s.mask
+			})
+			if count > 0 {
+				s.counter(serviceName, d.Name).Inc(int64(count))
+			}
+		}
+	}
+}
+
+func (s *redactionSanitizer) counter(serviceName, detector string) metrics.Counter {
+	serviceName = normalizer.ServiceName(serviceName)
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.services[serviceName]; !ok {
+		// the last slot is reserved for the fallback so the map never exceeds the limit
+		if len(s.services) >= maxRedactionServices-1 {
+			serviceName = otherServices
+		}
+		s.services[serviceName] = struct{}{}
+	}
+	key := serviceName + "$_$" + detector
+	if c, ok := s.counters[key]; ok {
+		return c
+	}
+	c := s.factory.Counter(metrics.Options{
+		Name: "redactions",
+		Tags: map[string]string{"svc": serviceName, "detector": detector},
+	})
+	s.counters[key] = c
+	return c
+}
+
+// luhnValid checks the Luhn checksum of the digits in the string, ignoring separators.
+func luhnValid(number string) bool {
+	sum := 0
+	digits := 0
+	double := false
+	for i := len(number) - 1; i >= 0; i-- {
+		c := number[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		digits++
+		double = !double
+	}
+	return digits >= 13 && sum%10 == 0
+}
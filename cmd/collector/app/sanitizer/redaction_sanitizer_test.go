@@ -0,0 +1,126 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func builtInDetectors() []RedactionDetector {
+	return []RedactionDetector{
+		BuiltInRedactionDetectors["email"],
+		BuiltInRedactionDetectors["credit-card"],
+		BuiltInRedactionDetectors["bearer-token"],
+	}
+}
+
+func TestRedactionSanitizer(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	s := NewRedactionSanitizer(builtInDetectors(), DefaultRedactionMask, mf)
+
+	span := &model.Span{
+		Process: model.NewProcess("billing", nil),
+		Tags: model.KeyValues{
+			model.String("db.statement", "SELECT * FROM users WHERE email = 'john@example.com' OR email = 'jane@example.org'"),
+			model.String("card", "4111 1111 1111 1111"),
+			model.String("order.id", "1234567890123456"),
+			model.Int64("amount", 42),
+		},
+		Logs: []model.Log{
+			{Fields: model.KeyValues{model.String("http.header", "Authorization: Bearer abc.def-ghi==")}},
+		},
+	}
+	span = s(span)
+
+	assert.Equal(t, model.KeyValues{
+		model.String("db.statement", "SELECT * FROM users WHERE email = '[REDACTED]' OR email = '[REDACTED]'"),
+		model.String("card", "[REDACTED]"),
+		// fails the Luhn check, so it is not a card number
+		model.String("order.id", "1234567890123456"),
+		model.Int64("amount", 42),
+	}, model.KeyValues(span.Tags))
+	assert.Equal(t, model.KeyValues{model.String("http.header", "Authorization: [REDACTED]")}, model.KeyValues(span.Logs[0].Fields))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "redaction.redactions|detector=email|svc=billing", Value: 2},
+		metricstest.ExpectedMetric{Name: "redaction.redactions|detector=credit-card|svc=billing", Value: 1},
+		metricstest.ExpectedMetric{Name: "redaction.redactions|detector=bearer-token|svc=billing", Value: 1},
+	)
+}
+
+func TestRedactionSanitizerServiceLimit(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	s := &redactionSanitizer{
+		factory:  mf,
+		services: make(map[string]struct{}),
+		counters: make(map[string]metrics.Counter),
+	}
+	for i := 0; i < maxRedactionServices-1; i++ {
+		s.counter(fmt.Sprintf("svc-%d", i), "email")
+	}
+	s.counter("one-too-many", "email").Inc(1)
+	s.counter("two-too-many", "email").Inc(1)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "redactions|detector=email|svc=other-services", Value: 2})
+	assert.Len(t, s.services, maxRedactionServices)
+}
+
+func TestLoadRedactionDetectors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "redaction")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	write := func(content string) string {
+		path := filepath.Join(dir, "detectors.json")
+		require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		return path
+	}
+
+	detectors, err := LoadRedactionDetectors(write(`{"detectors": [{"name": "ssn", "pattern": "\\d{3}-\\d{2}-\\d{4}"}]}`))
+	require.NoError(t, err)
+	require.Len(t, detectors, 1)
+	assert.Equal(t, "ssn", detectors[0].Name)
+	assert.True(t, detectors[0].Pattern.MatchString("123-45-6789"))
+
+	_, err = LoadRedactionDetectors(write(`{"detectors": [{"pattern": "x"}]}`))
+	assert.EqualError(t, err, `redaction detector with pattern "x" has no name`)
+
+	_, err = LoadRedactionDetectors(write(`{"detectors": [{"name": "bad", "pattern": "("}]}`))
+	assert.Contains(t, err.Error(), `invalid pattern for redaction detector "bad"`)
+
+	_, err = LoadRedactionDetectors(write(`{`))
+	assert.Contains(t, err.Error(), "failed to unmarshal redaction detectors")
+
+	_, err = LoadRedactionDetectors(filepath.Join(dir, "missing.json"))
+	assert.Contains(t, err.Error(), "failed to read redaction detectors file")
+}
+
+func TestLuhnValid(t *testing.T) {
+	assert.True(t, luhnValid("4111111111111111"))
+	assert.True(t, luhnValid("5500-0000-0000-0004"))
+	assert.False(t, luhnValid("4111111111111112"))
+	assert.False(t, luhnValid("0"))
+}
@@ -0,0 +1,183 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+)
+
+// Supported rule actions.
+const (
+	ActionInsert   = "insert"
+	ActionRename   = "rename"
+	ActionHash     = "hash"
+	ActionTruncate = "truncate"
+	ActionDelete   = "delete"
+	ActionDrop     = "drop"
+)
+
+// Supported action scopes.
+const (
+	ScopeSpan    = "span"
+	ScopeProcess = "process"
+)
+
+// Config is the JSON representation of the rules file.
+type Config struct {
+	Rules []RuleConfig `json:"rules"`
+}
+
+// RuleConfig describes a single rule: the actions are applied in order to every span the matcher accepts.
+type RuleConfig struct {
+	Name    string         `json:"name"`
+	Match   MatchConfig    `json:"match"`
+	Actions []ActionConfig `json:"actions"`
+}
+
+// MatchConfig selects the spans a rule applies to. All values are regular expressions
+// that must match the whole string; empty fields match everything.
+type MatchConfig struct {
+	Service     string            `json:"service"`
+	Operation   string            `json:"operation"`
+	Tags        map[string]string `json:"tags"`
+	ProcessTags map[string]string `json:"process_tags"`
+}
+
+// ActionConfig describes a single mutation of the span.
+type ActionConfig struct {
+	// Action is one of insert, rename, hash, truncate, delete or drop.
+	Action string `json:"action"`
+	// Scope is either span (default) or process, i.e. which set of tags the action applies to.
+	Scope string `json:"scope"`
+	// Key is the tag the action applies to.
+	Key string `json:"key"`
+	// Value is the value of the inserted tag.
+	Value string `json:"value"`
+	// NewKey is the new name of a renamed tag.
+	NewKey string `json:"new_key"`
+	// MaxLength is the maximum length in bytes of a truncated tag value.
+	MaxLength int `json:"max_length"`
+}
+
+type rule struct {
+	name        string
+	service     *regexp.Regexp
+	operation   *regexp.Regexp
+	tags        map[string]*regexp.Regexp
+	processTags map[string]*regexp.Regexp
+	actions     []ActionConfig
+}
+
+func loadRules(path string) ([]*rule, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read attribute rules file: %w", err)
+	}
+	var config Config
+	if err := json.Unmarshal(bytes, &config); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal attribute rules: %w", err)
+	}
+	return compileRules(&config)
+}
+
+func compileRules(config *Config) ([]*rule, error) {
+	rules := make([]*rule, 0, len(config.Rules))
+	for i, rc := range config.Rules {
+		r, err := compileRule(rc)
+		if err != nil {
+			return nil, fmt.Errorf("invalid attribute rule #%d %q: %w", i, rc.Name, err)
+		}
+		rules = append(rules, r)
+	}
+	return rules, nil
+}
+
+func compileRule(rc RuleConfig) (*rule, error) {
+	var err error
+	r := &rule{name: rc.Name, actions: rc.Actions}
+	if r.service, err = compileMatcher(rc.Match.Service); err != nil {
+		return nil, err
+	}
+	if r.operation, err = compileMatcher(rc.Match.Operation); err != nil {
+		return nil, err
+	}
+	if r.tags, err = compileTagMatchers(rc.Match.Tags); err != nil {
+		return nil, err
+	}
+	if r.processTags, err = compileTagMatchers(rc.Match.ProcessTags); err != nil {
+		return nil, err
+	}
+	for i := range r.actions {
+		if err := validateAction(&r.actions[i]); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+func compileMatcher(expr string) (*regexp.Regexp, error) {
+	if expr == "" {
+		return nil, nil
+	}
+	return regexp.Compile("^(?:" + expr + ")$")
+}
+
+func compileTagMatchers(tags map[string]string) (map[string]*regexp.Regexp, error) {
+	if len(tags) == 0 {
+		return nil, nil
+	}
+	matchers := make(map[string]*regexp.Regexp, len(tags))
+	for k, v := range tags {
+		re, err := compileMatcher(v)
+		if err != nil {
+			return nil, err
+		}
+		matchers[k] = re
+	}
+	return matchers, nil
+}
+
+func validateAction(a *ActionConfig) error {
+	switch a.Scope {
+	case "":
+		a.Scope = ScopeSpan
+	case ScopeSpan, ScopeProcess:
+	default:
+		return fmt.Errorf("unknown scope %q", a.Scope)
+	}
+	switch a.Action {
+	case ActionDrop:
+		return nil
+	case ActionInsert, ActionHash, ActionDelete:
+	case ActionRename:
+		if a.NewKey == "" {
+			return fmt.Errorf("action %s requires new_key", a.Action)
+		}
+	case ActionTruncate:
+		if a.MaxLength <= 0 {
+			return fmt.Errorf("action %s requires a positive max_length", a.Action)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", a.Action)
+	}
+	if a.Key == "" {
+		return fmt.Errorf("action %s requires key", a.Action)
+	}
+	return nil
+}
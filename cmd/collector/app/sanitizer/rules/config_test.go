@@ -0,0 +1,93 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCompileRules(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   RuleConfig
+		errMsg string
+	}{
+		{
+			name: "valid",
+			rule: RuleConfig{
+				Match:   MatchConfig{Service: "a|b", Tags: map[string]string{"k": ".*"}},
+				Actions: []ActionConfig{{Action: ActionDelete, Key: "k"}},
+			},
+		},
+		{
+			name:   "bad service regex",
+			rule:   RuleConfig{Match: MatchConfig{Service: "("}},
+			errMsg: "invalid attribute rule #0 \"bad service regex\": error parsing regexp: missing closing ): `^(?:()$`",
+		},
+		{
+			name:   "bad tag regex",
+			rule:   RuleConfig{Match: MatchConfig{ProcessTags: map[string]string{"k": "["}}},
+			errMsg: "invalid attribute rule #0 \"bad tag regex\": error parsing regexp: missing closing ]: `[)$`",
+		},
+		{
+			name:   "unknown action",
+			rule:   RuleConfig{Actions: []ActionConfig{{Action: "upsert", Key: "k"}}},
+			errMsg: "invalid attribute rule #0 \"unknown action\": unknown action \"upsert\"",
+		},
+		{
+			name:   "unknown scope",
+			rule:   RuleConfig{Actions: []ActionConfig{{Action: ActionDelete, Key: "k", Scope: "trace"}}},
+			errMsg: "invalid attribute rule #0 \"unknown scope\": unknown scope \"trace\"",
+		},
+		{
+			name:   "missing key",
+			rule:   RuleConfig{Actions: []ActionConfig{{Action: ActionHash}}},
+			errMsg: "invalid attribute rule #0 \"missing key\": action hash requires key",
+		},
+		{
+			name:   "missing new key",
+			rule:   RuleConfig{Actions: []ActionConfig{{Action: ActionRename, Key: "k"}}},
+			errMsg: "invalid attribute rule #0 \"missing new key\": action rename requires new_key",
+		},
+		{
+			name:   "missing max length",
+			rule:   RuleConfig{Actions: []ActionConfig{{Action: ActionTruncate, Key: "k"}}},
+			errMsg: "invalid attribute rule #0 \"missing max length\": action truncate requires a positive max_length",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.rule.Name = test.name
+			rules, err := compileRules(&Config{Rules: []RuleConfig{test.rule}})
+			if test.errMsg != "" {
+				assert.EqualError(t, err, test.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, rules, 1)
+			assert.Equal(t, ScopeSpan, rules[0].actions[0].Scope)
+		})
+	}
+}
+
+func TestLoadRulesErrors(t *testing.T) {
+	_, err := loadRules("/does/not/exist.json")
+	assert.Error(t, err)
+	_, err = loadRules("config.go")
+	assert.Contains(t, err.Error(), "failed to unmarshal attribute rules")
+}
@@ -0,0 +1,212 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"regexp"
+	"sync/atomic"
+	"unicode/utf8"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+type sanitizerMetrics struct {
+	// SpansDropped is the number of spans dropped by a drop action
+	SpansDropped metrics.Counter `metric:"spans.dropped"`
+	// SpansModified is the number of spans mutated by at least one rule
+	SpansModified metrics.Counter `metric:"spans.modified"`
+	// ReloadSuccess is the number of successful reloads of the rules file
+	ReloadSuccess metrics.Counter `metric:"reloads" tags:"result=ok"`
+	// ReloadFailure is the number of failed reloads of the rules file
+	ReloadFailure metrics.Counter `metric:"reloads" tags:"result=err"`
+}
+
+// Sanitizer applies the attribute rules loaded from a file to every span.
+// The file is watched and the rules are replaced whenever it changes.
+type Sanitizer struct {
+	path    string
+	logger  *zap.Logger
+	metrics sanitizerMetrics
+	rules   atomic.Value // []*rule
+	watcher io.Closer
+}
+
+// New creates a Sanitizer from the rules file at the given path and starts watching the file for changes.
+func New(path string, logger *zap.Logger, metricsFactory metrics.Factory) (*Sanitizer, error) {
+	rules, err := loadRules(path)
+	if err != nil {
+		return nil, err
+	}
+	s := &Sanitizer{
+		path:   path,
+		logger: logger,
+	}
+	metrics.MustInit(&s.metrics, metricsFactory.Namespace(metrics.NSOptions{Name: "attribute_rules"}), nil)
+	s.rules.Store(rules)
+	watcher, err := fswatcher.WatchFile(path, s.reload, logger)
+	if err != nil {
+		return nil, err
+	}
+	s.watcher = watcher
+	logger.Info("Loaded attribute rules", zap.String("file", path), zap.Int("rules", len(rules)))
+	return s, nil
+}
+
+// Close stops watching the rules file.
+func (s *Sanitizer) Close() error {
+	return s.watcher.Close()
+}
+
+func (s *Sanitizer) reload() {
+	rules, err := loadRules(s.path)
+	if err != nil {
+		s.metrics.ReloadFailure.Inc(1)
+		s.logger.Error("Failed to reload attribute rules, using the last known version", zap.String("file", s.path), zap.Error(err))
+		return
+	}
+	s.rules.Store(rules)
+	s.metrics.ReloadSuccess.Inc(1)
+	s.logger.Info("Reloaded attribute rules", zap.String("file", s.path), zap.Int("rules", len(rules)))
+}
+
+// Sanitize applies the matching rules to the span. It returns nil if the span must be dropped.
+// The Process is shared by all spans of a batch, so process-scope actions are applied to a private copy.
+func (s *Sanitizer) Sanitize(span *model.Span) *model.Span {
+	modified := false
+	processCopied := false
+	for _, r := range s.rules.Load().([]*rule) {
+		if !r.matches(span) {
+			continue
+		}
+		for _, a := range r.actions {
+			if a.Action == ActionDrop {
+				s.metrics.SpansDropped.Inc(1)
+				return nil
+			}
+			changed := false
+			if a.Scope == ScopeProcess {
+				if span.Process != nil {
+					if !processCopied {
+						span.Process = sanitizer.CopyProcess(span.Process)
+						processCopied = true
+					}
+					span.Process.Tags, changed = applyAction(a, span.Process.Tags)
+				}
+			} else {
+				span.Tags, changed = applyAction(a, span.Tags)
+			}
+			modified = modified || changed
+		}
+	}
+	if modified {
+		s.metrics.SpansModified.Inc(1)
+	}
+	return span
+}
+
+func (r *rule) matches(span *model.Span) bool {
+	if r.service != nil && (span.Process == nil || !r.service.MatchString(span.Process.ServiceName)) {
+		return false
+	}
+	if r.operation != nil && !r.operation.MatchString(span.OperationName) {
+		return false
+	}
+	if !matchTags(r.tags, span.Tags) {
+		return false
+	}
+	if len(r.processTags) > 0 && (span.Process == nil || !matchTags(r.processTags, span.Process.Tags)) {
+		return false
+	}
+	return true
+}
+
+func matchTags(matchers map[string]*regexp.Regexp, tags model.KeyValues) bool {
+	for key, re := range matchers {
+		tag, ok := tags.FindByKey(key)
+		if !ok {
+			return false
+		}
+		if re != nil && !re.MatchString(tag.AsString()) {
+			return false
+		}
+	}
+	return true
+}
+
+// applyAction applies the action to the tags and returns the resulting tags,
+// and whether the action changed them, e.g. a delete of a missing key does not.
+func applyAction(a ActionConfig, tags []model.KeyValue) ([]model.KeyValue, bool) {
+	changed := false
+	switch a.Action {
+	case ActionInsert:
+		if _, ok := model.KeyValues(tags).FindByKey(a.Key); !ok {
+			tags = append(tags, model.String(a.Key, a.Value))
+			changed = true
+		}
+	case ActionDelete:
+		filtered := tags[:0]
+		for _, tag := range tags {
+			if tag.Key != a.Key {
+				filtered = append(filtered, tag)
+			}
+		}
+		changed = len(filtered) != len(tags)
+		tags = filtered
+	default:
+		for i := range tags {
+			if tags[i].Key != a.Key {
+				continue
+			}
+			switch a.Action {
+			case ActionRename:
+				changed = changed || a.NewKey != a.Key
+				tags[i].Key = a.NewKey
+			case ActionHash:
+				sum := sha256.Sum256([]byte(tags[i].AsString()))
+				tags[i] = model.String(tags[i].Key, hex.EncodeToString(sum[:]))
+				changed = true
+			case ActionTruncate:
+				if tags[i].VType == model.StringType && len(tags[i].VStr) > a.MaxLength {
+					tags[i].VStr = truncate(tags[i].VStr, a.MaxLength)
+					changed = true
+				} else if tags[i].VType == model.BinaryType && len(tags[i].VBinary) > a.MaxLength {
+					tags[i].VBinary = tags[i].VBinary[:a.MaxLength]
+					changed = true
+				}
+			}
+		}
+	}
+	return tags, changed
+}
+
+// truncate shortens the string to at most maxLength bytes without splitting a multi-byte character.
+func truncate(value string, maxLength int) string {
+	if len(value) <= maxLength {
+		return value
+	}
+	value = value[:maxLength]
+	for len(value) > 0 && !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
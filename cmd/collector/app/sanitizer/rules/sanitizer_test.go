@@ -0,0 +1,200 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const testRules = `{
+  "rules": [
+    {
+      "name": "frontend",
+      "match": {"service": "front.*", "tags": {"http.method": "GET|POST"}},
+      "actions": [
+        {"action": "insert", "key": "team", "value": "web", "scope": "process"},
+        {"action": "rename", "key": "user", "new_key": "user.id"},
+        {"action": "hash", "key": "email"},
+        {"action": "truncate", "key": "db.statement", "max_length": 5},
+        {"action": "delete", "key": "password"}
+      ]
+    },
+    {
+      "name": "health checks",
+      "match": {"operation": "/health"},
+      "actions": [{"action": "drop"}]
+    }
+  ]
+}`
+
+func writeRules(t *testing.T, dir, content string) string {
+	path := filepath.Join(dir, "rules.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func newTestSanitizer(t *testing.T, content string) (*Sanitizer, *metricstest.Factory, string) {
+	dir, err := ioutil.TempDir("", "rules")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+
+	mf := metricstest.NewFactory(time.Hour)
+	path := writeRules(t, dir, content)
+	s, err := New(path, zap.NewNop(), mf)
+	require.NoError(t, err)
+	t.Cleanup(func() { s.Close() })
+	return s, mf, path
+}
+
+func TestSanitizerActions(t *testing.T) {
+	s, mf, _ := newTestSanitizer(t, testRules)
+
+	span := &model.Span{
+		OperationName: "GET /users",
+		Process:       model.NewProcess("frontend", nil),
+		Tags: model.KeyValues{
+			model.String("http.method", "GET"),
+			model.String("user", "42"),
+			model.String("email", "john@example.com"),
+			model.String("db.statement", "SELECT * FROM users"),
+			model.String("password", "secret"),
+		},
+	}
+	span = s.Sanitize(span)
+	require.NotNil(t, span)
+
+	assert.Equal(t, model.KeyValues{
+		model.String("http.method", "GET"),
+		model.String("user.id", "42"),
+		model.String("email", "855f96e983f1f8e8be944692b6f719fd54329826cb62e98015efee8e2e071dd4"),
+		model.String("db.statement", "SELEC"),
+	}, model.KeyValues(span.Tags))
+	assert.Equal(t, model.KeyValues{model.String("team", "web")}, model.KeyValues(span.Process.Tags))
+
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "attribute_rules.spans.modified", Value: 1})
+}
+
+func TestSanitizerNoMatch(t *testing.T) {
+	s, _, _ := newTestSanitizer(t, testRules)
+
+	span := &model.Span{
+		OperationName: "GET /users",
+		Process:       model.NewProcess("frontend", nil),
+		Tags:          model.KeyValues{model.String("http.method", "DELETE"), model.String("password", "secret")},
+	}
+	span = s.Sanitize(span)
+	assert.Equal(t, model.KeyValues{model.String("http.method", "DELETE"), model.String("password", "secret")}, model.KeyValues(span.Tags))
+	assert.Empty(t, span.Process.Tags)
+}
+
+func TestSanitizerNoChange(t *testing.T) {
+	s, mf, _ := newTestSanitizer(t, testRules)
+
+	// the span matches the rule, but has none of the keys and the inserted process tag already
+	tags := model.KeyValues{model.String("http.method", "GET"), model.String("db.statement", "SELEC")}
+	span := &model.Span{
+		OperationName: "GET /users",
+		Process:       model.NewProcess("frontend", []model.KeyValue{model.String("team", "mobile")}),
+		Tags:          append(model.KeyValues(nil), tags...),
+	}
+	span = s.Sanitize(span)
+	assert.Equal(t, tags, model.KeyValues(span.Tags))
+	assert.Equal(t, model.KeyValues{model.String("team", "mobile")}, model.KeyValues(span.Process.Tags))
+
+	counters, _ := mf.Snapshot()
+	assert.Zero(t, counters["attribute_rules.spans.modified"])
+}
+
+func TestSanitizerSharedProcess(t *testing.T) {
+	s, _, _ := newTestSanitizer(t, `{"rules": [{"name": "hash host", "actions": [
+		{"action": "hash", "key": "hostname", "scope": "process"}
+	]}]}`)
+
+	// spans of a batch share the process and are sanitized concurrently by the queue workers
+	process := model.NewProcess("frontend", []model.KeyValue{model.String("hostname", "host-1")})
+	spans := make([]*model.Span, 10)
+	var wg sync.WaitGroup
+	for i := range spans {
+		spans[i] = &model.Span{OperationName: "op", Process: process}
+		wg.Add(1)
+		go func(span *model.Span) {
+			defer wg.Done()
+			s.Sanitize(span)
+		}(spans[i])
+	}
+	wg.Wait()
+
+	sum := sha256.Sum256([]byte("host-1"))
+	for _, span := range spans {
+		assert.Equal(t, model.KeyValues{model.String("hostname", hex.EncodeToString(sum[:]))}, model.KeyValues(span.Process.Tags))
+	}
+	assert.Equal(t, model.KeyValues{model.String("hostname", "host-1")}, model.KeyValues(process.Tags))
+}
+
+func TestSanitizerDrop(t *testing.T) {
+	s, mf, _ := newTestSanitizer(t, testRules)
+
+	span := &model.Span{OperationName: "/health", Process: model.NewProcess("backend", nil)}
+	assert.Nil(t, s.Sanitize(span))
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "attribute_rules.spans.dropped", Value: 1})
+}
+
+func TestSanitizerReload(t *testing.T) {
+	s, mf, path := newTestSanitizer(t, testRules)
+
+	span := &model.Span{OperationName: "/health", Process: model.NewProcess("backend", nil)}
+	require.Nil(t, s.Sanitize(span))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"rules": [{"match": {"operation": "x"`), 0600))
+	assert.Eventually(t, func() bool {
+		c, _ := mf.Snapshot()
+		return c["attribute_rules.reloads|result=err"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	// invalid content keeps the previous rules
+	assert.Nil(t, s.Sanitize(span))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"rules": []}`), 0600))
+	assert.Eventually(t, func() bool {
+		c, _ := mf.Snapshot()
+		return c["attribute_rules.reloads|result=ok"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, span, s.Sanitize(span))
+}
+
+func TestNewErrors(t *testing.T) {
+	_, err := New("/does/not/exist.json", zap.NewNop(), metricstest.NewFactory(time.Hour))
+	assert.Error(t, err)
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "abc", truncate("abc", 5))
+	assert.Equal(t, "ab", truncate("abc", 2))
+	// "é" is two bytes long and must not be split
+	assert.Equal(t, "a", truncate("aé", 2))
+}
@@ -0,0 +1,36 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestChainedSanitizerStopsOnDroppedSpan(t *testing.T) {
+	called := false
+	s := NewChainedSanitizer(
+		func(span *model.Span) *model.Span { return nil },
+		func(span *model.Span) *model.Span {
+			called = true
+			return span
+		},
+	)
+	assert.Nil(t, s(&model.Span{}))
+	assert.False(t, called)
+}
@@ -0,0 +1,194 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"unicode/utf8"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	// TruncatedValuesTag counts the tag and log field values shortened by the span size sanitizer
+	TruncatedValuesTag = "jaeger.truncated_values_count"
+	// DroppedTagsTag counts the span tags removed by the span size sanitizer
+	DroppedTagsTag = "jaeger.dropped_tags_count"
+	// DroppedLogsTag counts the span logs removed by the span size sanitizer
+	DroppedLogsTag = "jaeger.dropped_logs_count"
+)
+
+// SpanSizeLimits defines the limits enforced by the span size sanitizer. Zero values disable the corresponding limit.
+type SpanSizeLimits struct {
+	// MaxTagValueLength is the maximum length in bytes of string and binary tag and log field values
+	MaxTagValueLength int
+	// MaxTags is the maximum number of span tags
+	MaxTags int
+	// MaxLogs is the maximum number of span logs
+	MaxLogs int
+	// MaxSpanSize is the maximum size in bytes of the serialized span
+	MaxSpanSize int
+}
+
+// Enabled returns true if at least one limit is set.
+func (l SpanSizeLimits) Enabled() bool {
+	return l.MaxTagValueLength > 0 || l.MaxTags > 0 || l.MaxLogs > 0 || l.MaxSpanSize > 0
+}
+
+type spanSizeMetrics struct {
+	// ValuesTruncated is the number of tag and log field values shortened
+	ValuesTruncated metrics.Counter `metric:"values-truncated"`
+	// TagsDropped is the number of span tags removed
+	TagsDropped metrics.Counter `metric:"tags-dropped"`
+	// LogsDropped is the number of span logs removed
+	LogsDropped metrics.Counter `metric:"logs-dropped"`
+	// SpansDropped is the number of spans dropped because they could not be made small enough
+	SpansDropped metrics.Counter `metric:"spans-dropped"`
+}
+
+// spanSizeSanitizer truncates or removes the parts of a span exceeding the limits
+type spanSizeSanitizer struct {
+	limits  SpanSizeLimits
+	metrics spanSizeMetrics
+}
+
+// NewSpanSizeSanitizer creates a sanitizer that enforces the given limits. Values longer than the limit are
+// truncated, extra tags and logs are removed, and if the span is still over the size limit its logs and then
+// its tags are removed from the end. Spans that remain too big are dropped. Every modified span is tagged with
+// the number of truncated values and removed tags and logs.
+func NewSpanSizeSanitizer(limits SpanSizeLimits, metricsFactory metrics.Factory) SanitizeSpan {
+	s := &spanSizeSanitizer{limits: limits}
+	metrics.MustInit(&s.metrics, metricsFactory.Namespace(metrics.NSOptions{Name: "span_size"}), nil)
+	return s.Sanitize
+}
+
+// Sanitize enforces the limits on the span.
+func (s *spanSizeSanitizer) Sanitize(span *model.Span) *model.Span {
+	truncatedValues, droppedTags, droppedLogs := 0, 0, 0
+
+	if s.limits.MaxTagValueLength > 0 {
+		truncatedValues += s.truncateValues(span.Tags)
+		if span.Process != nil && s.needsTruncation(span.Process.Tags) {
+			// the process is shared by all spans of a batch, which are sanitized concurrently
+			span.Process = CopyProcess(span.Process)
+			truncatedValues += s.truncateValues(span.Process.Tags)
+		}
+		for _, log := range span.Logs {
+			truncatedValues += s.truncateValues(log.Fields)
+		}
+	}
+	if s.limits.MaxTags > 0 && len(span.Tags) > s.limits.MaxTags {
+		droppedTags += len(span.Tags) - s.limits.MaxTags
+		span.Tags = span.Tags[:s.limits.MaxTags]
+	}
+	if s.limits.MaxLogs > 0 && len(span.Logs) > s.limits.MaxLogs {
+		droppedLogs += len(span.Logs) - s.limits.MaxLogs
+		span.Logs = span.Logs[:s.limits.MaxLogs]
+	}
+	if s.limits.MaxSpanSize > 0 {
+		// room is reserved for the count tags added below, assuming all of them are added with the largest
+		// possible counts. The size is updated with the size of the removed elements only, without their
+		// encoding overhead, so it stays an upper bound of the actual size
+		maxCount := truncatedValues + droppedTags + droppedLogs + len(span.Tags) + len(span.Logs)
+		limit := s.limits.MaxSpanSize - countTagsSize(int64(maxCount))
+		size := span.Size()
+		for len(span.Logs) > 0 && size > limit {
+			size -= span.Logs[len(span.Logs)-1].Size()
+			span.Logs = span.Logs[:len(span.Logs)-1]
+			droppedLogs++
+		}
+		for len(span.Tags) > 0 && size > limit {
+			size -= span.Tags[len(span.Tags)-1].Size()
+			span.Tags = span.Tags[:len(span.Tags)-1]
+			droppedTags++
+		}
+	}
+
+	s.metrics.ValuesTruncated.Inc(int64(truncatedValues))
+	s.metrics.TagsDropped.Inc(int64(droppedTags))
+	s.metrics.LogsDropped.Inc(int64(droppedLogs))
+
+	if truncatedValues > 0 {
+		span.Tags = append(span.Tags, model.Int64(TruncatedValuesTag, int64(truncatedValues)))
+	}
+	if droppedTags > 0 {
+		span.Tags = append(span.Tags, model.Int64(DroppedTagsTag, int64(droppedTags)))
+	}
+	if droppedLogs > 0 {
+		span.Tags = append(span.Tags, model.Int64(DroppedLogsTag, int64(droppedLogs)))
+	}
+	if s.limits.MaxSpanSize > 0 && span.Size() > s.limits.MaxSpanSize {
+		s.metrics.SpansDropped.Inc(1)
+		return nil
+	}
+	return span
+}
+
+// countTagsSize returns the encoded size of the three count tags with the given count.
+func countTagsSize(count int64) int {
+	withTags := &model.Span{Tags: []model.KeyValue{
+		model.Int64(TruncatedValuesTag, count),
+		model.Int64(DroppedTagsTag, count),
+		model.Int64(DroppedLogsTag, count),
+	}}
+	return withTags.Size() - (&model.Span{}).Size()
+}
+
+// CopyProcess returns a copy of the process whose tags can be changed without affecting
+// the other spans of the batch, which share the process.
+func CopyProcess(process *model.Process) *model.Process {
+	p := *process
+	p.Tags = append([]model.KeyValue(nil), process.Tags...)
+	return &p
+}
+
+func (s *spanSizeSanitizer) needsTruncation(keyValues model.KeyValues) bool {
+	for i := range keyValues {
+		if len(keyValues[i].VStr) > s.limits.MaxTagValueLength || len(keyValues[i].VBinary) > s.limits.MaxTagValueLength {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *spanSizeSanitizer) truncateValues(keyValues model.KeyValues) int {
+	truncated := 0
+	maxLength := s.limits.MaxTagValueLength
+	for i := range keyValues {
+		switch keyValues[i].VType {
+		case model.StringType:
+			if len(keyValues[i].VStr) > maxLength {
+				keyValues[i].VStr = truncateString(keyValues[i].VStr, maxLength)
+				truncated++
+			}
+		case model.BinaryType:
+			if len(keyValues[i].VBinary) > maxLength {
+				keyValues[i].VBinary = keyValues[i].VBinary[:maxLength]
+				truncated++
+			}
+		}
+	}
+	return truncated
+}
+
+// truncateString shortens the string to at most maxLength bytes without splitting a multi-byte character.
+func truncateString(value string, maxLength int) string {
+	value = value[:maxLength]
+	for len(value) > 0 && !utf8.ValidString(value) {
+		value = value[:len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,163 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sanitizer
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func TestSpanSizeLimitsEnabled(t *testing.T) {
+	assert.False(t, SpanSizeLimits{}.Enabled())
+	assert.True(t, SpanSizeLimits{MaxLogs: 1}.Enabled())
+}
+
+func TestSpanSizeSanitizerTruncatesValues(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	s := NewSpanSizeSanitizer(SpanSizeLimits{MaxTagValueLength: 4}, mf)
+
+	span := &model.Span{
+		Process: model.NewProcess("svc", model.KeyValues{model.String("hostname", "localhost")}),
+		Tags: model.KeyValues{
+			model.String("short", "abc"),
+			model.String("long", "abcdef"),
+			model.Binary("bytes", []byte{1, 2, 3, 4, 5}),
+			model.Int64("number", 123456789),
+		},
+		Logs: []model.Log{{Fields: model.KeyValues{model.String("event", "a long message")}}},
+	}
+	span = s(span)
+	require.NotNil(t, span)
+
+	assert.Equal(t, model.KeyValues{
+		model.String("short", "abc"),
+		model.String("long", "abcd"),
+		model.Binary("bytes", []byte{1, 2, 3, 4}),
+		model.Int64("number", 123456789),
+		model.Int64(TruncatedValuesTag, 4),
+	}, model.KeyValues(span.Tags))
+	assert.Equal(t, model.KeyValues{model.String("hostname", "loca")}, model.KeyValues(span.Process.Tags))
+	assert.Equal(t, model.KeyValues{model.String("event", "a lo")}, model.KeyValues(span.Logs[0].Fields))
+
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "span_size.values-truncated", Value: 4})
+}
+
+func TestSpanSizeSanitizerDropsTagsAndLogs(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	s := NewSpanSizeSanitizer(SpanSizeLimits{MaxTags: 1, MaxLogs: 1}, mf)
+
+	span := &model.Span{
+		Tags: model.KeyValues{model.String("a", "a"), model.String("b", "b"), model.String("c", "c")},
+		Logs: []model.Log{{}, {}},
+	}
+	span = s(span)
+
+	assert.Equal(t, model.KeyValues{
+		model.String("a", "a"),
+		model.Int64(DroppedTagsTag, 2),
+		model.Int64(DroppedLogsTag, 1),
+	}, model.KeyValues(span.Tags))
+	assert.Len(t, span.Logs, 1)
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "span_size.tags-dropped", Value: 2},
+		metricstest.ExpectedMetric{Name: "span_size.logs-dropped", Value: 1},
+	)
+}
+
+func TestSpanSizeSanitizerMaxSpanSize(t *testing.T) {
+	mf := metricstest.NewFactory(time.Hour)
+	s := NewSpanSizeSanitizer(SpanSizeLimits{MaxSpanSize: 1000}, mf)
+
+	payload := strings.Repeat("x", 5000)
+	span := &model.Span{
+		OperationName: "op",
+		Tags:          model.KeyValues{model.String("small", "tag")},
+		Logs: []model.Log{
+			{Fields: model.KeyValues{model.String("event", "small")}},
+			{Fields: model.KeyValues{model.String("payload", payload)}},
+		},
+	}
+	span = s(span)
+	require.NotNil(t, span)
+	// only the last log needs to be removed
+	assert.Equal(t, []model.Log{{Fields: model.KeyValues{model.String("event", "small")}}}, span.Logs)
+	assert.Equal(t, model.KeyValues{
+		model.String("small", "tag"),
+		model.Int64(DroppedLogsTag, 1),
+	}, model.KeyValues(span.Tags))
+
+	// a span that is too big even without tags and logs is dropped
+	span = &model.Span{OperationName: payload}
+	assert.Nil(t, s(span))
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "span_size.spans-dropped", Value: 1})
+}
+
+func TestSpanSizeSanitizerMaxSpanSizeWithCountTags(t *testing.T) {
+	newSpan := func() *model.Span {
+		span := &model.Span{OperationName: "op"}
+		for i := 0; i < 20; i++ {
+			span.Tags = append(span.Tags, model.String(fmt.Sprintf("tag-%d", i), "value"))
+			span.Logs = append(span.Logs, model.Log{Fields: model.KeyValues{model.String("event", fmt.Sprintf("log-%d", i))}})
+		}
+		return span
+	}
+	for maxSize := 20; maxSize < newSpan().Size(); maxSize += 7 {
+		s := NewSpanSizeSanitizer(SpanSizeLimits{MaxSpanSize: maxSize}, metricstest.NewFactory(time.Hour))
+		span := s(newSpan())
+		if span != nil {
+			// the count tags are included in the size limit
+			assert.LessOrEqual(t, span.Size(), maxSize)
+		}
+	}
+}
+
+func TestSpanSizeSanitizerSharedProcess(t *testing.T) {
+	s := NewSpanSizeSanitizer(SpanSizeLimits{MaxTagValueLength: 4}, metricstest.NewFactory(time.Hour))
+
+	// spans of a batch share the process and are sanitized concurrently by the queue workers
+	process := model.NewProcess("svc", model.KeyValues{model.String("hostname", "localhost")})
+	spans := make([]*model.Span, 10)
+	var wg sync.WaitGroup
+	for i := range spans {
+		spans[i] = &model.Span{Process: process}
+		wg.Add(1)
+		go func(span *model.Span) {
+			defer wg.Done()
+			s(span)
+		}(spans[i])
+	}
+	wg.Wait()
+
+	for _, span := range spans {
+		assert.Equal(t, model.KeyValues{model.String("hostname", "loca")}, model.KeyValues(span.Process.Tags))
+	}
+	assert.Equal(t, model.KeyValues{model.String("hostname", "localhost")}, model.KeyValues(process.Tags))
+}
+
+func TestTruncateString(t *testing.T) {
+	assert.Equal(t, "ab", truncateString("abc", 2))
+	// "é" is two bytes long and must not be split
+	assert.Equal(t, "a", truncateString("aé", 2))
+}
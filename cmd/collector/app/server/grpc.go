@@ -22,11 +22,14 @@ import (
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials"
 
+	baggageStore "github.com/jaegertracing/jaeger/cmd/collector/app/baggage"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/proto-gen/baggage_v1"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
 // GRPCServerParams to construct a new Jaeger Collector gRPC Server
@@ -35,8 +38,10 @@ type GRPCServerParams struct {
 	HostPort      string
 	Handler       *handler.GRPCHandler
 	SamplingStore strategystore.StrategyStore
-	Logger        *zap.Logger
-	OnError       func(error)
+	// BaggageManager serves baggage restrictions to the agents, if set
+	BaggageManager baggage.BaggageRestrictionManager
+	Logger         *zap.Logger
+	OnError        func(error)
 }
 
 // StartGRPCServer based on the given parameters
@@ -72,6 +77,9 @@ func StartGRPCServer(params *GRPCServerParams) (*grpc.Server, error) {
 func serveGRPC(server *grpc.Server, listener net.Listener, params *GRPCServerParams) error {
 	api_v2.RegisterCollectorServiceServer(server, params.Handler)
 	api_v2.RegisterSamplingManagerServer(server, sampling.NewGRPCHandler(params.SamplingStore))
+	if params.BaggageManager != nil {
+		baggage_v1.RegisterBaggageRestrictionManagerServer(server, baggageStore.NewGRPCHandler(params.BaggageManager))
+	}
 
 	params.Logger.Info("Starting jaeger-collector gRPC server", zap.String("grpc.host-port", params.HostPort))
 	go func() {
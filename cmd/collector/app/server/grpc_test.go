@@ -29,7 +29,9 @@ import (
 	"google.golang.org/grpc/test/bufconn"
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
+	"github.com/jaegertracing/jaeger/proto-gen/baggage_v1"
 )
 
 // test wrong port number
@@ -37,7 +39,7 @@ func TestFailToListen(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	server, err := StartGRPCServer(&GRPCServerParams{
 		HostPort:      ":-1",
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.Options{}),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 	})
@@ -54,7 +56,7 @@ func TestFailServe(t *testing.T) {
 
 	logger := zap.New(core)
 	serveGRPC(grpc.NewServer(), lis, &GRPCServerParams{
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.Options{}),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 		OnError: func(e error) {
@@ -69,7 +71,7 @@ func TestFailServe(t *testing.T) {
 func TestSpanCollector(t *testing.T) {
 	logger, _ := zap.NewDevelopment()
 	params := &GRPCServerParams{
-		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}),
+		Handler:       handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.Options{}),
 		SamplingStore: &mockSamplingStore{},
 		Logger:        logger,
 	}
@@ -92,3 +94,31 @@ func TestSpanCollector(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, response)
 }
+
+func TestBaggageRestrictions(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	params := &GRPCServerParams{
+		Handler:        handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.Options{}),
+		SamplingStore:  &mockSamplingStore{},
+		BaggageManager: &mockBaggageManager{},
+		Logger:         logger,
+	}
+
+	server := grpc.NewServer()
+	defer server.Stop()
+
+	listener, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	serveGRPC(server, listener, params)
+
+	conn, err := grpc.Dial(listener.Addr().String(), grpc.WithInsecure())
+	require.NoError(t, err)
+	defer conn.Close()
+
+	c := baggage_v1.NewBaggageRestrictionManagerClient(conn)
+	response, err := c.GetBaggageRestrictions(context.Background(), &baggage_v1.GetBaggageRestrictionsRequest{ServiceName: "svc"})
+	require.NoError(t, err)
+	assert.Equal(t, []*baggage_v1.BaggageRestriction{{BaggageKey: "key", MaxValueLength: 10}}, response.Restrictions)
+}
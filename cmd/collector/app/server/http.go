@@ -29,14 +29,25 @@ import (
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/pkg/httpmetrics"
 	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 )
 
 // HTTPServerParams to construct a new Jaeger Collector HTTP Server
 type HTTPServerParams struct {
-	TLSConfig      tlscfg.Options
-	HostPort       string
-	Handler        handler.JaegerBatchesHandler
-	SamplingStore  strategystore.StrategyStore
+	TLSConfig tlscfg.Options
+	HostPort  string
+	Handler   handler.JaegerBatchesHandler
+	// GRPCHandler also receives the api_v2.PostSpansRequest sent over HTTP, if set
+	GRPCHandler    *handler.GRPCHandler
+	AllowedOrigins string
+	AllowedHeaders string
+	// MaxBodySize is the maximum size in bytes of the decompressed request bodies (0 = unlimited)
+	MaxBodySize   int64
+	SamplingStore strategystore.StrategyStore
+	// BaggageManager serves baggage restrictions to the clients, if set
+	BaggageManager baggage.BaggageRestrictionManager
+	Tenancy        tenancy.Options
 	MetricsFactory metrics.Factory
 	HealthCheck    *healthcheck.HealthCheck
 	Logger         *zap.Logger
@@ -67,13 +78,25 @@ func StartHTTPServer(params *HTTPServerParams) (*http.Server, error) {
 
 func serveHTTP(server *http.Server, listener net.Listener, params *HTTPServerParams) {
 	r := mux.NewRouter()
-	apiHandler := handler.NewAPIHandler(params.Handler)
-	apiHandler.RegisterRoutes(r)
+	// only the span ingestion routes are tenanted, the clients fetching their configuration do not send a tenant
+	spansRouter := r.NewRoute().Subrouter()
+	spansRouter.Use(func(h http.Handler) http.Handler {
+		return tenancy.ExtractTenantHTTPHandler(params.Tenancy, h)
+	})
+	apiHandler := handler.NewAPIHandler(params.Handler, params.MaxBodySize)
+	apiHandler.RegisterRoutes(spansRouter)
+
+	if params.GRPCHandler != nil {
+		protoHandler := handler.NewProtoAPIHandler(params.GRPCHandler, params.MaxBodySize)
+		cors := newCORS(params.AllowedOrigins, params.AllowedHeaders)
+		spansRouter.Handle(handler.ProtoSpansPath, cors.Handler(http.HandlerFunc(protoHandler.SaveSpans))).
+			Methods(http.MethodPost, http.MethodOptions)
+	}
 
 	cfgHandler := clientcfgHandler.NewHTTPHandler(clientcfgHandler.HTTPHandlerParams{
 		ConfigManager: &clientcfgHandler.ConfigManager{
 			SamplingStrategyStore: params.SamplingStore,
-			// TODO provide baggage manager
+			BaggageManager:        params.BaggageManager,
 		},
 		MetricsFactory:         params.MetricsFactory,
 		BasePath:               "/api",
@@ -17,9 +17,11 @@ package server
 import (
 	"crypto/tls"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -31,6 +33,7 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/ports"
 )
 
@@ -86,6 +89,102 @@ func TestSpanCollectorHTTP(t *testing.T) {
 	assert.NotNil(t, response)
 }
 
+func TestBaggageRestrictionsHTTP(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	params := &HTTPServerParams{
+		Handler:        handler.NewJaegerSpanHandler(logger, &mockSpanProcessor{}),
+		SamplingStore:  &mockSamplingStore{},
+		BaggageManager: &mockBaggageManager{},
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		HealthCheck:    healthcheck.New(),
+		Logger:         logger,
+	}
+
+	server := httptest.NewServer(nil)
+	defer server.Close()
+
+	serveHTTP(server.Config, server.Listener, params)
+
+	response, err := http.Get(server.URL + "/api/baggageRestrictions?service=svc")
+	require.NoError(t, err)
+	defer response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+	body, err := ioutil.ReadAll(response.Body)
+	require.NoError(t, err)
+	assert.JSONEq(t, `[{"baggageKey":"key","maxValueLength":10}]`, string(body))
+}
+
+func TestTenancyOnlyOnSpanRoutesHTTP(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	params := &HTTPServerParams{
+		Handler:        handler.NewJaegerSpanHandler(logger, &mockSpanProcessor{}),
+		SamplingStore:  &mockSamplingStore{},
+		BaggageManager: &mockBaggageManager{},
+		Tenancy:        tenancy.Options{Enabled: true},
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		HealthCheck:    healthcheck.New(),
+		Logger:         logger,
+	}
+
+	server := httptest.NewServer(nil)
+	defer server.Close()
+
+	serveHTTP(server.Config, server.Listener, params)
+
+	// the clients fetching their configuration do not send the tenant header
+	response, err := http.Get(server.URL + "/api/baggageRestrictions?service=svc")
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusOK, response.StatusCode)
+
+	response, err = http.Post(server.URL+"/api/traces", "application/x-thrift", nil)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusUnauthorized, response.StatusCode)
+}
+
+func TestSpanCollectorHTTPProto(t *testing.T) {
+	logger, _ := zap.NewDevelopment()
+	params := &HTTPServerParams{
+		Handler:        handler.NewJaegerSpanHandler(logger, &mockSpanProcessor{}),
+		GRPCHandler:    handler.NewGRPCHandler(logger, &mockSpanProcessor{}, tenancy.Options{}),
+		AllowedOrigins: "http://example.com",
+		AllowedHeaders: "content-type",
+		SamplingStore:  &mockSamplingStore{},
+		Tenancy:        tenancy.Options{Enabled: true},
+		MetricsFactory: metricstest.NewFactory(time.Hour),
+		HealthCheck:    healthcheck.New(),
+		Logger:         logger,
+	}
+
+	server := httptest.NewServer(nil)
+	defer server.Close()
+
+	serveHTTP(server.Config, server.Listener, params)
+
+	// the CORS preflight request does not need the tenant header
+	req, err := http.NewRequest(http.MethodOptions, server.URL+handler.ProtoSpansPath, nil)
+	require.NoError(t, err)
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Access-Control-Request-Method", http.MethodPost)
+	req.Header.Set("Access-Control-Request-Headers", "content-type")
+	response, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, "http://example.com", response.Header.Get("Access-Control-Allow-Origin"))
+
+	req, err = http.NewRequest(http.MethodPost, server.URL+handler.ProtoSpansPath, strings.NewReader(`{"batch": {"spans": []}}`))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("x-tenant", "acme")
+	response, err = http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	response.Body.Close()
+	assert.Equal(t, http.StatusAccepted, response.StatusCode)
+	assert.Equal(t, "http://example.com", response.Header.Get("Access-Control-Allow-Origin"))
+}
+
 func TestSpanCollectorHTTPS(t *testing.T) {
 
 	testCases := []struct {
@@ -29,6 +29,7 @@ import (
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/pkg/httpmetrics"
 	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 )
 
 // ZipkinServerParams to construct a new Jaeger Collector Zipkin Server
@@ -37,11 +38,27 @@ type ZipkinServerParams struct {
 	Handler        handler.ZipkinSpansHandler
 	AllowedOrigins string
 	AllowedHeaders string
+	// MaxBodySize is the maximum size in bytes of the decompressed request bodies (0 = unlimited)
+	MaxBodySize    int64
+	Tenancy        tenancy.Options
 	HealthCheck    *healthcheck.HealthCheck
 	Logger         *zap.Logger
 	MetricsFactory metrics.Factory
 }
 
+// newCORS creates the CORS handler of the endpoints receiving spans from browsers,
+// from comma separated lists of allowed origins and headers
+func newCORS(allowedOrigins, allowedHeaders string) *cors.Cors {
+	origins := strings.Split(strings.ReplaceAll(allowedOrigins, " ", ""), ",")
+	headers := strings.Split(strings.ReplaceAll(allowedHeaders, " ", ""), ",")
+
+	return cors.New(cors.Options{
+		AllowedOrigins: origins,
+		AllowedMethods: []string{"POST"}, // Allowing only POST, because that's the only handled one
+		AllowedHeaders: headers,
+	})
+}
+
 // StartZipkinServer based on the given parameters
 func StartZipkinServer(params *ZipkinServerParams) (*http.Server, error) {
 	if params.HostPort == "" {
@@ -64,20 +81,13 @@ func StartZipkinServer(params *ZipkinServerParams) (*http.Server, error) {
 
 func serveZipkin(server *http.Server, listener net.Listener, params *ZipkinServerParams) {
 	r := mux.NewRouter()
-	zHandler := zipkin.NewAPIHandler(params.Handler)
+	zHandler := zipkin.NewAPIHandler(params.Handler, params.MaxBodySize)
 	zHandler.RegisterRoutes(r)
 
-	origins := strings.Split(strings.ReplaceAll(params.AllowedOrigins, " ", ""), ",")
-	headers := strings.Split(strings.ReplaceAll(params.AllowedHeaders, " ", ""), ",")
-
-	cors := cors.New(cors.Options{
-		AllowedOrigins: origins,
-		AllowedMethods: []string{"POST"}, // Allowing only POST, because that's the only handled one
-		AllowedHeaders: headers,
-	})
+	cors := newCORS(params.AllowedOrigins, params.AllowedHeaders)
 
 	recoveryHandler := recoveryhandler.NewRecoveryHandler(params.Logger, true)
-	server.Handler = cors.Handler(httpmetrics.Wrap(recoveryHandler(r), params.MetricsFactory))
+	server.Handler = cors.Handler(httpmetrics.Wrap(recoveryHandler(tenancy.ExtractTenantHTTPHandler(params.Tenancy, r)), params.MetricsFactory))
 	go func(listener net.Listener, server *http.Server) {
 		if err := server.Serve(listener); err != nil {
 			if err != http.ErrServerClosed {
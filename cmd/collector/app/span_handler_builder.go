@@ -23,8 +23,10 @@ import (
 
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
+	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
 	zs "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -34,6 +36,22 @@ type SpanHandlerBuilder struct {
 	CollectorOpts  CollectorOptions
 	Logger         *zap.Logger
 	MetricsFactory metrics.Factory
+	// Sanitizers are applied in order to every span taken from the queue, before it is saved
+	Sanitizers []sanitizer.SanitizeSpan
+	// SpanFilter rejects spans before they are processed by the SpanPipeline, and accepts all spans if nil
+	SpanFilter FilterSpan
+	// SpanPipeline is applied to every span accepted by the span filter, before it is queued
+	SpanPipeline sanitizer.SanitizeSpan
+	// PreSave is called for every span about to be saved, after the sanitizers
+	PreSave ProcessSpan
+	// Deduper drops the spans already enqueued recently, if not nil
+	Deduper *dedup.Deduper
+	// SpanQuota rejects batches whose services or tenant exceeded their quota, before they are enqueued
+	SpanQuota AllowSpans
+	// HostTags returns the tags to add to the process of a span, based on the host the span comes from
+	HostTags func(process *model.Process) map[string]string
+	// MemoryLimiter returns true when incoming spans must be rejected because the memory usage is too high
+	MemoryLimiter func() bool
 }
 
 // SpanHandlers holds instances to the span handlers built by the SpanHandlerBuilder
@@ -54,10 +72,18 @@ func (b *SpanHandlerBuilder) BuildSpanProcessor() processor.SpanProcessor {
 		Options.ServiceMetrics(svcMetrics),
 		Options.HostMetrics(hostMetrics),
 		Options.Logger(b.logger()),
-		Options.SpanFilter(defaultSpanFilter),
+		Options.SpanFilter(b.spanFilter()),
+		Options.SpanPipeline(b.SpanPipeline),
+		Options.Sanitizer(sanitizer.NewChainedSanitizer(b.Sanitizers...)),
+		Options.SpanQuota(b.SpanQuota),
+		Options.Deduper(b.Deduper),
+		Options.PreSave(b.PreSave),
+		Options.MemoryLimiter(b.MemoryLimiter),
 		Options.NumWorkers(b.CollectorOpts.NumWorkers),
 		Options.QueueSize(b.CollectorOpts.QueueSize),
+		Options.DrainTimeout(b.CollectorOpts.DrainTimeout),
 		Options.CollectorTags(b.CollectorOpts.CollectorTags),
+		Options.HostTags(b.HostTags),
 		Options.DynQueueSizeWarmup(uint(b.CollectorOpts.QueueSize)), // same as queue size for now
 		Options.DynQueueSizeMemory(b.CollectorOpts.DynQueueSizeMemory),
 	)
@@ -69,7 +95,7 @@ func (b *SpanHandlerBuilder) BuildHandlers(spanProcessor processor.SpanProcessor
 	return &SpanHandlers{
 		handler.NewZipkinSpanHandler(b.Logger, spanProcessor, zs.NewChainedSanitizer(zs.StandardSanitizers...)),
 		handler.NewJaegerSpanHandler(b.Logger, spanProcessor),
-		handler.NewGRPCHandler(b.Logger, spanProcessor),
+		handler.NewGRPCHandler(b.Logger, spanProcessor, b.CollectorOpts.Tenancy),
 	}
 }
 
@@ -77,6 +103,13 @@ func defaultSpanFilter(*model.Span) bool {
 	return true
 }
 
+func (b *SpanHandlerBuilder) spanFilter() FilterSpan {
+	if b.SpanFilter == nil {
+		return defaultSpanFilter
+	}
+	return b.SpanFilter
+}
+
 func (b *SpanHandlerBuilder) logger() *zap.Logger {
 	if b.Logger == nil {
 		return zap.NewNop()
@@ -24,6 +24,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/flags"
+	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/config"
 	"github.com/jaegertracing/jaeger/plugin/storage/memory"
 )
@@ -60,4 +61,6 @@ func TestNewSpanHandlerBuilder(t *testing.T) {
 
 func TestDefaultSpanFilter(t *testing.T) {
 	assert.True(t, defaultSpanFilter(nil))
+	assert.True(t, (&SpanHandlerBuilder{}).spanFilter()(nil))
+	assert.False(t, (&SpanHandlerBuilder{SpanFilter: func(*model.Span) bool { return false }}).spanFilter()(nil))
 }
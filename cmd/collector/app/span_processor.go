@@ -26,7 +26,9 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
 	"github.com/jaegertracing/jaeger/pkg/queue"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -36,6 +38,9 @@ const (
 
 	// if the new queue size isn't 20% bigger than the previous one, don't change
 	minRequiredChange = 1.2
+
+	// maxWriteBatchSize is the maximum number of spans saved at once when the span writer supports batches
+	maxWriteBatchSize = 100
 )
 
 type spanProcessor struct {
@@ -44,13 +49,20 @@ type spanProcessor struct {
 	metrics            *SpanProcessorMetrics
 	preProcessSpans    ProcessSpans
 	filterSpan         FilterSpan             // filter is called before the sanitizer but after preProcessSpans
+	spanPipeline       sanitizer.SanitizeSpan // spanPipeline is called after filterSpan, before the span is enqueued
+	spanQuota          AllowSpans             // spanQuota is called on the spans accepted by spanPipeline, before they are enqueued
+	deduper            *dedup.Deduper         // deduper drops the spans accepted by spanPipeline that were already enqueued
+	memoryLimiter      func() bool            // memoryLimiter is called before the batch is processed
 	sanitizer          sanitizer.SanitizeSpan // sanitizer is called before processSpan
-	processSpan        ProcessSpan
+	processSpan        func(span *model.Span, tenant string)
+	preSave            ProcessSpan
 	logger             *zap.Logger
 	spanWriter         spanstore.Writer
 	reportBusy         bool
 	numWorkers         int
+	drainTimeout       time.Duration
 	collectorTags      map[string]string
+	hostTags           func(process *model.Process) map[string]string
 	dynQueueSizeWarmup uint
 	dynQueueSizeMemory uint
 	bytesProcessed     *atomic.Uint64
@@ -61,6 +73,7 @@ type spanProcessor struct {
 type queueItem struct {
 	queuedTime time.Time
 	span       *model.Span
+	tenant     string
 }
 
 // NewSpanProcessor returns a SpanProcessor that preProcesses, filters, queues, sanitizes, and processes spans
@@ -70,10 +83,20 @@ func NewSpanProcessor(
 ) processor.SpanProcessor {
 	sp := newSpanProcessor(spanWriter, opts...)
 
-	sp.queue.StartConsumers(sp.numWorkers, func(item interface{}) {
-		value := item.(*queueItem)
-		sp.processItemFromQueue(value)
-	})
+	if _, ok := spanWriter.(spanstore.BatchWriter); ok {
+		sp.queue.StartBatchConsumers(sp.numWorkers, maxWriteBatchSize, func(items []interface{}) {
+			values := make([]*queueItem, len(items))
+			for i, item := range items {
+				values[i] = item.(*queueItem)
+			}
+			sp.processItemsFromQueue(values)
+		})
+	} else {
+		sp.queue.StartConsumers(sp.numWorkers, func(item interface{}) {
+			value := item.(*queueItem)
+			sp.processItemFromQueue(value)
+		})
+	}
 
 	sp.background(1*time.Second, sp.updateGauges)
 
@@ -101,11 +124,17 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 		logger:             options.logger,
 		preProcessSpans:    options.preProcessSpans,
 		filterSpan:         options.spanFilter,
+		spanPipeline:       options.spanPipeline,
+		spanQuota:          options.spanQuota,
+		deduper:            options.deduper,
+		memoryLimiter:      options.memoryLimiter,
 		sanitizer:          options.sanitizer,
 		reportBusy:         options.reportBusy,
 		numWorkers:         options.numWorkers,
+		drainTimeout:       options.drainTimeout,
 		spanWriter:         spanWriter,
 		collectorTags:      options.collectorTags,
+		hostTags:           options.hostTags,
 		stopCh:             make(chan struct{}),
 		dynQueueSizeMemory: options.dynQueueSizeMemory,
 		dynQueueSizeWarmup: options.dynQueueSizeWarmup,
@@ -113,27 +142,43 @@ func newSpanProcessor(spanWriter spanstore.Writer, opts ...Option) *spanProcesso
 		spansProcessed:     atomic.NewUint64(0),
 	}
 
-	processSpanFuncs := []ProcessSpan{options.preSave, sp.saveSpan}
+	postSave := func(*model.Span) {}
 	if options.dynQueueSizeMemory > 0 {
-		// add to processSpanFuncs
 		options.logger.Info("Dynamically adjusting the queue size at runtime.",
 			zap.Uint("memory-mib", options.dynQueueSizeMemory/1024/1024),
 			zap.Uint("queue-size-warmup", options.dynQueueSizeWarmup))
-		processSpanFuncs = append(processSpanFuncs, sp.countSpan)
+		postSave = sp.countSpan
 	}
 
-	sp.processSpan = ChainedProcessSpan(processSpanFuncs...)
+	sp.processSpan = func(span *model.Span, tenant string) {
+		options.preSave(span)
+		sp.saveSpan(span, tenant)
+		postSave(span)
+	}
+	sp.preSave = options.preSave
 	return &sp
 }
 
 func (sp *spanProcessor) Close() error {
 	close(sp.stopCh)
+	if sp.drainTimeout > 0 {
+		sp.logger.Info("Draining the span queue",
+			zap.Int("queue-length", sp.queue.Size()),
+			zap.Duration("timeout", sp.drainTimeout))
+		ctx, cancel := context.WithTimeout(context.Background(), sp.drainTimeout)
+		defer cancel()
+		sp.queue.Drain(ctx)
+	}
 	sp.queue.Stop()
+	if lost := sp.queue.Size(); lost > 0 {
+		sp.logger.Warn("Spans left in the queue were not saved", zap.Int("spans", lost))
+		sp.metrics.SpansDropped.Inc(int64(lost))
+	}
 
 	return nil
 }
 
-func (sp *spanProcessor) saveSpan(span *model.Span) {
+func (sp *spanProcessor) saveSpan(span *model.Span, tenant string) {
 	if nil == span.Process {
 		sp.logger.Error("process is empty for the span")
 		sp.metrics.SavedErrBySvc.ReportServiceNameForSpan(span)
@@ -142,7 +187,8 @@ func (sp *spanProcessor) saveSpan(span *model.Span) {
 
 	startTime := time.Now()
 	// TODO context should be propagated from upstream components
-	if err := sp.spanWriter.WriteSpan(context.TODO(), span); err != nil {
+	ctx := tenancy.WithTenant(context.TODO(), tenant)
+	if err := sp.spanWriter.WriteSpan(ctx, span); err != nil {
 		sp.logger.Error("Failed to save span", zap.Error(err))
 		sp.metrics.SavedErrBySvc.ReportServiceNameForSpan(span)
 	} else {
@@ -153,43 +199,156 @@ func (sp *spanProcessor) saveSpan(span *model.Span) {
 	sp.metrics.SaveLatency.Record(time.Since(startTime))
 }
 
+// saveSpans saves the spans of a tenant with a single call to the span writer, which must implement spanstore.BatchWriter
+func (sp *spanProcessor) saveSpans(spans []*model.Span, tenant string) {
+	toSave := spans[:0]
+	for _, span := range spans {
+		if nil == span.Process {
+			sp.logger.Error("process is empty for the span")
+			sp.metrics.SavedErrBySvc.ReportServiceNameForSpan(span)
+			continue
+		}
+		toSave = append(toSave, span)
+	}
+	if len(toSave) == 0 {
+		return
+	}
+
+	startTime := time.Now()
+	// TODO context should be propagated from upstream components
+	ctx := tenancy.WithTenant(context.TODO(), tenant)
+	if err := sp.spanWriter.(spanstore.BatchWriter).WriteSpans(ctx, toSave); err != nil {
+		sp.logger.Error("Failed to save spans", zap.Int("spans", len(toSave)), zap.Error(err))
+		for _, span := range toSave {
+			sp.metrics.SavedErrBySvc.ReportServiceNameForSpan(span)
+		}
+	} else {
+		sp.logger.Debug("Spans written to the storage by the collector", zap.Int("spans", len(toSave)))
+		for _, span := range toSave {
+			sp.metrics.SavedOkBySvc.ReportServiceNameForSpan(span)
+		}
+	}
+	sp.metrics.SaveLatency.Record(time.Since(startTime))
+}
+
 func (sp *spanProcessor) countSpan(span *model.Span) {
 	sp.bytesProcessed.Add(uint64(span.Size()))
 	sp.spansProcessed.Inc()
 }
 
 func (sp *spanProcessor) ProcessSpans(mSpans []*model.Span, options processor.SpansOptions) ([]bool, error) {
+	if sp.memoryLimiter() {
+		return nil, processor.ErrMemoryLimitExceeded
+	}
 	sp.preProcessSpans(mSpans)
 	sp.metrics.BatchSize.Update(int64(len(mSpans)))
 	retMe := make([]bool, len(mSpans))
+	accepted := make([]*model.Span, 0, len(mSpans))
+	indexes := make([]int, 0, len(mSpans))
 	for i, mSpan := range mSpans {
-		ok := sp.enqueueSpan(mSpan, options.SpanFormat, options.InboundTransport)
+		span := sp.acceptSpan(mSpan, options.SpanFormat, options.InboundTransport)
+		if span == nil {
+			retMe[i] = true // as in "not dropped", because it's actively rejected
+			continue
+		}
+		accepted = append(accepted, span)
+		indexes = append(indexes, i)
+	}
+	// the quota accepts or rejects the batch as a whole, so that clients retrying a rejected batch do not store spans twice
+	if !sp.spanQuota(accepted, options.Tenant) {
+		for _, span := range accepted {
+			sp.metrics.QuotaExceededBySvc.ReportServiceNameForSpan(span)
+		}
+		sp.forget(accepted)
+		return nil, processor.ErrQuotaExceeded
+	}
+	for i, span := range accepted {
+		ok := sp.enqueueSpan(span, options.SpanFormat, options.Tenant)
 		if !ok && sp.reportBusy {
+			// the spans not enqueued are sent again by the client
+			sp.forget(accepted[i:])
 			return nil, processor.ErrBusy
 		}
-		retMe[i] = ok
+		if !ok {
+			sp.forget(accepted[i : i+1])
+		}
+		retMe[indexes[i]] = ok
 	}
 	return retMe, nil
 }
 
+// forget removes the spans that were not enqueued from the deduper, so that they are accepted when received again
+func (sp *spanProcessor) forget(spans []*model.Span) {
+	if sp.deduper == nil {
+		return
+	}
+	for _, span := range spans {
+		sp.deduper.Forget(span)
+	}
+}
+
 func (sp *spanProcessor) processItemFromQueue(item *queueItem) {
-	sp.processSpan(sp.sanitizer(item.span))
+	// the sanitizer returns nil for spans that must be dropped
+	if span := sp.sanitizer(item.span); span != nil {
+		sp.processSpan(span, item.tenant)
+	}
 	sp.metrics.InQueueLatency.Record(time.Since(item.queuedTime))
 }
 
+// processItemsFromQueue is the batch version of processItemFromQueue, used when the span writer supports batches
+func (sp *spanProcessor) processItemsFromQueue(items []*queueItem) {
+	// spans of different tenants are saved separately, in the order their tenant first appears
+	var tenants []string
+	spansByTenant := make(map[string][]*model.Span)
+	for _, item := range items {
+		// the sanitizer returns nil for spans that must be dropped
+		if span := sp.sanitizer(item.span); span != nil {
+			sp.preSave(span)
+			if _, ok := spansByTenant[item.tenant]; !ok {
+				tenants = append(tenants, item.tenant)
+			}
+			spansByTenant[item.tenant] = append(spansByTenant[item.tenant], span)
+		}
+	}
+	for _, tenant := range tenants {
+		spans := spansByTenant[tenant]
+		sp.saveSpans(spans, tenant)
+		if sp.dynQueueSizeMemory > 0 {
+			for _, span := range spans {
+				sp.countSpan(span)
+			}
+		}
+	}
+	for _, item := range items {
+		sp.metrics.InQueueLatency.Record(time.Since(item.queuedTime))
+	}
+}
+
 func (sp *spanProcessor) addCollectorTags(span *model.Span) {
-	if len(sp.collectorTags) == 0 {
+	sp.addProcessTags(span, sp.collectorTags)
+}
+
+// addHostTags appends the metadata of the host the span comes from to its process tags
+func (sp *spanProcessor) addHostTags(span *model.Span) {
+	if span.Process == nil {
+		return
+	}
+	sp.addProcessTags(span, sp.hostTags(span.Process))
+}
+
+func (sp *spanProcessor) addProcessTags(span *model.Span, tags map[string]string) {
+	if len(tags) == 0 {
 		return
 	}
 	dedupKey := make(map[string]struct{})
 	for _, tag := range span.Process.Tags {
-		if value, ok := sp.collectorTags[tag.Key]; ok && value == tag.AsString() {
+		if value, ok := tags[tag.Key]; ok && value == tag.AsString() {
 			sp.logger.Debug("ignore collector process tags", zap.String("key", tag.Key), zap.String("value", value))
 			dedupKey[tag.Key] = struct{}{}
 		}
 	}
 	// ignore collector tags if has the same key-value in spans
-	for k, v := range sp.collectorTags {
+	for k, v := range tags {
 		if _, ok := dedupKey[k]; !ok {
 			span.Process.Tags = append(span.Process.Tags, model.String(k, v))
 		}
@@ -198,24 +357,41 @@ func (sp *spanProcessor) addCollectorTags(span *model.Span) {
 	typedTags.Sort()
 }
 
-func (sp *spanProcessor) enqueueSpan(span *model.Span, originalFormat processor.SpanFormat, transport processor.InboundTransport) bool {
+// acceptSpan applies the span filter, the span pipeline and the deduper, and returns nil if the span is rejected
+func (sp *spanProcessor) acceptSpan(span *model.Span, originalFormat processor.SpanFormat, transport processor.InboundTransport) *model.Span {
 	spanCounts := sp.metrics.GetCountsForFormat(originalFormat, transport)
 	spanCounts.ReceivedBySvc.ReportServiceNameForSpan(span)
 
 	if !sp.filterSpan(span) {
 		spanCounts.RejectedBySvc.ReportServiceNameForSpan(span)
-		return true // as in "not dropped", because it's actively rejected
+		return nil
+	}
+	// the pipeline returns nil for spans that must be dropped
+	processed := sp.spanPipeline(span)
+	if processed == nil {
+		spanCounts.RejectedBySvc.ReportServiceNameForSpan(span)
+		return nil
 	}
+	// the duplicates are detected last, so that only the spans about to be enqueued are remembered
+	if sp.deduper != nil && sp.deduper.IsDuplicate(processed) {
+		spanCounts.RejectedBySvc.ReportServiceNameForSpan(span)
+		return nil
+	}
+	return processed
+}
 
+func (sp *spanProcessor) enqueueSpan(span *model.Span, originalFormat processor.SpanFormat, tenant string) bool {
 	//add format tag
 	span.Tags = append(span.Tags, model.String("internal.span.format", string(originalFormat)))
 
 	// append the collector tags
 	sp.addCollectorTags(span)
+	sp.addHostTags(span)
 
 	item := &queueItem{
 		queuedTime: time.Now(),
 		span:       span,
+		tenant:     tenant,
 	}
 	return sp.queue.Produce(item)
 }
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 	"go.uber.org/atomic"
@@ -33,7 +34,10 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	zipkinSanitizer "github.com/jaegertracing/jaeger/cmd/collector/app/sanitizer/zipkin"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
 	"github.com/jaegertracing/jaeger/thrift-gen/jaeger"
 	zc "github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
 )
@@ -270,6 +274,139 @@ func TestSpanProcessorErrors(t *testing.T) {
 	mb.AssertCounterMetrics(t, expected...)
 }
 
+type fakeBatchSpanWriter struct {
+	fakeSpanWriter
+	mux     sync.Mutex
+	batches [][]*model.Span
+}
+
+func (n *fakeBatchSpanWriter) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	n.batches = append(n.batches, append([]*model.Span(nil), spans...))
+	return n.err
+}
+
+func (n *fakeBatchSpanWriter) getBatches() [][]*model.Span {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	return n.batches
+}
+
+func TestSpanProcessorBatchWriter(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
+	w := &fakeBatchSpanWriter{}
+	p := NewSpanProcessor(w,
+		Options.ServiceMetrics(serviceMetrics),
+		Options.NumWorkers(1),
+		Options.QueueSize(10),
+		Options.DrainTimeout(time.Second),
+	).(*spanProcessor)
+
+	res, err := p.ProcessSpans([]*model.Span{
+		{OperationName: "a", Process: &model.Process{ServiceName: "x"}},
+		{OperationName: "b", Process: &model.Process{ServiceName: "x"}},
+	}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	assert.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, res)
+	assert.NoError(t, p.Close())
+
+	total := 0
+	for _, batch := range w.getBatches() {
+		assert.NotEmpty(t, batch)
+		total += len(batch)
+	}
+	assert.Equal(t, 2, total)
+	mb.AssertCounterMetrics(t, metricstest.ExpectedMetric{
+		Name: "service.spans.saved-by-svc|debug=false|result=ok|svc=x", Value: 2,
+	})
+}
+
+func TestSpanProcessorBatchWriterErrors(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
+	w := &fakeBatchSpanWriter{fakeSpanWriter: fakeSpanWriter{err: fmt.Errorf("some-error")}}
+	p := NewSpanProcessor(w, Options.ServiceMetrics(serviceMetrics)).(*spanProcessor)
+	defer assert.NoError(t, p.Close())
+
+	p.saveSpans([]*model.Span{
+		{Process: &model.Process{ServiceName: "x"}},
+		{},
+	}, "")
+
+	mb.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "service.spans.saved-by-svc|debug=false|result=err|svc=x", Value: 1},
+		metricstest.ExpectedMetric{Name: "service.spans.saved-by-svc|debug=false|result=err|svc=__unknown", Value: 1},
+	)
+}
+
+type tenantSpanWriter struct {
+	fakeSpanWriter
+	mux     sync.Mutex
+	tenants map[string][]string
+}
+
+func (n *tenantSpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	tenant := tenancy.GetTenant(ctx)
+	n.tenants[tenant] = append(n.tenants[tenant], span.OperationName)
+	return nil
+}
+
+func (n *tenantSpanWriter) getTenants() map[string][]string {
+	n.mux.Lock()
+	defer n.mux.Unlock()
+	return n.tenants
+}
+
+type tenantBatchSpanWriter struct {
+	tenantSpanWriter
+}
+
+func (n *tenantBatchSpanWriter) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	for _, span := range spans {
+		n.tenantSpanWriter.WriteSpan(ctx, span)
+	}
+	return nil
+}
+
+func TestSpanProcessorTenant(t *testing.T) {
+	tests := []struct {
+		name   string
+		writer interface {
+			spanstore.Writer
+			getTenants() map[string][]string
+		}
+	}{
+		{name: "single", writer: &tenantSpanWriter{tenants: map[string][]string{}}},
+		{name: "batch", writer: &tenantBatchSpanWriter{tenantSpanWriter{tenants: map[string][]string{}}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			p := NewSpanProcessor(test.writer,
+				Options.NumWorkers(1),
+				Options.QueueSize(10),
+				Options.DrainTimeout(time.Second),
+			).(*spanProcessor)
+			for _, tenant := range []string{"acme", "", "globex", "acme"} {
+				_, err := p.ProcessSpans([]*model.Span{
+					{OperationName: "op-" + tenant, Process: &model.Process{ServiceName: "x"}},
+				}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat, Tenant: tenant})
+				require.NoError(t, err)
+			}
+			require.NoError(t, p.Close())
+
+			assert.Equal(t, map[string][]string{
+				"acme":   {"op-acme", "op-acme"},
+				"":       {"op-"},
+				"globex": {"op-globex"},
+			}, test.writer.getTenants())
+		})
+	}
+}
+
 type blockingWriter struct {
 	sync.Mutex
 }
@@ -316,6 +453,68 @@ func TestSpanProcessorBusy(t *testing.T) {
 	assert.Nil(t, res)
 }
 
+func TestSpanProcessorDrainOnClose(t *testing.T) {
+	logger, logBuf := testutils.NewLogger()
+	mb := metricstest.NewFactory(time.Hour)
+	w := &blockingWriter{}
+	p := NewSpanProcessor(w,
+		Options.Logger(logger),
+		Options.HostMetrics(mb),
+		Options.NumWorkers(1),
+		Options.QueueSize(10),
+		Options.DrainTimeout(time.Second),
+	).(*spanProcessor)
+
+	w.Lock()
+	spans := []*model.Span{
+		{Process: &model.Process{ServiceName: "x"}},
+		{Process: &model.Process{ServiceName: "x"}},
+		{Process: &model.Process{ServiceName: "x"}},
+	}
+	_, err := p.ProcessSpans(spans, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	// release the writer while Close is waiting for the queue to be drained
+	time.AfterFunc(50*time.Millisecond, w.Unlock)
+
+	assert.NoError(t, p.Close())
+	assert.Equal(t, 0, p.queue.Size())
+	assert.Contains(t, logBuf.String(), "Draining the span queue")
+	assert.NotContains(t, logBuf.String(), "not saved")
+}
+
+func TestSpanProcessorDrainTimeout(t *testing.T) {
+	logger, logBuf := testutils.NewLogger()
+	mb := metricstest.NewFactory(time.Hour)
+	w := &blockingWriter{}
+	p := NewSpanProcessor(w,
+		Options.Logger(logger),
+		Options.HostMetrics(mb),
+		Options.NumWorkers(1),
+		Options.QueueSize(10),
+		Options.DrainTimeout(10*time.Millisecond),
+	).(*spanProcessor)
+
+	w.Lock()
+	spans := []*model.Span{
+		{Process: &model.Process{ServiceName: "x"}},
+		{Process: &model.Process{ServiceName: "x"}},
+		{Process: &model.Process{ServiceName: "x"}},
+	}
+	_, err := p.ProcessSpans(spans, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	// the worker holding the first span finishes once the writer is released
+	time.AfterFunc(50*time.Millisecond, w.Unlock)
+
+	assert.NoError(t, p.Close())
+	assert.Contains(t, logBuf.String(), "Draining the span queue")
+	// once stopped, the worker may or may not pick up the remaining spans before exiting
+	lost := p.queue.Size()
+	if lost > 0 {
+		assert.Contains(t, logBuf.String(), "Spans left in the queue were not saved")
+	}
+	mb.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "spans.dropped", Value: lost})
+}
+
 func TestSpanProcessorWithNilProcess(t *testing.T) {
 	mb := metricstest.NewFactory(time.Hour)
 	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
@@ -324,7 +523,7 @@ func TestSpanProcessorWithNilProcess(t *testing.T) {
 	p := NewSpanProcessor(w, Options.ServiceMetrics(serviceMetrics)).(*spanProcessor)
 	defer assert.NoError(t, p.Close())
 
-	p.saveSpan(&model.Span{})
+	p.saveSpan(&model.Span{}, "")
 
 	expected := []metricstest.ExpectedMetric{{
 		Name: "service.spans.saved-by-svc|debug=false|result=err|svc=__unknown", Value: 1,
@@ -380,6 +579,188 @@ func TestSpanProcessorWithCollectorTags(t *testing.T) {
 	assert.Equal(t, expected.Process, span.Process)
 }
 
+func TestSpanProcessorSanitizerDropsSpan(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
+
+	w := &fakeSpanWriter{}
+	p := NewSpanProcessor(w,
+		Options.ServiceMetrics(serviceMetrics),
+		Options.Sanitizer(func(span *model.Span) *model.Span { return nil }),
+	).(*spanProcessor)
+	defer assert.NoError(t, p.Close())
+
+	p.processItemFromQueue(&queueItem{
+		queuedTime: time.Now(),
+		span:       &model.Span{Process: &model.Process{ServiceName: "x"}},
+	})
+
+	counters, _ := mb.Snapshot()
+	assert.NotContains(t, counters, "service.spans.saved-by-svc|debug=false|result=ok|svc=x")
+}
+
+func TestSpanProcessorDedupRetryAfterBusy(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	w := make(chanSpanWriter, 10)
+	// the consumers are started later, so that the queue is full after the first span
+	p := newSpanProcessor(w,
+		Options.QueueSize(1),
+		Options.ReportBusy(true),
+		Options.Deduper(dedup.New(dedup.Options{Window: time.Minute}, mb)),
+	)
+	newSpans := func() []*model.Span {
+		return []*model.Span{
+			{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(1), Process: &model.Process{ServiceName: "x"}},
+			{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(2), Process: &model.Process{ServiceName: "x"}},
+		}
+	}
+
+	_, err := p.ProcessSpans(newSpans(), processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	assert.Equal(t, processor.ErrBusy, err)
+
+	p.queue.StartConsumers(1, func(item interface{}) {
+		p.processItemFromQueue(item.(*queueItem))
+	})
+	defer func() { assert.NoError(t, p.Close()) }()
+	assert.Equal(t, model.NewSpanID(1), (<-w).SpanID)
+
+	// the retried batch only saves the span that was not enqueued
+	res, err := p.ProcessSpans(newSpans(), processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, res)
+	assert.Equal(t, model.NewSpanID(2), (<-w).SpanID)
+	mb.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "dedup.spans", Tags: map[string]string{"result": "unique"}, Value: 3},
+		metricstest.ExpectedMetric{Name: "dedup.spans", Tags: map[string]string{"result": "duplicate"}, Value: 1},
+	)
+}
+
+func TestSpanProcessorDedupRetryAfterQuotaExceeded(t *testing.T) {
+	w := make(chanSpanWriter, 10)
+	quotaExceeded := true
+	p := NewSpanProcessor(w,
+		Options.QueueSize(10),
+		Options.Deduper(dedup.New(dedup.Options{Window: time.Minute}, metricstest.NewFactory(time.Hour))),
+		Options.SpanQuota(func(spans []*model.Span, tenant string) bool { return !quotaExceeded }),
+	).(*spanProcessor)
+	defer func() { assert.NoError(t, p.Close()) }()
+	newSpans := func() []*model.Span {
+		return []*model.Span{{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(1), Process: &model.Process{ServiceName: "x"}}}
+	}
+
+	_, err := p.ProcessSpans(newSpans(), processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	assert.Equal(t, processor.ErrQuotaExceeded, err)
+
+	quotaExceeded = false
+	res, err := p.ProcessSpans(newSpans(), processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, res)
+	assert.Equal(t, model.NewSpanID(1), (<-w).SpanID, "the retried span is saved")
+}
+
+func TestSpanProcessorQuotaExceeded(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
+
+	w := make(chanSpanWriter, 10)
+	var quotaSpans []*model.Span
+	var quotaTenant string
+	p := NewSpanProcessor(w,
+		Options.ServiceMetrics(serviceMetrics),
+		Options.QueueSize(10),
+		Options.SpanFilter(func(span *model.Span) bool { return span.OperationName != "filtered" }),
+		Options.SpanQuota(func(spans []*model.Span, tenant string) bool {
+			quotaSpans, quotaTenant = spans, tenant
+			for _, span := range spans {
+				if span.Process.ServiceName == "noisy" {
+					return false
+				}
+			}
+			return true
+		}),
+	).(*spanProcessor)
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	res, err := p.ProcessSpans([]*model.Span{
+		{OperationName: "filtered", Process: &model.Process{ServiceName: "noisy"}},
+		{Process: &model.Process{ServiceName: "noisy"}},
+		{Process: &model.Process{ServiceName: "quiet"}},
+	}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat, Tenant: "acme"})
+	assert.Equal(t, processor.ErrQuotaExceeded, err)
+	assert.Nil(t, res)
+	// filtered spans do not consume the quota
+	assert.Len(t, quotaSpans, 2)
+	assert.Equal(t, "acme", quotaTenant)
+
+	// the batch is rejected as a whole, so none of its spans are saved
+	res, err = p.ProcessSpans([]*model.Span{
+		{Process: &model.Process{ServiceName: "quiet"}},
+	}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true}, res)
+	assert.Equal(t, "quiet", (<-w).Process.ServiceName)
+	assert.Len(t, w, 0)
+
+	mb.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "service.spans.quota-exceeded-by-svc|debug=false|svc=noisy", Value: 1},
+		metricstest.ExpectedMetric{Name: "service.spans.quota-exceeded-by-svc|debug=false|svc=quiet", Value: 1},
+		metricstest.ExpectedMetric{Name: "service.spans.received|debug=false|format=jaeger|svc=quiet|transport=unknown", Value: 2},
+	)
+}
+
+type chanSpanWriter chan *model.Span
+
+func (w chanSpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	w <- span
+	return nil
+}
+
+func TestSpanProcessorWithSpanPipeline(t *testing.T) {
+	mb := metricstest.NewFactory(time.Hour)
+	serviceMetrics := mb.Namespace(metrics.NSOptions{Name: "service", Tags: nil})
+
+	w := make(chanSpanWriter, 1)
+	p := NewSpanProcessor(w,
+		Options.ServiceMetrics(serviceMetrics),
+		Options.QueueSize(10),
+		Options.SpanPipeline(func(span *model.Span) *model.Span {
+			if span.Process.ServiceName == "noisy" {
+				return nil
+			}
+			return &model.Span{Process: span.Process, OperationName: "processed"}
+		}),
+	).(*spanProcessor)
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	res, err := p.ProcessSpans([]*model.Span{
+		{Process: &model.Process{ServiceName: "noisy"}},
+		{Process: &model.Process{ServiceName: "quiet"}},
+	}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	require.NoError(t, err)
+	assert.Equal(t, []bool{true, true}, res)
+
+	saved := <-w
+	assert.Equal(t, "quiet", saved.Process.ServiceName)
+	assert.Equal(t, "processed", saved.OperationName)
+	mb.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "service.spans.rejected|debug=false|format=jaeger|svc=noisy|transport=unknown", Value: 1},
+		metricstest.ExpectedMetric{Name: "service.spans.received|debug=false|format=jaeger|svc=quiet|transport=unknown", Value: 1},
+	)
+}
+
+func TestSpanProcessorMemoryLimitExceeded(t *testing.T) {
+	w := &fakeSpanWriter{}
+	p := NewSpanProcessor(w, Options.MemoryLimiter(func() bool { return true })).(*spanProcessor)
+	defer func() { assert.NoError(t, p.Close()) }()
+
+	res, err := p.ProcessSpans([]*model.Span{
+		{Process: &model.Process{ServiceName: "x"}},
+	}, processor.SpansOptions{SpanFormat: processor.JaegerSpanFormat})
+	assert.Equal(t, processor.ErrMemoryLimitExceeded, err)
+	assert.Nil(t, res)
+	assert.Equal(t, 0, p.queue.Size())
+}
+
 func TestSpanProcessorCountSpan(t *testing.T) {
 	mb := metricstest.NewFactory(time.Hour)
 	m := mb.Namespace(metrics.NSOptions{})
@@ -388,7 +769,7 @@ func TestSpanProcessorCountSpan(t *testing.T) {
 	p := NewSpanProcessor(w, Options.HostMetrics(m), Options.DynQueueSizeMemory(1000)).(*spanProcessor)
 	p.background(10*time.Millisecond, p.updateGauges)
 
-	p.processSpan(&model.Span{})
+	p.processSpan(&model.Span{}, "")
 	assert.NotEqual(t, uint64(0), p.bytesProcessed)
 
 	for i := 0; i < 15; i++ {
@@ -524,3 +905,34 @@ func TestStartDynQueueSizeUpdater(t *testing.T) {
 
 	assert.EqualValues(t, 104857, p.queue.Capacity())
 }
+
+func TestSpanProcessorWithHostTags(t *testing.T) {
+	hostTags := func(process *model.Process) map[string]string {
+		for _, tag := range process.Tags {
+			if tag.Key == "ip" && tag.AsString() == "10.0.0.1" {
+				return map[string]string{"pod": "web-1", "zone": "us-east-1a"}
+			}
+		}
+		return nil
+	}
+	w := &fakeSpanWriter{}
+	p := NewSpanProcessor(w, Options.HostTags(hostTags)).(*spanProcessor)
+	defer assert.NoError(t, p.Close())
+
+	span := &model.Span{Process: model.NewProcess("svc", []model.KeyValue{model.String("ip", "10.0.0.1")})}
+	p.addHostTags(span)
+	// adding the same tags twice, e.g. to another span of the same process, does not duplicate them
+	p.addHostTags(span)
+	assert.Equal(t, model.NewProcess("svc", []model.KeyValue{
+		model.String("ip", "10.0.0.1"),
+		model.String("pod", "web-1"),
+		model.String("zone", "us-east-1a"),
+	}), span.Process)
+
+	unknown := &model.Span{Process: model.NewProcess("svc", []model.KeyValue{model.String("ip", "10.0.0.2")})}
+	p.addHostTags(unknown)
+	assert.Equal(t, model.NewProcess("svc", []model.KeyValue{model.String("ip", "10.0.0.2")}), unknown.Process)
+
+	// spans without process are left untouched
+	p.addHostTags(&model.Span{})
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceevents
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"path/filepath"
+	"time"
+)
+
+const (
+	defaultMaxRetries     = 3
+	defaultRetryBackoffMs = 1000
+	defaultTimeoutMs      = 5000
+)
+
+// Config is the JSON representation of the trace events file.
+type Config struct {
+	Rules    []*RuleConfig    `json:"rules"`
+	Webhooks []*WebhookConfig `json:"webhooks"`
+}
+
+// RuleConfig selects the completed traces reported to webhooks. A trace matches
+// when it satisfies every condition set in the rule; a rule without conditions matches every trace.
+type RuleConfig struct {
+	Name string `json:"name"`
+	// Error requires at least one span with the error tag.
+	Error bool `json:"error"`
+	// MinDurationMs requires the trace to last at least this many milliseconds.
+	MinDurationMs int64 `json:"min_duration_ms"`
+	// Services requires at least one span from one of these services.
+	Services []string `json:"services"`
+	// Webhooks are the names of the webhooks notified of matching traces.
+	Webhooks []string `json:"webhooks"`
+}
+
+// WebhookConfig describes where and how the summaries of matching traces are posted.
+type WebhookConfig struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	// Headers are added to the requests, e.g. for authentication.
+	Headers map[string]string `json:"headers"`
+	// RatePerSecond limits the number of requests per second; zero means unlimited.
+	RatePerSecond float64 `json:"rate_per_second"`
+	// Burst is the number of requests that can be sent at once, and defaults to RatePerSecond.
+	Burst float64 `json:"burst"`
+	// MaxRetries is the number of times a failed request is retried, 3 by default.
+	MaxRetries *int `json:"max_retries"`
+	// RetryBackoffMs is the delay before the first retry, doubled for every next retry, 1000 by default.
+	RetryBackoffMs int64 `json:"retry_backoff_ms"`
+	// TimeoutMs is the timeout of each request, 5000 by default.
+	TimeoutMs int64 `json:"timeout_ms"`
+}
+
+func loadConfig(path string) (*Config, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trace events file: %w", err)
+	}
+	var c Config
+	if err := json.Unmarshal(bytes, &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal trace events: %w", err)
+	}
+	if err := c.validate(); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+func (c *Config) validate() error {
+	webhooks := make(map[string]struct{}, len(c.Webhooks))
+	for _, w := range c.Webhooks {
+		if w.Name == "" {
+			return fmt.Errorf("webhook without name")
+		}
+		if _, ok := webhooks[w.Name]; ok {
+			return fmt.Errorf("duplicate webhook %s", w.Name)
+		}
+		webhooks[w.Name] = struct{}{}
+		if err := w.validate(); err != nil {
+			return fmt.Errorf("invalid webhook %s: %w", w.Name, err)
+		}
+	}
+	for _, r := range c.Rules {
+		if r.Name == "" {
+			return fmt.Errorf("rule without name")
+		}
+		if r.MinDurationMs < 0 {
+			return fmt.Errorf("invalid rule %s: min_duration_ms must not be negative", r.Name)
+		}
+		if len(r.Webhooks) == 0 {
+			return fmt.Errorf("invalid rule %s: no webhooks", r.Name)
+		}
+		for _, name := range r.Webhooks {
+			if _, ok := webhooks[name]; !ok {
+				return fmt.Errorf("invalid rule %s: unknown webhook %s", r.Name, name)
+			}
+		}
+	}
+	return nil
+}
+
+func (w *WebhookConfig) validate() error {
+	u, err := url.Parse(w.URL)
+	if err != nil {
+		return fmt.Errorf("invalid url: %w", err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("url must be http or https, got %q", w.URL)
+	}
+	if w.RatePerSecond < 0 {
+		return fmt.Errorf("rate_per_second must not be negative")
+	}
+	if w.Burst == 0 {
+		w.Burst = w.RatePerSecond
+	}
+	if w.RatePerSecond > 0 && w.Burst < 1 {
+		return fmt.Errorf("burst must be at least 1")
+	}
+	if w.MaxRetries == nil {
+		maxRetries := defaultMaxRetries
+		w.MaxRetries = &maxRetries
+	} else if *w.MaxRetries < 0 {
+		return fmt.Errorf("max_retries must not be negative")
+	}
+	if w.RetryBackoffMs <= 0 {
+		w.RetryBackoffMs = defaultRetryBackoffMs
+	}
+	if w.TimeoutMs <= 0 {
+		w.TimeoutMs = defaultTimeoutMs
+	}
+	return nil
+}
+
+func (w *WebhookConfig) retryBackoff() time.Duration {
+	return time.Duration(w.RetryBackoffMs) * time.Millisecond
+}
+
+func (w *WebhookConfig) timeout() time.Duration {
+	return time.Duration(w.TimeoutMs) * time.Millisecond
+}
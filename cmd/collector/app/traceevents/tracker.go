@@ -0,0 +1,291 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceevents
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	// DefaultIdleTimeout is the default time without new spans after which a trace is considered complete
+	DefaultIdleTimeout = 30 * time.Second
+	// DefaultMaxTraces is the default maximum number of traces tracked at once
+	DefaultMaxTraces = 100000
+)
+
+// Options configures the Tracker.
+type Options struct {
+	// IdleTimeout is the time without new spans after which a trace is considered complete
+	IdleTimeout time.Duration
+	// MaxTraces bounds the number of traces tracked at once; spans of new traces are ignored above it
+	MaxTraces int
+}
+
+// Summary describes a completed trace, as posted to the webhooks.
+type Summary struct {
+	Rule          string    `json:"rule"`
+	TraceID       string    `json:"trace_id"`
+	RootService   string    `json:"root_service,omitempty"`
+	RootOperation string    `json:"root_operation,omitempty"`
+	Services      []string  `json:"services"`
+	StartTime     time.Time `json:"start_time"`
+	DurationMs    int64     `json:"duration_ms"`
+	SpanCount     int       `json:"span_count"`
+	ErrorCount    int       `json:"error_count"`
+}
+
+type trackerMetrics struct {
+	// Completed is the number of traces considered complete
+	Completed metrics.Counter `metric:"traces-completed"`
+	// Dropped is the number of traces not tracked because MaxTraces was reached
+	Dropped metrics.Counter `metric:"traces-dropped"`
+	// Tracked is the number of traces currently tracked
+	Tracked metrics.Gauge `metric:"traces-tracked"`
+}
+
+// trace accumulates what the rules need to know about the spans of a trace.
+type trace struct {
+	traceID       model.TraceID
+	services      map[string]struct{}
+	start         time.Time
+	end           time.Time
+	spans         int
+	errors        int
+	rootService   string
+	rootOperation string
+	lastSeen      time.Time
+}
+
+type rule struct {
+	config   *RuleConfig
+	services map[string]struct{}
+	webhooks []*webhook
+	matched  metrics.Counter
+}
+
+// Tracker groups spans by trace, considers a trace complete when it received no span during the idle timeout,
+// and notifies the webhooks of the rules the completed trace matches.
+type Tracker struct {
+	options  Options
+	logger   *zap.Logger
+	metrics  trackerMetrics
+	rules    []*rule
+	webhooks []*webhook
+	now      func() time.Time
+
+	lock   sync.Mutex
+	traces map[model.TraceID]*trace
+
+	stopCh chan struct{}
+	wg     sync.WaitGroup
+}
+
+// New creates a Tracker with the rules and webhooks of the file at the given path, and starts checking for completed traces.
+func New(path string, options Options, logger *zap.Logger, metricsFactory metrics.Factory) (*Tracker, error) {
+	config, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	t := newTracker(config, options, logger, metricsFactory)
+	t.wg.Add(1)
+	go t.run()
+	logger.Info("Loaded trace events", zap.String("file", path), zap.Int("rules", len(config.Rules)), zap.Int("webhooks", len(config.Webhooks)))
+	return t, nil
+}
+
+func newTracker(config *Config, options Options, logger *zap.Logger, metricsFactory metrics.Factory) *Tracker {
+	if options.IdleTimeout <= 0 {
+		options.IdleTimeout = DefaultIdleTimeout
+	}
+	if options.MaxTraces <= 0 {
+		options.MaxTraces = DefaultMaxTraces
+	}
+	metricsFactory = metricsFactory.Namespace(metrics.NSOptions{Name: "trace_events"})
+	t := &Tracker{
+		options: options,
+		logger:  logger,
+		now:     time.Now,
+		traces:  make(map[model.TraceID]*trace),
+		stopCh:  make(chan struct{}),
+	}
+	metrics.MustInit(&t.metrics, metricsFactory, nil)
+	webhooks := make(map[string]*webhook, len(config.Webhooks))
+	for _, wc := range config.Webhooks {
+		w := newWebhook(wc, logger, metricsFactory)
+		webhooks[wc.Name] = w
+		t.webhooks = append(t.webhooks, w)
+	}
+	for _, rc := range config.Rules {
+		r := &rule{
+			config:   rc,
+			services: make(map[string]struct{}, len(rc.Services)),
+			matched: metricsFactory.Counter(metrics.Options{
+				Name: "rule-matches",
+				Tags: map[string]string{"rule": rc.Name},
+			}),
+		}
+		for _, service := range rc.Services {
+			r.services[service] = struct{}{}
+		}
+		for _, name := range rc.Webhooks {
+			r.webhooks = append(r.webhooks, webhooks[name])
+		}
+		t.rules = append(t.rules, r)
+	}
+	return t
+}
+
+// Add records the span in its trace. It is meant to be called for every span saved by the collector.
+func (t *Tracker) Add(span *model.Span) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	tr, ok := t.traces[span.TraceID]
+	if !ok {
+		if len(t.traces) >= t.options.MaxTraces {
+			t.metrics.Dropped.Inc(1)
+			return
+		}
+		tr = &trace{
+			traceID:  span.TraceID,
+			services: make(map[string]struct{}),
+			start:    span.StartTime,
+			end:      span.StartTime.Add(span.Duration),
+		}
+		t.traces[span.TraceID] = tr
+	}
+	tr.spans++
+	tr.lastSeen = t.now()
+	if span.StartTime.Before(tr.start) {
+		tr.start = span.StartTime
+	}
+	if end := span.StartTime.Add(span.Duration); end.After(tr.end) {
+		tr.end = end
+	}
+	if isError(span) {
+		tr.errors++
+	}
+	if span.Process != nil {
+		tr.services[span.Process.ServiceName] = struct{}{}
+		if span.ParentSpanID() == 0 {
+			tr.rootService = span.Process.ServiceName
+			tr.rootOperation = span.OperationName
+		}
+	}
+}
+
+func isError(span *model.Span) bool {
+	kv, ok := model.KeyValues(span.Tags).FindByKey("error")
+	if !ok {
+		return false
+	}
+	return kv.AsString() == "true"
+}
+
+func (t *Tracker) run() {
+	defer t.wg.Done()
+	ticker := time.NewTicker(t.options.IdleTimeout / 2)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.checkCompleted()
+		case <-t.stopCh:
+			return
+		}
+	}
+}
+
+// checkCompleted removes the traces idle for longer than the timeout and evaluates the rules against them.
+func (t *Tracker) checkCompleted() {
+	now := t.now()
+	var completed []*trace
+	t.lock.Lock()
+	for id, tr := range t.traces {
+		if now.Sub(tr.lastSeen) >= t.options.IdleTimeout {
+			completed = append(completed, tr)
+			delete(t.traces, id)
+		}
+	}
+	t.metrics.Tracked.Update(int64(len(t.traces)))
+	t.lock.Unlock()
+
+	t.metrics.Completed.Inc(int64(len(completed)))
+	for _, tr := range completed {
+		for _, r := range t.rules {
+			if !r.matches(tr) {
+				continue
+			}
+			r.matched.Inc(1)
+			summary := tr.summary(r.config.Name)
+			for _, w := range r.webhooks {
+				w.notify(summary)
+			}
+		}
+	}
+}
+
+func (r *rule) matches(tr *trace) bool {
+	if r.config.Error && tr.errors == 0 {
+		return false
+	}
+	if r.config.MinDurationMs > 0 && tr.end.Sub(tr.start) < time.Duration(r.config.MinDurationMs)*time.Millisecond {
+		return false
+	}
+	if len(r.services) > 0 {
+		for service := range tr.services {
+			if _, ok := r.services[service]; ok {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func (tr *trace) summary(ruleName string) *Summary {
+	services := make([]string, 0, len(tr.services))
+	for service := range tr.services {
+		services = append(services, service)
+	}
+	sort.Strings(services)
+	return &Summary{
+		Rule:          ruleName,
+		TraceID:       tr.traceID.String(),
+		RootService:   tr.rootService,
+		RootOperation: tr.rootOperation,
+		Services:      services,
+		StartTime:     tr.start,
+		DurationMs:    tr.end.Sub(tr.start).Milliseconds(),
+		SpanCount:     tr.spans,
+		ErrorCount:    tr.errors,
+	}
+}
+
+// Close stops checking for completed traces and posting to the webhooks. Traces not completed yet are not reported.
+func (t *Tracker) Close() error {
+	close(t.stopCh)
+	t.wg.Wait()
+	for _, w := range t.webhooks {
+		w.close()
+	}
+	return nil
+}
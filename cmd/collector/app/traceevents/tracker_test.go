@@ -0,0 +1,304 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceevents
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+type webhookServer struct {
+	*httptest.Server
+	lock      sync.Mutex
+	summaries []map[string]interface{}
+	headers   []http.Header
+	statuses  []int // returned in order, then 200
+}
+
+func newWebhookServer(t *testing.T, statuses ...int) *webhookServer {
+	s := &webhookServer{statuses: statuses}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.headers = append(s.headers, r.Header)
+		if len(s.statuses) > 0 {
+			status := s.statuses[0]
+			s.statuses = s.statuses[1:]
+			w.WriteHeader(status)
+			return
+		}
+		var summary map[string]interface{}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&summary))
+		s.summaries = append(s.summaries, summary)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *webhookServer) received() []map[string]interface{} {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]map[string]interface{}(nil), s.summaries...)
+}
+
+func newTestTracker(t *testing.T, config *Config, options Options) (*Tracker, *metricstest.Factory, *time.Time) {
+	require.NoError(t, config.validate())
+	mf := metricstest.NewFactory(time.Hour)
+	tracker := newTracker(config, options, zap.NewNop(), mf)
+	t.Cleanup(func() { tracker.Close() })
+	now := time.Unix(1000, 0)
+	tracker.now = func() time.Time { return now }
+	return tracker, mf, &now
+}
+
+func newSpan(traceID uint64, spanID uint64, parentID uint64, service string, start time.Time, duration time.Duration, tags ...model.KeyValue) *model.Span {
+	span := &model.Span{
+		TraceID:       model.NewTraceID(0, traceID),
+		SpanID:        model.NewSpanID(spanID),
+		OperationName: service + "-op",
+		Process:       model.NewProcess(service, nil),
+		StartTime:     start,
+		Duration:      duration,
+		Tags:          tags,
+	}
+	if parentID != 0 {
+		span.References = []model.SpanRef{model.NewChildOfRef(span.TraceID, model.NewSpanID(parentID))}
+	}
+	return span
+}
+
+func TestTrackerRules(t *testing.T) {
+	alerts := newWebhookServer(t)
+	slow := newWebhookServer(t)
+	tracker, mf, now := newTestTracker(t, &Config{
+		Webhooks: []*WebhookConfig{
+			{Name: "alerts", URL: alerts.URL, Headers: map[string]string{"Authorization": "Bearer token"}},
+			{Name: "slow", URL: slow.URL},
+		},
+		Rules: []*RuleConfig{
+			{Name: "errors", Error: true, Webhooks: []string{"alerts"}},
+			{Name: "slow-payments", MinDurationMs: 1000, Services: []string{"payment"}, Webhooks: []string{"slow", "alerts"}},
+		},
+	}, Options{IdleTimeout: time.Minute})
+
+	start := time.Unix(500, 0).UTC()
+	// trace 1 has an error
+	tracker.Add(newSpan(1, 1, 0, "frontend", start, 100*time.Millisecond))
+	tracker.Add(newSpan(1, 2, 1, "backend", start.Add(10*time.Millisecond), 50*time.Millisecond, model.Bool("error", true)))
+	// trace 2 is slow and involves the payment service
+	tracker.Add(newSpan(2, 1, 0, "frontend", start, 2*time.Second))
+	tracker.Add(newSpan(2, 2, 1, "payment", start, time.Second))
+	// trace 3 matches no rule
+	tracker.Add(newSpan(3, 1, 0, "frontend", start, 2*time.Second))
+
+	// traces are not complete before the idle timeout
+	*now = now.Add(59 * time.Second)
+	tracker.checkCompleted()
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "trace_events.traces-tracked", Value: 3})
+
+	// a new span delays the completion of its trace
+	tracker.Add(newSpan(3, 2, 1, "backend", start, time.Millisecond))
+	*now = now.Add(time.Second)
+	tracker.checkCompleted()
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "trace_events.traces-tracked", Value: 1})
+
+	assert.Eventually(t, func() bool {
+		return len(alerts.received()) == 2 && len(slow.received()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	byRule := map[string]map[string]interface{}{}
+	for _, summary := range alerts.received() {
+		byRule[summary["rule"].(string)] = summary
+	}
+	assert.Equal(t, map[string]interface{}{
+		"rule":           "errors",
+		"trace_id":       "0000000000000001",
+		"root_service":   "frontend",
+		"root_operation": "frontend-op",
+		"services":       []interface{}{"backend", "frontend"},
+		"start_time":     start.Format(time.RFC3339),
+		"duration_ms":    float64(100),
+		"span_count":     float64(2),
+		"error_count":    float64(1),
+	}, byRule["errors"])
+	assert.Equal(t, "slow-payments", byRule["slow-payments"]["rule"])
+	assert.Equal(t, slow.received()[0], byRule["slow-payments"])
+	assert.Equal(t, "Bearer token", alerts.headers[0].Get("Authorization"))
+	assert.Equal(t, "application/json", alerts.headers[0].Get("Content-Type"))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "trace_events.traces-completed", Value: 2},
+		metricstest.ExpectedMetric{Name: "trace_events.rule-matches", Tags: map[string]string{"rule": "errors"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "trace_events.rule-matches", Tags: map[string]string{"rule": "slow-payments"}, Value: 1},
+	)
+}
+
+func TestTrackerMaxTraces(t *testing.T) {
+	tracker, mf, _ := newTestTracker(t, &Config{}, Options{MaxTraces: 1})
+	tracker.Add(newSpan(1, 1, 0, "svc", time.Now(), time.Second))
+	tracker.Add(newSpan(1, 2, 1, "svc", time.Now(), time.Second))
+	tracker.Add(newSpan(2, 1, 0, "svc", time.Now(), time.Second))
+	assert.Len(t, tracker.traces, 1)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "trace_events.traces-dropped", Value: 1})
+	assert.Equal(t, DefaultIdleTimeout, tracker.options.IdleTimeout)
+}
+
+func TestWebhookRetries(t *testing.T) {
+	server := newWebhookServer(t, http.StatusServiceUnavailable, http.StatusTooManyRequests)
+	maxRetries := 2
+	tracker, mf, now := newTestTracker(t, &Config{
+		Webhooks: []*WebhookConfig{{Name: "hook", URL: server.URL, MaxRetries: &maxRetries, RetryBackoffMs: 1}},
+		Rules:    []*RuleConfig{{Name: "all", Webhooks: []string{"hook"}}},
+	}, Options{IdleTimeout: time.Second})
+
+	tracker.Add(newSpan(1, 1, 0, "svc", time.Now(), time.Second))
+	*now = now.Add(time.Second)
+	tracker.checkCompleted()
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "trace_events.webhook-retries", Tags: map[string]string{"webhook": "hook"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "trace_events.webhook-requests", Tags: map[string]string{"webhook": "hook", "result": "ok"}, Value: 1},
+	)
+}
+
+func TestWebhookFailures(t *testing.T) {
+	badRequest := newWebhookServer(t, http.StatusBadRequest)
+	maxRetries := 1
+	unavailable := newWebhookServer(t, http.StatusBadGateway, http.StatusBadGateway)
+	tracker, mf, now := newTestTracker(t, &Config{
+		Webhooks: []*WebhookConfig{
+			{Name: "bad-request", URL: badRequest.URL, RetryBackoffMs: 1},
+			{Name: "unavailable", URL: unavailable.URL, MaxRetries: &maxRetries, RetryBackoffMs: 1},
+		},
+		Rules: []*RuleConfig{{Name: "all", Webhooks: []string{"bad-request", "unavailable"}}},
+	}, Options{IdleTimeout: time.Second})
+
+	tracker.Add(newSpan(1, 1, 0, "svc", time.Now(), time.Second))
+	*now = now.Add(time.Second)
+	tracker.checkCompleted()
+
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["trace_events.webhook-requests|result=err|webhook=bad-request"] == 1 &&
+			counters["trace_events.webhook-requests|result=err|webhook=unavailable"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	mf.AssertCounterMetrics(t,
+		// client errors are not retried
+		metricstest.ExpectedMetric{Name: "trace_events.webhook-retries", Tags: map[string]string{"webhook": "bad-request"}, Value: 0},
+		metricstest.ExpectedMetric{Name: "trace_events.webhook-retries", Tags: map[string]string{"webhook": "unavailable"}, Value: 1},
+	)
+}
+
+func TestWebhookRateLimit(t *testing.T) {
+	server := newWebhookServer(t)
+	tracker, mf, now := newTestTracker(t, &Config{
+		Webhooks: []*WebhookConfig{{Name: "hook", URL: server.URL, RatePerSecond: 0.001, Burst: 2}},
+		Rules:    []*RuleConfig{{Name: "all", Webhooks: []string{"hook"}}},
+	}, Options{IdleTimeout: time.Second})
+
+	for i := uint64(1); i <= 5; i++ {
+		tracker.Add(newSpan(i, 1, 0, "svc", time.Now(), time.Second))
+	}
+	*now = now.Add(time.Second)
+	tracker.checkCompleted()
+
+	assert.Eventually(t, func() bool {
+		return len(server.received()) == 2
+	}, 5*time.Second, 10*time.Millisecond)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "trace_events.webhook-requests", Tags: map[string]string{"webhook": "hook", "result": "rate_limited"}, Value: 3},
+	)
+}
+
+func TestNew(t *testing.T) {
+	dir, err := ioutil.TempDir("", "traceevents")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "events.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{
+		"webhooks": [{"name": "hook", "url": "http://localhost:1/hook"}],
+		"rules": [{"name": "errors", "error": true, "webhooks": ["hook"]}]
+	}`), 0600))
+
+	tracker, err := New(path, Options{IdleTimeout: time.Millisecond}, zap.NewNop(), metricstest.NewFactory(time.Hour))
+	require.NoError(t, err)
+	tracker.Add(newSpan(1, 1, 0, "svc", time.Now(), time.Second))
+	// the trace is completed by the background check
+	assert.Eventually(t, func() bool {
+		tracker.lock.Lock()
+		defer tracker.lock.Unlock()
+		return len(tracker.traces) == 0
+	}, 5*time.Second, time.Millisecond)
+	assert.NoError(t, tracker.Close())
+
+	_, err = New(filepath.Join(dir, "missing.json"), Options{}, zap.NewNop(), metricstest.NewFactory(time.Hour))
+	assert.Contains(t, err.Error(), "failed to read trace events file")
+}
+
+func TestConfigValidation(t *testing.T) {
+	negative := -1
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{content: `{`, err: "failed to unmarshal trace events"},
+		{content: `{"webhooks": [{"url": "http://x"}]}`, err: "webhook without name"},
+		{content: `{"webhooks": [{"name": "a", "url": "http://x"}, {"name": "a", "url": "http://x"}]}`, err: "duplicate webhook a"},
+		{content: `{"webhooks": [{"name": "a", "url": "ftp://x"}]}`, err: "invalid webhook a: url must be http or https"},
+		{content: `{"webhooks": [{"name": "a", "url": "http://x", "rate_per_second": -1}]}`, err: "rate_per_second must not be negative"},
+		{content: `{"webhooks": [{"name": "a", "url": "http://x", "rate_per_second": 1, "burst": 0.5}]}`, err: "burst must be at least 1"},
+		{content: `{"webhooks": [{"name": "a", "url": "http://x", "max_retries": -1}]}`, err: "max_retries must not be negative"},
+		{content: `{"rules": [{"webhooks": ["a"]}]}`, err: "rule without name"},
+		{content: `{"rules": [{"name": "r", "min_duration_ms": -1, "webhooks": ["a"]}]}`, err: "min_duration_ms must not be negative"},
+		{content: `{"rules": [{"name": "r"}]}`, err: "invalid rule r: no webhooks"},
+		{content: `{"rules": [{"name": "r", "webhooks": ["a"]}]}`, err: "invalid rule r: unknown webhook a"},
+	}
+	dir, err := ioutil.TempDir("", "traceevents")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	for _, test := range tests {
+		t.Run(test.err, func(t *testing.T) {
+			path := filepath.Join(dir, "events.json")
+			require.NoError(t, ioutil.WriteFile(path, []byte(test.content), 0600))
+			_, err := loadConfig(path)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	w := &WebhookConfig{Name: "a", URL: "https://x", RatePerSecond: 2}
+	require.NoError(t, w.validate())
+	assert.Equal(t, 2.0, w.Burst)
+	assert.Equal(t, defaultMaxRetries, *w.MaxRetries)
+	assert.Equal(t, time.Second, w.retryBackoff())
+	assert.Equal(t, 5*time.Second, w.timeout())
+	assert.Error(t, (&WebhookConfig{URL: "https://x", MaxRetries: &negative}).validate())
+}
@@ -0,0 +1,159 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package traceevents
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"github.com/uber/jaeger-lib/utils"
+	"go.uber.org/zap"
+)
+
+// webhookQueueSize is the number of summaries waiting to be posted to a webhook, above which new summaries are dropped
+const webhookQueueSize = 1000
+
+type webhookMetrics struct {
+	// Sent is the number of summaries posted successfully
+	Sent metrics.Counter `metric:"webhook-requests" tags:"result=ok"`
+	// Failed is the number of summaries that could not be posted after all retries
+	Failed metrics.Counter `metric:"webhook-requests" tags:"result=err"`
+	// RateLimited is the number of summaries dropped because of the rate limit
+	RateLimited metrics.Counter `metric:"webhook-requests" tags:"result=rate_limited"`
+	// QueueFull is the number of summaries dropped because too many were waiting to be posted
+	QueueFull metrics.Counter `metric:"webhook-requests" tags:"result=queue_full"`
+	// Retries is the number of retried requests
+	Retries metrics.Counter `metric:"webhook-retries"`
+}
+
+// webhook posts summaries to a URL from a single goroutine, with retries and an optional rate limit.
+type webhook struct {
+	config      *WebhookConfig
+	client      *http.Client
+	logger      *zap.Logger
+	metrics     webhookMetrics
+	rateLimiter utils.RateLimiter
+	queue       chan *Summary
+	stopCh      chan struct{}
+	wg          sync.WaitGroup
+}
+
+func newWebhook(config *WebhookConfig, logger *zap.Logger, metricsFactory metrics.Factory) *webhook {
+	w := &webhook{
+		config: config,
+		client: &http.Client{Timeout: config.timeout()},
+		logger: logger.With(zap.String("webhook", config.Name)),
+		queue:  make(chan *Summary, webhookQueueSize),
+		stopCh: make(chan struct{}),
+	}
+	metrics.MustInit(&w.metrics, metricsFactory.Namespace(metrics.NSOptions{Tags: map[string]string{"webhook": config.Name}}), nil)
+	if config.RatePerSecond > 0 {
+		w.rateLimiter = utils.NewRateLimiter(config.RatePerSecond, config.Burst)
+	}
+	w.wg.Add(1)
+	go w.run()
+	return w
+}
+
+// notify queues the summary to be posted, unless the rate limit is exceeded or the queue is full.
+func (w *webhook) notify(summary *Summary) {
+	if w.rateLimiter != nil && !w.rateLimiter.CheckCredit(1) {
+		w.metrics.RateLimited.Inc(1)
+		return
+	}
+	select {
+	case w.queue <- summary:
+	default:
+		w.metrics.QueueFull.Inc(1)
+	}
+}
+
+func (w *webhook) run() {
+	defer w.wg.Done()
+	for {
+		select {
+		case summary := <-w.queue:
+			w.post(summary)
+		case <-w.stopCh:
+			return
+		}
+	}
+}
+
+// post sends the summary, retrying with an exponential backoff on network errors, 429 and 5xx responses.
+func (w *webhook) post(summary *Summary) {
+	body, err := json.Marshal(summary)
+	if err != nil {
+		w.logger.Error("Failed to marshal trace summary", zap.Error(err))
+		w.metrics.Failed.Inc(1)
+		return
+	}
+	backoff := w.config.retryBackoff()
+	for attempt := 0; ; attempt++ {
+		retryable, err := w.send(body)
+		if err == nil {
+			w.metrics.Sent.Inc(1)
+			return
+		}
+		if !retryable || attempt >= *w.config.MaxRetries {
+			w.logger.Error("Failed to post trace summary", zap.String("trace-id", summary.TraceID), zap.Int("attempts", attempt+1), zap.Error(err))
+			w.metrics.Failed.Inc(1)
+			return
+		}
+		w.metrics.Retries.Inc(1)
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-w.stopCh:
+			w.metrics.Failed.Inc(1)
+			return
+		}
+	}
+}
+
+func (w *webhook) send(body []byte) (retryable bool, err error) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.config.timeout())
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.config.Headers {
+		req.Header.Set(k, v)
+	}
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	err = fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	return resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500, err
+}
+
+// close stops posting summaries; queued summaries are lost.
+func (w *webhook) close() {
+	close(w.stopCh)
+	w.wg.Wait()
+}
@@ -16,14 +16,11 @@
 package zipkin
 
 import (
-	"compress/gzip"
+	"context"
 	"fmt"
 	"html"
-	"io"
-	"io/ioutil"
 	"mime"
 	"net/http"
-	"strings"
 
 	"github.com/go-openapi/loads"
 	"github.com/go-openapi/strfmt"
@@ -34,6 +31,8 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/collector/app/handler"
 	"github.com/jaegertracing/jaeger/cmd/collector/app/processor"
 	"github.com/jaegertracing/jaeger/model/converter/thrift/zipkin"
+	"github.com/jaegertracing/jaeger/pkg/httpbody"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	zipkinProto "github.com/jaegertracing/jaeger/proto-gen/zipkin"
 	"github.com/jaegertracing/jaeger/swagger-gen/models"
 	"github.com/jaegertracing/jaeger/swagger-gen/restapi"
@@ -45,16 +44,19 @@ import (
 type APIHandler struct {
 	zipkinSpansHandler handler.ZipkinSpansHandler
 	zipkinV2Formats    strfmt.Registry
+	maxBodySize        int64
 }
 
-// NewAPIHandler returns a new APIHandler
+// NewAPIHandler returns a new APIHandler, which rejects request bodies bigger than maxBodySize bytes once decompressed
 func NewAPIHandler(
 	zipkinSpansHandler handler.ZipkinSpansHandler,
+	maxBodySize int64,
 ) *APIHandler {
 	swaggerSpec, _ := loads.Analyzed(restapi.SwaggerJSON, "")
 	return &APIHandler{
 		zipkinSpansHandler: zipkinSpansHandler,
 		zipkinV2Formats:    operations.NewZipkinAPI(swaggerSpec).Formats(),
+		maxBodySize:        maxBodySize,
 	}
 }
 
@@ -65,21 +67,9 @@ func (aH *APIHandler) RegisterRoutes(router *mux.Router) {
 }
 
 func (aH *APIHandler) saveSpans(w http.ResponseWriter, r *http.Request) {
-	bRead := r.Body
-	defer r.Body.Close()
-	if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
-		gz, err := gunzip(bRead)
-		if err != nil {
-			http.Error(w, fmt.Sprintf(handler.UnableToReadBodyErrFormat, err), http.StatusBadRequest)
-			return
-		}
-		defer gz.Close()
-		bRead = gz
-	}
-
-	bodyBytes, err := ioutil.ReadAll(bRead)
+	bodyBytes, err := httpbody.Read(r, aH.maxBodySize)
 	if err != nil {
-		http.Error(w, fmt.Sprintf(handler.UnableToReadBodyErrFormat, err), http.StatusInternalServerError)
+		httpbody.WriteError(w, err)
 		return
 	}
 
@@ -102,12 +92,12 @@ func (aH *APIHandler) saveSpans(w http.ResponseWriter, r *http.Request) {
 	}
 	if err != nil {
 		safeErr := html.EscapeString(err.Error())
-		http.Error(w, fmt.Sprintf(handler.UnableToReadBodyErrFormat, safeErr), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf(httpbody.UnableToReadErrFormat, safeErr), http.StatusBadRequest)
 		return
 	}
 
-	if err := aH.saveThriftSpans(tSpans); err != nil {
-		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), http.StatusInternalServerError)
+	if err := aH.saveThriftSpans(r.Context(), tSpans); err != nil {
+		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), handler.SubmitErrorStatusCode(err))
 		return
 	}
 
@@ -115,21 +105,9 @@ func (aH *APIHandler) saveSpans(w http.ResponseWriter, r *http.Request) {
 }
 
 func (aH *APIHandler) saveSpansV2(w http.ResponseWriter, r *http.Request) {
-	bRead := r.Body
-	defer r.Body.Close()
-	if strings.Contains(r.Header.Get("Content-Encoding"), "gzip") {
-		gz, err := gunzip(bRead)
-		if err != nil {
-			http.Error(w, fmt.Sprintf(handler.UnableToReadBodyErrFormat, err), http.StatusBadRequest)
-			return
-		}
-		defer gz.Close()
-		bRead = gz
-	}
-
-	bodyBytes, err := ioutil.ReadAll(bRead)
+	bodyBytes, err := httpbody.Read(r, aH.maxBodySize)
 	if err != nil {
-		http.Error(w, fmt.Sprintf(handler.UnableToReadBodyErrFormat, err), http.StatusInternalServerError)
+		httpbody.WriteError(w, err)
 		return
 	}
 
@@ -152,12 +130,12 @@ func (aH *APIHandler) saveSpansV2(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err != nil {
-		http.Error(w, fmt.Sprintf(handler.UnableToReadBodyErrFormat, err), http.StatusBadRequest)
+		http.Error(w, fmt.Sprintf(httpbody.UnableToReadErrFormat, err), http.StatusBadRequest)
 		return
 	}
 
-	if err = aH.saveThriftSpans(tSpans); err != nil {
-		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), http.StatusInternalServerError)
+	if err = aH.saveThriftSpans(r.Context(), tSpans); err != nil {
+		http.Error(w, fmt.Sprintf("Cannot submit Zipkin batch: %v", err), handler.SubmitErrorStatusCode(err))
 		return
 	}
 
@@ -193,17 +171,12 @@ func protoToThriftSpansV2(bodyBytes []byte) ([]*zipkincore.Span, error) {
 	return tSpans, nil
 }
 
-func gunzip(r io.ReadCloser) (*gzip.Reader, error) {
-	gz, err := gzip.NewReader(r)
-	if err != nil {
-		return nil, err
-	}
-	return gz, nil
-}
-
-func (aH *APIHandler) saveThriftSpans(tSpans []*zipkincore.Span) error {
+func (aH *APIHandler) saveThriftSpans(ctx context.Context, tSpans []*zipkincore.Span) error {
 	if len(tSpans) > 0 {
-		opts := handler.SubmitBatchOptions{InboundTransport: processor.HTTPTransport}
+		opts := handler.SubmitBatchOptions{
+			InboundTransport: processor.HTTPTransport,
+			Tenant:           tenancy.GetTenant(ctx),
+		}
 		if _, err := aH.zipkinSpansHandler.SubmitZipkinBatch(tSpans, opts); err != nil {
 			return err
 		}
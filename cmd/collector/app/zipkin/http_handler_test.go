@@ -29,6 +29,7 @@ import (
 
 	"github.com/golang/protobuf/proto"
 	"github.com/gorilla/mux"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	jaegerClient "github.com/uber/jaeger-client-go"
@@ -63,7 +64,7 @@ func (p *mockZipkinHandler) getSpans() []*zipkincore.Span {
 
 func initializeTestServer(err error) (*httptest.Server, *APIHandler) {
 	r := mux.NewRouter()
-	handler := NewAPIHandler(&mockZipkinHandler{err: err})
+	handler := NewAPIHandler(&mockZipkinHandler{err: err}, 0)
 	handler.RegisterRoutes(r)
 	return httptest.NewServer(r), handler
 }
@@ -202,6 +203,35 @@ func TestGzipBadBody(t *testing.T) {
 	assert.EqualValues(t, "Unable to process request body: unexpected EOF\n", resBodyStr)
 }
 
+func TestZstdEncodingV2(t *testing.T) {
+	server, _ := initializeTestServer(nil)
+	defer server.Close()
+	header := createHeader("application/json")
+	header.Add("Content-Encoding", "zstd")
+	zw, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	body := zw.EncodeAll([]byte("[]"), nil)
+	statusCode, resBodyStr, err := postBytes(server.URL+`/api/v2/spans`, body, header)
+	assert.NoError(t, err)
+	assert.EqualValues(t, http.StatusAccepted, statusCode)
+	assert.EqualValues(t, "", resBodyStr)
+}
+
+func TestDecompressedBodyTooLarge(t *testing.T) {
+	r := mux.NewRouter()
+	NewAPIHandler(&mockZipkinHandler{}, 10).RegisterRoutes(r)
+	server := httptest.NewServer(r)
+	defer server.Close()
+	header := createHeader("application/json")
+	header.Add("Content-Encoding", "gzip")
+	for _, path := range []string{`/api/v1/spans`, `/api/v2/spans`} {
+		statusCode, resBodyStr, err := postBytes(server.URL+path, gzipEncode(bytes.Repeat([]byte(" "), 1000)), header)
+		assert.NoError(t, err)
+		assert.EqualValues(t, http.StatusRequestEntityTooLarge, statusCode)
+		assert.EqualValues(t, "Unable to process request body: request body too large\n", resBodyStr)
+	}
+}
+
 func TestMalformedContentType(t *testing.T) {
 	server, _ := initializeTestServer(nil)
 	defer server.Close()
@@ -229,7 +259,7 @@ func TestFormatBadBody(t *testing.T) {
 }
 
 func TestCannotReadBodyFromRequest(t *testing.T) {
-	handler := NewAPIHandler(&mockZipkinHandler{})
+	handler := NewAPIHandler(&mockZipkinHandler{}, 0)
 	req, err := http.NewRequest(http.MethodPost, "whatever", &errReader{})
 	assert.NoError(t, err)
 	rw := dummyResponseWriter{}
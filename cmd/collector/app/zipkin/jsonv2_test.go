@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	zipkinTrift "github.com/jaegertracing/jaeger/model/converter/thrift/zipkin"
 	"github.com/jaegertracing/jaeger/swagger-gen/models"
 	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
 )
@@ -67,6 +68,22 @@ func TestLCFromLocalEndpoint(t *testing.T) {
 	assert.Equal(t, tSpan, tSpans[0])
 }
 
+func TestFixturesMatchSharedConverter(t *testing.T) {
+	for _, fileName := range []string{"fixtures/zipkin_01.json", "fixtures/zipkin_02.json"} {
+		t.Run(fileName, func(t *testing.T) {
+			var spans models.ListOfSpans
+			loadJSON(t, fileName, &spans)
+			expected, err := spansV2ToThrift(spans)
+			require.NoError(t, err)
+			b, err := ioutil.ReadFile(fileName)
+			require.NoError(t, err)
+			actual, err := zipkinTrift.DeserializeJSONV2(b)
+			require.NoError(t, err)
+			assert.Equal(t, expected, actual)
+		})
+	}
+}
+
 func TestKindToThrift(t *testing.T) {
 	tests := []struct {
 		ts       int64
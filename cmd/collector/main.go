@@ -35,6 +35,7 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/flags"
 	"github.com/jaegertracing/jaeger/cmd/status"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/version"
 	ss "github.com/jaegertracing/jaeger/plugin/sampling/strategystore"
 	"github.com/jaegertracing/jaeger/plugin/storage"
@@ -78,6 +79,7 @@ func main() {
 			if err != nil {
 				logger.Fatal("Failed to create span writer", zap.Error(err))
 			}
+			svc.Admin.Handle("/downsampling", storageFactory.DownsamplingHandler())
 
 			strategyStoreFactory.InitFromViper(v)
 			if err := strategyStoreFactory.Initialize(metricsFactory, logger); err != nil {
@@ -132,6 +134,7 @@ func main() {
 		app.AddFlags,
 		storageFactory.AddPipelineFlags,
 		strategyStoreFactory.AddFlags,
+		tenancy.AddFlags,
 	)
 
 	if err := command.Execute(); err != nil {
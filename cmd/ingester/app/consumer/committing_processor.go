@@ -49,3 +49,18 @@ func (d *comittingProcessor) Process(message processor.Message) error {
 	}
 	return errors.New("committing processor used with non-kafka message")
 }
+
+func (d *comittingProcessor) ProcessBatch(messages []processor.Message) error {
+	for _, message := range messages {
+		if _, ok := message.(Message); !ok {
+			return errors.New("committing processor used with non-kafka message")
+		}
+	}
+	err := processor.ProcessBatch(d.processor, messages)
+	if err == nil {
+		for _, message := range messages {
+			d.marker.MarkOffset(message.(Message).Offset())
+		}
+	}
+	return err
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	kafka "github.com/jaegertracing/jaeger/cmd/ingester/app/consumer/mocks"
+	"github.com/jaegertracing/jaeger/cmd/ingester/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/ingester/app/processor/mocks"
 )
 
@@ -73,3 +74,39 @@ func TestNewCommittingProcessorErrorNoKafkaMessage(t *testing.T) {
 
 	assert.Error(t, committingProcessor.Process(fakeProcessorMessage{}))
 }
+
+type fakeOffsetsMarker struct {
+	offsets []int64
+}
+
+func (f *fakeOffsetsMarker) MarkOffset(o int64) {
+	f.offsets = append(f.offsets, o)
+}
+
+func TestCommittingProcessorProcessBatch(t *testing.T) {
+	offsetMarker := &fakeOffsetsMarker{}
+	spanProcessor := &mocks.SpanProcessor{}
+	spanProcessor.On("Process", mock.Anything).Return(nil)
+	committingProcessor := NewCommittingProcessor(spanProcessor, offsetMarker).(processor.BatchSpanProcessor)
+
+	msg1 := &kafka.Message{}
+	msg1.On("Offset").Return(int64(1))
+	msg2 := &kafka.Message{}
+	msg2.On("Offset").Return(int64(2))
+
+	assert.NoError(t, committingProcessor.ProcessBatch([]processor.Message{msg1, msg2}))
+	assert.Equal(t, []int64{1, 2}, offsetMarker.offsets)
+}
+
+func TestCommittingProcessorProcessBatchError(t *testing.T) {
+	offsetMarker := &fakeOffsetsMarker{}
+	spanProcessor := &mocks.SpanProcessor{}
+	spanProcessor.On("Process", mock.Anything).Return(errors.New("boop"))
+	committingProcessor := NewCommittingProcessor(spanProcessor, offsetMarker).(processor.BatchSpanProcessor)
+
+	assert.Error(t, committingProcessor.ProcessBatch([]processor.Message{&kafka.Message{}}))
+	assert.Empty(t, offsetMarker.offsets)
+
+	assert.Error(t, committingProcessor.ProcessBatch([]processor.Message{fakeProcessorMessage{}}))
+	spanProcessor.AssertNumberOfCalls(t, "Process", 1)
+}
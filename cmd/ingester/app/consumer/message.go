@@ -16,6 +16,8 @@ package consumer
 
 import (
 	"github.com/Shopify/sarama"
+
+	"github.com/jaegertracing/jaeger/plugin/storage/kafka"
 )
 
 // Message contains the parts of a sarama ConsumerMessage that we care about.
@@ -50,3 +52,13 @@ func (m saramaMessageWrapper) Partition() int32 {
 func (m saramaMessageWrapper) Offset() int64 {
 	return m.ConsumerMessage.Offset
 }
+
+// Tenant returns the tenant from the header of the message, or an empty string if there is none.
+func (m saramaMessageWrapper) Tenant() string {
+	for _, header := range m.ConsumerMessage.Headers {
+		if header != nil && string(header.Key) == kafka.TenantHeader {
+			return string(header.Value)
+		}
+	}
+	return ""
+}
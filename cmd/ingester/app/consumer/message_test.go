@@ -19,6 +19,8 @@ import (
 
 	"github.com/Shopify/sarama"
 	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/plugin/storage/kafka"
 )
 
 func TestSaramaMessageWrapper(t *testing.T) {
@@ -38,3 +40,16 @@ func TestSaramaMessageWrapper(t *testing.T) {
 	assert.Equal(t, saramaMessage.Partition, wrappedMessage.Partition())
 	assert.Equal(t, saramaMessage.Offset, wrappedMessage.Offset())
 }
+
+func TestSaramaMessageWrapperTenant(t *testing.T) {
+	wrappedMessage := saramaMessageWrapper{&sarama.ConsumerMessage{}}
+	assert.Equal(t, "", wrappedMessage.Tenant())
+
+	wrappedMessage = saramaMessageWrapper{&sarama.ConsumerMessage{
+		Headers: []*sarama.RecordHeader{
+			{Key: []byte("other"), Value: []byte("value")},
+			{Key: []byte(kafka.TenantHeader), Value: []byte("acme")},
+		},
+	}}
+	assert.Equal(t, "acme", wrappedMessage.Tenant())
+}
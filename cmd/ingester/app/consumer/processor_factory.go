@@ -29,6 +29,7 @@ import (
 // ProcessorFactoryParams are the parameters of a ProcessorFactory
 type ProcessorFactoryParams struct {
 	Parallelism    int
+	BatchSize      int
 	Topic          string
 	BaseProcessor  processor.SpanProcessor
 	SaramaConsumer consumer.Consumer
@@ -45,6 +46,7 @@ type ProcessorFactory struct {
 	logger         *zap.Logger
 	baseProcessor  processor.SpanProcessor
 	parallelism    int
+	batchSize      int
 	retryOptions   []decorator.RetryOption
 }
 
@@ -57,6 +59,7 @@ func NewProcessorFactory(params ProcessorFactoryParams) (*ProcessorFactory, erro
 		logger:         params.Logger,
 		baseProcessor:  params.BaseProcessor,
 		parallelism:    params.Parallelism,
+		batchSize:      params.BatchSize,
 		retryOptions:   params.RetryOptions,
 	}, nil
 }
@@ -73,7 +76,12 @@ func (c *ProcessorFactory) new(partition int32, minOffset int64) processor.SpanP
 	retryProcessor := decorator.NewRetryingProcessor(c.metricsFactory, c.baseProcessor, c.retryOptions...)
 	cp := NewCommittingProcessor(retryProcessor, om)
 	spanProcessor := processor.NewDecoratedProcessor(c.metricsFactory, cp)
-	pp := processor.NewParallelProcessor(spanProcessor, c.parallelism, c.logger)
+	var pp *processor.ParallelProcessor
+	if c.batchSize > 1 {
+		pp = processor.NewBatchParallelProcessor(spanProcessor.(processor.BatchSpanProcessor), c.parallelism, c.batchSize, c.logger)
+	} else {
+		pp = processor.NewParallelProcessor(spanProcessor, c.parallelism, c.logger)
+	}
 
 	return newStartedProcessor(pp, om)
 }
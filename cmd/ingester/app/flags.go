@@ -23,6 +23,7 @@ import (
 
 	"github.com/spf13/viper"
 
+	"github.com/jaegertracing/jaeger/pkg/dedup"
 	"github.com/jaegertracing/jaeger/pkg/kafka/auth"
 	kafkaConsumer "github.com/jaegertracing/jaeger/pkg/kafka/consumer"
 	"github.com/jaegertracing/jaeger/plugin/storage/kafka"
@@ -49,6 +50,12 @@ const (
 	SuffixDeadlockInterval = ".deadlockInterval"
 	// SuffixParallelism is a suffix for the parallelism flag
 	SuffixParallelism = ".parallelism"
+	// SuffixBatchSize is a suffix for the batch size flag
+	SuffixBatchSize = ".batch-size"
+	// SuffixDedupWindow is a suffix for the duplicate spans detection window flag
+	SuffixDedupWindow = ".dedup.window"
+	// SuffixDedupMaxKeys is a suffix for the flag bounding the keys remembered to detect duplicate spans
+	SuffixDedupMaxKeys = ".dedup.max-keys"
 	// SuffixHTTPPort is a suffix for the HTTP port
 	SuffixHTTPPort = ".http-port"
 	// DefaultBroker is the default kafka broker
@@ -61,6 +68,8 @@ const (
 	DefaultClientID = "jaeger-ingester"
 	// DefaultParallelism is the default parallelism for the span processor
 	DefaultParallelism = 1000
+	// DefaultBatchSize is the default maximum number of messages written to storage at once
+	DefaultBatchSize = 1
 	// DefaultEncoding is the default span encoding
 	DefaultEncoding = kafka.EncodingProto
 	// DefaultDeadlockInterval is the default deadlock interval
@@ -71,8 +80,10 @@ const (
 type Options struct {
 	kafkaConsumer.Configuration `mapstructure:",squash"`
 	Parallelism                 int           `mapstructure:"parallelism"`
+	BatchSize                   int           `mapstructure:"batch_size"`
 	Encoding                    string        `mapstructure:"encoding"`
 	DeadlockInterval            time.Duration `mapstructure:"deadlock_interval"`
+	Dedup                       dedup.Options `mapstructure:"dedup"`
 }
 
 // AddFlags adds flags for Builder
@@ -81,10 +92,22 @@ func AddFlags(flagSet *flag.FlagSet) {
 		ConfigPrefix+SuffixParallelism,
 		strconv.Itoa(DefaultParallelism),
 		"The number of messages to process in parallel")
+	flagSet.Int(
+		ConfigPrefix+SuffixBatchSize,
+		DefaultBatchSize,
+		"The maximum number of queued messages written to storage at once when the span writer supports batches. Value of 1 disables batching.")
 	flagSet.Duration(
 		ConfigPrefix+SuffixDeadlockInterval,
 		DefaultDeadlockInterval,
 		"Interval to check for deadlocks. If no messages gets processed in given time, ingester app will exit. Value of 0 disables deadlock check.")
+	flagSet.Duration(
+		ConfigPrefix+SuffixDedupWindow,
+		0,
+		"The minimum time the (trace ID, span ID, start time) keys of consumed spans are remembered to drop the spans consumed again, e.g. after producer retries. Value of 0 disables duplicate detection.")
+	flagSet.Int(
+		ConfigPrefix+SuffixDedupMaxKeys,
+		dedup.DefaultMaxKeys,
+		"The maximum number of span keys remembered to detect duplicate spans; the oldest keys are forgotten early when it is reached.")
 
 	// Authentication flags
 	flagSet.String(
@@ -106,7 +129,7 @@ func AddFlags(flagSet *flag.FlagSet) {
 	flagSet.String(
 		KafkaConsumerConfigPrefix+SuffixProtocolVersion,
 		"",
-		"Kafka protocol version - must be supported by kafka server")
+		"Kafka protocol version - must be supported by kafka server. The tenants of the spans are only kept with version 0.11.0.0 or above")
 	flagSet.String(
 		KafkaConsumerConfigPrefix+SuffixEncoding,
 		DefaultEncoding,
@@ -125,7 +148,12 @@ func (o *Options) InitFromViper(v *viper.Viper) {
 	o.Encoding = v.GetString(KafkaConsumerConfigPrefix + SuffixEncoding)
 
 	o.Parallelism = v.GetInt(ConfigPrefix + SuffixParallelism)
+	o.BatchSize = v.GetInt(ConfigPrefix + SuffixBatchSize)
 	o.DeadlockInterval = v.GetDuration(ConfigPrefix + SuffixDeadlockInterval)
+	o.Dedup = dedup.Options{
+		Window:  v.GetDuration(ConfigPrefix + SuffixDedupWindow),
+		MaxKeys: v.GetInt(ConfigPrefix + SuffixDedupMaxKeys),
+	}
 	authenticationOptions := auth.AuthenticationConfig{}
 	authenticationOptions.InitFromViper(KafkaConsumerConfigPrefix, v)
 	o.AuthenticationConfig = authenticationOptions
@@ -24,6 +24,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/pkg/config"
 	"github.com/jaegertracing/jaeger/pkg/config/tlscfg"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
 	"github.com/jaegertracing/jaeger/pkg/kafka/auth"
 	"github.com/jaegertracing/jaeger/plugin/storage/kafka"
 )
@@ -39,7 +40,10 @@ func TestOptionsWithFlags(t *testing.T) {
 		"--kafka.consumer.encoding=json",
 		"--kafka.consumer.protocol-version=1.0.0",
 		"--ingester.parallelism=5",
+		"--ingester.batch-size=50",
 		"--ingester.deadlockInterval=2m",
+		"--ingester.dedup.window=5m",
+		"--ingester.dedup.max-keys=1000",
 	})
 	o.InitFromViper(v)
 
@@ -49,7 +53,9 @@ func TestOptionsWithFlags(t *testing.T) {
 	assert.Equal(t, "client-id1", o.ClientID)
 	assert.Equal(t, "1.0.0", o.ProtocolVersion)
 	assert.Equal(t, 5, o.Parallelism)
+	assert.Equal(t, 50, o.BatchSize)
 	assert.Equal(t, 2*time.Minute, o.DeadlockInterval)
+	assert.Equal(t, dedup.Options{Window: 5 * time.Minute, MaxKeys: 1000}, o.Dedup)
 	assert.Equal(t, kafka.EncodingJSON, o.Encoding)
 }
 
@@ -105,6 +111,9 @@ func TestFlagDefaults(t *testing.T) {
 	assert.Equal(t, DefaultGroupID, o.GroupID)
 	assert.Equal(t, DefaultClientID, o.ClientID)
 	assert.Equal(t, DefaultParallelism, o.Parallelism)
+	assert.Equal(t, DefaultBatchSize, o.BatchSize)
 	assert.Equal(t, DefaultEncoding, o.Encoding)
 	assert.Equal(t, DefaultDeadlockInterval, o.DeadlockInterval)
+	assert.Equal(t, dedup.Options{MaxKeys: dedup.DefaultMaxKeys}, o.Dedup)
+	assert.False(t, o.Dedup.Enabled())
 }
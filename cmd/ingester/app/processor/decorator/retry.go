@@ -129,6 +129,29 @@ func (d *retryDecorator) Process(message processor.Message) error {
 	return nil
 }
 
+func (d *retryDecorator) ProcessBatch(messages []processor.Message) error {
+	err := processor.ProcessBatch(d.processor, messages)
+
+	if err == nil {
+		return nil
+	}
+
+	for attempts := uint(0); err != nil && d.options.maxAttempts > attempts; attempts++ {
+		time.Sleep(d.computeInterval(attempts))
+		err = processor.ProcessBatch(d.processor, messages)
+		d.retryAttempts.Inc(1)
+	}
+
+	if err != nil {
+		d.exhausted.Inc(1)
+		if d.options.propagateError {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (d *retryDecorator) computeInterval(attempts uint) time.Duration {
 	dur := (1 << attempts) * d.options.minInterval.Nanoseconds()
 	if dur <= 0 || dur > d.options.maxInterval.Nanoseconds() {
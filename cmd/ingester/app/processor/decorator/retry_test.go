@@ -23,6 +23,7 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 
+	"github.com/jaegertracing/jaeger/cmd/ingester/app/processor"
 	"github.com/jaegertracing/jaeger/cmd/ingester/app/processor/mocks"
 )
 
@@ -88,6 +89,28 @@ func TestNewRetryingProcessorNoErrorPropagation(t *testing.T) {
 	assert.Equal(t, int64(1), c["span-processor.retry-attempts"])
 }
 
+func TestNewRetryingProcessorProcessBatch(t *testing.T) {
+	mockProcessor := &mocks.SpanProcessor{}
+	msg := &fakeMsg{}
+	mockProcessor.On("Process", msg).Return(errors.New("retry")).Once()
+	mockProcessor.On("Process", msg).Return(nil)
+	opts := []RetryOption{
+		MinBackoffInterval(0),
+		MaxBackoffInterval(time.Second),
+		MaxAttempts(2),
+		PropagateError(true),
+		Rand(&fakeRand{})}
+	lf := metricstest.NewFactory(0)
+	rp := NewRetryingProcessor(lf, mockProcessor, opts...).(processor.BatchSpanProcessor)
+
+	assert.NoError(t, rp.ProcessBatch([]processor.Message{msg, msg}))
+
+	mockProcessor.AssertNumberOfCalls(t, "Process", 4)
+	c, _ := lf.Snapshot()
+	assert.Equal(t, int64(0), c["span-processor.retry-exhausted"])
+	assert.Equal(t, int64(1), c["span-processor.retry-attempts"])
+}
+
 type fakeRand struct{}
 
 func (f *fakeRand) Int63n(v int64) int64 {
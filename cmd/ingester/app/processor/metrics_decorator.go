@@ -48,3 +48,14 @@ func (d *metricsDecorator) Process(message Message) error {
 	}
 	return err
 }
+
+func (d *metricsDecorator) ProcessBatch(messages []Message) error {
+	now := time.Now()
+
+	err := ProcessBatch(d.processor, messages)
+	d.latency.Record(time.Since(now))
+	if err != nil {
+		d.errors.Inc(1)
+	}
+	return err
+}
@@ -57,3 +57,17 @@ func TestProcessErr(t *testing.T) {
 	assert.Contains(t, g, "span-processor.latency.P90")
 	assert.Equal(t, int64(1), c["span-processor.errors"])
 }
+
+func TestProcessBatchErr(t *testing.T) {
+	p := &mocks.SpanProcessor{}
+	msg := fakeMsg{}
+	p.On("Process", msg).Return(errors.New("err"))
+	m := metricstest.NewFactory(0)
+	proc := processor.NewDecoratedProcessor(m, p).(processor.BatchSpanProcessor)
+
+	assert.Error(t, proc.ProcessBatch([]processor.Message{msg, msg}))
+	p.AssertNumberOfCalls(t, "Process", 2)
+	c, g := m.Snapshot()
+	assert.Contains(t, g, "span-processor.latency.P90")
+	assert.Equal(t, int64(1), c["span-processor.errors"])
+}
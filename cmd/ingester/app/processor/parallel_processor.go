@@ -25,6 +25,7 @@ type ParallelProcessor struct {
 	messages    chan Message
 	processor   SpanProcessor
 	numRoutines int
+	batchSize   int
 
 	logger *zap.Logger
 	closed chan struct{}
@@ -45,6 +46,19 @@ func NewParallelProcessor(
 	}
 }
 
+// NewBatchParallelProcessor creates a new parallel processor where each goroutine
+// processes up to batchSize queued messages at once
+func NewBatchParallelProcessor(
+	processor BatchSpanProcessor,
+	parallelism int,
+	batchSize int,
+	logger *zap.Logger) *ParallelProcessor {
+	pp := NewParallelProcessor(processor, parallelism, logger)
+	pp.messages = make(chan Message, batchSize)
+	pp.batchSize = batchSize
+	return pp
+}
+
 // Start begins processing queued messages
 func (k *ParallelProcessor) Start() {
 	k.logger.Debug("Spawning goroutines to process messages", zap.Int("num_routines", k.numRoutines))
@@ -54,7 +68,11 @@ func (k *ParallelProcessor) Start() {
 			for {
 				select {
 				case msg := <-k.messages:
-					k.processor.Process(msg)
+					if k.batchSize > 1 {
+						ProcessBatch(k.processor, k.takeWaiting(msg))
+					} else {
+						k.processor.Process(msg)
+					}
 				case <-k.closed:
 					k.wg.Done()
 					return
@@ -64,6 +82,20 @@ func (k *ParallelProcessor) Start() {
 	}
 }
 
+// takeWaiting returns msg followed by the messages already queued, up to batchSize, without blocking
+func (k *ParallelProcessor) takeWaiting(msg Message) []Message {
+	batch := []Message{msg}
+	for len(batch) < k.batchSize {
+		select {
+		case next := <-k.messages:
+			batch = append(batch, next)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
 // Process queues a message for processing
 func (k *ParallelProcessor) Process(message Message) error {
 	k.messages <- message
@@ -15,9 +15,12 @@
 package processor_test
 
 import (
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/cmd/ingester/app/processor"
@@ -44,3 +47,44 @@ func TestNewParallelProcessor(t *testing.T) {
 
 	mp.AssertExpectations(t)
 }
+
+type batchProcessor struct {
+	mockProcessor.SpanProcessor
+	mux     sync.Mutex
+	batches [][]processor.Message
+}
+
+func (p *batchProcessor) ProcessBatch(messages []processor.Message) error {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	p.batches = append(p.batches, messages)
+	return nil
+}
+
+func (p *batchProcessor) count() int {
+	p.mux.Lock()
+	defer p.mux.Unlock()
+	total := 0
+	for _, batch := range p.batches {
+		total += len(batch)
+	}
+	return total
+}
+
+func TestNewBatchParallelProcessor(t *testing.T) {
+	bp := &batchProcessor{}
+
+	pp := processor.NewBatchParallelProcessor(bp, 1, 10, zap.NewNop())
+	pp.Start()
+
+	for i := 0; i < 5; i++ {
+		pp.Process(&fakeMessage{})
+	}
+	for i := 0; i < 100 && bp.count() < 5; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	pp.Close()
+
+	assert.Equal(t, 5, bp.count())
+	bp.AssertNotCalled(t, "Process", mock.Anything)
+}
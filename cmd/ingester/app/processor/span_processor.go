@@ -19,6 +19,10 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
+	"github.com/jaegertracing/jaeger/pkg/multierror"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/plugin/storage/kafka"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
@@ -31,21 +35,59 @@ type SpanProcessor interface {
 	io.Closer
 }
 
+// BatchSpanProcessor is a SpanProcessor that can also process several messages at once
+type BatchSpanProcessor interface {
+	SpanProcessor
+	ProcessBatch(messages []Message) error
+}
+
+// ProcessBatch processes the messages with a single call if the processor is a BatchSpanProcessor,
+// otherwise it processes them one by one and returns the combined errors.
+func ProcessBatch(processor SpanProcessor, messages []Message) error {
+	if batchProcessor, ok := processor.(BatchSpanProcessor); ok {
+		return batchProcessor.ProcessBatch(messages)
+	}
+	var errors []error
+	for _, message := range messages {
+		if err := processor.Process(message); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return multierror.Wrap(errors)
+}
+
 // Message contains the fields of the kafka message that the span processor uses
 type Message interface {
 	Value() []byte
 }
 
+// TenantMessage is a Message that also carries the tenant of its span
+type TenantMessage interface {
+	Message
+	Tenant() string
+}
+
+// messageTenant returns the tenant of the message, or an empty string if it does not carry any
+func messageTenant(message Message) string {
+	if tenantMessage, ok := message.(TenantMessage); ok {
+		return tenantMessage.Tenant()
+	}
+	return ""
+}
+
 // SpanProcessorParams stores the necessary parameters for a SpanProcessor
 type SpanProcessorParams struct {
 	Writer       spanstore.Writer
 	Unmarshaller kafka.Unmarshaller
+	// Deduper drops the spans already consumed recently, if not nil
+	Deduper *dedup.Deduper
 }
 
 // KafkaSpanProcessor implements SpanProcessor for Kafka messages
 type KafkaSpanProcessor struct {
 	unmarshaller kafka.Unmarshaller
 	writer       spanstore.Writer
+	deduper      *dedup.Deduper
 	io.Closer
 }
 
@@ -54,6 +96,7 @@ func NewSpanProcessor(params SpanProcessorParams) *KafkaSpanProcessor {
 	return &KafkaSpanProcessor{
 		unmarshaller: params.Unmarshaller,
 		writer:       params.Writer,
+		deduper:      params.Deduper,
 	}
 }
 
@@ -63,6 +106,59 @@ func (s KafkaSpanProcessor) Process(message Message) error {
 	if err != nil {
 		return fmt.Errorf("cannot unmarshall byte array into span: %w", err)
 	}
+	if s.isDuplicate(span) {
+		return nil
+	}
 	// TODO context should be propagated from upstream components
-	return s.writer.WriteSpan(context.TODO(), span)
+	if err := s.writer.WriteSpan(tenancy.WithTenant(context.TODO(), messageTenant(message)), span); err != nil {
+		s.forget(span)
+		return err
+	}
+	return nil
+}
+
+// ProcessBatch unmarshals the kafka messages and writes the spans of each tenant with a single call
+// if the writer implements spanstore.BatchWriter
+func (s KafkaSpanProcessor) ProcessBatch(messages []Message) error {
+	var errors []error
+	var tenants []string
+	spansByTenant := make(map[string][]*model.Span)
+	for _, message := range messages {
+		span, err := s.unmarshaller.Unmarshal(message.Value())
+		if err != nil {
+			errors = append(errors, fmt.Errorf("cannot unmarshall byte array into span: %w", err))
+			continue
+		}
+		if s.isDuplicate(span) {
+			continue
+		}
+		tenant := messageTenant(message)
+		if _, ok := spansByTenant[tenant]; !ok {
+			tenants = append(tenants, tenant)
+		}
+		spansByTenant[tenant] = append(spansByTenant[tenant], span)
+	}
+	for _, tenant := range tenants {
+		// TODO context should be propagated from upstream components
+		ctx := tenancy.WithTenant(context.TODO(), tenant)
+		if err := spanstore.WriteSpans(ctx, s.writer, spansByTenant[tenant]); err != nil {
+			// the spans are consumed again when the batch is retried, so none of them may be dropped as duplicates
+			for _, span := range spansByTenant[tenant] {
+				s.forget(span)
+			}
+			errors = append(errors, err)
+		}
+	}
+	return multierror.Wrap(errors)
+}
+
+func (s KafkaSpanProcessor) isDuplicate(span *model.Span) bool {
+	return s.deduper != nil && s.deduper.IsDuplicate(span)
+}
+
+// forget removes a span that could not be written from the deduper, so that it is not dropped when retried
+func (s KafkaSpanProcessor) forget(span *model.Span) {
+	if s.deduper != nil {
+		s.deduper.Forget(span)
+	}
 }
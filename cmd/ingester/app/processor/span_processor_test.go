@@ -18,12 +18,17 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
 
 	cmocks "github.com/jaegertracing/jaeger/cmd/ingester/app/consumer/mocks"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/dedup"
 	umocks "github.com/jaegertracing/jaeger/pkg/kafka/mocks"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	smocks "github.com/jaegertracing/jaeger/storage/spanstore/mocks"
 )
 
@@ -73,3 +78,157 @@ func TestSpanProcessor_ProcessError(t *testing.T) {
 	message.AssertExpectations(t)
 	writer.AssertNotCalled(t, "WriteSpan")
 }
+
+func TestSpanProcessor_ProcessBatch(t *testing.T) {
+	writer := &smocks.Writer{}
+	unmarshallerMock := &umocks.Unmarshaller{}
+	processor := &KafkaSpanProcessor{
+		unmarshaller: unmarshallerMock,
+		writer:       writer,
+	}
+
+	good := &cmocks.Message{}
+	good.On("Value").Return([]byte("good"))
+	bad := &cmocks.Message{}
+	bad.On("Value").Return([]byte("bad"))
+	span := &model.Span{}
+
+	unmarshallerMock.On("Unmarshal", []byte("good")).Return(span, nil)
+	unmarshallerMock.On("Unmarshal", []byte("bad")).Return(nil, errors.New("moocow"))
+	writer.On("WriteSpan", mock.Anything, span).Return(nil)
+
+	err := processor.ProcessBatch([]Message{good, bad})
+	assert.EqualError(t, err, "cannot unmarshall byte array into span: moocow")
+
+	writer.AssertNumberOfCalls(t, "WriteSpan", 1)
+}
+
+// tenantMessage is a Message carrying a tenant
+type tenantMessage struct {
+	*cmocks.Message
+	tenant string
+}
+
+func (m tenantMessage) Tenant() string {
+	return m.tenant
+}
+
+func TestSpanProcessor_Tenant(t *testing.T) {
+	writer := &smocks.Writer{}
+	unmarshallerMock := &umocks.Unmarshaller{}
+	processor := NewSpanProcessor(SpanProcessorParams{
+		Writer:       writer,
+		Unmarshaller: unmarshallerMock,
+	})
+
+	newMessage := func(value, tenant string) Message {
+		message := &cmocks.Message{}
+		message.On("Value").Return([]byte(value))
+		return tenantMessage{Message: message, tenant: tenant}
+	}
+	acmeSpan := &model.Span{SpanID: model.NewSpanID(1)}
+	globexSpan := &model.Span{SpanID: model.NewSpanID(2)}
+	unmarshallerMock.On("Unmarshal", []byte("acme")).Return(acmeSpan, nil)
+	unmarshallerMock.On("Unmarshal", []byte("globex")).Return(globexSpan, nil)
+	var tenants []string
+	writer.On("WriteSpan", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		tenants = append(tenants, tenancy.GetTenant(args.Get(0).(context.Context)))
+	}).Return(nil)
+
+	assert.NoError(t, processor.Process(newMessage("acme", "acme")))
+	assert.NoError(t, processor.ProcessBatch([]Message{
+		newMessage("globex", "globex"),
+		newMessage("acme", "acme"),
+		newMessage("globex", "globex"),
+	}))
+
+	assert.Equal(t, []string{"acme", "globex", "globex", "acme"}, tenants)
+}
+
+func TestSpanProcessor_Dedup(t *testing.T) {
+	writer := &smocks.Writer{}
+	unmarshallerMock := &umocks.Unmarshaller{}
+	mf := metricstest.NewFactory(time.Hour)
+	processor := NewSpanProcessor(SpanProcessorParams{
+		Writer:       writer,
+		Unmarshaller: unmarshallerMock,
+		Deduper:      dedup.New(dedup.Options{Window: time.Minute}, mf),
+	})
+
+	first := &cmocks.Message{}
+	first.On("Value").Return([]byte("first"))
+	retry := &cmocks.Message{}
+	retry.On("Value").Return([]byte("retry"))
+	other := &cmocks.Message{}
+	other.On("Value").Return([]byte("other"))
+	span := &model.Span{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(1)}
+	otherSpan := &model.Span{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(2)}
+
+	unmarshallerMock.On("Unmarshal", []byte("first")).Return(span, nil)
+	unmarshallerMock.On("Unmarshal", []byte("retry")).Return(&model.Span{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(1)}, nil)
+	unmarshallerMock.On("Unmarshal", []byte("other")).Return(otherSpan, nil)
+	writer.On("WriteSpan", mock.Anything, mock.Anything).Return(nil)
+
+	assert.NoError(t, processor.Process(first))
+	assert.NoError(t, processor.Process(retry))
+	assert.NoError(t, processor.ProcessBatch([]Message{retry, other, other}))
+
+	writer.AssertNumberOfCalls(t, "WriteSpan", 2)
+	writer.AssertCalled(t, "WriteSpan", mock.Anything, span)
+	writer.AssertCalled(t, "WriteSpan", mock.Anything, otherSpan)
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "dedup.spans", Tags: map[string]string{"result": "unique"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "dedup.spans", Tags: map[string]string{"result": "duplicate"}, Value: 3},
+	)
+}
+
+func TestSpanProcessor_DedupRetryAfterFailedWrite(t *testing.T) {
+	writer := &smocks.Writer{}
+	unmarshallerMock := &umocks.Unmarshaller{}
+	processor := NewSpanProcessor(SpanProcessorParams{
+		Writer:       writer,
+		Unmarshaller: unmarshallerMock,
+		Deduper:      dedup.New(dedup.Options{Window: time.Minute}, metricstest.NewFactory(time.Hour)),
+	})
+
+	message := &cmocks.Message{}
+	message.On("Value").Return([]byte("span"))
+	span := &model.Span{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(1)}
+	unmarshallerMock.On("Unmarshal", []byte("span")).Return(span, nil)
+	writer.On("WriteSpan", mock.Anything, span).Return(errors.New("write failed")).Once()
+	writer.On("WriteSpan", mock.Anything, span).Return(nil).Once()
+
+	assert.EqualError(t, processor.Process(message), "write failed")
+	assert.NoError(t, processor.Process(message), "the retried span is written")
+	writer.AssertNumberOfCalls(t, "WriteSpan", 2)
+
+	writer.On("WriteSpan", mock.Anything, mock.Anything).Return(errors.New("write failed")).Once()
+	writer.On("WriteSpan", mock.Anything, mock.Anything).Return(nil).Once()
+	other := &cmocks.Message{}
+	other.On("Value").Return([]byte("other"))
+	unmarshallerMock.On("Unmarshal", []byte("other")).Return(&model.Span{TraceID: model.NewTraceID(0, 1), SpanID: model.NewSpanID(2)}, nil)
+
+	assert.EqualError(t, processor.ProcessBatch([]Message{other}), "write failed")
+	assert.NoError(t, processor.ProcessBatch([]Message{other}), "the retried batch is written")
+	writer.AssertNumberOfCalls(t, "WriteSpan", 4)
+}
+
+func TestProcessBatchFallback(t *testing.T) {
+	writer := &smocks.Writer{}
+	unmarshallerMock := &umocks.Unmarshaller{}
+	processor := struct{ SpanProcessor }{
+		SpanProcessor: &KafkaSpanProcessor{
+			unmarshaller: unmarshallerMock,
+			writer:       writer,
+		},
+	}
+
+	message := &cmocks.Message{}
+	message.On("Value").Return([]byte("police"))
+	span := &model.Span{}
+	unmarshallerMock.On("Unmarshal", []byte("police")).Return(span, nil)
+	writer.On("WriteSpan", mock.Anything, span).Return(errors.New("boop"))
+
+	assert.EqualError(t, ProcessBatch(processor, []Message{message, message}), "[boop, boop]")
+	writer.AssertNumberOfCalls(t, "WriteSpan", 2)
+}
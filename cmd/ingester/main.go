@@ -67,6 +67,7 @@ func main() {
 			if err != nil {
 				logger.Fatal("Failed to create span writer", zap.Error(err))
 			}
+			svc.Admin.Handle("/downsampling", storageFactory.DownsamplingHandler())
 
 			options := app.Options{}
 			options.InitFromViper(v)
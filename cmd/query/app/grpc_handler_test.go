@@ -30,10 +30,12 @@ import (
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
 
 	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 	depsmocks "github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
@@ -498,3 +500,40 @@ func TestSendSpanChunksError(t *testing.T) {
 	})
 	assert.EqualError(t, err, expectedErr.Error())
 }
+
+func TestTenancyGRPC(t *testing.T) {
+	spanReader := &spanstoremocks.Reader{}
+	q := querysvc.NewQueryService(spanReader, &depsmocks.Reader{}, querysvc.QueryServiceOptions{})
+	server, err := createGRPCServer(q, &QueryOptions{Tenancy: tenancy.Options{Enabled: true}}, zap.NewNop(), opentracing.NoopTracer{})
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", ":0")
+	require.NoError(t, err)
+	go server.Serve(lis)
+	defer server.Stop()
+	client := newGRPCClient(t, lis.Addr().String())
+	defer client.conn.Close()
+
+	isAcme := mock.MatchedBy(func(ctx context.Context) bool {
+		return tenancy.GetTenant(ctx) == "acme"
+	})
+	spanReader.On("GetServices", isAcme).Return([]string{"trifle"}, nil).Once()
+	spanReader.On("GetTrace", isAcme, mock.AnythingOfType("model.TraceID")).Return(mockTraceGRPC, nil).Once()
+
+	_, err = client.GetServices(context.Background(), &api_v2.GetServicesRequest{})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+	stream, err := client.GetTrace(context.Background(), &api_v2.GetTraceRequest{TraceID: mockTraceID})
+	require.NoError(t, err)
+	_, err = stream.Recv()
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), tenancy.DefaultHeader, "acme")
+	res, err := client.GetServices(ctx, &api_v2.GetServicesRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"trifle"}, res.Services)
+	stream, err = client.GetTrace(ctx, &api_v2.GetTraceRequest{TraceID: mockTraceID})
+	require.NoError(t, err)
+	chunk, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Len(t, chunk.Spans, len(mockTraceGRPC.Spans))
+	spanReader.AssertExpectations(t)
+}
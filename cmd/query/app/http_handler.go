@@ -34,6 +34,7 @@ import (
 	uiconv "github.com/jaegertracing/jaeger/model/converter/json"
 	ui "github.com/jaegertracing/jaeger/model/json"
 	"github.com/jaegertracing/jaeger/pkg/multierror"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -79,6 +80,7 @@ type APIHandler struct {
 	apiPrefix    string
 	logger       *zap.Logger
 	tracer       opentracing.Tracer
+	tenancy      tenancy.Options
 }
 
 // NewAPIHandler returns an APIHandler
@@ -132,7 +134,7 @@ func (aH *APIHandler) handleFunc(
 		nethttp.OperationNameFunc(func(r *http.Request) string {
 			return route
 		}))
-	return router.HandleFunc(route, traceMiddleware.ServeHTTP)
+	return router.Handle(route, tenancy.ExtractTenantHTTPHandler(aH.tenancy, traceMiddleware))
 }
 
 func (aH *APIHandler) route(route string, args ...interface{}) string {
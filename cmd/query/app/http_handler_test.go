@@ -17,6 +17,7 @@ package app
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -40,6 +41,7 @@ import (
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/model/adjuster"
 	ui "github.com/jaegertracing/jaeger/model/json"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	depsmocks "github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 	spanstoremocks "github.com/jaegertracing/jaeger/storage/spanstore/mocks"
@@ -470,6 +472,25 @@ func TestGetServicesSuccess(t *testing.T) {
 	assert.Equal(t, expectedServices, actualServices)
 }
 
+func TestGetServicesTenancy(t *testing.T) {
+	server, readMock, _ := initializeTestServer(HandlerOptions.Tenancy(tenancy.Options{Enabled: true}))
+	defer server.Close()
+	readMock.On("GetServices", mock.MatchedBy(func(ctx context.Context) bool {
+		return tenancy.GetTenant(ctx) == "acme"
+	})).Return([]string{"trifle"}, nil).Once()
+
+	var response structuredResponse
+	err := getJSON(server.URL+"/api/services", &response)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401 error from server: missing tenant header")
+
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/api/services", nil)
+	require.NoError(t, err)
+	req.Header.Set(tenancy.DefaultHeader, "acme")
+	require.NoError(t, execJSON(req, &response))
+	assert.Equal(t, []interface{}{"trifle"}, response.Data)
+}
+
 func TestGetServicesStorageFailure(t *testing.T) {
 	server, readMock, _ := initializeTestServer()
 	defer server.Close()
@@ -31,6 +31,7 @@ import (
 	"github.com/jaegertracing/jaeger/pkg/healthcheck"
 	"github.com/jaegertracing/jaeger/pkg/netutils"
 	"github.com/jaegertracing/jaeger/pkg/recoveryhandler"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/proto-gen/api_v2"
 )
 
@@ -107,6 +108,12 @@ func createGRPCServer(querySvc *querysvc.QueryService, options *QueryOptions, lo
 
 		grpcOpts = append(grpcOpts, grpc.Creds(creds))
 	}
+	if options.Tenancy.Enabled {
+		grpcOpts = append(grpcOpts,
+			grpc.UnaryInterceptor(tenancy.NewGuardingUnaryInterceptor(options.Tenancy)),
+			grpc.StreamInterceptor(tenancy.NewGuardingStreamInterceptor(options.Tenancy)),
+		)
+	}
 
 	server := grpc.NewServer(grpcOpts...)
 
@@ -119,6 +126,7 @@ func createHTTPServer(querySvc *querysvc.QueryService, queryOpts *QueryOptions,
 	apiHandlerOptions := []HandlerOption{
 		HandlerOptions.Logger(logger),
 		HandlerOptions.Tracer(tracer),
+		HandlerOptions.Tenancy(queryOpts.Tenancy),
 	}
 
 	apiHandler := NewAPIHandler(
@@ -36,6 +36,7 @@ import (
 	"github.com/jaegertracing/jaeger/cmd/query/app/querysvc"
 	"github.com/jaegertracing/jaeger/cmd/status"
 	"github.com/jaegertracing/jaeger/pkg/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/version"
 	"github.com/jaegertracing/jaeger/plugin/storage"
 	"github.com/jaegertracing/jaeger/ports"
@@ -143,6 +144,7 @@ func main() {
 		svc.AddFlags,
 		storageFactory.AddFlags,
 		app.AddFlags,
+		tenancy.AddFlags,
 	)
 
 	if error := command.Execute(); error != nil {
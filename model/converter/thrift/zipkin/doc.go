@@ -13,5 +13,6 @@
 // See the License for the specific language governing permissions and
 // limitations under the License.
 
-// Package zipkin allows converting model.Trace to/from zipkin.thrift model.
+// Package zipkin allows converting model.Trace to/from zipkin.thrift model,
+// and Zipkin JSON v2 spans to zipkin.thrift model.
 package zipkin
@@ -0,0 +1,264 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+var (
+	errWrongIpv4 = errors.New("wrong ipv4")
+	errWrongIpv6 = errors.New("wrong ipv6")
+)
+
+// spanV2 is a span in the Zipkin JSON v2 format, see https://zipkin.io/zipkin-api/zipkin2-api.yaml.
+type spanV2 struct {
+	TraceID        string            `json:"traceId"`
+	ID             string            `json:"id"`
+	ParentID       string            `json:"parentId"`
+	Name           string            `json:"name"`
+	Kind           string            `json:"kind"`
+	Timestamp      int64             `json:"timestamp"`
+	Duration       int64             `json:"duration"`
+	Debug          bool              `json:"debug"`
+	LocalEndpoint  *endpointV2       `json:"localEndpoint"`
+	RemoteEndpoint *endpointV2       `json:"remoteEndpoint"`
+	Annotations    []annotationV2    `json:"annotations"`
+	Tags           map[string]string `json:"tags"`
+}
+
+type endpointV2 struct {
+	ServiceName string `json:"serviceName"`
+	IPv4        string `json:"ipv4"`
+	IPv6        string `json:"ipv6"`
+	Port        int32  `json:"port"`
+}
+
+type annotationV2 struct {
+	Timestamp int64  `json:"timestamp"`
+	Value     string `json:"value"`
+}
+
+// DeserializeJSONV2 decodes a list of Zipkin JSON v2 spans and converts them to Zipkin Thrift spans.
+func DeserializeJSONV2(b []byte) ([]*zipkincore.Span, error) {
+	var spans []*spanV2
+	if err := json.Unmarshal(b, &spans); err != nil {
+		return nil, err
+	}
+	tSpans := make([]*zipkincore.Span, 0, len(spans))
+	for i, span := range spans {
+		if span == nil {
+			return nil, fmt.Errorf("span %d is null", i)
+		}
+		if span.ID == "" {
+			return nil, fmt.Errorf("span %d: id is required", i)
+		}
+		if span.TraceID == "" {
+			return nil, fmt.Errorf("span %d: traceId is required", i)
+		}
+		tSpan, err := spanV2ToThrift(span)
+		if err != nil {
+			return nil, err
+		}
+		tSpans = append(tSpans, tSpan)
+	}
+	return tSpans, nil
+}
+
+func spanV2ToThrift(s *spanV2) (*zipkincore.Span, error) {
+	id, err := model.SpanIDFromString(cutLongID(s.ID))
+	if err != nil {
+		return nil, err
+	}
+	traceID, err := model.TraceIDFromString(s.TraceID)
+	if err != nil {
+		return nil, err
+	}
+	tSpan := &zipkincore.Span{
+		ID:        int64(id),
+		TraceID:   int64(traceID.Low),
+		Name:      s.Name,
+		Debug:     s.Debug,
+		Timestamp: &s.Timestamp,
+		Duration:  &s.Duration,
+	}
+	if traceID.High != 0 {
+		help := int64(traceID.High)
+		tSpan.TraceIDHigh = &help
+	}
+
+	if len(s.ParentID) > 0 {
+		parentID, err := model.SpanIDFromString(cutLongID(s.ParentID))
+		if err != nil {
+			return nil, err
+		}
+		signed := int64(parentID)
+		tSpan.ParentID = &signed
+	}
+
+	var localE *zipkincore.Endpoint
+	if s.LocalEndpoint != nil {
+		localE, err = endpointV2ToThrift(s.LocalEndpoint)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, a := range s.Annotations {
+		tSpan.Annotations = append(tSpan.Annotations, &zipkincore.Annotation{
+			Value:     a.Value,
+			Timestamp: a.Timestamp,
+			Host:      localE,
+		})
+	}
+
+	for k, v := range s.Tags {
+		tSpan.BinaryAnnotations = append(tSpan.BinaryAnnotations, &zipkincore.BinaryAnnotation{
+			Key:            k,
+			Value:          []byte(v),
+			AnnotationType: zipkincore.AnnotationType_STRING,
+			Host:           localE,
+		})
+	}
+	tSpan.Annotations = append(tSpan.Annotations, kindToThrift(s.Timestamp, s.Duration, s.Kind, localE)...)
+
+	if s.RemoteEndpoint != nil {
+		rAddrAnno, err := remoteEndpToThrift(s.RemoteEndpoint, s.Kind)
+		if err != nil {
+			return nil, err
+		}
+		if rAddrAnno != nil {
+			tSpan.BinaryAnnotations = append(tSpan.BinaryAnnotations, rAddrAnno)
+		}
+	}
+
+	// add local component to represent service name
+	// to_domain looks for a service name in all [bin]annotations
+	if localE != nil && len(tSpan.BinaryAnnotations) == 0 && len(tSpan.Annotations) == 0 {
+		tSpan.BinaryAnnotations = append(tSpan.BinaryAnnotations, &zipkincore.BinaryAnnotation{
+			Key:            zipkincore.LOCAL_COMPONENT,
+			Host:           localE,
+			AnnotationType: zipkincore.AnnotationType_STRING,
+		})
+	}
+	return tSpan, nil
+}
+
+func remoteEndpToThrift(e *endpointV2, kind string) (*zipkincore.BinaryAnnotation, error) {
+	rEndp, err := endpointV2ToThrift(e)
+	if err != nil {
+		return nil, err
+	}
+	var key string
+	switch kind {
+	case "CLIENT":
+		key = zipkincore.SERVER_ADDR
+	case "SERVER":
+		key = zipkincore.CLIENT_ADDR
+	case "CONSUMER", "PRODUCER":
+		key = zipkincore.MESSAGE_ADDR
+	default:
+		return nil, nil
+	}
+
+	return &zipkincore.BinaryAnnotation{
+		Key:            key,
+		Host:           rEndp,
+		AnnotationType: zipkincore.AnnotationType_BOOL,
+	}, nil
+}
+
+func kindToThrift(ts int64, d int64, kind string, localE *zipkincore.Endpoint) []*zipkincore.Annotation {
+	switch kind {
+	case "SERVER":
+		return []*zipkincore.Annotation{
+			{Value: zipkincore.SERVER_RECV, Host: localE, Timestamp: ts},
+			{Value: zipkincore.SERVER_SEND, Host: localE, Timestamp: ts + d},
+		}
+	case "CLIENT":
+		return []*zipkincore.Annotation{
+			{Value: zipkincore.CLIENT_SEND, Host: localE, Timestamp: ts},
+			{Value: zipkincore.CLIENT_RECV, Host: localE, Timestamp: ts + d},
+		}
+	case "PRODUCER":
+		return []*zipkincore.Annotation{{Value: zipkincore.MESSAGE_SEND, Host: localE, Timestamp: ts}}
+	case "CONSUMER":
+		return []*zipkincore.Annotation{{Value: zipkincore.MESSAGE_RECV, Host: localE, Timestamp: ts}}
+	}
+	return nil
+}
+
+func endpointV2ToThrift(e *endpointV2) (*zipkincore.Endpoint, error) {
+	ipv4, err := parseIpv4(e.IPv4)
+	if err != nil {
+		return nil, err
+	}
+	ipv6, err := parseIpv6(e.IPv6)
+	if err != nil {
+		return nil, err
+	}
+	port := e.Port
+	if port >= (1 << 15) {
+		// Zipkin.thrift defines port as i16, so values between (2^15 and 2^16-1) must be encoded as negative
+		port = port - (1 << 16)
+	}
+	return &zipkincore.Endpoint{
+		ServiceName: e.ServiceName,
+		Port:        int16(port),
+		Ipv4:        ipv4,
+		Ipv6:        ipv6,
+	}, nil
+}
+
+func cutLongID(id string) string {
+	l := len(id)
+	if l > 16 && l <= 32 {
+		start := l - 16
+		return id[start:]
+	}
+	return id
+}
+
+func parseIpv6(str string) (net.IP, error) {
+	if str == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(str).To16()
+	if ip == nil {
+		return nil, errWrongIpv6
+	}
+	return ip, nil
+}
+
+func parseIpv4(str string) (int32, error) {
+	if str == "" {
+		return 0, nil
+	}
+	ip := net.ParseIP(str).To4()
+	if ip == nil {
+		return 0, errWrongIpv4
+	}
+	var ipv4 int32
+	for _, segment := range ip {
+		ipv4 = ipv4<<8 | (int32(segment) & 0xff)
+	}
+	return ipv4, nil
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package zipkin
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/thrift-gen/zipkincore"
+)
+
+func TestDeserializeJSONV2(t *testing.T) {
+	spans, err := DeserializeJSONV2([]byte(`[{
+		"traceId": "bd7a974555f6b982bd71977555f6b981", "id": "2", "parentId": "1", "name": "foo",
+		"kind": "SERVER", "timestamp": 1, "duration": 10,
+		"localEndpoint": {"serviceName": "foo", "ipv4": "10.43.17.42", "port": 65535},
+		"remoteEndpoint": {"serviceName": "bar", "ipv6": "::1"},
+		"annotations": [{"value": "foo", "timestamp": 1}]
+	}]`))
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+
+	var pid, ts, d int64 = 1, 1, 10
+	highID := int64(-4793352529331701374)
+	localE := &zipkincore.Endpoint{ServiceName: "foo", Ipv4: 170594602, Port: -1}
+	remoteE := &zipkincore.Endpoint{ServiceName: "bar", Ipv6: []byte{0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1}}
+	assert.Equal(t, &zipkincore.Span{
+		ID: 2, TraceID: int64(-4795885597963667071), TraceIDHigh: &highID, ParentID: &pid, Name: "foo", Timestamp: &ts, Duration: &d,
+		Annotations: []*zipkincore.Annotation{
+			{Value: "foo", Timestamp: 1, Host: localE},
+			{Value: zipkincore.SERVER_RECV, Timestamp: 1, Host: localE},
+			{Value: zipkincore.SERVER_SEND, Timestamp: 11, Host: localE},
+		},
+		BinaryAnnotations: []*zipkincore.BinaryAnnotation{
+			{Key: zipkincore.CLIENT_ADDR, Host: remoteE, AnnotationType: zipkincore.AnnotationType_BOOL},
+		},
+	}, spans[0])
+}
+
+func TestDeserializeJSONV2LocalComponent(t *testing.T) {
+	spans, err := DeserializeJSONV2([]byte(`[{"traceId": "2", "id": "2", "localEndpoint": {"serviceName": "bar"}}]`))
+	require.NoError(t, err)
+	require.Len(t, spans, 1)
+	assert.Equal(t, []*zipkincore.BinaryAnnotation{{
+		Key:            zipkincore.LOCAL_COMPONENT,
+		Host:           &zipkincore.Endpoint{ServiceName: "bar"},
+		AnnotationType: zipkincore.AnnotationType_STRING,
+	}}, spans[0].BinaryAnnotations)
+}
+
+func TestDeserializeJSONV2Errors(t *testing.T) {
+	tests := []struct {
+		json string
+		err  string
+	}{
+		{json: `{}`, err: "json: cannot unmarshal object into Go value of type []*zipkin.spanV2"},
+		{json: `[null]`, err: "span 0 is null"},
+		{json: `[{"traceId": "1"}]`, err: "span 0: id is required"},
+		{json: `[{"id": "1"}]`, err: "span 0: traceId is required"},
+		{json: `[{"id": "zz", "traceId": "1"}]`, err: `strconv.ParseUint: parsing "zz": invalid syntax`},
+		{json: `[{"id": "1", "traceId": "zz"}]`, err: `strconv.ParseUint: parsing "zz": invalid syntax`},
+		{json: `[{"id": "1", "traceId": "1", "parentId": "zz"}]`, err: `strconv.ParseUint: parsing "zz": invalid syntax`},
+		{json: `[{"id": "1", "traceId": "1", "localEndpoint": {"ipv4": "::1"}}]`, err: errWrongIpv4.Error()},
+		{json: `[{"id": "1", "traceId": "1", "localEndpoint": {"ipv6": "foo"}}]`, err: errWrongIpv6.Error()},
+		{json: `[{"id": "1", "traceId": "1", "kind": "CLIENT", "remoteEndpoint": {"ipv4": "foo"}}]`, err: errWrongIpv4.Error()},
+	}
+	for _, test := range tests {
+		t.Run(test.json, func(t *testing.T) {
+			_, err := DeserializeJSONV2([]byte(test.json))
+			assert.EqualError(t, err, test.err)
+		})
+	}
+}
+
+func TestKindV2ToThrift(t *testing.T) {
+	for _, kind := range []string{"CLIENT", "SERVER", "PRODUCER", "CONSUMER"} {
+		spans, err := DeserializeJSONV2([]byte(`[{"id": "1", "traceId": "1", "kind": "` + kind + `", "remoteEndpoint": {}}]`))
+		require.NoError(t, err, kind)
+		assert.NotEmpty(t, spans[0].Annotations, kind)
+		assert.Len(t, spans[0].BinaryAnnotations, 1, kind)
+	}
+}
@@ -16,6 +16,8 @@
 package gocql
 
 import (
+	"fmt"
+
 	"github.com/gocql/gocql"
 
 	"github.com/jaegertracing/jaeger/pkg/cassandra"
@@ -36,6 +38,11 @@ func (s CQLSession) Query(stmt string, values ...interface{}) cassandra.Query {
 	return WrapCQLQuery(s.session.Query(stmt, values...))
 }
 
+// NewBatch delegates to gocql.Session#NewBatch and wraps the result as Batch.
+func (s CQLSession) NewBatch(batchType cassandra.BatchType) cassandra.Batch {
+	return WrapCQLBatch(s.session, s.session.NewBatch(gocql.BatchType(batchType)))
+}
+
 // Close delegates to gocql.Session#Close.
 func (s CQLSession) Close() {
 	s.session.Close()
@@ -43,6 +50,39 @@ func (s CQLSession) Close() {
 
 // ---
 
+// CQLBatch is a wrapper around gocql.Batch.
+type CQLBatch struct {
+	session *gocql.Session
+	batch   *gocql.Batch
+}
+
+// WrapCQLBatch creates a Batch out of *gocql.Batch, executed by the given session.
+func WrapCQLBatch(session *gocql.Session, batch *gocql.Batch) CQLBatch {
+	return CQLBatch{session: session, batch: batch}
+}
+
+// Query delegates to gocql.Batch#Query.
+func (b CQLBatch) Query(stmt string, values ...interface{}) {
+	b.batch.Query(stmt, values...)
+}
+
+// Size delegates to gocql.Batch#Size.
+func (b CQLBatch) Size() int {
+	return b.batch.Size()
+}
+
+// Exec delegates to gocql.Session#ExecuteBatch.
+func (b CQLBatch) Exec() error {
+	return b.session.ExecuteBatch(b.batch)
+}
+
+// String returns string representation of this batch.
+func (b CQLBatch) String() string {
+	return fmt.Sprintf("[batch statements=%d]", b.batch.Size())
+}
+
+// ---
+
 // CQLQuery is a wrapper around gocql.Query.
 type CQLQuery struct {
 	query *gocql.Query
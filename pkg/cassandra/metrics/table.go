@@ -38,8 +38,23 @@ func NewTable(factory metrics.Factory, tableName string) *Table {
 	return &Table{t}
 }
 
+// executable is the part of UpdateQuery and Batch used by Table
+type executable interface {
+	Exec() error
+	String() string
+}
+
 // Exec executes an update query and reports metrics/logs about it.
 func (t *Table) Exec(query cassandra.UpdateQuery, logger *zap.Logger) error {
+	return t.exec(query, logger)
+}
+
+// ExecBatch executes a batch and reports metrics/logs about it.
+func (t *Table) ExecBatch(batch cassandra.Batch, logger *zap.Logger) error {
+	return t.exec(batch, logger)
+}
+
+func (t *Table) exec(query executable, logger *zap.Logger) error {
 	start := time.Now()
 	err := query.Exec()
 	t.Emit(err, time.Since(start))
@@ -23,6 +23,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 
+	"github.com/jaegertracing/jaeger/pkg/cassandra/mocks"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
 )
 
@@ -141,6 +142,23 @@ func TestTableExec(t *testing.T) {
 	}
 }
 
+func TestTableExecBatch(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	tm := NewTable(mf, "a_table")
+
+	batch := &mocks.Batch{}
+	batch.On("Exec").Return(errors.New("failed"))
+	batch.On("String").Return("[batch statements=2]")
+	err := tm.ExecBatch(batch, nil)
+	assert.EqualError(t, err, "failed to Exec query '[batch statements=2]': failed")
+
+	counts, _ := mf.Snapshot()
+	assert.Equal(t, map[string]int64{
+		"attempts|table=a_table": 1,
+		"errors|table=a_table":   1,
+	}, counts)
+}
+
 type insertQuery struct {
 	err error
 	str string
@@ -0,0 +1,72 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mocks
+
+import cassandra "github.com/jaegertracing/jaeger/pkg/cassandra"
+import mock "github.com/stretchr/testify/mock"
+
+// Batch is an autogenerated mock type for the Batch type
+type Batch struct {
+	mock.Mock
+}
+
+// Exec provides a mock function with given fields:
+func (_m *Batch) Exec() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Query provides a mock function with given fields: stmt, values
+func (_m *Batch) Query(stmt string, values ...interface{}) {
+	_m.Called(stmt, values)
+}
+
+// Size provides a mock function with given fields:
+func (_m *Batch) Size() int {
+	ret := _m.Called()
+
+	var r0 int
+	if rf, ok := ret.Get(0).(func() int); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	return r0
+}
+
+// String provides a mock function with given fields:
+func (_m *Batch) String() string {
+	ret := _m.Called()
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func() string); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	return r0
+}
+
+var _ cassandra.Batch = (*Batch)(nil)
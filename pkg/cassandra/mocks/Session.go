@@ -28,6 +28,22 @@ func (_m *Session) Close() {
 	_m.Called()
 }
 
+// NewBatch provides a mock function with given fields: batchType
+func (_m *Session) NewBatch(batchType cassandra.BatchType) cassandra.Batch {
+	ret := _m.Called(batchType)
+
+	var r0 cassandra.Batch
+	if rf, ok := ret.Get(0).(func(cassandra.BatchType) cassandra.Batch); ok {
+		r0 = rf(batchType)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(cassandra.Batch)
+		}
+	}
+
+	return r0
+}
+
 // Query provides a mock function with given fields: stmt, values
 func (_m *Session) Query(stmt string, values ...interface{}) cassandra.Query {
 	ret := _m.Called(stmt, values)
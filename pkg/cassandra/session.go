@@ -39,12 +39,35 @@ const (
 	LocalOne Consistency = 0x0A
 )
 
+// BatchType is the type of a Cassandra batch.
+type BatchType byte
+
+const (
+	// LoggedBatch ...
+	LoggedBatch BatchType = 0
+	// UnloggedBatch ...
+	UnloggedBatch BatchType = 1
+	// CounterBatch ...
+	CounterBatch BatchType = 2
+)
+
 // Session is an abstraction of gocql.Session
 type Session interface {
 	Query(stmt string, values ...interface{}) Query
+	NewBatch(batchType BatchType) Batch
 	Close()
 }
 
+// Batch is an abstraction of gocql.Batch
+type Batch interface {
+	// Query adds the query to the batch.
+	Query(stmt string, values ...interface{})
+	// Size returns the number of queries in the batch.
+	Size() int
+	Exec() error
+	String() string
+}
+
 // UpdateQuery is a subset of Query just for updates
 type UpdateQuery interface {
 	Exec() error
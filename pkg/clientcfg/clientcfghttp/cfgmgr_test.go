@@ -22,6 +22,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
+	"github.com/jaegertracing/jaeger/cmd/agent/app/throttling"
 	"github.com/jaegertracing/jaeger/thrift-gen/baggage"
 	"github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
@@ -48,6 +49,23 @@ func (m *mockBaggageMgr) GetBaggageRestrictions(_ context.Context, serviceName s
 	return m.baggageResponse, nil
 }
 
+type mockCreditsMgr struct {
+	serviceName string
+	clientID    string
+}
+
+func (m *mockCreditsMgr) GetCredits(_ context.Context, serviceName, clientID string, operations []string) (*throttling.CreditsResponse, error) {
+	if serviceName == "error" {
+		return nil, errors.New("no credits")
+	}
+	m.serviceName, m.clientID = serviceName, clientID
+	resp := &throttling.CreditsResponse{}
+	for _, operation := range operations {
+		resp.Balances = append(resp.Balances, throttling.OperationBalance{Operation: operation, Balance: 1.5})
+	}
+	return resp, nil
+}
+
 func TestConfigManager(t *testing.T) {
 	bgm := &mockBaggageMgr{}
 	mgr := &ConfigManager{
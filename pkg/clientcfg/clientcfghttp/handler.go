@@ -26,6 +26,7 @@ import (
 	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/jaegertracing/jaeger/cmd/agent/app/configmanager"
+	"github.com/jaegertracing/jaeger/cmd/agent/app/throttling"
 	tSampling "github.com/jaegertracing/jaeger/thrift-gen/sampling"
 )
 
@@ -46,6 +47,9 @@ type HTTPHandlerParams struct {
 	// LegacySamplingEndpoint enables returning sampling strategy from "/" endpoint
 	// using Thrift 0.9.2 enum codes.
 	LegacySamplingEndpoint bool
+
+	// CreditsManager enables the "/credits" endpoint used by the remote throttler of clients.
+	CreditsManager throttling.CreditsManager
 }
 
 // HTTPHandler implements endpoints for used by Jaeger clients to retrieve client configuration,
@@ -62,6 +66,9 @@ type HTTPHandler struct {
 		// Number of good baggage requests
 		BaggageRequestSuccess metrics.Counter `metric:"http-server.requests" tags:"type=baggage"`
 
+		// Number of good credits requests
+		CreditsRequestSuccess metrics.Counter `metric:"http-server.requests" tags:"type=credits"`
+
 		// Number of bad requests (400s)
 		BadRequest metrics.Counter `metric:"http-server.errors" tags:"status=4xx,source=all"`
 
@@ -100,6 +107,12 @@ func (h *HTTPHandler) RegisterRoutes(router *mux.Router) {
 		h.serveBaggageHTTP(w, r)
 	}).Methods(http.MethodGet)
 
+	if h.params.CreditsManager != nil {
+		router.HandleFunc(prefix+"/credits", func(w http.ResponseWriter, r *http.Request) {
+			h.serveCreditsHTTP(w, r)
+		}).Methods(http.MethodGet)
+	}
+
 }
 
 func (h *HTTPHandler) serviceFromRequest(w http.ResponseWriter, r *http.Request) (string, error) {
@@ -170,6 +183,26 @@ func (h *HTTPHandler) serveBaggageHTTP(w http.ResponseWriter, r *http.Request) {
 	h.metrics.BaggageRequestSuccess.Inc(1)
 }
 
+func (h *HTTPHandler) serveCreditsHTTP(w http.ResponseWriter, r *http.Request) {
+	service, err := h.serviceFromRequest(w, r)
+	if err != nil {
+		return
+	}
+	query := r.URL.Query()
+	resp, err := h.params.CreditsManager.GetCredits(r.Context(), service, query.Get("uuid"), query["operations"])
+	if err != nil {
+		h.metrics.CollectorProxyFailures.Inc(1)
+		http.Error(w, fmt.Sprintf("credits error: %+v", err), http.StatusInternalServerError)
+		return
+	}
+	// NB. it's literally impossible for this Marshal to fail
+	jsonBytes, _ := json.Marshal(resp)
+	if err = h.writeJSON(w, jsonBytes); err != nil {
+		return
+	}
+	h.metrics.CreditsRequestSuccess.Inc(1)
+}
+
 var samplingStrategyTypes = []tSampling.SamplingStrategyType{
 	tSampling.SamplingStrategyType_PROBABILISTIC,
 	tSampling.SamplingStrategyType_RATE_LIMITING,
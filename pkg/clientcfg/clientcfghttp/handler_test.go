@@ -38,6 +38,7 @@ type testServer struct {
 	metricsFactory *metricstest.Factory
 	samplingStore  *mockSamplingStore
 	bgMgr          *mockBaggageMgr
+	creditsMgr     *mockCreditsMgr
 	server         *httptest.Server
 	handler        *HTTPHandler
 }
@@ -51,6 +52,7 @@ func withServer(
 	metricsFactory := metricstest.NewFactory(0)
 	samplingStore := &mockSamplingStore{samplingResponse: mockSamplingResponse}
 	bgMgr := &mockBaggageMgr{baggageResponse: mockBaggageResponse}
+	creditsMgr := &mockCreditsMgr{}
 	cfgMgr := &ConfigManager{
 		SamplingStrategyStore: samplingStore,
 		BaggageManager:        bgMgr,
@@ -60,6 +62,7 @@ func withServer(
 		MetricsFactory:         metricsFactory,
 		BasePath:               basePath,
 		LegacySamplingEndpoint: true,
+		CreditsManager:         creditsMgr,
 	})
 	r := mux.NewRouter()
 	handler.RegisterRoutes(r)
@@ -69,6 +72,7 @@ func withServer(
 		metricsFactory: metricsFactory,
 		samplingStore:  samplingStore,
 		bgMgr:          bgMgr,
+		creditsMgr:     creditsMgr,
 		server:         server,
 		handler:        handler,
 	})
@@ -119,11 +123,24 @@ func testHTTPHandler(t *testing.T, basePath string) {
 			assert.EqualValues(t, ts.bgMgr.baggageResponse, objResp)
 		})
 
+		t.Run("request against endpoint /credits", func(t *testing.T) {
+			resp, err := http.Get(ts.server.URL + basePath + "/credits?service=Y&uuid=Z&operations=op1&operations=op2")
+			require.NoError(t, err)
+			assert.Equal(t, http.StatusOK, resp.StatusCode)
+			body, err := ioutil.ReadAll(resp.Body)
+			resp.Body.Close()
+			require.NoError(t, err)
+			assert.JSONEq(t, `{"balances":[{"operation":"op1","balance":1.5},{"operation":"op2","balance":1.5}]}`, string(body))
+			assert.Equal(t, "Y", ts.creditsMgr.serviceName)
+			assert.Equal(t, "Z", ts.creditsMgr.clientID)
+		})
+
 		// handler must emit metrics
 		ts.metricsFactory.AssertCounterMetrics(t, []metricstest.ExpectedMetric{
 			{Name: "http-server.requests", Tags: map[string]string{"type": "sampling"}, Value: 1},
 			{Name: "http-server.requests", Tags: map[string]string{"type": "sampling-legacy"}, Value: 1},
 			{Name: "http-server.requests", Tags: map[string]string{"type": "baggage"}, Value: 1},
+			{Name: "http-server.requests", Tags: map[string]string{"type": "credits"}, Value: 1},
 		}...)
 	})
 }
@@ -183,6 +200,24 @@ func TestHTTPHandlerErrors(t *testing.T) {
 				{Name: "http-server.errors", Tags: map[string]string{"source": "collector-proxy", "status": "5xx"}, Value: 1},
 			},
 		},
+		{
+			description: "credits endpoint no service name",
+			url:         "/credits?operations=op",
+			statusCode:  http.StatusBadRequest,
+			body:        "'service' parameter must be provided once\n",
+			metrics: []metricstest.ExpectedMetric{
+				{Name: "http-server.errors", Tags: map[string]string{"source": "all", "status": "4xx"}, Value: 1},
+			},
+		},
+		{
+			description: "credits error",
+			url:         "/credits?service=error&operations=op",
+			statusCode:  http.StatusInternalServerError,
+			body:        "credits error: no credits\n",
+			metrics: []metricstest.ExpectedMetric{
+				{Name: "http-server.errors", Tags: map[string]string{"source": "collector-proxy", "status": "5xx"}, Value: 1},
+			},
+		},
 		{
 			description:          "sampler marshalling error",
 			mockSamplingResponse: probabilistic(math.NaN()),
@@ -230,10 +265,28 @@ func TestHTTPHandlerErrors(t *testing.T) {
 
 			ts.metricsFactory.AssertCounterMetrics(t,
 				metricstest.ExpectedMetric{Name: "http-server.errors", Tags: map[string]string{"source": "write", "status": "5xx"}, Value: 2})
+
+			req = httptest.NewRequest("GET", "http://localhost:80/credits?service=X&operations=op", nil)
+			handler.serveCreditsHTTP(w, req)
+
+			ts.metricsFactory.AssertCounterMetrics(t,
+				metricstest.ExpectedMetric{Name: "http-server.errors", Tags: map[string]string{"source": "write", "status": "5xx"}, Value: 3})
 		})
 	})
 }
 
+func TestHTTPHandlerWithoutCredits(t *testing.T) {
+	handler := NewHTTPHandler(HTTPHandlerParams{
+		ConfigManager:  &ConfigManager{},
+		MetricsFactory: metricstest.NewFactory(0),
+	})
+	r := mux.NewRouter()
+	handler.RegisterRoutes(r)
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost:80/credits?service=X", nil))
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
 func probabilistic(probability float64) *sampling.SamplingStrategyResponse {
 	return &sampling.SamplingStrategyResponse{
 		StrategyType: sampling.SamplingStrategyType_PROBABILISTIC,
@@ -0,0 +1,150 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"sync"
+	"time"
+
+	"github.com/uber/jaeger-lib/metrics"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+const (
+	// DefaultMaxKeys is the default maximum number of span keys remembered
+	DefaultMaxKeys = 1000000
+)
+
+// Options configures the Deduper.
+type Options struct {
+	// Window is the minimum time a span key is remembered; zero disables the deduplication
+	Window time.Duration
+	// MaxKeys bounds the number of remembered keys; when it is reached, the oldest keys are forgotten early
+	MaxKeys int
+}
+
+// Enabled returns true if the deduplication is enabled.
+func (o Options) Enabled() bool {
+	return o.Window > 0
+}
+
+type dedupMetrics struct {
+	// Unique is the number of spans seen for the first time
+	Unique metrics.Counter `metric:"spans" tags:"result=unique"`
+	// Duplicates is the number of spans already seen within the window
+	Duplicates metrics.Counter `metric:"spans" tags:"result=duplicate"`
+	// EarlyRotations is the number of times keys were forgotten before the end of the window because MaxKeys was reached
+	EarlyRotations metrics.Counter `metric:"early-rotations"`
+	// Keys is the number of remembered keys
+	Keys metrics.Gauge `metric:"keys"`
+}
+
+// key identifies a span. The start time distinguishes spans of clients reusing span IDs.
+type key struct {
+	traceID   model.TraceID
+	spanID    model.SpanID
+	startTime int64
+}
+
+// Deduper detects the spans already seen within a time window, so that they are not written to storage again.
+// It complements the query-side adjuster.SpanIDDeduper, which cannot tell retries from spans sharing an ID.
+//
+// The keys are kept in two generations: new keys are added to the current generation, which becomes
+// the previous one after a window, when the previous one is forgotten. A key is therefore remembered
+// for at least a window. When the current generation holds half of MaxKeys keys it is rotated early,
+// so at most MaxKeys keys are held at any time.
+type Deduper struct {
+	window   time.Duration
+	maxKeys  int
+	metrics  dedupMetrics
+	now      func() time.Time
+	lock     sync.Mutex
+	current  map[key]struct{}
+	previous map[key]struct{}
+	rotated  time.Time
+}
+
+// New creates a Deduper.
+func New(options Options, metricsFactory metrics.Factory) *Deduper {
+	if options.MaxKeys <= 0 {
+		options.MaxKeys = DefaultMaxKeys
+	}
+	d := &Deduper{
+		window:   options.Window,
+		maxKeys:  options.MaxKeys,
+		now:      time.Now,
+		current:  make(map[key]struct{}),
+		previous: make(map[key]struct{}),
+	}
+	metrics.MustInit(&d.metrics, metricsFactory.Namespace(metrics.NSOptions{Name: "dedup"}), nil)
+	d.rotated = d.now()
+	return d
+}
+
+func spanKey(span *model.Span) key {
+	return key{
+		traceID:   span.TraceID,
+		spanID:    span.SpanID,
+		startTime: span.StartTime.UnixNano(),
+	}
+}
+
+// IsDuplicate returns true if a span with the same trace ID, span ID and start time was seen within the window.
+// Otherwise the span is remembered and false is returned. Callers that fail to store a span remembered
+// by IsDuplicate must call Forget, so that the span is not dropped when it is received again.
+func (d *Deduper) IsDuplicate(span *model.Span) bool {
+	k := spanKey(span)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.rotate()
+	if _, ok := d.current[k]; ok {
+		d.metrics.Duplicates.Inc(1)
+		return true
+	}
+	if _, ok := d.previous[k]; ok {
+		d.metrics.Duplicates.Inc(1)
+		return true
+	}
+	d.current[k] = struct{}{}
+	d.metrics.Unique.Inc(1)
+	d.metrics.Keys.Update(int64(len(d.current) + len(d.previous)))
+	return false
+}
+
+// Forget removes the key of the span, so that the span is accepted when it is received again.
+func (d *Deduper) Forget(span *model.Span) {
+	k := spanKey(span)
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.current, k)
+	delete(d.previous, k)
+	d.metrics.Keys.Update(int64(len(d.current) + len(d.previous)))
+}
+
+// rotate moves the current generation to the previous one when it is a window old or full.
+func (d *Deduper) rotate() {
+	now := d.now()
+	if now.Sub(d.rotated) < d.window {
+		if len(d.current) < d.maxKeys/2 {
+			return
+		}
+		d.metrics.EarlyRotations.Inc(1)
+	}
+	d.previous = d.current
+	d.current = make(map[key]struct{}, len(d.previous))
+	d.rotated = now
+	d.metrics.Keys.Update(int64(len(d.previous)))
+}
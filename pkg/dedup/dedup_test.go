@@ -0,0 +1,112 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dedup
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+
+	"github.com/jaegertracing/jaeger/model"
+)
+
+func newTestDeduper(options Options) (*Deduper, *metricstest.Factory, *time.Time) {
+	mf := metricstest.NewFactory(time.Hour)
+	d := New(options, mf)
+	now := time.Unix(1000, 0)
+	d.now = func() time.Time { return now }
+	d.rotated = now
+	return d, mf, &now
+}
+
+func newSpan(traceID, spanID uint64, startTime time.Time) *model.Span {
+	return &model.Span{
+		TraceID:   model.NewTraceID(0, traceID),
+		SpanID:    model.NewSpanID(spanID),
+		StartTime: startTime,
+	}
+}
+
+func TestIsDuplicate(t *testing.T) {
+	d, mf, now := newTestDeduper(Options{Window: time.Minute})
+	start := time.Unix(500, 0)
+
+	assert.False(t, d.IsDuplicate(newSpan(1, 1, start)))
+	assert.True(t, d.IsDuplicate(newSpan(1, 1, start)))
+	assert.True(t, d.IsDuplicate(newSpan(1, 1, start)))
+	// any part of the key differs
+	assert.False(t, d.IsDuplicate(newSpan(2, 1, start)))
+	assert.False(t, d.IsDuplicate(newSpan(1, 2, start)))
+	assert.False(t, d.IsDuplicate(newSpan(1, 1, start.Add(time.Millisecond))))
+	assert.True(t, d.IsDuplicate(newSpan(1, 1, start)))
+
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "dedup.spans", Tags: map[string]string{"result": "unique"}, Value: 4},
+		metricstest.ExpectedMetric{Name: "dedup.spans", Tags: map[string]string{"result": "duplicate"}, Value: 3},
+	)
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "dedup.keys", Value: 4})
+
+	// keys are remembered for at least a window
+	*now = now.Add(59 * time.Second)
+	assert.True(t, d.IsDuplicate(newSpan(1, 1, start)))
+	*now = now.Add(time.Second)
+	assert.True(t, d.IsDuplicate(newSpan(1, 1, start)))
+	assert.False(t, d.IsDuplicate(newSpan(3, 1, start)))
+	// and forgotten after two windows at most
+	*now = now.Add(time.Minute)
+	assert.False(t, d.IsDuplicate(newSpan(1, 1, start)))
+	assert.True(t, d.IsDuplicate(newSpan(3, 1, start)))
+}
+
+func TestForget(t *testing.T) {
+	d, mf, now := newTestDeduper(Options{Window: time.Minute})
+	start := time.Unix(500, 0)
+
+	assert.False(t, d.IsDuplicate(newSpan(1, 1, start)))
+	d.Forget(newSpan(1, 1, start))
+	assert.False(t, d.IsDuplicate(newSpan(1, 1, start)), "a forgotten span is accepted again")
+	assert.True(t, d.IsDuplicate(newSpan(1, 1, start)))
+
+	// the keys of the previous generation are forgotten too
+	*now = now.Add(time.Minute)
+	assert.False(t, d.IsDuplicate(newSpan(2, 1, start)))
+	d.Forget(newSpan(1, 1, start))
+	assert.False(t, d.IsDuplicate(newSpan(1, 1, start)))
+	mf.AssertGaugeMetrics(t, metricstest.ExpectedMetric{Name: "dedup.keys", Value: 2})
+}
+
+func TestMaxKeys(t *testing.T) {
+	d, mf, _ := newTestDeduper(Options{Window: time.Minute, MaxKeys: 4})
+	start := time.Unix(500, 0)
+
+	for i := uint64(0); i < 6; i++ {
+		assert.False(t, d.IsDuplicate(newSpan(i, 1, start)))
+	}
+	assert.LessOrEqual(t, len(d.current)+len(d.previous), 4)
+	// the most recent keys are still remembered
+	assert.True(t, d.IsDuplicate(newSpan(5, 1, start)))
+	assert.True(t, d.IsDuplicate(newSpan(4, 1, start)))
+	assert.False(t, d.IsDuplicate(newSpan(0, 1, start)))
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "dedup.early-rotations", Value: 3})
+}
+
+func TestOptions(t *testing.T) {
+	assert.False(t, Options{}.Enabled())
+	assert.True(t, Options{Window: time.Second}.Enabled())
+	d := New(Options{Window: time.Second}, metricstest.NewFactory(time.Hour))
+	assert.Equal(t, DefaultMaxKeys, d.maxKeys)
+}
@@ -0,0 +1,172 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+const dnsLookupTimeout = 5 * time.Second
+
+// dnsResolver is implemented by net.Resolver.
+type dnsResolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+// DNSDiscoverer periodically resolves a DNS name into instances and notifies
+// the observers when the set of instances changes. A target in the host:port
+// form is resolved using A/AAAA records, while a target without a port,
+// e.g. _grpc._tcp.jaeger-collector, is resolved using SRV records.
+type DNSDiscoverer struct {
+	Dispatcher
+
+	host     string
+	port     string
+	interval time.Duration
+	resolver dnsResolver
+	logger   *zap.Logger
+
+	mux       sync.RWMutex
+	instances []string
+	resolved  bool
+
+	done      chan struct{}
+	closeOnce sync.Once
+	wg        sync.WaitGroup
+}
+
+// NewDNSDiscoverer creates DNSDiscoverer, resolves the target and starts refreshing it every interval.
+// A failed initial lookup does not fail the creation, the discoverer reports no instances
+// until the target is resolved, so that the agent can start while DNS is unavailable.
+func NewDNSDiscoverer(target string, interval time.Duration, logger *zap.Logger) (*DNSDiscoverer, error) {
+	return newDNSDiscoverer(target, interval, net.DefaultResolver, logger)
+}
+
+func newDNSDiscoverer(target string, interval time.Duration, resolver dnsResolver, logger *zap.Logger) (*DNSDiscoverer, error) {
+	if interval <= 0 {
+		return nil, errors.New("DNS refresh interval must be positive")
+	}
+	d := &DNSDiscoverer{
+		interval: interval,
+		resolver: resolver,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+	if strings.Contains(target, ":") {
+		host, port, err := net.SplitHostPort(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid DNS discovery target %s: %w", target, err)
+		}
+		d.host, d.port = host, port
+	} else {
+		d.host = target
+	}
+	if d.host == "" {
+		return nil, fmt.Errorf("invalid DNS discovery target %q: empty host", target)
+	}
+	if instances, err := d.resolve(); err != nil {
+		logger.Warn("Initial DNS discovery failed", zap.String("target", target), zap.Error(err))
+	} else {
+		d.instances, d.resolved = instances, true
+	}
+	d.wg.Add(1)
+	go d.refreshLoop()
+	return d, nil
+}
+
+// Instances implements Discoverer.
+func (d *DNSDiscoverer) Instances() ([]string, error) {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	return d.instances, nil
+}
+
+func (d *DNSDiscoverer) resolve() ([]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), dnsLookupTimeout)
+	defer cancel()
+	var instances []string
+	if d.port != "" {
+		addrs, err := d.resolver.LookupHost(ctx, d.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, addr := range addrs {
+			instances = append(instances, net.JoinHostPort(addr, d.port))
+		}
+	} else {
+		_, srvs, err := d.resolver.LookupSRV(ctx, "", "", d.host)
+		if err != nil {
+			return nil, err
+		}
+		for _, srv := range srvs {
+			instances = append(instances, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+		}
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no DNS records found for %s", d.host)
+	}
+	sort.Strings(instances)
+	return instances, nil
+}
+
+func (d *DNSDiscoverer) refreshLoop() {
+	defer d.wg.Done()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			d.refresh()
+		case <-d.done:
+			return
+		}
+	}
+}
+
+func (d *DNSDiscoverer) refresh() {
+	instances, err := d.resolve()
+	if err != nil {
+		// keep using the last known instances, DNS outages should not disconnect the agent
+		d.logger.Warn("DNS discovery failed, using the last known instances", zap.String("host", d.host), zap.Error(err))
+		return
+	}
+	d.mux.Lock()
+	changed := !d.resolved || !reflect.DeepEqual(instances, d.instances)
+	d.instances, d.resolved = instances, true
+	d.mux.Unlock()
+	if changed {
+		d.logger.Info("DNS discovery found new instances", zap.Strings("instances", instances))
+		d.Notify(instances)
+	}
+}
+
+// Close stops refreshing the instances.
+func (d *DNSDiscoverer) Close() error {
+	d.closeOnce.Do(func() { close(d.done) })
+	d.wg.Wait()
+	return nil
+}
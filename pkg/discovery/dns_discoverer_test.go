@@ -0,0 +1,120 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type fakeResolver struct {
+	mux   sync.Mutex
+	hosts []string
+	srvs  []*net.SRV
+	err   error
+}
+
+func (r *fakeResolver) set(hosts []string, srvs []*net.SRV, err error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	r.hosts, r.srvs, r.err = hosts, srvs, err
+}
+
+func (r *fakeResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return r.hosts, r.err
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+	return name, r.srvs, r.err
+}
+
+func TestDNSDiscovererHost(t *testing.T) {
+	resolver := &fakeResolver{hosts: []string{"10.0.0.2", "10.0.0.1"}}
+	d, err := newDNSDiscoverer("jaeger-collector:14250", 10*time.Millisecond, resolver, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, d.Close()) }()
+
+	instances, err := d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:14250", "10.0.0.2:14250"}, instances)
+
+	ch := make(chan []string)
+	d.Register(ch)
+	defer d.Unregister(ch)
+
+	// failed lookups keep the last known instances
+	resolver.set(nil, nil, errors.New("lookup failed"))
+	time.Sleep(50 * time.Millisecond)
+	instances, err = d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.1:14250", "10.0.0.2:14250"}, instances)
+
+	resolver.set([]string{"10.0.0.3", "10.0.0.1"}, nil, nil)
+	select {
+	case instances := <-ch:
+		assert.Equal(t, []string{"10.0.0.1:14250", "10.0.0.3:14250"}, instances)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+}
+
+func TestDNSDiscovererSRV(t *testing.T) {
+	resolver := &fakeResolver{err: errors.New("no such host")}
+	d, err := newDNSDiscoverer("_grpc._tcp.jaeger-collector", 10*time.Millisecond, resolver, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, d.Close()) }()
+
+	// the discoverer starts while DNS is down and reports no instances until resolved
+	instances, err := d.Instances()
+	require.NoError(t, err)
+	assert.Empty(t, instances)
+
+	resolver.set(nil, []*net.SRV{
+		{Target: "collector-2.jaeger.", Port: 14250},
+		{Target: "collector-1.jaeger.", Port: 14251},
+	}, nil)
+	assert.Eventually(t, func() bool {
+		instances, err := d.Instances()
+		return err == nil && assert.ObjectsAreEqual([]string{"collector-1.jaeger:14251", "collector-2.jaeger:14250"}, instances)
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestDNSDiscovererErrors(t *testing.T) {
+	resolver := &fakeResolver{}
+	_, err := newDNSDiscoverer("jaeger-collector:14250", 0, resolver, zap.NewNop())
+	assert.EqualError(t, err, "DNS refresh interval must be positive")
+	_, err = newDNSDiscoverer("jaeger-collector:14250:1", time.Second, resolver, zap.NewNop())
+	assert.Contains(t, err.Error(), "invalid DNS discovery target")
+	_, err = newDNSDiscoverer(":14250", time.Second, resolver, zap.NewNop())
+	assert.Contains(t, err.Error(), "empty host")
+
+	d, err := NewDNSDiscoverer("localhost:14250", time.Hour, zap.NewNop())
+	require.NoError(t, err)
+	assert.NoError(t, d.Close())
+	// closing again, e.g. by both the proxy and the connection builder, must not panic
+	assert.NoError(t, d.Close())
+}
@@ -0,0 +1,109 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"sync"
+
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+// FileDiscoverer yields the instances listed in a file, and notifies the observers
+// every time the list changes. Instances are separated by new lines or commas,
+// and lines starting with # are ignored.
+type FileDiscoverer struct {
+	Dispatcher
+
+	path    string
+	logger  *zap.Logger
+	watcher *fswatcher.FileWatcher
+
+	mux       sync.RWMutex
+	instances []string
+}
+
+// NewFileDiscoverer creates FileDiscoverer and starts watching the file.
+func NewFileDiscoverer(path string, logger *zap.Logger) (*FileDiscoverer, error) {
+	instances, err := loadInstances(path)
+	if err != nil {
+		return nil, err
+	}
+	d := &FileDiscoverer{
+		path:      path,
+		logger:    logger,
+		instances: instances,
+	}
+	d.watcher, err = fswatcher.WatchFile(path, d.reload, logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch instances file: %w", err)
+	}
+	return d, nil
+}
+
+// Instances implements Discoverer.
+func (d *FileDiscoverer) Instances() ([]string, error) {
+	d.mux.RLock()
+	defer d.mux.RUnlock()
+	return d.instances, nil
+}
+
+func (d *FileDiscoverer) reload() {
+	instances, err := loadInstances(d.path)
+	if err != nil {
+		d.logger.Error("Failed to reload instances file, using the last known instances", zap.Error(err))
+		return
+	}
+	d.mux.Lock()
+	d.instances = instances
+	d.mux.Unlock()
+	d.logger.Info("Instances file reloaded", zap.Strings("instances", instances))
+	d.Notify(instances)
+}
+
+// Close stops watching the file.
+func (d *FileDiscoverer) Close() error {
+	return d.watcher.Close()
+}
+
+func loadInstances(path string) ([]string, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read instances file: %w", err)
+	}
+	var instances []string
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		for _, instance := range strings.Split(line, ",") {
+			if instance = strings.TrimSpace(instance); instance != "" {
+				instances = append(instances, instance)
+			}
+		}
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("instances file %s does not contain any instance", path)
+	}
+	return instances, nil
+}
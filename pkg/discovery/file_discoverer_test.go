@@ -0,0 +1,77 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestFileDiscoverer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "collectors")
+	require.NoError(t, ioutil.WriteFile(path, []byte("# collectors\ncollector-1:14250\n\n collector-2:14250 \n"), 0600))
+
+	d, err := NewFileDiscoverer(path, zap.NewNop())
+	require.NoError(t, err)
+	defer func() { assert.NoError(t, d.Close()) }()
+
+	instances, err := d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collector-1:14250", "collector-2:14250"}, instances)
+
+	ch := make(chan []string)
+	d.Register(ch)
+	defer d.Unregister(ch)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte("collector-1:14250,collector-3:14250"), 0600))
+	select {
+	case instances := <-ch:
+		assert.Equal(t, []string{"collector-1:14250", "collector-3:14250"}, instances)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for notification")
+	}
+
+	// an empty list keeps the last known instances
+	require.NoError(t, ioutil.WriteFile(path, []byte("# no collectors\n"), 0600))
+	time.Sleep(50 * time.Millisecond)
+	instances, err = d.Instances()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"collector-1:14250", "collector-3:14250"}, instances)
+}
+
+func TestFileDiscovererErrors(t *testing.T) {
+	_, err := NewFileDiscoverer("/does/not/exist", zap.NewNop())
+	assert.Error(t, err)
+
+	dir, err := ioutil.TempDir("", "discovery")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "collectors")
+	require.NoError(t, ioutil.WriteFile(path, []byte("\n# nothing\n"), 0600))
+	_, err = NewFileDiscoverer(path, zap.NewNop())
+	assert.Contains(t, err.Error(), "does not contain any instance")
+}
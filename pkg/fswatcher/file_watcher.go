@@ -0,0 +1,120 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fswatcher
+
+import (
+	"crypto/sha256"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+)
+
+// FileWatcher invokes a callback whenever the content of a watched file changes.
+// The parent directory is watched rather than the file itself, so that files replaced
+// atomically (e.g. Kubernetes ConfigMaps updated through symlink swaps) are detected.
+type FileWatcher struct {
+	watcher  Watcher
+	path     string
+	onChange func()
+	logger   *zap.Logger
+	hash     [sha256.Size]byte
+	done     chan struct{}
+}
+
+var _ io.Closer = (*FileWatcher)(nil)
+
+// WatchFile starts watching the given file and calls onChange every time its content changes.
+// The callback is executed from the watcher's goroutine and is expected to reload the file itself.
+func WatchFile(path string, onChange func(), logger *zap.Logger) (*FileWatcher, error) {
+	return watchFile(path, onChange, logger, NewWatcher)
+}
+
+func watchFile(path string, onChange func(), logger *zap.Logger, newWatcher func() (Watcher, error)) (*FileWatcher, error) {
+	watcher, err := newWatcher()
+	if err != nil {
+		return nil, err
+	}
+	path = filepath.Clean(path)
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		watcher.Close()
+		return nil, err
+	}
+	w := &FileWatcher{
+		watcher:  watcher,
+		path:     path,
+		onChange: onChange,
+		logger:   logger,
+		done:     make(chan struct{}),
+	}
+	// the initial hash lets us ignore events that do not modify the content
+	w.hash, _ = hashFile(path)
+	go w.watchLoop()
+	return w, nil
+}
+
+// Close stops watching the file.
+func (w *FileWatcher) Close() error {
+	err := w.watcher.Close()
+	<-w.done
+	return err
+}
+
+func (w *FileWatcher) watchLoop() {
+	defer close(w.done)
+	for {
+		select {
+		case event, ok := <-w.watcher.Events():
+			if !ok {
+				return
+			}
+			// ignore if the event is a chmod event (permission or owner changes)
+			if event.Op&fsnotify.Chmod == fsnotify.Chmod {
+				continue
+			}
+			w.checkForChanges()
+		case err, ok := <-w.watcher.Errors():
+			if !ok {
+				return
+			}
+			w.logger.Error("File watcher got error", zap.String("file", w.path), zap.Error(err))
+		}
+	}
+}
+
+func (w *FileWatcher) checkForChanges() {
+	hash, err := hashFile(w.path)
+	if err != nil {
+		w.logger.Warn("Watched file cannot be read, using the last known version",
+			zap.String("file", w.path), zap.Error(err))
+		return
+	}
+	if hash == w.hash {
+		return
+	}
+	w.hash = hash
+	w.logger.Info("Watched file has changed", zap.String("file", w.path))
+	w.onChange()
+}
+
+func hashFile(path string) ([sha256.Size]byte, error) {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+	return sha256.Sum256(content), nil
+}
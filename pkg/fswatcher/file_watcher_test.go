@@ -0,0 +1,71 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fswatcher
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/atomic"
+	"go.uber.org/zap"
+)
+
+func TestWatchFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fswatcher")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"a": 1}`), 0600))
+
+	changes := atomic.NewInt32(0)
+	w, err := WatchFile(path, func() { changes.Inc() }, zap.NewNop())
+	require.NoError(t, err)
+
+	// same content must not trigger the callback
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"a": 1}`), 0600))
+	// unrelated file in the same directory must not trigger the callback
+	require.NoError(t, ioutil.WriteFile(filepath.Join(dir, "other.json"), []byte(`{}`), 0600))
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 0, changes.Load())
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"a": 2}`), 0600))
+	assert.Eventually(t, func() bool {
+		return changes.Load() == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// removal keeps the last known version
+	require.NoError(t, os.Remove(path))
+	time.Sleep(50 * time.Millisecond)
+	assert.EqualValues(t, 1, changes.Load())
+
+	assert.NoError(t, w.Close())
+}
+
+func TestWatchFileErrors(t *testing.T) {
+	_, err := WatchFile("/does/not/exist/config.json", func() {}, zap.NewNop())
+	assert.Error(t, err)
+
+	_, err = watchFile("config.json", func() {}, zap.NewNop(), func() (Watcher, error) {
+		return nil, errors.New("watcher error")
+	})
+	assert.EqualError(t, err, "watcher error")
+}
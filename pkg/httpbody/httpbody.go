@@ -0,0 +1,127 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpbody
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+)
+
+const (
+	// SupportedEncodings is the list of the encodings of request bodies accepted by Read
+	SupportedEncodings = "gzip, deflate, zstd, snappy"
+
+	// UnableToReadErrFormat is an error message for invalid requests
+	UnableToReadErrFormat = "Unable to process request body: %v"
+)
+
+// ErrTooLarge is returned by Read when the decompressed body exceeds the maximum size
+var ErrTooLarge = errors.New("request body too large")
+
+// requestBodyError is an error returned by Read with the HTTP status code to respond with
+type requestBodyError struct {
+	statusCode int
+	err        error
+}
+
+func (e *requestBodyError) Error() string {
+	return e.err.Error()
+}
+
+func (e *requestBodyError) Unwrap() error {
+	return e.err
+}
+
+// Read reads the body of the request, decompressed according to its Content-Encoding header.
+// The encodings listed in SupportedEncodings are accepted, and the body is rejected with ErrTooLarge
+// if its decompressed size is bigger than maxSize bytes, which protects the servers from zip bombs.
+// A maxSize of 0 means that the size is not limited.
+func Read(r *http.Request, maxSize int64) ([]byte, error) {
+	defer r.Body.Close()
+	encoding := strings.ToLower(strings.TrimSpace(r.Header.Get("Content-Encoding")))
+	var body io.Reader = r.Body
+	// errors of the decompressors are caused by invalid payloads, errors of the raw body by the connection
+	failureCode := http.StatusInternalServerError
+	switch encoding {
+	case "", "identity":
+	case "gzip", "x-gzip":
+		gz, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return nil, &requestBodyError{statusCode: http.StatusBadRequest, err: err}
+		}
+		defer gz.Close()
+		body, failureCode = gz, http.StatusBadRequest
+	case "deflate":
+		zr, err := zlib.NewReader(r.Body)
+		if err != nil {
+			return nil, &requestBodyError{statusCode: http.StatusBadRequest, err: err}
+		}
+		defer zr.Close()
+		body, failureCode = zr, http.StatusBadRequest
+	case "zstd":
+		zr, err := zstd.NewReader(r.Body)
+		if err != nil {
+			return nil, &requestBodyError{statusCode: http.StatusBadRequest, err: err}
+		}
+		defer zr.Close()
+		body, failureCode = zr, http.StatusBadRequest
+	case "snappy":
+		body, failureCode = snappy.NewReader(r.Body), http.StatusBadRequest
+	default:
+		return nil, &requestBodyError{
+			statusCode: http.StatusUnsupportedMediaType,
+			err:        fmt.Errorf("unsupported content encoding %q, supported encodings are %s", encoding, SupportedEncodings),
+		}
+	}
+	if maxSize > 0 {
+		// read one more byte to tell bodies of exactly maxSize bytes from bigger ones
+		body = io.LimitReader(body, maxSize+1)
+	}
+	bodyBytes, err := ioutil.ReadAll(body)
+	if err != nil {
+		return nil, &requestBodyError{statusCode: failureCode, err: err}
+	}
+	if maxSize > 0 && int64(len(bodyBytes)) > maxSize {
+		return nil, &requestBodyError{statusCode: http.StatusRequestEntityTooLarge, err: ErrTooLarge}
+	}
+	return bodyBytes, nil
+}
+
+// ErrorStatusCode returns the HTTP status code for an error returned by Read
+func ErrorStatusCode(err error) int {
+	var bodyErr *requestBodyError
+	if errors.As(err, &bodyErr) {
+		return bodyErr.statusCode
+	}
+	return http.StatusInternalServerError
+}
+
+// WriteError responds to a request whose body could not be read by Read
+func WriteError(w http.ResponseWriter, err error) {
+	statusCode := ErrorStatusCode(err)
+	if statusCode == http.StatusUnsupportedMediaType {
+		w.Header().Set("Accept-Encoding", SupportedEncodings)
+	}
+	http.Error(w, fmt.Sprintf(UnableToReadErrFormat, err), statusCode)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package httpbody
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang/snappy"
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func compress(t *testing.T, encoding string, data []byte) []byte {
+	var buf bytes.Buffer
+	var w io.WriteCloser
+	switch encoding {
+	case "gzip":
+		w = gzip.NewWriter(&buf)
+	case "deflate":
+		w = zlib.NewWriter(&buf)
+	case "zstd":
+		zw, err := zstd.NewWriter(&buf)
+		require.NoError(t, err)
+		w = zw
+	case "snappy":
+		w = snappy.NewBufferedWriter(&buf)
+	default:
+		return data
+	}
+	_, err := w.Write(data)
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+	return buf.Bytes()
+}
+
+func newBodyRequest(encoding string, body []byte) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/", bytes.NewReader(body))
+	if encoding != "" {
+		req.Header.Set("Content-Encoding", encoding)
+	}
+	return req
+}
+
+func TestRead(t *testing.T) {
+	payload := []byte(strings.Repeat("span", 100))
+	for _, encoding := range []string{"", "identity", "gzip", "deflate", "zstd", "snappy"} {
+		t.Run(encoding, func(t *testing.T) {
+			body, err := Read(newBodyRequest(encoding, compress(t, encoding, payload)), int64(len(payload)))
+			require.NoError(t, err)
+			assert.Equal(t, payload, body)
+
+			// the limit applies to the decompressed size, however small the compressed body is
+			_, err = Read(newBodyRequest(encoding, compress(t, encoding, payload)), int64(len(payload)-1))
+			assert.True(t, errors.Is(err, ErrTooLarge))
+			assert.Equal(t, http.StatusRequestEntityTooLarge, ErrorStatusCode(err))
+		})
+	}
+}
+
+func TestReadUnlimited(t *testing.T) {
+	payload := []byte(strings.Repeat("span", 100))
+	body, err := Read(newBodyRequest("gzip", compress(t, "gzip", payload)), 0)
+	require.NoError(t, err)
+	assert.Equal(t, payload, body)
+}
+
+func TestReadErrors(t *testing.T) {
+	for _, encoding := range []string{"gzip", "deflate", "zstd", "snappy"} {
+		t.Run(encoding, func(t *testing.T) {
+			_, err := Read(newBodyRequest(encoding, []byte("not compressed")), 0)
+			require.Error(t, err)
+			assert.Equal(t, http.StatusBadRequest, ErrorStatusCode(err))
+		})
+	}
+
+	_, err := Read(newBodyRequest("br", []byte("whatever")), 0)
+	assert.EqualError(t, err, `unsupported content encoding "br", supported encodings are gzip, deflate, zstd, snappy`)
+	assert.Equal(t, http.StatusUnsupportedMediaType, ErrorStatusCode(err))
+
+	req, err := http.NewRequest(http.MethodPost, "/", &errReader{})
+	require.NoError(t, err)
+	_, err = Read(req, 0)
+	assert.Equal(t, http.StatusInternalServerError, ErrorStatusCode(err))
+}
+
+func TestWriteError(t *testing.T) {
+	_, err := Read(newBodyRequest("br", []byte("whatever")), 0)
+	rec := httptest.NewRecorder()
+	WriteError(rec, err)
+	assert.Equal(t, http.StatusUnsupportedMediaType, rec.Code)
+	assert.Equal(t, SupportedEncodings, rec.Header().Get("Accept-Encoding"))
+	assert.Contains(t, rec.Body.String(), "Unable to process request body: unsupported content encoding")
+}
+
+type errReader struct{}
+
+func (e *errReader) Read(p []byte) (int, error) {
+	return 0, errors.New("simulated read error")
+}
@@ -16,5 +16,6 @@ package config
 
 // Configuration describes the options to customize the storage behavior
 type Configuration struct {
+	// MaxTraces is the maximum number of traces stored for each tenant, unbounded if zero
 	MaxTraces int `yaml:"max-traces" mapstructure:"max_traces"`
 }
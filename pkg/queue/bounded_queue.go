@@ -16,6 +16,7 @@
 package queue
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -30,6 +31,12 @@ type Consumer interface {
 	Consume(item interface{})
 }
 
+// BatchConsumer consumes several items from a bounded queue at once
+type BatchConsumer interface {
+	Consumer
+	ConsumeBatch(items []interface{})
+}
+
 // BoundedQueue implements a producer-consumer exchange similar to a ring buffer queue,
 // where the queue is bounded and if it fills up due to slow consumers, the new items written by
 // the producer force the earliest items to be dropped. The implementation is actually based on
@@ -37,6 +44,7 @@ type Consumer interface {
 // the items from the top of the queue until its size drops back to maxSize
 type BoundedQueue struct {
 	workers       int
+	maxBatchSize  int
 	stopWG        sync.WaitGroup
 	size          *uatomic.Uint32
 	capacity      *uatomic.Uint32
@@ -80,7 +88,11 @@ func (q *BoundedQueue) StartConsumersWithFactory(num int, factory func() Consume
 				case item, ok := <-queue:
 					if ok {
 						q.size.Sub(1)
-						consumer.Consume(item)
+						if batchConsumer, isBatch := consumer.(BatchConsumer); isBatch && q.maxBatchSize > 1 {
+							batchConsumer.ConsumeBatch(q.takeWaiting(queue, item))
+						} else {
+							consumer.Consume(item)
+						}
 					} else {
 						// channel closed, finish worker
 						return
@@ -95,6 +107,24 @@ func (q *BoundedQueue) StartConsumersWithFactory(num int, factory func() Consume
 	startWG.Wait()
 }
 
+// takeWaiting returns the item together with the items already waiting in the queue, up to maxBatchSize items.
+func (q *BoundedQueue) takeWaiting(queue chan interface{}, item interface{}) []interface{} {
+	items := []interface{}{item}
+	for len(items) < q.maxBatchSize {
+		select {
+		case next, ok := <-queue:
+			if !ok {
+				return items
+			}
+			q.size.Sub(1)
+			items = append(items, next)
+		default:
+			return items
+		}
+	}
+	return items
+}
+
 // ConsumerFunc is an adapter to allow the use of
 // a consume function callback as a Consumer.
 type ConsumerFunc func(item interface{})
@@ -112,6 +142,30 @@ func (q *BoundedQueue) StartConsumers(num int, callback func(item interface{}))
 	})
 }
 
+// BatchConsumerFunc is an adapter to allow the use of
+// a consume function callback as a BatchConsumer.
+type BatchConsumerFunc func(items []interface{})
+
+// Consume calls c with a single item
+func (c BatchConsumerFunc) Consume(item interface{}) {
+	c([]interface{}{item})
+}
+
+// ConsumeBatch calls c(items)
+func (c BatchConsumerFunc) ConsumeBatch(items []interface{}) {
+	c(items)
+}
+
+// StartBatchConsumers starts a given number of goroutines consuming items from the queue.
+// Each goroutine passes the items already waiting in the queue to the callback at once, up to maxBatchSize items.
+// The callback never waits for more items to arrive, so batches are only formed when the consumers fall behind.
+func (q *BoundedQueue) StartBatchConsumers(num, maxBatchSize int, callback func(items []interface{})) {
+	q.maxBatchSize = maxBatchSize
+	q.StartConsumersWithFactory(num, func() Consumer {
+		return BatchConsumerFunc(callback)
+	})
+}
+
 // Produce is used by the producer to submit new item to the queue. Returns false in case of queue overflow.
 func (q *BoundedQueue) Produce(item interface{}) bool {
 	if q.stopped.Load() != 0 {
@@ -142,6 +196,22 @@ func (q *BoundedQueue) Produce(item interface{}) bool {
 	}
 }
 
+// Drain disables the producer and waits until the consumers have taken all items from the queue,
+// or until the context is done. It returns the number of items still in the queue.
+func (q *BoundedQueue) Drain(ctx context.Context) int {
+	q.stopped.Store(1) // disable producer
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for q.Size() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return q.Size()
+		}
+	}
+	return 0
+}
+
 // Stop stops all consumers, as well as the length reporter if started,
 // and releases the items channel. It blocks until all consumers have stopped.
 func (q *BoundedQueue) Stop() {
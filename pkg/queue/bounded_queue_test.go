@@ -16,6 +16,7 @@
 package queue
 
 import (
+	"context"
 	"fmt"
 	"reflect"
 	"sync"
@@ -357,3 +358,73 @@ func BenchmarkBoundedQueueWithFactory(b *testing.B) {
 		q.Produce(n)
 	}
 }
+
+func TestDrain(t *testing.T) {
+	q := NewBoundedQueue(10, func(item interface{}) {})
+	var consumed uatomic.Int32
+	startLock := &sync.Mutex{}
+	startLock.Lock()
+	q.StartConsumers(1, func(item interface{}) {
+		startLock.Lock()
+		defer startLock.Unlock()
+		consumed.Inc()
+	})
+	for i := 0; i < 5; i++ {
+		require.True(t, q.Produce(i))
+	}
+
+	// consumers are blocked, so the deadline passes before the queue is empty
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	assert.True(t, q.Drain(ctx) > 0)
+	assert.False(t, q.Produce(5), "producer must be disabled while draining")
+
+	startLock.Unlock()
+	assert.Equal(t, 0, q.Drain(context.Background()))
+	q.Stop()
+	assert.EqualValues(t, 5, consumed.Load())
+}
+
+func TestBatchConsumers(t *testing.T) {
+	q := NewBoundedQueue(10, func(item interface{}) {})
+	var lock sync.Mutex
+	var batches [][]interface{}
+	startLock := &sync.Mutex{}
+	startLock.Lock()
+	consumed := make(chan struct{}, 10)
+	q.StartBatchConsumers(1, 3, func(items []interface{}) {
+		startLock.Lock()
+		defer startLock.Unlock()
+		lock.Lock()
+		batches = append(batches, items)
+		lock.Unlock()
+		consumed <- struct{}{}
+	})
+
+	require.True(t, q.Produce(0))
+	// wait until the consumer has taken the first item and is blocked
+	require.Eventually(t, func() bool { return q.Size() == 0 }, time.Second, time.Millisecond)
+	for i := 1; i <= 5; i++ {
+		require.True(t, q.Produce(i))
+	}
+	startLock.Unlock()
+	for i := 0; i < 3; i++ {
+		<-consumed
+	}
+	q.Stop()
+
+	lock.Lock()
+	defer lock.Unlock()
+	// the items waiting in the queue are consumed together, up to the maximum batch size
+	assert.Equal(t, [][]interface{}{{0}, {1, 2, 3}, {4, 5}}, batches)
+}
+
+func TestBatchConsumerFunc(t *testing.T) {
+	var items []interface{}
+	c := BatchConsumerFunc(func(batch []interface{}) {
+		items = append(items, batch...)
+	})
+	c.Consume(1)
+	c.ConsumeBatch([]interface{}{2, 3})
+	assert.Equal(t, []interface{}{1, 2, 3}, items)
+}
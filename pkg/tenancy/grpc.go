@@ -0,0 +1,79 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// TenantFromIncomingContext returns a context carrying the tenant found in the incoming gRPC metadata.
+// It returns an error when multi-tenancy is enabled and the metadata carries no valid tenant.
+func TenantFromIncomingContext(ctx context.Context, options Options) (context.Context, error) {
+	if !options.Enabled {
+		return ctx, nil
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	tenants := md.Get(strings.ToLower(options.header()))
+	if len(tenants) == 0 || tenants[0] == "" {
+		return nil, status.Errorf(codes.Unauthenticated, "missing tenant header")
+	}
+	if len(tenants) > 1 {
+		return nil, status.Errorf(codes.PermissionDenied, "extra tenant header")
+	}
+	if !options.Valid(tenants[0]) {
+		return nil, status.Errorf(codes.PermissionDenied, "unknown tenant")
+	}
+	return WithTenant(ctx, tenants[0]), nil
+}
+
+// NewGuardingUnaryInterceptor returns a unary server interceptor that rejects calls without a valid tenant
+// and adds the tenant to the context of the accepted calls
+func NewGuardingUnaryInterceptor(options Options) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx, err := TenantFromIncomingContext(ctx, options)
+		if err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// NewGuardingStreamInterceptor returns a stream server interceptor that rejects calls without a valid tenant
+// and adds the tenant to the context of the accepted calls
+func NewGuardingStreamInterceptor(options Options) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx, err := TenantFromIncomingContext(ss.Context(), options)
+		if err != nil {
+			return err
+		}
+		return handler(srv, &tenantedServerStream{ServerStream: ss, ctx: ctx})
+	}
+}
+
+// tenantedServerStream overrides the context of the wrapped stream
+type tenantedServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *tenantedServerStream) Context() context.Context {
+	return s.ctx
+}
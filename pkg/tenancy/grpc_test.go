@@ -0,0 +1,90 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestTenantFromIncomingContext(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		md      metadata.MD
+		code    codes.Code
+		tenant  string
+	}{
+		{name: "disabled", options: Options{}, md: metadata.Pairs(DefaultHeader, "acme"), code: codes.OK},
+		{name: "missing", options: Options{Enabled: true}, md: metadata.MD{}, code: codes.Unauthenticated},
+		{name: "extra", options: Options{Enabled: true}, md: metadata.Pairs(DefaultHeader, "acme", DefaultHeader, "globex"), code: codes.PermissionDenied},
+		{name: "unknown", options: Options{Enabled: true, Tenants: []string{"globex"}}, md: metadata.Pairs(DefaultHeader, "acme"), code: codes.PermissionDenied},
+		{name: "valid", options: Options{Enabled: true, Header: "X-Team"}, md: metadata.Pairs("x-team", "acme"), code: codes.OK, tenant: "acme"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, err := TenantFromIncomingContext(metadata.NewIncomingContext(context.Background(), test.md), test.options)
+			assert.Equal(t, test.code, status.Code(err))
+			if err == nil {
+				assert.Equal(t, test.tenant, GetTenant(ctx))
+			}
+		})
+	}
+}
+
+type fakeServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *fakeServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func TestGuardingInterceptors(t *testing.T) {
+	options := Options{Enabled: true}
+	valid := metadata.NewIncomingContext(context.Background(), metadata.Pairs(DefaultHeader, "acme"))
+
+	unary := NewGuardingUnaryInterceptor(options)
+	res, err := unary(valid, "req", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return GetTenant(ctx), nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", res)
+	_, err = unary(context.Background(), "req", &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+
+	stream := NewGuardingStreamInterceptor(options)
+	var tenant string
+	err = stream(nil, &fakeServerStream{ctx: valid}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		tenant = GetTenant(ss.Context())
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "acme", tenant)
+	err = stream(nil, &fakeServerStream{ctx: context.Background()}, &grpc.StreamServerInfo{}, func(srv interface{}, ss grpc.ServerStream) error {
+		return nil
+	})
+	assert.Equal(t, codes.Unauthenticated, status.Code(err))
+}
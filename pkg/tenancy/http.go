@@ -0,0 +1,47 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import "net/http"
+
+// ExtractTenantHTTPHandler returns a handler that rejects requests without a valid tenant header
+// and adds the tenant to the context of the accepted requests.
+// CORS preflight requests are let through, since browsers never send custom headers with them.
+// The handler is returned unchanged when multi-tenancy is not enabled.
+func ExtractTenantHTTPHandler(options Options, h http.Handler) http.Handler {
+	if !options.Enabled {
+		return h
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPreflight(r) {
+			h.ServeHTTP(w, r)
+			return
+		}
+		tenant := r.Header.Get(options.header())
+		if tenant == "" {
+			http.Error(w, "missing tenant header", http.StatusUnauthorized)
+			return
+		}
+		if !options.Valid(tenant) {
+			http.Error(w, "unknown tenant", http.StatusForbidden)
+			return
+		}
+		h.ServeHTTP(w, r.WithContext(WithTenant(r.Context(), tenant)))
+	})
+}
+
+func isPreflight(r *http.Request) bool {
+	return r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+}
@@ -0,0 +1,95 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"flag"
+	"regexp"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	tenancyEnabled = "multi-tenancy.enabled"
+	tenancyHeader  = "multi-tenancy.header"
+	tenancyTenants = "multi-tenancy.tenants"
+
+	// DefaultHeader is the default HTTP header and gRPC metadata key carrying the tenant
+	DefaultHeader = "x-tenant"
+)
+
+// validTenant restricts tenants to names that can be used safely as index names and key prefixes by all storage backends
+var validTenant = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+
+// Options describes the configuration of multi-tenancy
+type Options struct {
+	// Enabled requires every request to carry a valid tenant
+	Enabled bool
+	// Header is the HTTP header and gRPC metadata key carrying the tenant
+	Header string
+	// Tenants is the list of allowed tenants, any valid tenant is allowed when empty
+	Tenants []string
+}
+
+// AddFlags adds flags for multi-tenancy to the FlagSet.
+func AddFlags(flags *flag.FlagSet) {
+	flags.Bool(tenancyEnabled, false, "Enable tenancy header when receiving or querying")
+	flags.String(tenancyHeader, DefaultHeader, "HTTP header and gRPC metadata key carrying the tenant")
+	flags.String(tenancyTenants, "", "Comma-separated list of allowed tenants. All tenants are allowed when empty. Tenants may only contain lower case letters, digits, '_' and '-'")
+}
+
+// InitFromViper creates Options populated with values retrieved from Viper.
+func InitFromViper(v *viper.Viper) Options {
+	var p Options
+	p.Enabled = v.GetBool(tenancyEnabled)
+	p.Header = v.GetString(tenancyHeader)
+	if tenants := v.GetString(tenancyTenants); tenants != "" {
+		for _, tenant := range strings.Split(tenants, ",") {
+			if tenant = strings.TrimSpace(tenant); tenant != "" {
+				p.Tenants = append(p.Tenants, tenant)
+			}
+		}
+	}
+	return p
+}
+
+// WellFormed returns true if the tenant only contains the characters allowed in tenants
+func WellFormed(tenant string) bool {
+	return validTenant.MatchString(tenant)
+}
+
+// Valid returns true if the tenant is well-formed and allowed
+func (o Options) Valid(tenant string) bool {
+	if !WellFormed(tenant) {
+		return false
+	}
+	if len(o.Tenants) == 0 {
+		return true
+	}
+	for _, t := range o.Tenants {
+		if t == tenant {
+			return true
+		}
+	}
+	return false
+}
+
+func (o Options) header() string {
+	if o.Header == "" {
+		return DefaultHeader
+	}
+	return o.Header
+}
@@ -0,0 +1,78 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tenancy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/jaegertracing/jaeger/pkg/config"
+)
+
+func TestOptionsFromFlags(t *testing.T) {
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{
+		"--multi-tenancy.enabled=true",
+		"--multi-tenancy.header=x-team",
+		"--multi-tenancy.tenants=acme, globex,",
+	})
+	options := InitFromViper(v)
+
+	assert.True(t, options.Enabled)
+	assert.Equal(t, "x-team", options.Header)
+	assert.Equal(t, []string{"acme", "globex"}, options.Tenants)
+}
+
+func TestOptionsDefaults(t *testing.T) {
+	v, command := config.Viperize(AddFlags)
+	command.ParseFlags([]string{})
+	options := InitFromViper(v)
+
+	assert.False(t, options.Enabled)
+	assert.Equal(t, DefaultHeader, options.Header)
+	assert.Empty(t, options.Tenants)
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		tenants []string
+		tenant  string
+		valid   bool
+	}{
+		{tenant: "acme", valid: true},
+		{tenant: "team_1-a", valid: true},
+		{tenant: "", valid: false},
+		{tenant: "Acme", valid: false},
+		{tenant: "-acme", valid: false},
+		{tenant: "ac/me", valid: false},
+		{tenants: []string{"acme"}, tenant: "acme", valid: true},
+		{tenants: []string{"acme"}, tenant: "globex", valid: false},
+	}
+	for _, test := range tests {
+		t.Run(test.tenant, func(t *testing.T) {
+			options := Options{Enabled: true, Tenants: test.tenants}
+			assert.Equal(t, test.valid, options.Valid(test.tenant))
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	ctx := context.Background()
+	assert.Equal(t, "", GetTenant(ctx))
+	assert.Equal(t, ctx, WithTenant(ctx, ""))
+	assert.Equal(t, "acme", GetTenant(WithTenant(ctx, "acme")))
+}
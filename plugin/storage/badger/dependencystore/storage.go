@@ -46,8 +46,8 @@ func (s *DependencyStore) GetDependencies(ctx context.Context, endTs time.Time,
 	// We need to do a full table scan - if this becomes a bottleneck, we can write an index that describes
 	// dependencyKeyPrefix + timestamp + parent + child key and do a key-only seek (which is fast - but requires additional writes)
 
-	// GetDependencies is not shipped with a context like the SpanReader / SpanWriter
-	traces, err := s.reader.FindTraces(context.Background(), params)
+	// the context carries the tenant, so that only the traces of the tenant are read
+	traces, err := s.reader.FindTraces(ctx, params)
 	if err != nil {
 		return nil, err
 	}
@@ -153,12 +153,12 @@ func initializeDir(path string) {
 
 // CreateSpanReader implements storage.Factory
 func (f *Factory) CreateSpanReader() (spanstore.Reader, error) {
-	return badgerStore.NewTraceReader(f.store, f.cache), nil
+	return spanstore.NewTenantKeyPrefixReader(badgerStore.NewTraceReader(f.store, f.cache)), nil
 }
 
 // CreateSpanWriter implements storage.Factory
 func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
-	return badgerStore.NewSpanWriter(f.store, f.cache, f.Options.Primary.SpanStoreTTL), nil
+	return spanstore.NewTenantKeyPrefixWriter(badgerStore.NewSpanWriter(f.store, f.cache, f.Options.Primary.SpanStoreTTL)), nil
 }
 
 // CreateDependencyReader implements storage.Factory
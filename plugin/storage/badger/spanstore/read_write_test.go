@@ -90,6 +90,50 @@ func TestWriteReadBack(t *testing.T) {
 	})
 }
 
+func TestWriteSpansReadBack(t *testing.T) {
+	runFactoryTest(t, func(tb testing.TB, sw spanstore.Writer, sr spanstore.Reader) {
+		tid := time.Now()
+		traces := 40
+		spansPerTrace := 3
+
+		var spans []*model.Span
+		for i := 0; i < traces; i++ {
+			for j := 0; j < spansPerTrace; j++ {
+				spans = append(spans, &model.Span{
+					TraceID: model.TraceID{
+						Low:  uint64(i),
+						High: 1,
+					},
+					SpanID:        model.SpanID(j),
+					OperationName: fmt.Sprintf("operation-%d", j),
+					Process: &model.Process{
+						ServiceName: "service",
+					},
+					StartTime: tid.Add(time.Duration(i)),
+					Duration:  time.Duration(i + j),
+					Tags:      model.KeyValues{model.String("key", "value")},
+				})
+			}
+		}
+		batchWriter, ok := sw.(spanstore.BatchWriter)
+		require.True(t, ok)
+		require.NoError(t, batchWriter.WriteSpans(context.Background(), spans))
+
+		for i := 0; i < traces; i++ {
+			tr, err := sr.GetTrace(context.Background(), model.TraceID{
+				Low:  uint64(i),
+				High: 1,
+			})
+			assert.NoError(t, err)
+			assert.Equal(t, spansPerTrace, len(tr.Spans))
+		}
+
+		operations, err := sr.GetOperations(context.Background(), spanstore.OperationQueryParameters{ServiceName: "service"})
+		assert.NoError(t, err)
+		assert.Len(t, operations, spansPerTrace)
+	})
+}
+
 func TestValidation(t *testing.T) {
 	runFactoryTest(t, func(tb testing.TB, sw spanstore.Writer, sr spanstore.Reader) {
 		tid := time.Now()
@@ -16,12 +16,15 @@ package spanstore
 import (
 	"context"
 	"encoding/binary"
+	"fmt"
 	"math/rand"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/dgraph-io/badger"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
@@ -129,6 +132,46 @@ func TestDuplicateTraceIDDetection(t *testing.T) {
 	})
 }
 
+func TestWriteSpansPartlyCommitted(t *testing.T) {
+	runWithBadger(t, func(store *badger.DB, t *testing.T) {
+		cache := NewCacheStore(store, time.Duration(1*time.Hour), false)
+		sw := NewSpanWriter(store, cache, time.Duration(1*time.Hour))
+
+		// the tag index keys embed the tag values, so the transaction becomes too big and is split
+		var spans []*model.Span
+		for i := 0; i < 200; i++ {
+			spans = append(spans, &model.Span{
+				TraceID:       model.TraceID{Low: uint64(i), High: 1},
+				OperationName: "operation",
+				Process:       &model.Process{ServiceName: fmt.Sprintf("service-%d", i)},
+				StartTime:     time.Now(),
+				Tags:          model.KeyValues{model.String("key", strings.Repeat("v", 60000))},
+			})
+		}
+		// a key too large for badger fails the last transaction
+		spans = append(spans, &model.Span{
+			TraceID:       model.TraceID{Low: 200, High: 1},
+			OperationName: "operation",
+			Process:       &model.Process{ServiceName: "too-large"},
+			StartTime:     time.Now(),
+			Tags:          model.KeyValues{model.String("key", strings.Repeat("v", 70000))},
+		})
+		assert.Error(t, sw.WriteSpans(context.Background(), spans))
+
+		rw := NewTraceReader(store, cache)
+		tr, err := rw.GetTrace(context.Background(), spans[0].TraceID)
+		require.NoError(t, err)
+		assert.Len(t, tr.Spans, 1)
+
+		// the services of the committed spans are cached, those of the discarded spans are not
+		services, err := cache.GetServices()
+		require.NoError(t, err)
+		assert.Contains(t, services, "service-0")
+		assert.NotContains(t, services, "too-large")
+		assert.Less(t, len(services), len(spans)-1)
+	})
+}
+
 func createDummySpan() model.Span {
 	tid := time.Now()
 
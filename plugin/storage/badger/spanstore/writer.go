@@ -66,15 +66,78 @@ func NewSpanWriter(db *badger.DB, c *CacheStore, ttl time.Duration) *SpanWriter
 
 // WriteSpan writes the encoded span as well as creates indexes with defined TTL
 func (w *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	return w.WriteSpans(ctx, []*model.Span{span})
+}
+
+// WriteSpans writes the encoded spans and their indexes with defined TTL in a single transaction.
+// The transaction is only split if it becomes too big for badger, in which case the batch may be
+// partly committed when an error is returned: the spans of the committed transactions are stored.
+func (w *SpanWriter) WriteSpans(ctx context.Context, spans []*model.Span) error {
 	expireTime := uint64(time.Now().Add(w.ttl).Unix())
-	startTime := model.TimeAsEpochMicroseconds(span.StartTime)
 
 	// Avoid doing as much as possible inside the transaction boundary, create entries here
+	var entriesToStore []*badger.Entry
+	// spanEnds holds the number of entries up to and including those of each span
+	spanEnds := make([]int, len(spans))
+	for i, span := range spans {
+		entries, err := w.createSpanEntries(span, expireTime)
+		if err != nil {
+			return err
+		}
+		entriesToStore = append(entriesToStore, entries...)
+		spanEnds[i] = len(entriesToStore)
+	}
+
+	committed := 0
+	defer func() {
+		// Do cache refresh here to release the transaction earlier, and also when a later transaction
+		// failed, for the services and operations of the spans already committed
+		for i, span := range spans {
+			if spanEnds[i] > committed {
+				break
+			}
+			w.cache.Update(span.Process.ServiceName, span.OperationName, expireTime)
+		}
+	}()
+	txn := w.store.NewTransaction(true)
+	defer func() {
+		// txn is replaced when it is split, so it must not be bound to the deferred call
+		txn.Discard()
+	}()
+	for i := range entriesToStore {
+		err := txn.SetEntry(entriesToStore[i])
+		if err == badger.ErrTxnTooBig {
+			if err = txn.Commit(); err != nil {
+				return err
+			}
+			committed = i
+			txn = w.store.NewTransaction(true)
+			err = txn.SetEntry(entriesToStore[i])
+		}
+		if err != nil {
+			// Most likely primary key conflict, but let the caller check this
+			return err
+		}
+	}
+
+	// TODO Alternative option is to use simpler keys with the merge value interface.
+	// Requires at least this to be solved: https://github.com/dgraph-io/badger/issues/373
+
+	if err := txn.Commit(); err != nil {
+		return err
+	}
+	committed = len(entriesToStore)
+	return nil
+}
+
+func (w *SpanWriter) createSpanEntries(span *model.Span, expireTime uint64) ([]*badger.Entry, error) {
+	startTime := model.TimeAsEpochMicroseconds(span.StartTime)
+
 	entriesToStore := make([]*badger.Entry, 0, len(span.Tags)+4+len(span.Process.Tags)+len(span.Logs)*4)
 
 	trace, err := w.createTraceEntry(span, startTime, expireTime)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	entriesToStore = append(entriesToStore, trace)
@@ -101,27 +164,7 @@ func (w *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
 			entriesToStore = append(entriesToStore, w.createBadgerEntry(createIndexKey(tagIndexKey, []byte(span.Process.ServiceName+kv.Key+kv.AsString()), startTime, span.TraceID), nil, expireTime))
 		}
 	}
-
-	err = w.store.Update(func(txn *badger.Txn) error {
-		// Write the entries
-		for i := range entriesToStore {
-			err = txn.SetEntry(entriesToStore[i])
-			if err != nil {
-				// Most likely primary key conflict, but let the caller check this
-				return err
-			}
-		}
-
-		// TODO Alternative option is to use simpler keys with the merge value interface.
-		// Requires at least this to be solved: https://github.com/dgraph-io/badger/issues/373
-
-		return nil
-	})
-
-	// Do cache refresh here to release the transaction earlier
-	w.cache.Update(span.Process.ServiceName, span.OperationName, expireTime)
-
-	return err
+	return entriesToStore, nil
 }
 
 func createIndexKey(indexPrefixKey byte, value []byte, startTime uint64, traceID model.TraceID) []byte {
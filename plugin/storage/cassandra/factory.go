@@ -109,7 +109,7 @@ func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger)
 
 // CreateSpanReader implements storage.Factory
 func (f *Factory) CreateSpanReader() (spanstore.Reader, error) {
-	return cSpanStore.NewSpanReader(f.primarySession, f.primaryMetricsFactory, f.logger), nil
+	return spanstore.NewTenantKeyPrefixReader(cSpanStore.NewSpanReader(f.primarySession, f.primaryMetricsFactory, f.logger)), nil
 }
 
 // CreateSpanWriter implements storage.Factory
@@ -118,13 +118,17 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return cSpanStore.NewSpanWriter(f.primarySession, f.Options.SpanStoreWriteCacheTTL, f.primaryMetricsFactory, f.logger, options...), nil
+	return spanstore.NewTenantKeyPrefixWriter(cSpanStore.NewSpanWriter(f.primarySession, f.Options.SpanStoreWriteCacheTTL, f.primaryMetricsFactory, f.logger, options...)), nil
 }
 
 // CreateDependencyReader implements storage.Factory
 func (f *Factory) CreateDependencyReader() (dependencystore.Reader, error) {
 	version := cDepStore.GetDependencyVersion(f.primarySession)
-	return cDepStore.NewDependencyStore(f.primarySession, f.primaryMetricsFactory, f.logger, version)
+	store, err := cDepStore.NewDependencyStore(f.primarySession, f.primaryMetricsFactory, f.logger, version)
+	if err != nil {
+		return nil, err
+	}
+	return dependencystore.NewTenantKeyPrefixReader(store), nil
 }
 
 // CreateArchiveSpanReader implements storage.ArchiveFactory
@@ -132,7 +136,7 @@ func (f *Factory) CreateArchiveSpanReader() (spanstore.Reader, error) {
 	if f.archiveSession == nil {
 		return nil, storage.ErrArchiveStorageNotConfigured
 	}
-	return cSpanStore.NewSpanReader(f.archiveSession, f.archiveMetricsFactory, f.logger), nil
+	return spanstore.NewTenantKeyPrefixReader(cSpanStore.NewSpanReader(f.archiveSession, f.archiveMetricsFactory, f.logger)), nil
 }
 
 // CreateArchiveSpanWriter implements storage.ArchiveFactory
@@ -144,7 +148,7 @@ func (f *Factory) CreateArchiveSpanWriter() (spanstore.Writer, error) {
 	if err != nil {
 		return nil, err
 	}
-	return cSpanStore.NewSpanWriter(f.archiveSession, f.Options.SpanStoreWriteCacheTTL, f.archiveMetricsFactory, f.logger, options...), nil
+	return spanstore.NewTenantKeyPrefixWriter(cSpanStore.NewSpanWriter(f.archiveSession, f.Options.SpanStoreWriteCacheTTL, f.archiveMetricsFactory, f.logger, options...)), nil
 }
 
 func writerOptions(opts *Options) ([]cSpanStore.Option, error) {
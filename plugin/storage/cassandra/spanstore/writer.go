@@ -66,6 +66,10 @@ const (
 	defaultNumBuckets = 10
 
 	durationBucketSize = time.Hour
+
+	// maxBatchBytes keeps the batches of spans below the default batch_size_fail_threshold_in_kb of Cassandra (50KB),
+	// using the size of the domain spans as an estimate of the size of the statements
+	maxBatchBytes = 32 * 1024
 )
 
 const (
@@ -151,9 +155,74 @@ func (s *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
 	return nil
 }
 
-func (s *SpanWriter) writeSpan(span *model.Span, ds *dbmodel.Span) error {
-	mainQuery := s.session.Query(
-		insertSpan,
+// WriteSpans saves the spans into Cassandra. The spans of the same trace are inserted with unlogged batches,
+// which Cassandra applies to the trace's partition in a single request. The indexes are saved one span at a time.
+func (s *SpanWriter) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	dbSpans := make([]*dbmodel.Span, len(spans))
+	for i, span := range spans {
+		dbSpans[i] = dbmodel.FromDomain(span)
+	}
+	if s.storageMode&storeFlag == storeFlag {
+		if err := s.writeSpansByTrace(spans, dbSpans); err != nil {
+			return err
+		}
+	}
+	if s.storageMode&indexFlag == indexFlag {
+		for i, span := range spans {
+			if err := s.writeIndexes(span, dbSpans[i]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *SpanWriter) writeSpansByTrace(spans []*model.Span, dbSpans []*dbmodel.Span) error {
+	var traceIDs []model.TraceID
+	byTraceID := make(map[model.TraceID][]int)
+	for i, span := range spans {
+		if _, ok := byTraceID[span.TraceID]; !ok {
+			traceIDs = append(traceIDs, span.TraceID)
+		}
+		byTraceID[span.TraceID] = append(byTraceID[span.TraceID], i)
+	}
+	for _, traceID := range traceIDs {
+		var batch []*dbmodel.Span
+		batchBytes := 0
+		for _, i := range byTraceID[traceID] {
+			spanBytes := spans[i].Size()
+			if len(batch) > 0 && batchBytes+spanBytes > maxBatchBytes {
+				if err := s.writeSpanBatch(batch); err != nil {
+					return err
+				}
+				batch, batchBytes = nil, 0
+			}
+			batch = append(batch, dbSpans[i])
+			batchBytes += spanBytes
+		}
+		if err := s.writeSpanBatch(batch); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SpanWriter) writeSpanBatch(dbSpans []*dbmodel.Span) error {
+	if len(dbSpans) == 1 {
+		return s.writeSpan(nil, dbSpans[0])
+	}
+	batch := s.session.NewBatch(cassandra.UnloggedBatch)
+	for _, ds := range dbSpans {
+		batch.Query(insertSpan, insertSpanValues(ds)...)
+	}
+	if err := s.writerMetrics.traces.ExecBatch(batch, s.logger); err != nil {
+		return s.logError(dbSpans[0], err, "Failed to insert span batch", s.logger)
+	}
+	return nil
+}
+
+func insertSpanValues(ds *dbmodel.Span) []interface{} {
+	return []interface{}{
 		ds.TraceID,
 		ds.SpanID,
 		ds.SpanHash,
@@ -166,7 +235,11 @@ func (s *SpanWriter) writeSpan(span *model.Span, ds *dbmodel.Span) error {
 		ds.Logs,
 		ds.Refs,
 		ds.Process,
-	)
+	}
+}
+
+func (s *SpanWriter) writeSpan(span *model.Span, ds *dbmodel.Span) error {
+	mainQuery := s.session.Query(insertSpan, insertSpanValues(ds)...)
 	if err := s.writerMetrics.traces.Exec(mainQuery, s.logger); err != nil {
 		return s.logError(ds, err, "Failed to insert span", s.logger)
 	}
@@ -30,6 +30,7 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/cassandra"
 	"github.com/jaegertracing/jaeger/pkg/cassandra/mocks"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
 	"github.com/jaegertracing/jaeger/plugin/storage/cassandra/spanstore/dbmodel"
@@ -62,7 +63,8 @@ func withSpanWriter(writeCacheTTL time.Duration, fn func(w *spanWriterTest), opt
 	fn(w)
 }
 
-var _ spanstore.Writer = &SpanWriter{} // check API conformance
+var _ spanstore.Writer = &SpanWriter{}      // check API conformance
+var _ spanstore.BatchWriter = &SpanWriter{} // check API conformance
 
 func TestClientClose(t *testing.T) {
 	withSpanWriter(0, func(w *spanWriterTest) {
@@ -434,3 +436,52 @@ func TestStorageMode_StoreWithoutIndexing(t *testing.T) {
 		w.session.AssertNotCalled(t, "Query", stringMatcher(serviceNameIndex), matchEverything())
 	}, StoreWithoutIndexing())
 }
+
+func TestSpanWriterWriteSpans(t *testing.T) {
+	withSpanWriter(0, func(w *spanWriterTest) {
+		bigTag := model.String("payload", strings.Repeat("x", maxBatchBytes/2))
+		spans := []*model.Span{
+			{TraceID: model.NewTraceID(0, 1), SpanID: 1, Process: model.NewProcess("service-a", nil)},
+			{TraceID: model.NewTraceID(0, 2), SpanID: 1, Process: model.NewProcess("service-a", nil)},
+			{TraceID: model.NewTraceID(0, 1), SpanID: 2, Process: model.NewProcess("service-a", nil)},
+			// the spans of trace 3 do not fit in a single batch
+			{TraceID: model.NewTraceID(0, 3), SpanID: 1, Process: model.NewProcess("service-a", nil), Tags: model.KeyValues{bigTag}},
+			{TraceID: model.NewTraceID(0, 3), SpanID: 2, Process: model.NewProcess("service-a", nil), Tags: model.KeyValues{bigTag}},
+		}
+
+		batch := &mocks.Batch{}
+		batch.On("Query", insertSpan, mock.Anything).Return()
+		batch.On("Exec").Return(nil)
+		w.session.On("NewBatch", cassandra.UnloggedBatch).Return(batch)
+		spanQuery := &mocks.Query{}
+		spanQuery.On("Exec").Return(nil)
+		w.session.On("Query", stringMatcher(insertSpan), matchEverything()).Return(spanQuery)
+
+		err := w.writer.WriteSpans(context.Background(), spans)
+		assert.NoError(t, err)
+		// trace 1 is saved with a batch, traces 2 and 3 span by span
+		w.session.AssertNumberOfCalls(t, "NewBatch", 1)
+		batch.AssertNumberOfCalls(t, "Query", 2)
+		batch.AssertNumberOfCalls(t, "Exec", 1)
+		spanQuery.AssertNumberOfCalls(t, "Exec", 3)
+	}, StoreWithoutIndexing())
+}
+
+func TestSpanWriterWriteSpansBatchError(t *testing.T) {
+	withSpanWriter(0, func(w *spanWriterTest) {
+		spans := []*model.Span{
+			{TraceID: model.NewTraceID(0, 1), SpanID: 1, Process: model.NewProcess("service-a", nil)},
+			{TraceID: model.NewTraceID(0, 1), SpanID: 2, Process: model.NewProcess("service-a", nil)},
+		}
+
+		batch := &mocks.Batch{}
+		batch.On("Query", insertSpan, mock.Anything).Return()
+		batch.On("Exec").Return(errors.New("batch error"))
+		batch.On("String").Return("batch")
+		w.session.On("NewBatch", cassandra.UnloggedBatch).Return(batch)
+
+		err := w.writer.WriteSpans(context.Background(), spans)
+		assert.EqualError(t, err, "Failed to insert span batch: failed to Exec query 'batch': batch error")
+		assert.Contains(t, w.logBuffer.String(), `"trace_id":"0000000000000001"`)
+	}, StoreWithoutIndexing())
+}
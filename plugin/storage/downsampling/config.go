@@ -0,0 +1,84 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package downsampling
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+)
+
+// operationRatio defines an operation specific downsampling ratio.
+type operationRatio struct {
+	Operation string  `json:"operation"`
+	Ratio     float64 `json:"ratio"`
+}
+
+// serviceRatio defines a service specific downsampling ratio and the ratios of some of its operations.
+// When Ratio is omitted, the operations without a specific ratio use the default ratio.
+type serviceRatio struct {
+	Service         string            `json:"service"`
+	Ratio           *float64          `json:"ratio,omitempty"`
+	OperationRatios []*operationRatio `json:"operation_ratios,omitempty"`
+}
+
+// config holds a default ratio, applied to every service without a specific one, and service specific ratios.
+type config struct {
+	DefaultRatio  *float64        `json:"default_ratio"`
+	ServiceRatios []*serviceRatio `json:"service_ratios"`
+}
+
+func loadConfig(path string) (*config, error) {
+	bytes, err := ioutil.ReadFile(filepath.Clean(path))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read downsampling ratios file: %w", err)
+	}
+	var c config
+	if err := json.Unmarshal(bytes, &c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal downsampling ratios: %w", err)
+	}
+	if c.DefaultRatio != nil {
+		if err := validateRatio(*c.DefaultRatio); err != nil {
+			return nil, fmt.Errorf("invalid default ratio: %w", err)
+		}
+	}
+	for _, sr := range c.ServiceRatios {
+		if sr.Service == "" {
+			return nil, fmt.Errorf("service ratio without service name")
+		}
+		if sr.Ratio != nil {
+			if err := validateRatio(*sr.Ratio); err != nil {
+				return nil, fmt.Errorf("invalid ratio for service %s: %w", sr.Service, err)
+			}
+		}
+		for _, or := range sr.OperationRatios {
+			if or.Operation == "" {
+				return nil, fmt.Errorf("operation ratio without operation name in service %s", sr.Service)
+			}
+			if err := validateRatio(or.Ratio); err != nil {
+				return nil, fmt.Errorf("invalid ratio for operation %s of service %s: %w", or.Operation, sr.Service, err)
+			}
+		}
+	}
+	return &c, nil
+}
+
+func validateRatio(ratio float64) error {
+	if ratio < 0 || ratio > 1 {
+		return fmt.Errorf("ratio must be between 0 and 1, got %v", ratio)
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package downsampling
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"sort"
+	"sync/atomic"
+
+	"github.com/uber/jaeger-lib/metrics"
+	"go.uber.org/zap"
+
+	"github.com/jaegertracing/jaeger/pkg/fswatcher"
+)
+
+type ratiosMetrics struct {
+	// ReloadSuccess is the number of successful reloads of the downsampling ratios file
+	ReloadSuccess metrics.Counter `metric:"reloads" tags:"result=ok"`
+	// ReloadFailure is the number of failed reloads of the downsampling ratios file
+	ReloadFailure metrics.Counter `metric:"reloads" tags:"result=err"`
+}
+
+// Ratios provides per-service and per-operation downsampling ratios read from a file.
+// The file is watched and the ratios are replaced whenever it changes.
+type Ratios struct {
+	path         string
+	defaultRatio float64
+	logger       *zap.Logger
+	metrics      ratiosMetrics
+	table        atomic.Value // *table
+	watcher      io.Closer
+}
+
+// table is the immutable lookup structure built from a config.
+type table struct {
+	defaultRatio float64
+	services     map[string]*serviceTable
+}
+
+type serviceTable struct {
+	ratio      float64
+	operations map[string]float64
+}
+
+// Effective describes the ratios in use, as returned by the admin endpoint.
+type Effective struct {
+	DefaultRatio  float64            `json:"default_ratio"`
+	ServiceRatios []EffectiveService `json:"service_ratios,omitempty"`
+}
+
+// EffectiveService describes the ratios in use for a service.
+type EffectiveService struct {
+	Service         string             `json:"service"`
+	Ratio           float64            `json:"ratio"`
+	OperationRatios map[string]float64 `json:"operation_ratios,omitempty"`
+}
+
+// NewRatios creates Ratios from the file at the given path and starts watching the file for changes.
+// defaultRatio is used when the file does not define a default ratio.
+func NewRatios(path string, defaultRatio float64, logger *zap.Logger, metricsFactory metrics.Factory) (*Ratios, error) {
+	c, err := loadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	r := &Ratios{
+		path:         path,
+		defaultRatio: defaultRatio,
+		logger:       logger,
+	}
+	metrics.MustInit(&r.metrics, metricsFactory.Namespace(metrics.NSOptions{Name: "downsampling_ratios"}), nil)
+	r.table.Store(newTable(c, defaultRatio))
+	watcher, err := fswatcher.WatchFile(path, r.reload, logger)
+	if err != nil {
+		return nil, err
+	}
+	r.watcher = watcher
+	logger.Info("Loaded downsampling ratios", zap.String("file", path), zap.Int("services", len(c.ServiceRatios)))
+	return r, nil
+}
+
+// Close stops watching the ratios file.
+func (r *Ratios) Close() error {
+	return r.watcher.Close()
+}
+
+func (r *Ratios) reload() {
+	c, err := loadConfig(r.path)
+	if err != nil {
+		r.metrics.ReloadFailure.Inc(1)
+		r.logger.Error("Failed to reload downsampling ratios, using the last known version", zap.String("file", r.path), zap.Error(err))
+		return
+	}
+	r.table.Store(newTable(c, r.defaultRatio))
+	r.metrics.ReloadSuccess.Inc(1)
+	r.logger.Info("Reloaded downsampling ratios", zap.String("file", r.path), zap.Int("services", len(c.ServiceRatios)))
+}
+
+// Ratio returns the downsampling ratio of the operation of the service, falling back
+// to the ratio of the service and then to the default ratio.
+func (r *Ratios) Ratio(service, operation string) float64 {
+	t := r.table.Load().(*table)
+	st, ok := t.services[service]
+	if !ok {
+		return t.defaultRatio
+	}
+	if ratio, ok := st.operations[operation]; ok {
+		return ratio
+	}
+	return st.ratio
+}
+
+// Effective returns the ratios currently in use.
+func (r *Ratios) Effective() Effective {
+	t := r.table.Load().(*table)
+	e := Effective{DefaultRatio: t.defaultRatio}
+	for service, st := range t.services {
+		e.ServiceRatios = append(e.ServiceRatios, EffectiveService{
+			Service:         service,
+			Ratio:           st.ratio,
+			OperationRatios: st.operations,
+		})
+	}
+	sort.Slice(e.ServiceRatios, func(i, j int) bool {
+		return e.ServiceRatios[i].Service < e.ServiceRatios[j].Service
+	})
+	return e
+}
+
+// ServeHTTP writes the ratios currently in use as JSON.
+func (r *Ratios) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	WriteEffective(w, r.Effective())
+}
+
+// WriteEffective writes the effective ratios as a JSON response.
+func WriteEffective(w http.ResponseWriter, e Effective) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(e)
+}
+
+func newTable(c *config, defaultRatio float64) *table {
+	t := &table{
+		defaultRatio: defaultRatio,
+		services:     make(map[string]*serviceTable, len(c.ServiceRatios)),
+	}
+	if c.DefaultRatio != nil {
+		t.defaultRatio = *c.DefaultRatio
+	}
+	for _, sr := range c.ServiceRatios {
+		st := &serviceTable{ratio: t.defaultRatio}
+		if sr.Ratio != nil {
+			st.ratio = *sr.Ratio
+		}
+		if len(sr.OperationRatios) > 0 {
+			st.operations = make(map[string]float64, len(sr.OperationRatios))
+			for _, or := range sr.OperationRatios {
+				st.operations[or.Operation] = or.Ratio
+			}
+		}
+		t.services[sr.Service] = st
+	}
+	return t
+}
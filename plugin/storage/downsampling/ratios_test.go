@@ -0,0 +1,139 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package downsampling
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
+	"go.uber.org/zap"
+)
+
+func writeRatios(t *testing.T, content string) string {
+	dir, err := ioutil.TempDir("", "downsampling")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	path := filepath.Join(dir, "ratios.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(content), 0600))
+	return path
+}
+
+func newTestRatios(t *testing.T, content string) (*Ratios, *metricstest.Factory, string) {
+	path := writeRatios(t, content)
+	mf := metricstest.NewFactory(time.Hour)
+	r, err := NewRatios(path, 1, zap.NewNop(), mf)
+	require.NoError(t, err)
+	t.Cleanup(func() { r.Close() })
+	return r, mf, path
+}
+
+func TestRatios(t *testing.T) {
+	r, _, _ := newTestRatios(t, `{
+		"default_ratio": 0.5,
+		"service_ratios": [
+			{"service": "noisy", "ratio": 0.1, "operation_ratios": [{"operation": "login", "ratio": 1}]},
+			{"service": "chatty", "operation_ratios": [{"operation": "health", "ratio": 0}]}
+		]
+	}`)
+
+	assert.Equal(t, 0.5, r.Ratio("other", "get"))
+	assert.Equal(t, 0.1, r.Ratio("noisy", "get"))
+	assert.Equal(t, 1.0, r.Ratio("noisy", "login"))
+	assert.Equal(t, 0.5, r.Ratio("chatty", "get"))
+	assert.Equal(t, 0.0, r.Ratio("chatty", "health"))
+}
+
+func TestRatiosDefaultFromFlag(t *testing.T) {
+	r, _, _ := newTestRatios(t, `{"service_ratios": [{"service": "noisy", "ratio": 0.1}]}`)
+
+	assert.Equal(t, 1.0, r.Ratio("other", "get"))
+	assert.Equal(t, 0.1, r.Ratio("noisy", "get"))
+}
+
+func TestRatiosInvalidFile(t *testing.T) {
+	tests := []struct {
+		content string
+		err     string
+	}{
+		{content: `{`, err: "failed to unmarshal downsampling ratios"},
+		{content: `{"default_ratio": 2}`, err: "invalid default ratio"},
+		{content: `{"service_ratios": [{"ratio": 0.5}]}`, err: "service ratio without service name"},
+		{content: `{"service_ratios": [{"service": "a", "ratio": -1}]}`, err: "invalid ratio for service a"},
+		{content: `{"service_ratios": [{"service": "a", "operation_ratios": [{"ratio": 1}]}]}`, err: "operation ratio without operation name"},
+		{content: `{"service_ratios": [{"service": "a", "operation_ratios": [{"operation": "b", "ratio": 3}]}]}`, err: "invalid ratio for operation b of service a"},
+	}
+	for _, test := range tests {
+		t.Run(test.err, func(t *testing.T) {
+			_, err := NewRatios(writeRatios(t, test.content), 1, zap.NewNop(), metricstest.NewFactory(time.Hour))
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), test.err)
+		})
+	}
+
+	_, err := NewRatios("/does/not/exist.json", 1, zap.NewNop(), metricstest.NewFactory(time.Hour))
+	assert.Contains(t, err.Error(), "failed to read downsampling ratios file")
+}
+
+func TestRatiosReload(t *testing.T) {
+	r, mf, path := newTestRatios(t, `{"default_ratio": 0.5}`)
+	assert.Equal(t, 0.5, r.Ratio("noisy", "get"))
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"default_ratio": 0.5, "service_ratios": [{"service": "noisy", "ratio": 0.1}]}`), 0600))
+	assert.Eventually(t, func() bool {
+		return r.Ratio("noisy", "get") == 0.1
+	}, 5*time.Second, 10*time.Millisecond)
+	mf.AssertCounterMetrics(t, metricstest.ExpectedMetric{Name: "downsampling_ratios.reloads", Tags: map[string]string{"result": "ok"}, Value: 1})
+
+	// an invalid file keeps the last known ratios
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"default_ratio": 5}`), 0600))
+	assert.Eventually(t, func() bool {
+		counters, _ := mf.Snapshot()
+		return counters["downsampling_ratios.reloads|result=err"] == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, 0.1, r.Ratio("noisy", "get"))
+}
+
+func TestRatiosServeHTTP(t *testing.T) {
+	r, _, _ := newTestRatios(t, `{
+		"default_ratio": 0.5,
+		"service_ratios": [
+			{"service": "noisy", "ratio": 0.1, "operation_ratios": [{"operation": "login", "ratio": 1}]},
+			{"service": "chatty", "ratio": 0.2}
+		]
+	}`)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/downsampling", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	var e Effective
+	require.NoError(t, json.Unmarshal(w.Body.Bytes(), &e))
+	assert.Equal(t, Effective{
+		DefaultRatio: 0.5,
+		ServiceRatios: []EffectiveService{
+			{Service: "chatty", Ratio: 0.2},
+			{Service: "noisy", Ratio: 0.1, OperationRatios: map[string]float64{"login": 1}},
+		},
+	}, e)
+}
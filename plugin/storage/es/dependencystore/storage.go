@@ -28,6 +28,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/es"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/plugin/storage/es/dependencystore/dbmodel"
 )
 
@@ -83,9 +84,11 @@ func (s *DependencyStore) writeDependencies(indexName string, ts time.Time, depe
 		}).Add()
 }
 
-// GetDependencies returns all interservice dependencies
+// GetDependencies returns all interservice dependencies of the tenant from the context.
+// Like the span indices, the dependency indices of a tenant have the tenant inserted
+// after the index base name, e.g. jaeger-dependencies-acme-2006-01-02 for tenant acme.
 func (s *DependencyStore) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
-	indices := getIndices(s.indexPrefix, s.indexDateLayout, endTs, lookback)
+	indices := getIndices(tenantIndexPrefix(s.indexPrefix, tenancy.GetTenant(ctx)), s.indexDateLayout, endTs, lookback)
 	searchResult, err := s.client.Search(indices...).
 		Size(s.maxDocCount).
 		Query(buildTSQuery(endTs, lookback)).
@@ -108,6 +111,14 @@ func (s *DependencyStore) GetDependencies(ctx context.Context, endTs time.Time,
 	return dbmodel.ToDomainDependencies(retDependencies), nil
 }
 
+// returns index prefix with the tenant inserted after the index base name
+func tenantIndexPrefix(indexPrefix, tenant string) string {
+	if tenant == "" {
+		return indexPrefix
+	}
+	return indexPrefix + tenant + "-"
+}
+
 func buildTSQuery(endTs time.Time, lookback time.Duration) elastic.Query {
 	return elastic.NewRangeQuery("timestamp").Gte(endTs.Add(-lookback)).Lte(endTs)
 }
@@ -30,6 +30,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/es/mocks"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
 	"github.com/jaegertracing/jaeger/storage/dependencystore"
 )
@@ -132,6 +133,7 @@ func TestGetDependencies(t *testing.T) {
 		expectedError  string
 		expectedOutput []model.DependencyLink
 		indexPrefix    string
+		tenant         string
 		maxDocCount    int
 		indices        []interface{}
 	}{
@@ -163,6 +165,12 @@ func TestGetDependencies(t *testing.T) {
 			indexPrefix:   "foo",
 			indices:       []interface{}{"foo-jaeger-dependencies-1995-04-21", "foo-jaeger-dependencies-1995-04-20"},
 		},
+		{
+			searchError:   errors.New("search failure"),
+			expectedError: "failed to search for dependencies: search failure",
+			tenant:        "acme",
+			indices:       []interface{}{"jaeger-dependencies-acme-1995-04-21", "jaeger-dependencies-acme-1995-04-20"},
+		},
 	}
 	for _, testCase := range testCases {
 		withDepStorage(testCase.indexPrefix, "2006-01-02", testCase.maxDocCount, func(r *depStorageTest) {
@@ -178,7 +186,8 @@ func TestGetDependencies(t *testing.T) {
 			searchService.On("IgnoreUnavailable", mock.AnythingOfType("bool")).Return(searchService)
 			searchService.On("Do", mock.Anything).Return(testCase.searchResult, testCase.searchError)
 
-			actual, err := r.storage.GetDependencies(context.Background(), fixedTime, 24*time.Hour)
+			ctx := tenancy.WithTenant(context.Background(), testCase.tenant)
+			actual, err := r.storage.GetDependencies(ctx, fixedTime, 24*time.Hour)
 			if testCase.expectedError != "" {
 				assert.EqualError(t, err, testCase.expectedError)
 				assert.Nil(t, actual)
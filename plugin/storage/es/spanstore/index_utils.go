@@ -24,6 +24,15 @@ func indexWithDate(indexPrefix, indexDateLayout string, date time.Time) string {
 	return indexPrefix + spanDate
 }
 
+// returns index prefix with the tenant inserted after the index base name,
+// e.g. jaeger-span- becomes jaeger-span-acme- for tenant acme
+func tenantIndexPrefix(indexPrefix, tenant string) string {
+	if tenant == "" {
+		return indexPrefix
+	}
+	return indexPrefix + tenant + "-"
+}
+
 // returns archive index name
 func archiveIndex(indexPrefix, archiveSuffix string) string {
 	return indexPrefix + archiveSuffix
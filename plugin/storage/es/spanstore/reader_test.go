@@ -35,6 +35,7 @@ import (
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/es/mocks"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/pkg/testutils"
 	"github.com/jaegertracing/jaeger/plugin/storage/es/spanstore/dbmodel"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
@@ -584,6 +585,22 @@ func returnSearchFunc(typ string, r *spanReaderTest) (interface{}, error) {
 	}
 	return nil, errors.New("Specify services, operations, traceIDs only")
 }
+func TestSpanReader_GetServicesTenant(t *testing.T) {
+	withArchiveSpanReader(false, func(r *spanReaderTest) {
+		searchService := &mocks.SearchService{}
+		searchService.On("Size", 0).Return(searchService)
+		searchService.On("Query", mock.Anything).Return(searchService)
+		searchService.On("IgnoreUnavailable", true).Return(searchService)
+		searchService.On("Aggregation", stringMatcher(servicesAggregation), mock.Anything).Return(searchService)
+		searchService.On("Do", mock.Anything).Return(nil, errors.New("search failure"))
+		r.client.On("Search", "jaeger-service-acme-archive").Return(searchService)
+
+		_, err := r.reader.GetServices(tenancy.WithTenant(context.Background(), "acme"))
+		require.EqualError(t, err, "search services failed: search failure")
+		r.client.AssertExpectations(t)
+	})
+}
+
 func TestSpanReader_bucketToStringArray(t *testing.T) {
 	withSpanReader(func(r *spanReaderTest) {
 		buckets := make([]*elastic.AggregationBucketKeyItem, 3)
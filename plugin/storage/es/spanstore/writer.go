@@ -26,6 +26,7 @@ import (
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/cache"
 	"github.com/jaegertracing/jaeger/pkg/es"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/plugin/storage/es/spanstore/dbmodel"
 	storageMetrics "github.com/jaegertracing/jaeger/storage/spanstore/metrics"
 )
@@ -117,8 +118,8 @@ func (s *SpanWriter) CreateTemplates(spanTemplate, serviceTemplate, indexPrefix
 	return nil
 }
 
-// spanAndServiceIndexFn returns names of span and service indices
-type spanAndServiceIndexFn func(spanTime time.Time) (string, string)
+// spanAndServiceIndexFn returns names of span and service indices for the given tenant
+type spanAndServiceIndexFn func(tenant string, spanTime time.Time) (string, string)
 
 func getSpanAndServiceIndexFn(archive, useReadWriteAliases bool, prefix, dateLayout string) spanAndServiceIndexFn {
 	if prefix != "" {
@@ -127,27 +128,28 @@ func getSpanAndServiceIndexFn(archive, useReadWriteAliases bool, prefix, dateLay
 	spanIndexPrefix := prefix + spanIndex
 	serviceIndexPrefix := prefix + serviceIndex
 	if archive {
-		return func(date time.Time) (string, string) {
+		return func(tenant string, date time.Time) (string, string) {
 			if useReadWriteAliases {
-				return archiveIndex(spanIndexPrefix, archiveWriteIndexSuffix), ""
+				return archiveIndex(tenantIndexPrefix(spanIndexPrefix, tenant), archiveWriteIndexSuffix), ""
 			}
-			return archiveIndex(spanIndexPrefix, archiveIndexSuffix), ""
+			return archiveIndex(tenantIndexPrefix(spanIndexPrefix, tenant), archiveIndexSuffix), ""
 		}
 	}
 
 	if useReadWriteAliases {
-		return func(spanTime time.Time) (string, string) {
-			return spanIndexPrefix + "write", serviceIndexPrefix + "write"
+		return func(tenant string, spanTime time.Time) (string, string) {
+			return tenantIndexPrefix(spanIndexPrefix, tenant) + "write", tenantIndexPrefix(serviceIndexPrefix, tenant) + "write"
 		}
 	}
-	return func(date time.Time) (string, string) {
-		return indexWithDate(spanIndexPrefix, dateLayout, date), indexWithDate(serviceIndexPrefix, dateLayout, date)
+	return func(tenant string, date time.Time) (string, string) {
+		return indexWithDate(tenantIndexPrefix(spanIndexPrefix, tenant), dateLayout, date),
+			indexWithDate(tenantIndexPrefix(serviceIndexPrefix, tenant), dateLayout, date)
 	}
 }
 
 // WriteSpan writes a span and its corresponding service:operation in ElasticSearch
-func (s *SpanWriter) WriteSpan(_ context.Context, span *model.Span) error {
-	spanIndexName, serviceIndexName := s.spanServiceIndex(span.StartTime)
+func (s *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	spanIndexName, serviceIndexName := s.spanServiceIndex(tenancy.GetTenant(ctx), span.StartTime)
 	jsonSpan := s.spanConverter.FromDomainEmbedProcess(span)
 	if serviceIndexName != "" {
 		s.writeService(serviceIndexName, jsonSpan)
@@ -156,6 +158,17 @@ func (s *SpanWriter) WriteSpan(_ context.Context, span *model.Span) error {
 	return nil
 }
 
+// WriteSpans writes the spans and their corresponding service:operation in ElasticSearch.
+// All the index requests are added to the bulk processor, which sends them in as few bulk requests as possible.
+func (s *SpanWriter) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	for _, span := range spans {
+		if err := s.WriteSpan(ctx, span); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // Close closes SpanWriter
 func (s *SpanWriter) Close() error {
 	return s.client.Close()
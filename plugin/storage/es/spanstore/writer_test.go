@@ -55,7 +55,36 @@ func withSpanWriter(fn func(w *spanWriterTest)) {
 	fn(w)
 }
 
-var _ spanstore.Writer = &SpanWriter{} // check API conformance
+var _ spanstore.Writer = &SpanWriter{}      // check API conformance
+var _ spanstore.BatchWriter = &SpanWriter{} // check API conformance
+
+func TestSpanWriterIndicesTenant(t *testing.T) {
+	client := &mocks.Client{}
+	logger, _ := testutils.NewLogger()
+	metricsFactory := metricstest.NewFactory(0)
+	date := time.Now()
+	layout := "2006-01-02"
+	dateFormat := date.UTC().Format(layout)
+	testCases := []struct {
+		indices []string
+		params  SpanWriterParams
+	}{
+		{params: SpanWriterParams{Client: client, Logger: logger, MetricsFactory: metricsFactory,
+			IndexPrefix: "", IndexDateLayout: layout},
+			indices: []string{spanIndex + "acme-" + dateFormat, serviceIndex + "acme-" + dateFormat}},
+		{params: SpanWriterParams{Client: client, Logger: logger, MetricsFactory: metricsFactory,
+			IndexPrefix: "foo:", IndexDateLayout: layout, UseReadWriteAliases: true},
+			indices: []string{"foo:" + indexPrefixSeparator + spanIndex + "acme-write", "foo:" + indexPrefixSeparator + serviceIndex + "acme-write"}},
+		{params: SpanWriterParams{Client: client, Logger: logger, MetricsFactory: metricsFactory,
+			IndexPrefix: "", IndexDateLayout: layout, Archive: true},
+			indices: []string{spanIndex + "acme-" + archiveIndexSuffix, ""}},
+	}
+	for _, testCase := range testCases {
+		w := NewSpanWriter(testCase.params)
+		spanIndexName, serviceIndexName := w.spanServiceIndex("acme", date)
+		assert.Equal(t, testCase.indices, []string{spanIndexName, serviceIndexName})
+	}
+}
 
 func TestSpanWriterIndices(t *testing.T) {
 	client := &mocks.Client{}
@@ -92,7 +121,7 @@ func TestSpanWriterIndices(t *testing.T) {
 	}
 	for _, testCase := range testCases {
 		w := NewSpanWriter(testCase.params)
-		spanIndexName, serviceIndexName := w.spanServiceIndex(date)
+		spanIndexName, serviceIndexName := w.spanServiceIndex("", date)
 		assert.Equal(t, testCase.indices, []string{spanIndexName, serviceIndexName})
 	}
 }
@@ -300,6 +329,34 @@ func TestWriteSpanInternal(t *testing.T) {
 	})
 }
 
+func TestSpanWriter_WriteSpans(t *testing.T) {
+	withSpanWriter(func(w *spanWriterTest) {
+		indexService := &mocks.IndexService{}
+
+		indexName := "jaeger-span-1995-04-21"
+		indexService.On("Index", stringMatcher(indexName)).Return(indexService)
+		indexService.On("Type", stringMatcher(spanType)).Return(indexService)
+		indexService.On("BodyJson", mock.AnythingOfType("**dbmodel.Span")).Return(indexService)
+		indexService.On("Add")
+		w.client.On("Index").Return(indexService)
+
+		var services []string
+		w.writer.serviceWriter = func(indexName string, span *dbmodel.Span) {
+			services = append(services, indexName+"/"+span.Process.ServiceName)
+		}
+
+		date, err := time.Parse(time.RFC3339, "1995-04-21T22:08:41+00:00")
+		require.NoError(t, err)
+		spans := []*model.Span{
+			{StartTime: date, Process: model.NewProcess("service-a", nil)},
+			{StartTime: date, Process: model.NewProcess("service-b", nil)},
+		}
+		require.NoError(t, w.writer.WriteSpans(context.Background(), spans))
+		indexService.AssertNumberOfCalls(t, "Add", 2)
+		assert.Equal(t, []string{"jaeger-service-1995-04-21/service-a", "jaeger-service-1995-04-21/service-b"}, services)
+	})
+}
+
 func TestWriteSpanInternalError(t *testing.T) {
 	withSpanWriter(func(w *spanWriterTest) {
 		indexService := &mocks.IndexService{}
@@ -19,6 +19,7 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"net/http"
 
 	"github.com/spf13/viper"
 	"github.com/uber/jaeger-lib/metrics"
@@ -28,6 +29,7 @@ import (
 	"github.com/jaegertracing/jaeger/plugin"
 	"github.com/jaegertracing/jaeger/plugin/storage/badger"
 	"github.com/jaegertracing/jaeger/plugin/storage/cassandra"
+	"github.com/jaegertracing/jaeger/plugin/storage/downsampling"
 	"github.com/jaegertracing/jaeger/plugin/storage/es"
 	"github.com/jaegertracing/jaeger/plugin/storage/grpc"
 	"github.com/jaegertracing/jaeger/plugin/storage/kafka"
@@ -46,6 +48,7 @@ const (
 	badgerStorageType        = "badger"
 	downsamplingRatio        = "downsampling.ratio"
 	downsamplingHashSalt     = "downsampling.hashsalt"
+	downsamplingRatiosFile   = "downsampling.ratios-file"
 	spanStorageType          = "span-storage-type"
 
 	// defaultDownsamplingRatio is the default downsampling ratio.
@@ -61,8 +64,10 @@ var AllStorageTypes = []string{cassandraStorageType, elasticsearchStorageType, m
 type Factory struct {
 	FactoryConfig
 	metricsFactory         metrics.Factory
+	logger                 *zap.Logger
 	factories              map[string]storage.Factory
 	downsamplingFlagsAdded bool
+	downsamplingRatios     *downsampling.Ratios
 }
 
 // NewFactory creates the meta-factory.
@@ -108,6 +113,7 @@ func (f *Factory) getFactoryOfType(factoryType string) (storage.Factory, error)
 // Initialize implements storage.Factory.
 func (f *Factory) Initialize(metricsFactory metrics.Factory, logger *zap.Logger) error {
 	f.metricsFactory = metricsFactory
+	f.logger = logger
 	for _, factory := range f.factories {
 		if err := factory.Initialize(metricsFactory, logger); err != nil {
 			return err
@@ -147,15 +153,36 @@ func (f *Factory) CreateSpanWriter() (spanstore.Writer, error) {
 	} else {
 		spanWriter = spanstore.NewCompositeWriter(writers...)
 	}
-	// Turn off DownsamplingWriter entirely if ratio == defaultDownsamplingRatio.
-	if f.DownsamplingRatio == defaultDownsamplingRatio {
-		return spanWriter, nil
-	}
-	return spanstore.NewDownsamplingWriter(spanWriter, spanstore.DownsamplingOptions{
+	options := spanstore.DownsamplingOptions{
 		Ratio:          f.DownsamplingRatio,
 		HashSalt:       f.DownsamplingHashSalt,
 		MetricsFactory: f.metricsFactory.Namespace(metrics.NSOptions{Name: "downsampling_writer"}),
-	}), nil
+	}
+	if f.DownsamplingRatiosFile != "" {
+		if f.downsamplingRatios == nil {
+			ratios, err := downsampling.NewRatios(f.DownsamplingRatiosFile, f.DownsamplingRatio, f.logger, f.metricsFactory)
+			if err != nil {
+				return nil, err
+			}
+			f.downsamplingRatios = ratios
+		}
+		options.Ratios = f.downsamplingRatios
+	} else if f.DownsamplingRatio == defaultDownsamplingRatio {
+		// Turn off DownsamplingWriter entirely if ratio == defaultDownsamplingRatio and there are no per-service ratios.
+		return spanWriter, nil
+	}
+	return spanstore.NewDownsamplingWriter(spanWriter, options), nil
+}
+
+// DownsamplingHandler returns an http.Handler that shows the downsampling ratios in effect as JSON.
+func (f *Factory) DownsamplingHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if f.downsamplingRatios != nil {
+			f.downsamplingRatios.ServeHTTP(w, r)
+			return
+		}
+		downsampling.WriteEffective(w, downsampling.Effective{DefaultRatio: f.DownsamplingRatio})
+	})
 }
 
 // CreateDependencyReader implements storage.Factory
@@ -197,6 +224,11 @@ func (f *Factory) addDownsamplingFlags(flagSet *flag.FlagSet) {
 		defaultDownsamplingHashSalt,
 		"Salt used when hashing trace id for downsampling.",
 	)
+	flagSet.String(
+		downsamplingRatiosFile,
+		"",
+		"Path to a JSON file with per-service and per-operation downsampling ratios, overriding "+downsamplingRatio+" for the listed services. The file is reloaded when it changes.",
+	)
 }
 
 // InitFromViper implements plugin.Configurable
@@ -215,6 +247,7 @@ func (f *Factory) initDownsamplingFromViper(v *viper.Viper) {
 	if !f.downsamplingFlagsAdded {
 		f.FactoryConfig.DownsamplingRatio = defaultDownsamplingRatio
 		f.FactoryConfig.DownsamplingHashSalt = defaultDownsamplingHashSalt
+		f.FactoryConfig.DownsamplingRatiosFile = ""
 		return
 	}
 
@@ -224,6 +257,7 @@ func (f *Factory) initDownsamplingFromViper(v *viper.Viper) {
 		f.FactoryConfig.DownsamplingRatio = 1.0
 	}
 	f.FactoryConfig.DownsamplingHashSalt = v.GetString(downsamplingHashSalt)
+	f.FactoryConfig.DownsamplingRatiosFile = v.GetString(downsamplingRatiosFile)
 }
 
 // CreateArchiveSpanReader implements storage.ArchiveFactory
@@ -257,6 +291,11 @@ var _ io.Closer = (*Factory)(nil)
 // Close closes the resources held by the factory
 func (f *Factory) Close() error {
 	var errs []error
+	if f.downsamplingRatios != nil {
+		if err := f.downsamplingRatios.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
 	for _, storageType := range f.SpanWriterTypes {
 		if factory, ok := f.factories[storageType]; ok {
 			if closer, ok := factory.(io.Closer); ok {
@@ -20,6 +20,11 @@ import (
 	"flag"
 	"fmt"
 	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"reflect"
 	"strings"
 	"testing"
@@ -198,6 +203,42 @@ func TestCreateDownsamplingWriter(t *testing.T) {
 	}
 }
 
+func TestCreateDownsamplingWriterWithRatiosFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "downsampling")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "ratios.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"service_ratios": [{"service": "noisy", "ratio": 0.1}]}`), 0600))
+
+	f, err := NewFactory(defaultCfg())
+	require.NoError(t, err)
+	mock := new(mocks.Factory)
+	f.factories[cassandraStorageType] = mock
+	mock.On("CreateSpanWriter").Return(new(spanStoreMocks.Writer), nil)
+	mock.On("Initialize", metrics.NullFactory, zap.NewNop()).Return(nil)
+	require.NoError(t, f.Initialize(metrics.NullFactory, zap.NewNop()))
+
+	w := httptest.NewRecorder()
+	f.DownsamplingHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/downsampling", nil))
+	assert.JSONEq(t, `{"default_ratio": 1}`, w.Body.String())
+
+	// the writer is created even with the default ratio when there is a ratios file
+	f.DownsamplingRatiosFile = path
+	newWriter, err := f.CreateSpanWriter()
+	require.NoError(t, err)
+	assert.IsType(t, &spanstore.DownsamplingWriter{}, newWriter)
+
+	w = httptest.NewRecorder()
+	f.DownsamplingHandler().ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/downsampling", nil))
+	assert.JSONEq(t, `{"default_ratio": 1, "service_ratios": [{"service": "noisy", "ratio": 0.1}]}`, w.Body.String())
+	assert.NoError(t, f.Close())
+
+	f.downsamplingRatios = nil
+	f.DownsamplingRatiosFile = filepath.Join(dir, "missing.json")
+	_, err = f.CreateSpanWriter()
+	assert.Error(t, err)
+}
+
 func TestCreateMulti(t *testing.T) {
 	cfg := defaultCfg()
 	cfg.SpanWriterTypes = append(cfg.SpanWriterTypes, elasticsearchStorageType)
@@ -351,6 +392,12 @@ func TestParsingDownsamplingRatio(t *testing.T) {
 	assert.NoError(t, err)
 	f.InitFromViper(v)
 	assert.Equal(t, f.FactoryConfig.DownsamplingRatio, 0.5)
+
+	err = command.ParseFlags([]string{
+		"--downsampling.ratios-file=/etc/jaeger/ratios.json"})
+	assert.NoError(t, err)
+	f.InitFromViper(v)
+	assert.Equal(t, "/etc/jaeger/ratios.json", f.FactoryConfig.DownsamplingRatiosFile)
 }
 
 func TestDefaultDownsamplingWithAddFlags(t *testing.T) {
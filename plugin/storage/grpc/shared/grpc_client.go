@@ -20,6 +20,7 @@ import (
 	"io"
 	"time"
 
+	"go.uber.org/atomic"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/grpc/status"
@@ -44,6 +45,9 @@ type grpcClient struct {
 	archiveWriterClient storage_v1.ArchiveSpanWriterPluginClient
 	capabilitiesClient  storage_v1.PluginCapabilitiesClient
 	depsReaderClient    storage_v1.DependenciesReaderPluginClient
+
+	// writeSpansUnimplemented is set when the plugin does not implement WriteSpans
+	writeSpansUnimplemented atomic.Bool
 }
 
 // upgradeContextWithBearerToken turns the context into a gRPC outgoing context with bearer token
@@ -210,6 +214,29 @@ func (c *grpcClient) WriteSpan(ctx context.Context, span *model.Span) error {
 	return nil
 }
 
+// WriteSpans saves the spans with a single request. Plugins built before WriteSpans was added
+// do not implement it, in which case the spans are saved one at a time.
+func (c *grpcClient) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	if !c.writeSpansUnimplemented.Load() {
+		_, err := c.writerClient.WriteSpans(ctx, &storage_v1.WriteSpansRequest{
+			Spans: spans,
+		})
+		if status.Code(err) != codes.Unimplemented {
+			if err != nil {
+				return fmt.Errorf("plugin error: %w", err)
+			}
+			return nil
+		}
+		c.writeSpansUnimplemented.Store(true)
+	}
+	for _, span := range spans {
+		if err := c.WriteSpan(ctx, span); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // GetDependencies returns all interservice dependencies
 func (c *grpcClient) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
 	resp, err := c.depsReaderClient.GetDependencies(ctx, &storage_v1.GetDependenciesRequest{
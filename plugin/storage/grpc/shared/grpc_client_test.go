@@ -298,6 +298,36 @@ func TestGRPCClientWriteSpan(t *testing.T) {
 	})
 }
 
+func TestGRPCClientWriteSpans(t *testing.T) {
+	withGRPCClient(func(r *grpcClientTest) {
+		spans := []*model.Span{&mockTraceSpans[0], &mockTraceSpans[1]}
+		r.spanWriter.On("WriteSpans", mock.Anything, &storage_v1.WriteSpansRequest{
+			Spans: spans,
+		}).Return(&storage_v1.WriteSpanResponse{}, nil).Once()
+		r.spanWriter.On("WriteSpans", mock.Anything, &storage_v1.WriteSpansRequest{
+			Spans: spans,
+		}).Return(nil, errors.New("plugin failure")).Once()
+
+		assert.NoError(t, r.client.WriteSpans(context.Background(), spans))
+		assert.EqualError(t, r.client.WriteSpans(context.Background(), spans), "plugin error: plugin failure")
+	})
+}
+
+func TestGRPCClientWriteSpansUnimplemented(t *testing.T) {
+	withGRPCClient(func(r *grpcClientTest) {
+		spans := []*model.Span{&mockTraceSpans[0], &mockTraceSpans[1]}
+		r.spanWriter.On("WriteSpans", mock.Anything, mock.Anything).
+			Return(nil, status.Error(codes.Unimplemented, "method WriteSpans not implemented")).Once()
+		r.spanWriter.On("WriteSpan", mock.Anything, mock.Anything).Return(&storage_v1.WriteSpanResponse{}, nil)
+
+		assert.NoError(t, r.client.WriteSpans(context.Background(), spans))
+		assert.NoError(t, r.client.WriteSpans(context.Background(), spans))
+		// WriteSpans is only attempted once
+		r.spanWriter.AssertNumberOfCalls(t, "WriteSpans", 1)
+		r.spanWriter.AssertNumberOfCalls(t, "WriteSpan", 4)
+	})
+}
+
 func TestGRPCClientGetDependencies(t *testing.T) {
 	withGRPCClient(func(r *grpcClientTest) {
 		lookback := time.Duration(1 * time.Second)
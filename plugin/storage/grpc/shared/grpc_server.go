@@ -54,6 +54,15 @@ func (s *grpcServer) WriteSpan(ctx context.Context, r *storage_v1.WriteSpanReque
 	return &storage_v1.WriteSpanResponse{}, nil
 }
 
+// WriteSpans saves the spans, with a single call if the span writer supports batches
+func (s *grpcServer) WriteSpans(ctx context.Context, r *storage_v1.WriteSpansRequest) (*storage_v1.WriteSpanResponse, error) {
+	err := spanstore.WriteSpans(ctx, s.Impl.SpanWriter(), r.Spans)
+	if err != nil {
+		return nil, err
+	}
+	return &storage_v1.WriteSpanResponse{}, nil
+}
+
 // GetTrace takes a traceID and streams a Trace associated with that traceID
 func (s *grpcServer) GetTrace(r *storage_v1.GetTraceRequest, stream storage_v1.SpanReaderPlugin_GetTraceServer) error {
 	trace, err := s.Impl.SpanReader().GetTrace(stream.Context(), r.TraceID)
@@ -16,6 +16,7 @@ package shared
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"testing"
 	"time"
@@ -221,6 +222,26 @@ func TestGRPCServerWriteSpan(t *testing.T) {
 	})
 }
 
+func TestGRPCServerWriteSpans(t *testing.T) {
+	withGRPCServer(func(r *grpcServerTest) {
+		r.impl.spanWriter.On("WriteSpan", context.Background(), &mockTraceSpans[0]).
+			Return(nil)
+		r.impl.spanWriter.On("WriteSpan", context.Background(), &mockTraceSpans[1]).
+			Return(errors.New("storage failure"))
+
+		s, err := r.server.WriteSpans(context.Background(), &storage_v1.WriteSpansRequest{
+			Spans: []*model.Span{&mockTraceSpans[0]},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, &storage_v1.WriteSpanResponse{}, s)
+
+		_, err = r.server.WriteSpans(context.Background(), &storage_v1.WriteSpansRequest{
+			Spans: []*model.Span{&mockTraceSpans[0], &mockTraceSpans[1]},
+		})
+		assert.EqualError(t, err, "storage failure")
+	})
+}
+
 func TestGRPCServerGetDependencies(t *testing.T) {
 	withGRPCServer(func(r *grpcServerTest) {
 		lookback := time.Duration(1 * time.Second)
@@ -163,7 +163,7 @@ func (opt *Options) AddFlags(flagSet *flag.FlagSet) {
 	flagSet.String(
 		configPrefix+suffixProtocolVersion,
 		"",
-		"Kafka protocol version - must be supported by kafka server")
+		"Kafka protocol version - must be supported by kafka server. The tenants of the spans are only kept with version 0.11.0.0 or above")
 	flagSet.String(
 		configPrefix+suffixEncoding,
 		defaultEncoding,
@@ -22,8 +22,13 @@ import (
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 )
 
+// TenantHeader is the header of the kafka messages carrying the tenant of the span, if any.
+// Headers require the kafka protocol version 0.11.0.0 or above.
+const TenantHeader = "jaeger-tenant"
+
 type spanWriterMetrics struct {
 	SpansWrittenSuccess metrics.Counter
 	SpansWrittenFailure metrics.Counter
@@ -82,11 +87,15 @@ func (w *SpanWriter) WriteSpan(ctx context.Context, span *model.Span) error {
 
 	// The AsyncProducer accepts messages on a channel and produces them asynchronously
 	// in the background as efficiently as possible
-	w.producer.Input() <- &sarama.ProducerMessage{
+	message := &sarama.ProducerMessage{
 		Topic: w.topic,
 		Key:   sarama.StringEncoder(span.TraceID.String()),
 		Value: sarama.ByteEncoder(spanBytes),
 	}
+	if tenant := tenancy.GetTenant(ctx); tenant != "" {
+		message.Headers = []sarama.RecordHeader{{Key: []byte(TenantHeader), Value: []byte(tenant)}}
+	}
+	w.producer.Input() <- message
 	return nil
 }
 
@@ -24,11 +24,13 @@ import (
 	saramaMocks "github.com/Shopify/sarama/mocks"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/jaeger-lib/metrics/metricstest"
 	"go.uber.org/zap"
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/kafka/mocks"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -126,6 +128,37 @@ func TestKafkaWriter(t *testing.T) {
 	})
 }
 
+// inputProducer is an AsyncProducer that exposes the produced messages on its input channel
+type inputProducer struct {
+	sarama.AsyncProducer
+	input chan *sarama.ProducerMessage
+}
+
+func (p *inputProducer) Input() chan<- *sarama.ProducerMessage {
+	return p.input
+}
+
+func (p *inputProducer) Successes() <-chan *sarama.ProducerMessage {
+	return nil
+}
+
+func (p *inputProducer) Errors() <-chan *sarama.ProducerError {
+	return nil
+}
+
+func TestKafkaWriterTenantHeader(t *testing.T) {
+	producer := &inputProducer{input: make(chan *sarama.ProducerMessage, 2)}
+	marshaller := &mocks.Marshaller{}
+	marshaller.On("Marshal", mock.AnythingOfType("*model.Span")).Return([]byte{}, nil)
+	writer := NewSpanWriter(producer, marshaller, "someTopic", metricstest.NewFactory(time.Hour), zap.NewNop())
+
+	require.NoError(t, writer.WriteSpan(context.Background(), sampleSpan))
+	require.NoError(t, writer.WriteSpan(tenancy.WithTenant(context.Background(), "acme"), sampleSpan))
+
+	assert.Empty(t, (<-producer.input).Headers)
+	assert.Equal(t, []sarama.RecordHeader{{Key: []byte(TenantHeader), Value: []byte("acme")}}, (<-producer.input).Headers)
+}
+
 func TestKafkaWriterErr(t *testing.T) {
 	withSpanWriter(t, func(span *model.Span, w *spanWriterTest) {
 
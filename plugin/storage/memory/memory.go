@@ -27,11 +27,20 @@ import (
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/model/adjuster"
 	"github.com/jaegertracing/jaeger/pkg/memory/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
-// Store is an in-memory store of traces
+// Store is an in-memory store of traces, partitioned by tenant
 type Store struct {
+	sync.RWMutex
+	// defaultConfig is the configuration used for the traces of every tenant
+	defaultConfig config.Configuration
+	perTenant     map[string]*tenantStore
+}
+
+// tenantStore is an in-memory store of the traces of a single tenant
+type tenantStore struct {
 	sync.RWMutex
 	ids        []*model.TraceID
 	traces     map[model.TraceID]*model.Trace
@@ -50,6 +59,13 @@ func NewStore() *Store {
 // WithConfiguration creates a new in memory storage based on the given configuration
 func WithConfiguration(configuration config.Configuration) *Store {
 	return &Store{
+		defaultConfig: configuration,
+		perTenant:     map[string]*tenantStore{},
+	}
+}
+
+func newTenantStore(configuration config.Configuration) *tenantStore {
+	return &tenantStore{
 		ids:        make([]*model.TraceID, configuration.MaxTraces),
 		traces:     map[model.TraceID]*model.Trace{},
 		services:   map[string]struct{}{},
@@ -59,8 +75,45 @@ func WithConfiguration(configuration config.Configuration) *Store {
 	}
 }
 
+// emptyTenantStore is returned to the reads of tenants without any span, it is never written
+var emptyTenantStore = newTenantStore(config.Configuration{})
+
+// getTenant returns the store of the tenant carried by the context, or an empty store if the tenant
+// has not written any span, so that reads with arbitrary tenants do not allocate stores.
+// Requests without a tenant use the store of the empty tenant.
+func (st *Store) getTenant(ctx context.Context) *tenantStore {
+	st.RLock()
+	defer st.RUnlock()
+	if m, ok := st.perTenant[tenancy.GetTenant(ctx)]; ok {
+		return m
+	}
+	return emptyTenantStore
+}
+
+// getOrCreateTenant returns the store of the tenant carried by the context, creating it if needed.
+func (st *Store) getOrCreateTenant(ctx context.Context) *tenantStore {
+	tenant := tenancy.GetTenant(ctx)
+	st.RLock()
+	m, ok := st.perTenant[tenant]
+	st.RUnlock()
+	if ok {
+		return m
+	}
+	st.Lock()
+	defer st.Unlock()
+	if m, ok = st.perTenant[tenant]; !ok {
+		m = newTenantStore(st.defaultConfig)
+		st.perTenant[tenant] = m
+	}
+	return m
+}
+
 // GetDependencies returns dependencies between services
-func (m *Store) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+func (st *Store) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	return st.getTenant(ctx).getDependencies(endTs, lookback)
+}
+
+func (m *tenantStore) getDependencies(endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
 	// deduper used below can modify the spans, so we take an exclusive lock
 	m.Lock()
 	defer m.Unlock()
@@ -97,7 +150,7 @@ func (m *Store) GetDependencies(ctx context.Context, endTs time.Time, lookback t
 	return retMe, nil
 }
 
-func (m *Store) findSpan(trace *model.Trace, spanID model.SpanID) *model.Span {
+func (m *tenantStore) findSpan(trace *model.Trace, spanID model.SpanID) *model.Span {
 	for _, s := range trace.Spans {
 		if s.SpanID == spanID {
 			return s
@@ -106,7 +159,7 @@ func (m *Store) findSpan(trace *model.Trace, spanID model.SpanID) *model.Span {
 	return nil
 }
 
-func (m *Store) traceIsBetweenStartAndEnd(startTs, endTs time.Time, trace *model.Trace) bool {
+func (m *tenantStore) traceIsBetweenStartAndEnd(startTs, endTs time.Time, trace *model.Trace) bool {
 	for _, s := range trace.Spans {
 		if s.StartTime.After(startTs) && endTs.After(s.StartTime) {
 			return true
@@ -116,7 +169,11 @@ func (m *Store) traceIsBetweenStartAndEnd(startTs, endTs time.Time, trace *model
 }
 
 // WriteSpan writes the given span
-func (m *Store) WriteSpan(ctx context.Context, span *model.Span) error {
+func (st *Store) WriteSpan(ctx context.Context, span *model.Span) error {
+	return st.getOrCreateTenant(ctx).writeSpan(span)
+}
+
+func (m *tenantStore) writeSpan(span *model.Span) error {
 	m.Lock()
 	defer m.Unlock()
 	if _, ok := m.operations[span.Process.ServiceName]; !ok {
@@ -159,7 +216,11 @@ func (m *Store) WriteSpan(ctx context.Context, span *model.Span) error {
 }
 
 // GetTrace gets a trace
-func (m *Store) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+func (st *Store) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	return st.getTenant(ctx).getTrace(traceID)
+}
+
+func (m *tenantStore) getTrace(traceID model.TraceID) (*model.Trace, error) {
 	m.RLock()
 	defer m.RUnlock()
 	trace, ok := m.traces[traceID]
@@ -170,7 +231,7 @@ func (m *Store) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Tra
 }
 
 // Spans may still be added to traces after they are returned to user code, so make copies.
-func (m *Store) copyTrace(trace *model.Trace) (*model.Trace, error) {
+func (m *tenantStore) copyTrace(trace *model.Trace) (*model.Trace, error) {
 	bytes, err := proto.Marshal(trace)
 	if err != nil {
 		return nil, err
@@ -182,7 +243,11 @@ func (m *Store) copyTrace(trace *model.Trace) (*model.Trace, error) {
 }
 
 // GetServices returns a list of all known services
-func (m *Store) GetServices(ctx context.Context) ([]string, error) {
+func (st *Store) GetServices(ctx context.Context) ([]string, error) {
+	return st.getTenant(ctx).getServices()
+}
+
+func (m *tenantStore) getServices() ([]string, error) {
 	m.RLock()
 	defer m.RUnlock()
 	var retMe []string
@@ -193,10 +258,14 @@ func (m *Store) GetServices(ctx context.Context) ([]string, error) {
 }
 
 // GetOperations returns the operations of a given service
-func (m *Store) GetOperations(
+func (st *Store) GetOperations(
 	ctx context.Context,
 	query spanstore.OperationQueryParameters,
 ) ([]spanstore.Operation, error) {
+	return st.getTenant(ctx).getOperations(query)
+}
+
+func (m *tenantStore) getOperations(query spanstore.OperationQueryParameters) ([]spanstore.Operation, error) {
 	m.RLock()
 	defer m.RUnlock()
 	var retMe []spanstore.Operation
@@ -211,7 +280,11 @@ func (m *Store) GetOperations(
 }
 
 // FindTraces returns all traces in the query parameters are satisfied by a trace's span
-func (m *Store) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+func (st *Store) FindTraces(ctx context.Context, query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
+	return st.getTenant(ctx).findTraces(query)
+}
+
+func (m *tenantStore) findTraces(query *spanstore.TraceQueryParameters) ([]*model.Trace, error) {
 	m.RLock()
 	defer m.RUnlock()
 	var retMe []*model.Trace
@@ -239,11 +312,11 @@ func (m *Store) FindTraces(ctx context.Context, query *spanstore.TraceQueryParam
 }
 
 // FindTraceIDs is not implemented.
-func (m *Store) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
+func (st *Store) FindTraceIDs(ctx context.Context, query *spanstore.TraceQueryParameters) ([]model.TraceID, error) {
 	return nil, errors.New("not implemented")
 }
 
-func (m *Store) validTrace(trace *model.Trace, query *spanstore.TraceQueryParameters) bool {
+func (m *tenantStore) validTrace(trace *model.Trace, query *spanstore.TraceQueryParameters) bool {
 	for _, span := range trace.Spans {
 		if m.validSpan(span, query) {
 			return true
@@ -261,7 +334,7 @@ func findKeyValueMatch(kvs model.KeyValues, key, value string) (model.KeyValue,
 	return model.KeyValue{}, false
 }
 
-func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameters) bool {
+func (m *tenantStore) validSpan(span *model.Span, query *spanstore.TraceQueryParameters) bool {
 	if query.ServiceName != span.Process.ServiceName {
 		return false
 	}
@@ -290,7 +363,7 @@ func (m *Store) validSpan(span *model.Span, query *spanstore.TraceQueryParameter
 	return true
 }
 
-func (m *Store) flattenTags(span *model.Span) model.KeyValues {
+func (m *tenantStore) flattenTags(span *model.Span) model.KeyValues {
 	retMe := span.Tags
 	retMe = append(retMe, span.Process.Tags...)
 	for _, l := range span.Logs {
@@ -21,9 +21,11 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/jaegertracing/jaeger/model"
 	"github.com/jaegertracing/jaeger/pkg/memory/config"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
 	"github.com/jaegertracing/jaeger/storage/spanstore"
 )
 
@@ -205,8 +207,8 @@ func TestStoreWithLimit(t *testing.T) {
 		assert.NoError(t, err)
 	}
 
-	assert.Equal(t, maxTraces, len(store.traces))
-	assert.Equal(t, maxTraces, len(store.ids))
+	assert.Equal(t, maxTraces, len(store.getTenant(context.Background()).traces))
+	assert.Equal(t, maxTraces, len(store.getTenant(context.Background()).ids))
 }
 
 func TestStoreGetTraceSuccess(t *testing.T) {
@@ -238,7 +240,7 @@ func TestStoreGetAndMutateTrace(t *testing.T) {
 
 func TestStoreGetTraceError(t *testing.T) {
 	withPopulatedMemoryStore(func(store *Store) {
-		store.traces[testingSpan.TraceID] = &model.Trace{
+		store.getTenant(context.Background()).traces[testingSpan.TraceID] = &model.Trace{
 			Spans: []*model.Span{nonSerializableSpan},
 		}
 		_, err := store.GetTrace(context.Background(), testingSpan.TraceID)
@@ -462,3 +464,51 @@ func TestStore_FindTraceIDs(t *testing.T) {
 		assert.EqualError(t, err, "not implemented")
 	})
 }
+
+func TestTenantStore(t *testing.T) {
+	withMemoryStore(func(store *Store) {
+		ctxAcme := tenancy.WithTenant(context.Background(), "acme")
+		ctxGlobex := tenancy.WithTenant(context.Background(), "globex")
+
+		require.NoError(t, store.WriteSpan(ctxAcme, testingSpan))
+		require.NoError(t, store.WriteSpan(ctxGlobex, childSpan1))
+
+		trace, err := store.GetTrace(ctxAcme, testingSpan.TraceID)
+		require.NoError(t, err)
+		assert.Equal(t, []*model.Span{testingSpan}, trace.Spans)
+
+		trace, err = store.GetTrace(ctxGlobex, testingSpan.TraceID)
+		require.NoError(t, err)
+		require.Len(t, trace.Spans, 1)
+		assert.Equal(t, childSpan1.SpanID, trace.Spans[0].SpanID)
+
+		_, err = store.GetTrace(context.Background(), testingSpan.TraceID)
+		assert.Equal(t, spanstore.ErrTraceNotFound, err)
+
+		services, err := store.GetServices(ctxAcme)
+		require.NoError(t, err)
+		assert.Equal(t, []string{testingSpan.Process.ServiceName}, services)
+
+		// reads of unknown tenants return empty results without creating their stores
+		ctxInitech := tenancy.WithTenant(context.Background(), "initech")
+		_, err = store.GetTrace(ctxInitech, testingSpan.TraceID)
+		assert.Equal(t, spanstore.ErrTraceNotFound, err)
+		services, err = store.GetServices(ctxInitech)
+		require.NoError(t, err)
+		assert.Empty(t, services)
+		operations, err := store.GetOperations(ctxInitech, spanstore.OperationQueryParameters{ServiceName: testingSpan.Process.ServiceName})
+		require.NoError(t, err)
+		assert.Empty(t, operations)
+		deps, err := store.GetDependencies(ctxInitech, time.Now(), time.Hour)
+		require.NoError(t, err)
+		assert.Empty(t, deps)
+		assert.NotContains(t, store.perTenant, "initech")
+
+		traces, err := store.FindTraces(ctxGlobex, &spanstore.TraceQueryParameters{
+			ServiceName:   testingSpan.Process.ServiceName,
+			OperationName: testingSpan.OperationName,
+		})
+		require.NoError(t, err)
+		assert.Empty(t, traces)
+	})
+}
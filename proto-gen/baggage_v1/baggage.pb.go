@@ -0,0 +1,827 @@
+// Code generated by protoc-gen-gogo. DO NOT EDIT.
+// source: baggage.proto
+
+package baggage_v1
+
+import (
+	context "context"
+	fmt "fmt"
+	_ "github.com/gogo/protobuf/gogoproto"
+	proto "github.com/gogo/protobuf/proto"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	io "io"
+	math "math"
+	math_bits "math/bits"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.GoGoProtoPackageIsVersion3 // please upgrade the proto package
+
+type GetBaggageRestrictionsRequest struct {
+	ServiceName          string   `protobuf:"bytes,1,opt,name=service_name,json=serviceName,proto3" json:"service_name,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetBaggageRestrictionsRequest) Reset()         { *m = GetBaggageRestrictionsRequest{} }
+func (m *GetBaggageRestrictionsRequest) String() string { return proto.CompactTextString(m) }
+func (*GetBaggageRestrictionsRequest) ProtoMessage()    {}
+func (*GetBaggageRestrictionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{0}
+}
+func (m *GetBaggageRestrictionsRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetBaggageRestrictionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetBaggageRestrictionsRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetBaggageRestrictionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBaggageRestrictionsRequest.Merge(m, src)
+}
+func (m *GetBaggageRestrictionsRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetBaggageRestrictionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBaggageRestrictionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBaggageRestrictionsRequest proto.InternalMessageInfo
+
+func (m *GetBaggageRestrictionsRequest) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
+}
+
+// BaggageRestriction limits the length of the values of a baggage key.
+type BaggageRestriction struct {
+	BaggageKey           string   `protobuf:"bytes,1,opt,name=baggage_key,json=baggageKey,proto3" json:"baggage_key,omitempty"`
+	MaxValueLength       int32    `protobuf:"varint,2,opt,name=max_value_length,json=maxValueLength,proto3" json:"max_value_length,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *BaggageRestriction) Reset()         { *m = BaggageRestriction{} }
+func (m *BaggageRestriction) String() string { return proto.CompactTextString(m) }
+func (*BaggageRestriction) ProtoMessage()    {}
+func (*BaggageRestriction) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{1}
+}
+func (m *BaggageRestriction) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *BaggageRestriction) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_BaggageRestriction.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *BaggageRestriction) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_BaggageRestriction.Merge(m, src)
+}
+func (m *BaggageRestriction) XXX_Size() int {
+	return m.Size()
+}
+func (m *BaggageRestriction) XXX_DiscardUnknown() {
+	xxx_messageInfo_BaggageRestriction.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_BaggageRestriction proto.InternalMessageInfo
+
+func (m *BaggageRestriction) GetBaggageKey() string {
+	if m != nil {
+		return m.BaggageKey
+	}
+	return ""
+}
+
+func (m *BaggageRestriction) GetMaxValueLength() int32 {
+	if m != nil {
+		return m.MaxValueLength
+	}
+	return 0
+}
+
+type GetBaggageRestrictionsResponse struct {
+	Restrictions         []*BaggageRestriction `protobuf:"bytes,1,rep,name=restrictions,proto3" json:"restrictions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}              `json:"-"`
+	XXX_unrecognized     []byte                `json:"-"`
+	XXX_sizecache        int32                 `json:"-"`
+}
+
+func (m *GetBaggageRestrictionsResponse) Reset()         { *m = GetBaggageRestrictionsResponse{} }
+func (m *GetBaggageRestrictionsResponse) String() string { return proto.CompactTextString(m) }
+func (*GetBaggageRestrictionsResponse) ProtoMessage()    {}
+func (*GetBaggageRestrictionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_b9e101d0014c1cc3, []int{2}
+}
+func (m *GetBaggageRestrictionsResponse) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *GetBaggageRestrictionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_GetBaggageRestrictionsResponse.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *GetBaggageRestrictionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetBaggageRestrictionsResponse.Merge(m, src)
+}
+func (m *GetBaggageRestrictionsResponse) XXX_Size() int {
+	return m.Size()
+}
+func (m *GetBaggageRestrictionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetBaggageRestrictionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetBaggageRestrictionsResponse proto.InternalMessageInfo
+
+func (m *GetBaggageRestrictionsResponse) GetRestrictions() []*BaggageRestriction {
+	if m != nil {
+		return m.Restrictions
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*GetBaggageRestrictionsRequest)(nil), "jaeger.baggage.v1.GetBaggageRestrictionsRequest")
+	proto.RegisterType((*BaggageRestriction)(nil), "jaeger.baggage.v1.BaggageRestriction")
+	proto.RegisterType((*GetBaggageRestrictionsResponse)(nil), "jaeger.baggage.v1.GetBaggageRestrictionsResponse")
+}
+
+func init() { proto.RegisterFile("baggage.proto", fileDescriptor_b9e101d0014c1cc3) }
+
+var fileDescriptor_b9e101d0014c1cc3 = []byte{
+	// 280 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xe2, 0xe2, 0x4d, 0x4a, 0x4c, 0x4f,
+	0x4f, 0x4c, 0x4f, 0xd5, 0x2b, 0x28, 0xca, 0x2f, 0xc9, 0x17, 0x12, 0xcc, 0x4a, 0x4c, 0x4d, 0x4f,
+	0x2d, 0xd2, 0x83, 0x89, 0x96, 0x19, 0x4a, 0x89, 0xa4, 0xe7, 0xa7, 0xe7, 0x83, 0x65, 0xf5, 0x41,
+	0x2c, 0x88, 0x42, 0x25, 0x27, 0x2e, 0x59, 0xf7, 0xd4, 0x12, 0x27, 0x88, 0xb2, 0xa0, 0xd4, 0xe2,
+	0x92, 0xa2, 0xcc, 0xe4, 0x92, 0xcc, 0xfc, 0xbc, 0xe2, 0xa0, 0xd4, 0xc2, 0xd2, 0xd4, 0xe2, 0x12,
+	0x21, 0x45, 0x2e, 0x9e, 0xe2, 0xd4, 0xa2, 0xb2, 0xcc, 0xe4, 0xd4, 0xf8, 0xbc, 0xc4, 0xdc, 0x54,
+	0x09, 0x46, 0x05, 0x46, 0x0d, 0xce, 0x20, 0x6e, 0xa8, 0x98, 0x5f, 0x62, 0x6e, 0xaa, 0x52, 0x3c,
+	0x97, 0x10, 0xa6, 0x01, 0x42, 0xf2, 0x5c, 0xdc, 0x50, 0xdb, 0xe3, 0xb3, 0x53, 0x2b, 0xa1, 0xfa,
+	0xb8, 0xa0, 0x42, 0xde, 0xa9, 0x95, 0x42, 0x1a, 0x5c, 0x02, 0xb9, 0x89, 0x15, 0xf1, 0x65, 0x89,
+	0x39, 0xa5, 0xa9, 0xf1, 0x39, 0xa9, 0x79, 0xe9, 0x25, 0x19, 0x12, 0x4c, 0x0a, 0x8c, 0x1a, 0xac,
+	0x41, 0x7c, 0xb9, 0x89, 0x15, 0x61, 0x20, 0x61, 0x1f, 0xb0, 0xa8, 0x52, 0x36, 0x97, 0x1c, 0x2e,
+	0x47, 0x16, 0x17, 0xe4, 0xe7, 0x15, 0xa7, 0x0a, 0x79, 0x72, 0xf1, 0x14, 0x21, 0x89, 0x4b, 0x30,
+	0x2a, 0x30, 0x6b, 0x70, 0x1b, 0xa9, 0xea, 0x61, 0x04, 0x83, 0x1e, 0xa6, 0x29, 0x41, 0x28, 0x5a,
+	0x8d, 0x66, 0x31, 0x72, 0x49, 0x62, 0x2a, 0xf2, 0x4d, 0xcc, 0x4b, 0x4c, 0x4f, 0x2d, 0x12, 0xaa,
+	0xe5, 0x12, 0xc3, 0xee, 0x14, 0x21, 0x03, 0x2c, 0x96, 0xe1, 0x0d, 0x5a, 0x29, 0x43, 0x12, 0x74,
+	0x40, 0xfc, 0xe9, 0x24, 0x71, 0xe2, 0x91, 0x1c, 0xe3, 0x85, 0x47, 0x72, 0x8c, 0x0f, 0x1e, 0xc9,
+	0x31, 0x46, 0xc1, 0x42, 0x33, 0xbe, 0xcc, 0x30, 0x89, 0x0d, 0x1c, 0x9f, 0xc6, 0x80, 0x01, 0x00,
+	0x0d, 0x79, 0xe4, 0x1f, 0x09, 0x02, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// BaggageRestrictionManagerClient is the client API for BaggageRestrictionManager service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type BaggageRestrictionManagerClient interface {
+	GetBaggageRestrictions(ctx context.Context, in *GetBaggageRestrictionsRequest, opts ...grpc.CallOption) (*GetBaggageRestrictionsResponse, error)
+}
+
+type baggageRestrictionManagerClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewBaggageRestrictionManagerClient(cc *grpc.ClientConn) BaggageRestrictionManagerClient {
+	return &baggageRestrictionManagerClient{cc}
+}
+
+func (c *baggageRestrictionManagerClient) GetBaggageRestrictions(ctx context.Context, in *GetBaggageRestrictionsRequest, opts ...grpc.CallOption) (*GetBaggageRestrictionsResponse, error) {
+	out := new(GetBaggageRestrictionsResponse)
+	err := c.cc.Invoke(ctx, "/jaeger.baggage.v1.BaggageRestrictionManager/GetBaggageRestrictions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// BaggageRestrictionManagerServer is the server API for BaggageRestrictionManager service.
+type BaggageRestrictionManagerServer interface {
+	GetBaggageRestrictions(context.Context, *GetBaggageRestrictionsRequest) (*GetBaggageRestrictionsResponse, error)
+}
+
+// UnimplementedBaggageRestrictionManagerServer can be embedded to have forward compatible implementations.
+type UnimplementedBaggageRestrictionManagerServer struct {
+}
+
+func (*UnimplementedBaggageRestrictionManagerServer) GetBaggageRestrictions(ctx context.Context, req *GetBaggageRestrictionsRequest) (*GetBaggageRestrictionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetBaggageRestrictions not implemented")
+}
+
+func RegisterBaggageRestrictionManagerServer(s *grpc.Server, srv BaggageRestrictionManagerServer) {
+	s.RegisterService(&_BaggageRestrictionManager_serviceDesc, srv)
+}
+
+func _BaggageRestrictionManager_GetBaggageRestrictions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetBaggageRestrictionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(BaggageRestrictionManagerServer).GetBaggageRestrictions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jaeger.baggage.v1.BaggageRestrictionManager/GetBaggageRestrictions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(BaggageRestrictionManagerServer).GetBaggageRestrictions(ctx, req.(*GetBaggageRestrictionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var _BaggageRestrictionManager_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "jaeger.baggage.v1.BaggageRestrictionManager",
+	HandlerType: (*BaggageRestrictionManagerServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetBaggageRestrictions",
+			Handler:    _BaggageRestrictionManager_GetBaggageRestrictions_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "baggage.proto",
+}
+
+func (m *GetBaggageRestrictionsRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetBaggageRestrictionsRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetBaggageRestrictionsRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.ServiceName) > 0 {
+		i -= len(m.ServiceName)
+		copy(dAtA[i:], m.ServiceName)
+		i = encodeVarintBaggage(dAtA, i, uint64(len(m.ServiceName)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *BaggageRestriction) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *BaggageRestriction) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *BaggageRestriction) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.MaxValueLength != 0 {
+		i = encodeVarintBaggage(dAtA, i, uint64(m.MaxValueLength))
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.BaggageKey) > 0 {
+		i -= len(m.BaggageKey)
+		copy(dAtA[i:], m.BaggageKey)
+		i = encodeVarintBaggage(dAtA, i, uint64(len(m.BaggageKey)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *GetBaggageRestrictionsResponse) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *GetBaggageRestrictionsResponse) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *GetBaggageRestrictionsResponse) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Restrictions) > 0 {
+		for iNdEx := len(m.Restrictions) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Restrictions[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintBaggage(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintBaggage(dAtA []byte, offset int, v uint64) int {
+	offset -= sovBaggage(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *GetBaggageRestrictionsRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ServiceName)
+	if l > 0 {
+		n += 1 + l + sovBaggage(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *BaggageRestriction) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.BaggageKey)
+	if l > 0 {
+		n += 1 + l + sovBaggage(uint64(l))
+	}
+	if m.MaxValueLength != 0 {
+		n += 1 + sovBaggage(uint64(m.MaxValueLength))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *GetBaggageRestrictionsResponse) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Restrictions) > 0 {
+		for _, e := range m.Restrictions {
+			l = e.Size()
+			n += 1 + l + sovBaggage(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovBaggage(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozBaggage(x uint64) (n int) {
+	return sovBaggage(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *GetBaggageRestrictionsRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBaggage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetBaggageRestrictionsRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetBaggageRestrictionsRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBaggage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *BaggageRestriction) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBaggage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: BaggageRestriction: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: BaggageRestriction: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BaggageKey", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.BaggageKey = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxValueLength", wireType)
+			}
+			m.MaxValueLength = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.MaxValueLength |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBaggage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *GetBaggageRestrictionsResponse) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowBaggage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: GetBaggageRestrictionsResponse: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: GetBaggageRestrictionsResponse: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Restrictions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Restrictions = append(m.Restrictions, &BaggageRestriction{})
+			if err := m.Restrictions[len(m.Restrictions)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipBaggage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthBaggage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func skipBaggage(dAtA []byte) (n int, err error) {
+	l := len(dAtA)
+	iNdEx := 0
+	depth := 0
+	for iNdEx < l {
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return 0, ErrIntOverflowBaggage
+			}
+			if iNdEx >= l {
+				return 0, io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		wireType := int(wire & 0x7)
+		switch wireType {
+		case 0:
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				iNdEx++
+				if dAtA[iNdEx-1] < 0x80 {
+					break
+				}
+			}
+		case 1:
+			iNdEx += 8
+		case 2:
+			var length int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return 0, ErrIntOverflowBaggage
+				}
+				if iNdEx >= l {
+					return 0, io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				length |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if length < 0 {
+				return 0, ErrInvalidLengthBaggage
+			}
+			iNdEx += length
+		case 3:
+			depth++
+		case 4:
+			if depth == 0 {
+				return 0, ErrUnexpectedEndOfGroupBaggage
+			}
+			depth--
+		case 5:
+			iNdEx += 4
+		default:
+			return 0, fmt.Errorf("proto: illegal wireType %d", wireType)
+		}
+		if iNdEx < 0 {
+			return 0, ErrInvalidLengthBaggage
+		}
+		if depth == 0 {
+			return iNdEx, nil
+		}
+	}
+	return 0, io.ErrUnexpectedEOF
+}
+
+var (
+	ErrInvalidLengthBaggage        = fmt.Errorf("proto: negative length found during unmarshaling")
+	ErrIntOverflowBaggage          = fmt.Errorf("proto: integer overflow")
+	ErrUnexpectedEndOfGroupBaggage = fmt.Errorf("proto: unexpected end of group")
+)
@@ -889,6 +889,53 @@ func (m *CapabilitiesResponse) GetArchiveSpanWriter() bool {
 	return false
 }
 
+type WriteSpansRequest struct {
+	Spans                []*model.Span `protobuf:"bytes,1,rep,name=spans,proto3" json:"spans,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}      `json:"-"`
+	XXX_unrecognized     []byte        `json:"-"`
+	XXX_sizecache        int32         `json:"-"`
+}
+
+func (m *WriteSpansRequest) Reset()         { *m = WriteSpansRequest{} }
+func (m *WriteSpansRequest) String() string { return proto.CompactTextString(m) }
+func (*WriteSpansRequest) ProtoMessage()    {}
+func (*WriteSpansRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_0d2c4ccf1453ffdb, []int{17}
+}
+func (m *WriteSpansRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *WriteSpansRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_WriteSpansRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalTo(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *WriteSpansRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WriteSpansRequest.Merge(m, src)
+}
+func (m *WriteSpansRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *WriteSpansRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WriteSpansRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WriteSpansRequest proto.InternalMessageInfo
+
+func (m *WriteSpansRequest) GetSpans() []*model.Span {
+	if m != nil {
+		return m.Spans
+	}
+	return nil
+}
+
 func init() {
 	proto.RegisterType((*GetDependenciesRequest)(nil), "jaeger.storage.v1.GetDependenciesRequest")
 	proto.RegisterType((*GetDependenciesResponse)(nil), "jaeger.storage.v1.GetDependenciesResponse")
@@ -908,78 +955,80 @@ func init() {
 	proto.RegisterType((*FindTraceIDsResponse)(nil), "jaeger.storage.v1.FindTraceIDsResponse")
 	proto.RegisterType((*CapabilitiesRequest)(nil), "jaeger.storage.v1.CapabilitiesRequest")
 	proto.RegisterType((*CapabilitiesResponse)(nil), "jaeger.storage.v1.CapabilitiesResponse")
+	proto.RegisterType((*WriteSpansRequest)(nil), "jaeger.storage.v1.WriteSpansRequest")
 }
 
 func init() { proto.RegisterFile("storage.proto", fileDescriptor_0d2c4ccf1453ffdb) }
 
 var fileDescriptor_0d2c4ccf1453ffdb = []byte{
-	// 1045 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4d, 0x73, 0xdb, 0xc4,
-	0x1b, 0xff, 0x2b, 0xb1, 0x6b, 0xfb, 0xb1, 0xd3, 0x7f, 0xb2, 0x76, 0xa9, 0x10, 0x34, 0x0e, 0x82,
-	0xbc, 0xc0, 0x80, 0x4c, 0xcc, 0x01, 0x06, 0xca, 0x40, 0x9d, 0xa4, 0x9e, 0x00, 0x85, 0xa2, 0x66,
-	0xe8, 0x0c, 0x85, 0x7a, 0xd6, 0xd6, 0xa2, 0xa8, 0xb1, 0x56, 0xaa, 0x5e, 0x3c, 0xf6, 0x81, 0x1b,
-	0x1f, 0x80, 0x23, 0x27, 0xae, 0x7c, 0x95, 0x1e, 0x39, 0x73, 0x08, 0x4c, 0xae, 0x7c, 0x09, 0x46,
-	0xbb, 0x2b, 0x59, 0x92, 0x35, 0x49, 0x9a, 0xc9, 0x4d, 0xfb, 0xec, 0x6f, 0x7f, 0xcf, 0xfb, 0xf3,
-	0x08, 0x56, 0xfc, 0xc0, 0xf1, 0xb0, 0x49, 0x34, 0xd7, 0x73, 0x02, 0x07, 0xad, 0x3d, 0xc3, 0xc4,
-	0x24, 0x9e, 0x16, 0x4b, 0x27, 0xbb, 0x4a, 0xcb, 0x74, 0x4c, 0x87, 0xdd, 0x76, 0xa2, 0x2f, 0x0e,
-	0x54, 0xda, 0xa6, 0xe3, 0x98, 0x63, 0xd2, 0x61, 0xa7, 0x61, 0xf8, 0x53, 0x27, 0xb0, 0x6c, 0xe2,
-	0x07, 0xd8, 0x76, 0x05, 0x60, 0x3d, 0x0f, 0x30, 0x42, 0x0f, 0x07, 0x96, 0x43, 0xc5, 0x7d, 0xdd,
-	0x76, 0x0c, 0x32, 0xe6, 0x07, 0xf5, 0x77, 0x09, 0x5e, 0xe9, 0x93, 0x60, 0x9f, 0xb8, 0x84, 0x1a,
-	0x84, 0x8e, 0x2c, 0xe2, 0xeb, 0xe4, 0x79, 0x48, 0xfc, 0x00, 0xed, 0x01, 0xf8, 0x01, 0xf6, 0x82,
-	0x41, 0xa4, 0x40, 0x96, 0x36, 0xa4, 0x9d, 0x7a, 0x57, 0xd1, 0x38, 0xb9, 0x16, 0x93, 0x6b, 0x47,
-	0xb1, 0xf6, 0x5e, 0xf5, 0xc5, 0x69, 0xfb, 0x7f, 0xbf, 0xfe, 0xdd, 0x96, 0xf4, 0x1a, 0x7b, 0x17,
-	0xdd, 0xa0, 0xcf, 0xa0, 0x4a, 0xa8, 0xc1, 0x29, 0x96, 0x5e, 0x82, 0xa2, 0x42, 0xa8, 0x11, 0xc9,
-	0xd5, 0x21, 0xdc, 0x5e, 0xb0, 0xcf, 0x77, 0x1d, 0xea, 0x13, 0xd4, 0x87, 0x86, 0x91, 0x92, 0xcb,
-	0xd2, 0xc6, 0xf2, 0x4e, 0xbd, 0x7b, 0x47, 0x13, 0x91, 0xc4, 0xae, 0x35, 0x98, 0x74, 0xb5, 0xe4,
-	0xe9, 0xec, 0x2b, 0x8b, 0x9e, 0xf4, 0x4a, 0x91, 0x0a, 0x3d, 0xf3, 0x50, 0xfd, 0x04, 0x56, 0x1f,
-	0x7b, 0x56, 0x40, 0x1e, 0xb9, 0x98, 0xc6, 0xde, 0x6f, 0x43, 0xc9, 0x77, 0x31, 0x15, 0x7e, 0x37,
-	0x73, 0xa4, 0x0c, 0xc9, 0x00, 0x6a, 0x13, 0xd6, 0x52, 0x8f, 0xb9, 0x69, 0x2a, 0x85, 0xff, 0xf7,
-	0x49, 0x70, 0xe4, 0xe1, 0x11, 0x89, 0x09, 0x9f, 0x40, 0x35, 0x88, 0xce, 0x03, 0xcb, 0x60, 0xa4,
-	0x8d, 0xde, 0xe7, 0x91, 0x29, 0x7f, 0x9d, 0xb6, 0xdf, 0x33, 0xad, 0xe0, 0x38, 0x1c, 0x6a, 0x23,
-	0xc7, 0xee, 0x70, 0x35, 0x11, 0xd0, 0xa2, 0xa6, 0x38, 0x75, 0x78, 0xc2, 0x18, 0xdb, 0xe1, 0xfe,
-	0xd9, 0x69, 0xbb, 0x22, 0x3e, 0xf5, 0x0a, 0x63, 0x3c, 0x34, 0xd4, 0x16, 0xa0, 0x3e, 0x09, 0x1e,
-	0x11, 0x6f, 0x62, 0x8d, 0x92, 0x0c, 0xaa, 0xbb, 0xd0, 0xcc, 0x48, 0x45, 0xdc, 0x14, 0xa8, 0xfa,
-	0x42, 0xc6, 0x62, 0x56, 0xd3, 0x93, 0xb3, 0xfa, 0x00, 0x5a, 0x7d, 0x12, 0x7c, 0xe3, 0x12, 0x5e,
-	0x32, 0x49, 0x31, 0xc8, 0x50, 0x11, 0x18, 0x66, 0x7c, 0x4d, 0x8f, 0x8f, 0xe8, 0x35, 0xa8, 0x45,
-	0x71, 0x18, 0x9c, 0x58, 0xd4, 0x60, 0x29, 0x8e, 0xe8, 0x5c, 0x4c, 0xbf, 0xb4, 0xa8, 0xa1, 0xde,
-	0x85, 0x5a, 0xc2, 0x85, 0x10, 0x94, 0x28, 0xb6, 0x63, 0x02, 0xf6, 0x7d, 0xfe, 0xeb, 0x9f, 0xe1,
-	0x56, 0xce, 0x18, 0xe1, 0xc1, 0x16, 0xdc, 0x74, 0x62, 0xe9, 0xd7, 0xd8, 0x4e, 0xfc, 0xc8, 0x49,
-	0xd1, 0x5d, 0x80, 0x44, 0xe2, 0xcb, 0x4b, 0xac, 0x3e, 0x5e, 0xd7, 0x16, 0x3a, 0x4d, 0x4b, 0x54,
-	0xe8, 0x29, 0xbc, 0xfa, 0x47, 0x09, 0x5a, 0x2c, 0xd2, 0xdf, 0x86, 0xc4, 0x9b, 0x3d, 0xc4, 0x1e,
-	0xb6, 0x49, 0x40, 0x3c, 0x1f, 0xbd, 0x01, 0x0d, 0xe1, 0xfd, 0x20, 0xe5, 0x50, 0x5d, 0xc8, 0x22,
-	0xd5, 0x68, 0x33, 0x65, 0x21, 0x07, 0x71, 0xe7, 0x56, 0x32, 0x16, 0xa2, 0x03, 0x28, 0x05, 0xd8,
-	0xf4, 0xe5, 0x65, 0x66, 0xda, 0x6e, 0x81, 0x69, 0x45, 0x06, 0x68, 0x47, 0xd8, 0xf4, 0x0f, 0x68,
-	0xe0, 0xcd, 0x74, 0xf6, 0x1c, 0x7d, 0x01, 0x37, 0xe7, 0xad, 0x3a, 0xb0, 0x2d, 0x2a, 0x97, 0x5e,
-	0xa2, 0xd7, 0x1a, 0x49, 0xbb, 0x3e, 0xb0, 0x68, 0x9e, 0x0b, 0x4f, 0xe5, 0xf2, 0xd5, 0xb8, 0xf0,
-	0x14, 0xdd, 0x87, 0x46, 0x3c, 0x7c, 0x98, 0x55, 0x37, 0x18, 0xd3, 0xab, 0x0b, 0x4c, 0xfb, 0x02,
-	0xc4, 0x89, 0x7e, 0x8b, 0x88, 0xea, 0xf1, 0xc3, 0xc8, 0xa6, 0x0c, 0x0f, 0x9e, 0xca, 0x95, 0xab,
-	0xf0, 0xe0, 0x29, 0xba, 0x03, 0x40, 0x43, 0x7b, 0xc0, 0xba, 0xc6, 0x97, 0xab, 0x1b, 0xd2, 0x4e,
-	0x59, 0xaf, 0xd1, 0xd0, 0x66, 0x41, 0xf6, 0x95, 0x0f, 0xa1, 0x96, 0x44, 0x16, 0xad, 0xc2, 0xf2,
-	0x09, 0x99, 0x89, 0xdc, 0x46, 0x9f, 0xa8, 0x05, 0xe5, 0x09, 0x1e, 0x87, 0x71, 0x2a, 0xf9, 0xe1,
-	0xe3, 0xa5, 0x8f, 0x24, 0x55, 0x87, 0xb5, 0xfb, 0x16, 0x35, 0x38, 0x4d, 0xdc, 0x32, 0x9f, 0x42,
-	0xf9, 0x79, 0x94, 0x37, 0x31, 0x42, 0xb6, 0x2f, 0x99, 0x5c, 0x9d, 0xbf, 0x52, 0x0f, 0x00, 0x45,
-	0x23, 0x25, 0x29, 0xfa, 0xbd, 0xe3, 0x90, 0x9e, 0xa0, 0x0e, 0x94, 0xa3, 0xf6, 0x88, 0x87, 0x5d,
-	0xd1, 0x5c, 0x12, 0x23, 0x8e, 0xe3, 0xd4, 0x23, 0x68, 0x26, 0xa6, 0x1d, 0xee, 0x5f, 0x97, 0x71,
-	0x13, 0x68, 0x65, 0x59, 0x45, 0x63, 0x3e, 0x85, 0x5a, 0x3c, 0xe4, 0xb8, 0x89, 0x8d, 0xde, 0xbd,
-	0xab, 0x4e, 0xb9, 0x6a, 0xc2, 0x5e, 0x15, 0x63, 0xce, 0x57, 0x6f, 0x41, 0x73, 0x0f, 0xbb, 0x78,
-	0x68, 0x8d, 0xad, 0x60, 0xbe, 0xaa, 0x54, 0x0f, 0x5a, 0x59, 0xb1, 0x30, 0xe7, 0x5d, 0x58, 0xc3,
-	0xde, 0xe8, 0xd8, 0x9a, 0x88, 0xe9, 0x8c, 0x0d, 0xe2, 0x31, 0x8f, 0xab, 0xfa, 0xe2, 0x45, 0x0e,
-	0xcd, 0x86, 0xba, 0xc7, 0x72, 0x9d, 0x45, 0xf3, 0x8b, 0xee, 0x33, 0x58, 0x9d, 0x9f, 0x1e, 0x8e,
-	0x43, 0xd3, 0xa2, 0xe8, 0x3b, 0xa8, 0x25, 0xbb, 0x00, 0xbd, 0x59, 0x10, 0xd3, 0xfc, 0x9a, 0x51,
-	0xde, 0x3a, 0x1f, 0xc4, 0xfd, 0xe8, 0xfe, 0xbb, 0xcc, 0x95, 0x71, 0x43, 0x85, 0xb2, 0xc7, 0x50,
-	0x8d, 0x77, 0x0c, 0x52, 0x0b, 0x68, 0x72, 0x0b, 0x48, 0xd9, 0x2c, 0xc0, 0x2c, 0x56, 0xd8, 0xfb,
-	0x12, 0xfa, 0x01, 0xea, 0xa9, 0xb5, 0x81, 0x36, 0x8b, 0xb9, 0x73, 0xcb, 0x46, 0xd9, 0xba, 0x08,
-	0x26, 0x72, 0x32, 0x84, 0x95, 0xcc, 0x50, 0x47, 0xdb, 0xc5, 0x0f, 0x17, 0x76, 0x90, 0xb2, 0x73,
-	0x31, 0x50, 0xe8, 0x78, 0x02, 0x30, 0xef, 0x47, 0x54, 0x14, 0xe3, 0x85, 0x76, 0xbd, 0x7c, 0x78,
-	0x06, 0xd0, 0x48, 0xd7, 0x3e, 0xda, 0x3a, 0x8f, 0x7e, 0xde, 0x72, 0xca, 0xf6, 0x85, 0x38, 0x91,
-	0xed, 0x29, 0xdc, 0xbe, 0x97, 0x2f, 0x37, 0x91, 0xf3, 0x1f, 0xc5, 0x9f, 0x4a, 0xea, 0xfe, 0x3a,
-	0xeb, 0x6c, 0x96, 0xd1, 0x9c, 0xa9, 0xb6, 0xa7, 0xec, 0x8f, 0x46, 0xdc, 0x5e, 0x7f, 0xd1, 0x75,
-	0x7f, 0x91, 0x40, 0xce, 0xfe, 0xe5, 0xa5, 0x94, 0x1f, 0x33, 0xe5, 0xe9, 0x6b, 0xf4, 0x76, 0xb1,
-	0xf2, 0x82, 0x1f, 0x59, 0xe5, 0x9d, 0xcb, 0x40, 0x45, 0x04, 0x42, 0x40, 0x5c, 0x67, 0x7a, 0x9e,
-	0x44, 0x29, 0xcf, 0x9c, 0x8b, 0x52, 0x5e, 0x30, 0x97, 0x0a, 0x53, 0x5e, 0x34, 0xa8, 0x7a, 0xf2,
-	0x8b, 0xb3, 0x75, 0xe9, 0xcf, 0xb3, 0x75, 0xe9, 0x9f, 0xb3, 0x75, 0xe9, 0x7b, 0x10, 0xf0, 0xc1,
-	0x64, 0x77, 0x78, 0x83, 0x2d, 0xb7, 0x0f, 0xfe, 0x0b, 0x00, 0x00, 0xff, 0xff, 0xf0, 0x66, 0x94,
-	0xe3, 0x2f, 0x0c, 0x00, 0x00,
+	// 1068 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x56, 0x4f, 0x73, 0xdb, 0x44,
+	0x14, 0x47, 0x89, 0x5d, 0xdb, 0xcf, 0x4e, 0x49, 0xd6, 0x2e, 0x15, 0x82, 0xc6, 0x41, 0x90, 0x38,
+	0x65, 0x40, 0x26, 0xe6, 0x00, 0x03, 0xe5, 0x4f, 0x9d, 0xa4, 0x9e, 0x00, 0x85, 0xa2, 0x66, 0x28,
+	0x43, 0xa1, 0x9e, 0xb5, 0xb5, 0x28, 0x22, 0xd6, 0x4a, 0xd5, 0x1f, 0x8f, 0x7d, 0xe0, 0xc6, 0x07,
+	0xe0, 0xc8, 0x89, 0x2b, 0x5f, 0x84, 0x43, 0x8e, 0x9c, 0x39, 0x04, 0x26, 0x57, 0xbe, 0x04, 0xa3,
+	0xd5, 0x4a, 0x96, 0x64, 0x4d, 0x9c, 0x64, 0x72, 0xd3, 0xbe, 0xfd, 0xbd, 0xdf, 0xfb, 0xb7, 0xef,
+	0x3d, 0xc1, 0x8a, 0xeb, 0x59, 0x0e, 0xd6, 0x89, 0x62, 0x3b, 0x96, 0x67, 0xa1, 0xb5, 0x9f, 0x30,
+	0xd1, 0x89, 0xa3, 0x44, 0xd2, 0xf1, 0x8e, 0xd4, 0xd0, 0x2d, 0xdd, 0x62, 0xb7, 0xed, 0xe0, 0x2b,
+	0x04, 0x4a, 0x4d, 0xdd, 0xb2, 0xf4, 0x11, 0x69, 0xb3, 0xd3, 0xc0, 0xff, 0xb1, 0xed, 0x19, 0x26,
+	0x71, 0x3d, 0x6c, 0xda, 0x1c, 0xb0, 0x9e, 0x05, 0x68, 0xbe, 0x83, 0x3d, 0xc3, 0xa2, 0xfc, 0xbe,
+	0x6a, 0x5a, 0x1a, 0x19, 0x85, 0x07, 0xf9, 0x77, 0x01, 0x5e, 0xea, 0x11, 0x6f, 0x8f, 0xd8, 0x84,
+	0x6a, 0x84, 0x0e, 0x0d, 0xe2, 0xaa, 0xe4, 0xb9, 0x4f, 0x5c, 0x0f, 0xed, 0x02, 0xb8, 0x1e, 0x76,
+	0xbc, 0x7e, 0x60, 0x40, 0x14, 0x36, 0x84, 0xed, 0x6a, 0x47, 0x52, 0x42, 0x72, 0x25, 0x22, 0x57,
+	0x0e, 0x23, 0xeb, 0xdd, 0xf2, 0xc9, 0x69, 0xf3, 0x85, 0x5f, 0xff, 0x69, 0x0a, 0x6a, 0x85, 0xe9,
+	0x05, 0x37, 0xe8, 0x13, 0x28, 0x13, 0xaa, 0x85, 0x14, 0x4b, 0x97, 0xa0, 0x28, 0x11, 0xaa, 0x05,
+	0x72, 0x79, 0x00, 0xb7, 0xe7, 0xfc, 0x73, 0x6d, 0x8b, 0xba, 0x04, 0xf5, 0xa0, 0xa6, 0x25, 0xe4,
+	0xa2, 0xb0, 0xb1, 0xbc, 0x5d, 0xed, 0xdc, 0x51, 0x78, 0x26, 0xb1, 0x6d, 0xf4, 0xc7, 0x1d, 0x25,
+	0x56, 0x9d, 0x7e, 0x61, 0xd0, 0xe3, 0x6e, 0x21, 0x30, 0xa1, 0xa6, 0x14, 0xe5, 0x0f, 0x61, 0xf5,
+	0x89, 0x63, 0x78, 0xe4, 0xb1, 0x8d, 0x69, 0x14, 0x7d, 0x0b, 0x0a, 0xae, 0x8d, 0x29, 0x8f, 0xbb,
+	0x9e, 0x21, 0x65, 0x48, 0x06, 0x90, 0xeb, 0xb0, 0x96, 0x50, 0x0e, 0x5d, 0x93, 0x29, 0xbc, 0xd8,
+	0x23, 0xde, 0xa1, 0x83, 0x87, 0x24, 0x22, 0x7c, 0x0a, 0x65, 0x2f, 0x38, 0xf7, 0x0d, 0x8d, 0x91,
+	0xd6, 0xba, 0x9f, 0x06, 0xae, 0xfc, 0x7d, 0xda, 0x7c, 0x5b, 0x37, 0xbc, 0x23, 0x7f, 0xa0, 0x0c,
+	0x2d, 0xb3, 0x1d, 0x9a, 0x09, 0x80, 0x06, 0xd5, 0xf9, 0xa9, 0x1d, 0x16, 0x8c, 0xb1, 0x1d, 0xec,
+	0x9d, 0x9d, 0x36, 0x4b, 0xfc, 0x53, 0x2d, 0x31, 0xc6, 0x03, 0x4d, 0x6e, 0x00, 0xea, 0x11, 0xef,
+	0x31, 0x71, 0xc6, 0xc6, 0x30, 0xae, 0xa0, 0xbc, 0x03, 0xf5, 0x94, 0x94, 0xe7, 0x4d, 0x82, 0xb2,
+	0xcb, 0x65, 0x2c, 0x67, 0x15, 0x35, 0x3e, 0xcb, 0x0f, 0xa1, 0xd1, 0x23, 0xde, 0x57, 0x36, 0x09,
+	0x9f, 0x4c, 0xfc, 0x18, 0x44, 0x28, 0x71, 0x0c, 0x73, 0xbe, 0xa2, 0x46, 0x47, 0xf4, 0x0a, 0x54,
+	0x82, 0x3c, 0xf4, 0x8f, 0x0d, 0xaa, 0xb1, 0x12, 0x07, 0x74, 0x36, 0xa6, 0x9f, 0x1b, 0x54, 0x93,
+	0xef, 0x41, 0x25, 0xe6, 0x42, 0x08, 0x0a, 0x14, 0x9b, 0x11, 0x01, 0xfb, 0x3e, 0x5f, 0xfb, 0x67,
+	0xb8, 0x95, 0x71, 0x86, 0x47, 0xb0, 0x05, 0x37, 0xad, 0x48, 0xfa, 0x25, 0x36, 0xe3, 0x38, 0x32,
+	0x52, 0x74, 0x0f, 0x20, 0x96, 0xb8, 0xe2, 0x12, 0x7b, 0x1f, 0xaf, 0x2a, 0x73, 0x9d, 0xa6, 0xc4,
+	0x26, 0xd4, 0x04, 0x5e, 0xfe, 0xa3, 0x00, 0x0d, 0x96, 0xe9, 0xaf, 0x7d, 0xe2, 0x4c, 0x1f, 0x61,
+	0x07, 0x9b, 0xc4, 0x23, 0x8e, 0x8b, 0x5e, 0x83, 0x1a, 0x8f, 0xbe, 0x9f, 0x08, 0xa8, 0xca, 0x65,
+	0x81, 0x69, 0xb4, 0x99, 0xf0, 0x30, 0x04, 0x85, 0xc1, 0xad, 0xa4, 0x3c, 0x44, 0xfb, 0x50, 0xf0,
+	0xb0, 0xee, 0x8a, 0xcb, 0xcc, 0xb5, 0x9d, 0x1c, 0xd7, 0xf2, 0x1c, 0x50, 0x0e, 0xb1, 0xee, 0xee,
+	0x53, 0xcf, 0x99, 0xaa, 0x4c, 0x1d, 0x7d, 0x06, 0x37, 0x67, 0xad, 0xda, 0x37, 0x0d, 0x2a, 0x16,
+	0x2e, 0xd1, 0x6b, 0xb5, 0xb8, 0x5d, 0x1f, 0x1a, 0x34, 0xcb, 0x85, 0x27, 0x62, 0xf1, 0x6a, 0x5c,
+	0x78, 0x82, 0x1e, 0x40, 0x2d, 0x1a, 0x3e, 0xcc, 0xab, 0x1b, 0x8c, 0xe9, 0xe5, 0x39, 0xa6, 0x3d,
+	0x0e, 0x0a, 0x89, 0x7e, 0x0b, 0x88, 0xaa, 0x91, 0x62, 0xe0, 0x53, 0x8a, 0x07, 0x4f, 0xc4, 0xd2,
+	0x55, 0x78, 0xf0, 0x04, 0xdd, 0x01, 0xa0, 0xbe, 0xd9, 0x67, 0x5d, 0xe3, 0x8a, 0xe5, 0x0d, 0x61,
+	0xbb, 0xa8, 0x56, 0xa8, 0x6f, 0xb2, 0x24, 0xbb, 0xd2, 0x7b, 0x50, 0x89, 0x33, 0x8b, 0x56, 0x61,
+	0xf9, 0x98, 0x4c, 0x79, 0x6d, 0x83, 0x4f, 0xd4, 0x80, 0xe2, 0x18, 0x8f, 0xfc, 0xa8, 0x94, 0xe1,
+	0xe1, 0x83, 0xa5, 0xf7, 0x05, 0x59, 0x85, 0xb5, 0x07, 0x06, 0xd5, 0x42, 0x9a, 0xa8, 0x65, 0x3e,
+	0x82, 0xe2, 0xf3, 0xa0, 0x6e, 0x7c, 0x84, 0xb4, 0x2e, 0x58, 0x5c, 0x35, 0xd4, 0x92, 0xf7, 0x01,
+	0x05, 0x23, 0x25, 0x7e, 0xf4, 0xbb, 0x47, 0x3e, 0x3d, 0x46, 0x6d, 0x28, 0x06, 0xed, 0x11, 0x0d,
+	0xbb, 0xbc, 0xb9, 0xc4, 0x47, 0x5c, 0x88, 0x93, 0x0f, 0xa1, 0x1e, 0xbb, 0x76, 0xb0, 0x77, 0x5d,
+	0xce, 0x8d, 0xa1, 0x91, 0x66, 0xe5, 0x8d, 0xf9, 0x0c, 0x2a, 0xd1, 0x90, 0x0b, 0x5d, 0xac, 0x75,
+	0xef, 0x5f, 0x75, 0xca, 0x95, 0x63, 0xf6, 0x32, 0x1f, 0x73, 0xae, 0x7c, 0x0b, 0xea, 0xbb, 0xd8,
+	0xc6, 0x03, 0x63, 0x64, 0x78, 0xb3, 0x55, 0x25, 0x3b, 0xd0, 0x48, 0x8b, 0xb9, 0x3b, 0x6f, 0xc1,
+	0x1a, 0x76, 0x86, 0x47, 0xc6, 0x98, 0x4f, 0x67, 0xac, 0x11, 0x87, 0x45, 0x5c, 0x56, 0xe7, 0x2f,
+	0x32, 0x68, 0x36, 0xd4, 0x1d, 0x71, 0x69, 0x0e, 0x1d, 0x5e, 0xc8, 0x1f, 0x27, 0xe6, 0x7e, 0x9c,
+	0xd6, 0xbb, 0x8b, 0xcb, 0xc3, 0x0b, 0xd3, 0xf9, 0x53, 0x80, 0xd5, 0x19, 0xdd, 0xa3, 0x91, 0xaf,
+	0x1b, 0x14, 0x7d, 0x03, 0x95, 0x98, 0x14, 0xbd, 0x9e, 0x53, 0x94, 0xec, 0x9e, 0x92, 0xde, 0x38,
+	0x1f, 0xc4, 0x13, 0xf1, 0x2d, 0xc0, 0xcc, 0x59, 0x74, 0xae, 0x8e, 0x7b, 0x29, 0xe6, 0xce, 0x7f,
+	0xcb, 0xb0, 0x3a, 0xcb, 0x21, 0x0f, 0xe3, 0x09, 0x94, 0xa3, 0xf5, 0x87, 0xe4, 0x1c, 0x9a, 0xcc,
+	0x6e, 0x94, 0x36, 0x73, 0x30, 0xf3, 0x8f, 0xff, 0x1d, 0x01, 0x7d, 0x0f, 0xd5, 0xc4, 0x46, 0x43,
+	0x9b, 0xf9, 0xdc, 0x99, 0x3d, 0x28, 0x6d, 0x2d, 0x82, 0xf1, 0x2c, 0x0d, 0x60, 0x25, 0xb5, 0x6f,
+	0x50, 0x2b, 0x5f, 0x71, 0x6e, 0x3d, 0x4a, 0xdb, 0x8b, 0x81, 0xdc, 0xc6, 0x53, 0x80, 0xd9, 0xa8,
+	0xc8, 0xad, 0xc4, 0xdc, 0x24, 0xb9, 0x78, 0x7a, 0xfa, 0x50, 0x4b, 0xb6, 0x25, 0xda, 0x3a, 0x8f,
+	0x7e, 0x36, 0x0d, 0xa4, 0xd6, 0x42, 0x1c, 0xaf, 0xf6, 0x04, 0x6e, 0xdf, 0xcf, 0x76, 0x02, 0xaf,
+	0xf9, 0x0f, 0xfc, 0x27, 0x2a, 0x71, 0x7f, 0x8d, 0x2f, 0xb8, 0x33, 0x4d, 0x59, 0x4e, 0xbd, 0xb6,
+	0x67, 0xec, 0x67, 0x8b, 0xdf, 0x5e, 0xff, 0xa3, 0xeb, 0xfc, 0x22, 0x80, 0x98, 0xfe, 0x01, 0x4d,
+	0x18, 0x3f, 0x62, 0xc6, 0x93, 0xd7, 0xe8, 0x6e, 0xbe, 0xf1, 0x9c, 0x7f, 0x6c, 0xe9, 0xcd, 0x8b,
+	0x40, 0x79, 0x06, 0x7c, 0x40, 0xa1, 0xcd, 0xe4, 0xa8, 0x0b, 0x4a, 0x9e, 0x3a, 0xe7, 0x95, 0x3c,
+	0x67, 0x64, 0x4a, 0xad, 0x85, 0xb8, 0xd0, 0x6c, 0x57, 0x3c, 0x39, 0x5b, 0x17, 0xfe, 0x3a, 0x5b,
+	0x17, 0xfe, 0x3d, 0x5b, 0x17, 0xbe, 0x03, 0x0e, 0xef, 0x8f, 0x77, 0x06, 0x37, 0xd8, 0xde, 0x7d,
+	0xf7, 0xff, 0x01, 0x00, 0xcf, 0x79, 0xe5, 0x84, 0xca, 0x0c, 0x00, 0x00,
 }
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -996,6 +1045,7 @@ const _ = grpc.SupportPackageIsVersion4
 type SpanWriterPluginClient interface {
 	// spanstore/Writer
 	WriteSpan(ctx context.Context, in *WriteSpanRequest, opts ...grpc.CallOption) (*WriteSpanResponse, error)
+	WriteSpans(ctx context.Context, in *WriteSpansRequest, opts ...grpc.CallOption) (*WriteSpanResponse, error)
 }
 
 type spanWriterPluginClient struct {
@@ -1015,10 +1065,20 @@ func (c *spanWriterPluginClient) WriteSpan(ctx context.Context, in *WriteSpanReq
 	return out, nil
 }
 
+func (c *spanWriterPluginClient) WriteSpans(ctx context.Context, in *WriteSpansRequest, opts ...grpc.CallOption) (*WriteSpanResponse, error) {
+	out := new(WriteSpanResponse)
+	err := c.cc.Invoke(ctx, "/jaeger.storage.v1.SpanWriterPlugin/WriteSpans", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // SpanWriterPluginServer is the server API for SpanWriterPlugin service.
 type SpanWriterPluginServer interface {
 	// spanstore/Writer
 	WriteSpan(context.Context, *WriteSpanRequest) (*WriteSpanResponse, error)
+	WriteSpans(context.Context, *WriteSpansRequest) (*WriteSpanResponse, error)
 }
 
 func RegisterSpanWriterPluginServer(s *grpc.Server, srv SpanWriterPluginServer) {
@@ -1043,6 +1103,24 @@ func _SpanWriterPlugin_WriteSpan_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _SpanWriterPlugin_WriteSpans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(WriteSpansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SpanWriterPluginServer).WriteSpans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/jaeger.storage.v1.SpanWriterPlugin/WriteSpans",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SpanWriterPluginServer).WriteSpans(ctx, req.(*WriteSpansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 var _SpanWriterPlugin_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "jaeger.storage.v1.SpanWriterPlugin",
 	HandlerType: (*SpanWriterPluginServer)(nil),
@@ -1051,6 +1129,10 @@ var _SpanWriterPlugin_serviceDesc = grpc.ServiceDesc{
 			MethodName: "WriteSpan",
 			Handler:    _SpanWriterPlugin_WriteSpan_Handler,
 		},
+		{
+			MethodName: "WriteSpans",
+			Handler:    _SpanWriterPlugin_WriteSpans_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "storage.proto",
@@ -2197,6 +2279,39 @@ func (m *CapabilitiesResponse) MarshalTo(dAtA []byte) (int, error) {
 	return i, nil
 }
 
+func (m *WriteSpansRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *WriteSpansRequest) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Spans) > 0 {
+		for _, msg := range m.Spans {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintStorage(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		i += copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	return i, nil
+}
+
 func encodeVarintStorage(dAtA []byte, offset int, v uint64) int {
 	for v >= 1<<7 {
 		dAtA[offset] = uint8(v&0x7f | 0x80)
@@ -2513,6 +2628,24 @@ func (m *CapabilitiesResponse) Size() (n int) {
 	return n
 }
 
+func (m *WriteSpansRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Spans) > 0 {
+		for _, e := range m.Spans {
+			l = e.Size()
+			n += 1 + l + sovStorage(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
 func sovStorage(x uint64) (n int) {
 	for {
 		n++
@@ -4362,6 +4495,94 @@ func (m *CapabilitiesResponse) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
+func (m *WriteSpansRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowStorage
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: WriteSpansRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: WriteSpansRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Spans", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowStorage
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthStorage
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Spans = append(m.Spans, &model.Span{})
+			if err := m.Spans[len(m.Spans)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipStorage(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) < 0 {
+				return ErrInvalidLengthStorage
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
 func skipStorage(dAtA []byte) (n int, err error) {
 	l := len(dAtA)
 	iNdEx := 0
@@ -0,0 +1,58 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencystore
+
+import (
+	"context"
+	"time"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+// TenantKeyPrefixReader is a dependency Reader that only returns the links between the services
+// of the tenant from the context, for stores whose service names were prefixed with the tenant
+// by a spanstore.TenantKeyPrefixWriter. The tenant prefix is removed from the returned links.
+type TenantKeyPrefixReader struct {
+	reader Reader
+}
+
+// NewTenantKeyPrefixReader creates a TenantKeyPrefixReader.
+func NewTenantKeyPrefixReader(reader Reader) *TenantKeyPrefixReader {
+	return &TenantKeyPrefixReader{reader: reader}
+}
+
+// GetDependencies returns the dependency links of the tenant.
+func (r *TenantKeyPrefixReader) GetDependencies(ctx context.Context, endTs time.Time, lookback time.Duration) ([]model.DependencyLink, error) {
+	links, err := r.reader.GetDependencies(ctx, endTs, lookback)
+	if err != nil {
+		return nil, err
+	}
+	prefix := spanstore.TenantKeyPrefix(ctx)
+	tenantLinks := []model.DependencyLink{}
+	for _, link := range links {
+		parent, ok := spanstore.OwnsService(prefix, link.Parent)
+		if !ok {
+			continue
+		}
+		child, ok := spanstore.OwnsService(prefix, link.Child)
+		if !ok {
+			continue
+		}
+		link.Parent, link.Child = parent, child
+		tenantLinks = append(tenantLinks, link)
+	}
+	return tenantLinks, nil
+}
@@ -0,0 +1,64 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dependencystore_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	. "github.com/jaegertracing/jaeger/storage/dependencystore"
+	"github.com/jaegertracing/jaeger/storage/dependencystore/mocks"
+)
+
+func TestTenantKeyPrefixReader(t *testing.T) {
+	links := []model.DependencyLink{
+		{Parent: "acme/frontend", Child: "acme/backend", CallCount: 1},
+		{Parent: "acme/frontend", Child: "globex/backend", CallCount: 2},
+		{Parent: "globex/frontend", Child: "globex/backend", CallCount: 3},
+		{Parent: "frontend", Child: "backend", CallCount: 4},
+		{Parent: "frontend", Child: "acme/backend", CallCount: 5},
+	}
+	store := &mocks.Reader{}
+	store.On("GetDependencies", mock.Anything, mock.Anything, mock.Anything).Return(links, nil)
+	r := NewTenantKeyPrefixReader(store)
+
+	deps, err := r.GetDependencies(tenancy.WithTenant(context.Background(), "acme"), time.Now(), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []model.DependencyLink{{Parent: "frontend", Child: "backend", CallCount: 1}}, deps)
+
+	deps, err = r.GetDependencies(context.Background(), time.Now(), time.Hour)
+	require.NoError(t, err)
+	assert.Equal(t, []model.DependencyLink{{Parent: "frontend", Child: "backend", CallCount: 4}}, deps)
+
+	deps, err = r.GetDependencies(tenancy.WithTenant(context.Background(), "initech"), time.Now(), time.Hour)
+	require.NoError(t, err)
+	assert.Empty(t, deps)
+	assert.Equal(t, "acme/frontend", links[0].Parent, "the links of the store must not be modified")
+}
+
+func TestTenantKeyPrefixReaderError(t *testing.T) {
+	store := &mocks.Reader{}
+	store.On("GetDependencies", mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("read error"))
+	_, err := NewTenantKeyPrefixReader(store).GetDependencies(context.Background(), time.Now(), time.Hour)
+	assert.EqualError(t, err, "read error")
+}
@@ -44,3 +44,15 @@ func (c *CompositeWriter) WriteSpan(ctx context.Context, span *model.Span) error
 	}
 	return multierror.Wrap(errors)
 }
+
+// WriteSpans calls WriteSpans on each span writer, using batches for the writers that support them.
+// It will sum up failures, it is not transactional
+func (c *CompositeWriter) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	var errors []error
+	for _, writer := range c.spanWriters {
+		if err := WriteSpans(ctx, writer, spans); err != nil {
+			errors = append(errors, err)
+		}
+	}
+	return multierror.Wrap(errors)
+}
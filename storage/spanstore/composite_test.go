@@ -55,3 +55,37 @@ func TestCompositeWriteSpanStoreFirstFailure(t *testing.T) {
 	c := NewCompositeWriter(&errProneWriteSpanStore{}, &noopWriteSpanStore{})
 	assert.Equal(t, errIWillAlwaysFail, c.WriteSpan(context.Background(), nil))
 }
+
+type batchWriteSpanStore struct {
+	noopWriteSpanStore
+	batches [][]*model.Span
+}
+
+func (b *batchWriteSpanStore) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	b.batches = append(b.batches, spans)
+	return nil
+}
+
+func TestWriteSpans(t *testing.T) {
+	spans := []*model.Span{{SpanID: 1}, {SpanID: 2}}
+
+	batchWriter := &batchWriteSpanStore{}
+	assert.NoError(t, WriteSpans(context.Background(), batchWriter, spans))
+	assert.Equal(t, [][]*model.Span{spans}, batchWriter.batches)
+
+	assert.NoError(t, WriteSpans(context.Background(), &noopWriteSpanStore{}, spans))
+	assert.EqualError(t, WriteSpans(context.Background(), &errProneWriteSpanStore{}, spans),
+		fmt.Sprintf("[%s, %s]", errIWillAlwaysFail, errIWillAlwaysFail))
+}
+
+func TestCompositeWriteSpans(t *testing.T) {
+	spans := []*model.Span{{SpanID: 1}, {SpanID: 2}}
+	batchWriter := &batchWriteSpanStore{}
+	c := NewCompositeWriter(batchWriter, &noopWriteSpanStore{})
+	assert.NoError(t, c.WriteSpans(context.Background(), spans))
+	assert.Equal(t, [][]*model.Span{spans}, batchWriter.batches)
+
+	c = NewCompositeWriter(&errProneWriteSpanStore{}, batchWriter)
+	assert.Error(t, c.WriteSpans(context.Background(), spans))
+	assert.Len(t, batchWriter.batches, 2)
+}
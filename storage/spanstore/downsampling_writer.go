@@ -21,13 +21,21 @@ import (
 	"math"
 	"math/big"
 	"sync"
+	"sync/atomic"
 
 	"github.com/uber/jaeger-lib/metrics"
 
 	"github.com/jaegertracing/jaeger/model"
 )
 
-const defaultHashSalt = "downsampling-default-salt"
+const (
+	defaultHashSalt = "downsampling-default-salt"
+
+	// maxServiceMetrics limits the number of services with their own kept and dropped span counters
+	maxServiceMetrics = 2000
+	// otherServices is the service tag of the counters shared by the services above maxServiceMetrics
+	otherServices = "other-services"
+)
 
 var (
 	traceIDByteSize = (&model.TraceID{}).Size()
@@ -45,16 +53,36 @@ type downsamplingWriterMetrics struct {
 	SpansAccepted metrics.Counter `metric:"spans_accepted"`
 }
 
-// DownsamplingWriter is a span Writer that drops spans with a predefined downsamplingRatio.
+// serviceMetrics keeps track of the dropped and accepted spans of a service.
+type serviceMetrics struct {
+	SpansDropped  metrics.Counter
+	SpansAccepted metrics.Counter
+}
+
+// DownsamplingRatios provides the downsampling ratio of the spans of each service and operation.
+type DownsamplingRatios interface {
+	Ratio(service, operation string) float64
+}
+
+// DownsamplingWriter is a span Writer that drops spans with a predefined downsamplingRatio,
+// or with the ratio of their service and operation when DownsamplingRatios are provided.
+// Spans with the debug flag are never dropped.
 type DownsamplingWriter struct {
-	spanWriter Writer
-	metrics    downsamplingWriterMetrics
-	sampler    *Sampler
+	spanWriter     Writer
+	metrics        downsamplingWriterMetrics
+	sampler        *Sampler
+	ratio          float64
+	ratios         DownsamplingRatios
+	thresholds     sync.Map // float64 ratio -> uint64 threshold
+	metricsFactory metrics.Factory
+	serviceMetrics sync.Map // string service -> *serviceMetrics
+	servicesCount  int32
 }
 
 // DownsamplingOptions contains the options for constructing a DownsamplingWriter.
 type DownsamplingOptions struct {
 	Ratio          float64
+	Ratios         DownsamplingRatios
 	HashSalt       string
 	MetricsFactory metrics.Factory
 }
@@ -63,24 +91,110 @@ type DownsamplingOptions struct {
 func NewDownsamplingWriter(spanWriter Writer, downsamplingOptions DownsamplingOptions) *DownsamplingWriter {
 	writeMetrics := &downsamplingWriterMetrics{}
 	metrics.Init(writeMetrics, downsamplingOptions.MetricsFactory, nil)
+	metricsFactory := downsamplingOptions.MetricsFactory
+	if metricsFactory == nil {
+		metricsFactory = metrics.NullFactory
+	}
 	return &DownsamplingWriter{
-		sampler:    NewSampler(downsamplingOptions.Ratio, downsamplingOptions.HashSalt),
-		spanWriter: spanWriter,
-		metrics:    *writeMetrics,
+		sampler:        NewSampler(downsamplingOptions.Ratio, downsamplingOptions.HashSalt),
+		spanWriter:     spanWriter,
+		metrics:        *writeMetrics,
+		ratio:          downsamplingOptions.Ratio,
+		ratios:         downsamplingOptions.Ratios,
+		metricsFactory: metricsFactory,
 	}
 }
 
 // WriteSpan calls WriteSpan on wrapped span writer.
 func (ds *DownsamplingWriter) WriteSpan(ctx context.Context, span *model.Span) error {
-	if !ds.sampler.ShouldSample(span) {
+	if !ds.shouldSample(span) {
 		// Drops spans when hashVal falls beyond computed threshold.
 		ds.metrics.SpansDropped.Inc(1)
+		ds.metricsFor(span).SpansDropped.Inc(1)
 		return nil
 	}
 	ds.metrics.SpansAccepted.Inc(1)
+	ds.metricsFor(span).SpansAccepted.Inc(1)
 	return ds.spanWriter.WriteSpan(ctx, span)
 }
 
+// WriteSpans calls WriteSpans on wrapped span writer with the spans that are not dropped.
+func (ds *DownsamplingWriter) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	sampled := make([]*model.Span, 0, len(spans))
+	for _, span := range spans {
+		if ds.shouldSample(span) {
+			sampled = append(sampled, span)
+			ds.metricsFor(span).SpansAccepted.Inc(1)
+		} else {
+			ds.metricsFor(span).SpansDropped.Inc(1)
+		}
+	}
+	ds.metrics.SpansDropped.Inc(int64(len(spans) - len(sampled)))
+	ds.metrics.SpansAccepted.Inc(int64(len(sampled)))
+	if len(sampled) == 0 {
+		return nil
+	}
+	return WriteSpans(ctx, ds.spanWriter, sampled)
+}
+
+func (ds *DownsamplingWriter) shouldSample(span *model.Span) bool {
+	if span.Flags.IsDebug() {
+		return true
+	}
+	if ds.ratios == nil {
+		return ds.sampler.ShouldSample(span)
+	}
+	ratio := ds.ratios.Ratio(serviceName(span), span.OperationName)
+	if ratio == ds.ratio {
+		return ds.sampler.ShouldSample(span)
+	}
+	return ds.sampler.shouldSample(span, ds.thresholdFor(ratio))
+}
+
+// thresholdFor returns the hash threshold of the ratio, which is calculated once per distinct ratio.
+func (ds *DownsamplingWriter) thresholdFor(ratio float64) uint64 {
+	if threshold, ok := ds.thresholds.Load(ratio); ok {
+		return threshold.(uint64)
+	}
+	threshold := calculateThreshold(ratio)
+	ds.thresholds.Store(ratio, threshold)
+	return threshold
+}
+
+// metricsFor returns the counters of the span's service, shared with other services above maxServiceMetrics.
+func (ds *DownsamplingWriter) metricsFor(span *model.Span) *serviceMetrics {
+	service := serviceName(span)
+	if m, ok := ds.serviceMetrics.Load(service); ok {
+		return m.(*serviceMetrics)
+	}
+	if atomic.AddInt32(&ds.servicesCount, 1) > maxServiceMetrics {
+		atomic.AddInt32(&ds.servicesCount, -1)
+		service = otherServices
+	}
+	m, loaded := ds.serviceMetrics.LoadOrStore(service, &serviceMetrics{
+		SpansDropped: ds.metricsFactory.Counter(metrics.Options{
+			Name: "spans_by_svc",
+			Tags: map[string]string{"service": service, "result": "dropped"},
+		}),
+		SpansAccepted: ds.metricsFactory.Counter(metrics.Options{
+			Name: "spans_by_svc",
+			Tags: map[string]string{"service": service, "result": "accepted"},
+		}),
+	})
+	if loaded && service != otherServices {
+		// another goroutine created the counters of the service first
+		atomic.AddInt32(&ds.servicesCount, -1)
+	}
+	return m.(*serviceMetrics)
+}
+
+func serviceName(span *model.Span) string {
+	if span.Process == nil {
+		return ""
+	}
+	return span.Process.ServiceName
+}
+
 // hashBytes returns the uint64 hash value of byte slice.
 func (h *hasher) hashBytes() uint64 {
 	h.hash.Reset()
@@ -130,11 +244,16 @@ func calculateThreshold(ratio float64) uint64 {
 
 // ShouldSample decides if a span should be sampled
 func (s *Sampler) ShouldSample(span *model.Span) bool {
+	return s.shouldSample(span, s.threshold)
+}
+
+// shouldSample decides if a span should be sampled, using a threshold instead of the sampler's one
+func (s *Sampler) shouldSample(span *model.Span, threshold uint64) bool {
 	hasherInstance := s.hasherPool.Get().(*hasher)
 	// Currently MarshalTo will only return err if size of traceIDBytes is smaller than 16
 	// Since we force traceIDBytes to be size of 16 metrics is not necessary here.
 	_, _ = span.TraceID.MarshalTo(hasherInstance.buffer[s.lengthOfSalt:])
 	hashVal := hasherInstance.hashBytes()
 	s.hasherPool.Put(hasherInstance)
-	return hashVal <= s.threshold
+	return hashVal <= threshold
 }
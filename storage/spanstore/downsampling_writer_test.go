@@ -21,6 +21,7 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/uber/jaeger-lib/metrics/metricstest"
 
 	"github.com/jaegertracing/jaeger/model"
 )
@@ -60,6 +61,108 @@ func TestDownSamplingWriter_WriteSpan(t *testing.T) {
 	assert.Error(t, c.WriteSpan(context.Background(), span))
 }
 
+func TestDownSamplingWriter_WriteSpans(t *testing.T) {
+	spans := []*model.Span{
+		{TraceID: model.TraceID{Low: 0, High: 1}},
+		{TraceID: model.TraceID{Low: 1, High: 1}},
+	}
+	downsamplingOptions := DownsamplingOptions{
+		Ratio:    0,
+		HashSalt: "jaeger-test",
+	}
+	c := NewDownsamplingWriter(&errorWriteSpanStore{}, downsamplingOptions)
+	assert.NoError(t, c.WriteSpans(context.Background(), spans))
+
+	downsamplingOptions.Ratio = 1
+	c = NewDownsamplingWriter(&errorWriteSpanStore{}, downsamplingOptions)
+	assert.Error(t, c.WriteSpans(context.Background(), spans))
+}
+
+type fixedRatios map[string]float64
+
+func (r fixedRatios) Ratio(service, operation string) float64 {
+	if ratio, ok := r[service+"/"+operation]; ok {
+		return ratio
+	}
+	if ratio, ok := r[service]; ok {
+		return ratio
+	}
+	return r[""]
+}
+
+func TestDownSamplingWriter_Ratios(t *testing.T) {
+	newSpan := func(service, operation string) *model.Span {
+		return &model.Span{
+			TraceID:       model.TraceID{Low: 1, High: 1},
+			OperationName: operation,
+			Process:       &model.Process{ServiceName: service},
+		}
+	}
+	c := NewDownsamplingWriter(&errorWriteSpanStore{}, DownsamplingOptions{
+		Ratio:    1,
+		HashSalt: "jaeger-test",
+		Ratios: fixedRatios{
+			"":            1,
+			"noisy":       0,
+			"noisy/login": 1,
+		},
+	})
+	assert.Error(t, c.WriteSpan(context.Background(), newSpan("quiet", "get")))
+	assert.NoError(t, c.WriteSpan(context.Background(), newSpan("noisy", "get")))
+	assert.Error(t, c.WriteSpan(context.Background(), newSpan("noisy", "login")))
+	assert.NoError(t, c.WriteSpans(context.Background(), []*model.Span{newSpan("noisy", "get")}))
+	assert.Error(t, c.WriteSpans(context.Background(), []*model.Span{newSpan("noisy", "get"), newSpan("noisy", "login")}))
+}
+
+func TestDownSamplingWriter_DebugSpansNotDropped(t *testing.T) {
+	span := &model.Span{
+		TraceID: model.TraceID{Low: 1, High: 1},
+		Flags:   model.DebugFlag,
+		Process: &model.Process{ServiceName: "noisy"},
+	}
+	c := NewDownsamplingWriter(&errorWriteSpanStore{}, DownsamplingOptions{Ratio: 0})
+	assert.Error(t, c.WriteSpan(context.Background(), span))
+
+	c = NewDownsamplingWriter(&errorWriteSpanStore{}, DownsamplingOptions{Ratio: 1, Ratios: fixedRatios{"noisy": 0}})
+	assert.Error(t, c.WriteSpans(context.Background(), []*model.Span{span}))
+}
+
+func TestDownSamplingWriter_ServiceMetrics(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	c := NewDownsamplingWriter(&noopWriteSpanStore{}, DownsamplingOptions{
+		Ratio:          1,
+		Ratios:         fixedRatios{"": 1, "noisy": 0},
+		MetricsFactory: mf,
+	})
+	spans := []*model.Span{
+		{Process: &model.Process{ServiceName: "noisy"}},
+		{Process: &model.Process{ServiceName: "noisy"}},
+		{Process: &model.Process{ServiceName: "quiet"}},
+	}
+	assert.NoError(t, c.WriteSpans(context.Background(), spans))
+	assert.NoError(t, c.WriteSpan(context.Background(), spans[2]))
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans_dropped", Value: 2},
+		metricstest.ExpectedMetric{Name: "spans_accepted", Value: 2},
+		metricstest.ExpectedMetric{Name: "spans_by_svc", Tags: map[string]string{"service": "noisy", "result": "dropped"}, Value: 2},
+		metricstest.ExpectedMetric{Name: "spans_by_svc", Tags: map[string]string{"service": "noisy", "result": "accepted"}, Value: 0},
+		metricstest.ExpectedMetric{Name: "spans_by_svc", Tags: map[string]string{"service": "quiet", "result": "accepted"}, Value: 2},
+	)
+}
+
+func TestDownSamplingWriter_ServiceMetricsLimit(t *testing.T) {
+	mf := metricstest.NewFactory(0)
+	c := NewDownsamplingWriter(&noopWriteSpanStore{}, DownsamplingOptions{Ratio: 1, MetricsFactory: mf})
+	c.servicesCount = maxServiceMetrics - 1
+	assert.NoError(t, c.WriteSpan(context.Background(), &model.Span{Process: &model.Process{ServiceName: "last"}}))
+	assert.NoError(t, c.WriteSpan(context.Background(), &model.Span{Process: &model.Process{ServiceName: "one-too-many"}}))
+	assert.NoError(t, c.WriteSpan(context.Background(), &model.Span{Process: &model.Process{ServiceName: "two-too-many"}}))
+	mf.AssertCounterMetrics(t,
+		metricstest.ExpectedMetric{Name: "spans_by_svc", Tags: map[string]string{"service": "last", "result": "accepted"}, Value: 1},
+		metricstest.ExpectedMetric{Name: "spans_by_svc", Tags: map[string]string{"service": otherServices, "result": "accepted"}, Value: 2},
+	)
+}
+
 // This test is to make sure h.hash.Reset() works and same traceID will always hash to the same value.
 func TestDownSamplingWriter_hashBytes(t *testing.T) {
 	downsamplingOptions := DownsamplingOptions{
@@ -21,6 +21,7 @@ import (
 	"time"
 
 	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/multierror"
 )
 
 var (
@@ -33,6 +34,26 @@ type Writer interface {
 	WriteSpan(ctx context.Context, span *model.Span) error
 }
 
+// BatchWriter is an optional interface of Writers that can save several spans more efficiently than one at a time.
+type BatchWriter interface {
+	WriteSpans(ctx context.Context, spans []*model.Span) error
+}
+
+// WriteSpans saves the spans with a single call if the writer implements BatchWriter, and one at a time otherwise.
+// When the spans are saved one at a time, all of them are attempted and the errors are combined.
+func WriteSpans(ctx context.Context, writer Writer, spans []*model.Span) error {
+	if batchWriter, ok := writer.(BatchWriter); ok {
+		return batchWriter.WriteSpans(ctx, spans)
+	}
+	var errs []error
+	for _, span := range spans {
+		if err := writer.WriteSpan(ctx, span); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return multierror.Wrap(errs)
+}
+
 // Reader finds and loads traces and other data from storage.
 type Reader interface {
 	// GetTrace retrieves the trace with a given id.
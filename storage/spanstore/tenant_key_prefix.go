@@ -0,0 +1,222 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore
+
+import (
+	"context"
+	"strings"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+)
+
+// tenantKeySeparator separates the tenant from the service name in the stored keys.
+// Valid tenants never contain it, so the prefix is unambiguous.
+const tenantKeySeparator = "/"
+
+func tenantKeyPrefix(ctx context.Context) string {
+	if tenant := tenancy.GetTenant(ctx); tenant != "" {
+		return tenant + tenantKeySeparator
+	}
+	return ""
+}
+
+// OwnsService returns the service name without the tenant prefix and true if the stored service name
+// belongs to the tenant of the prefix. Without a tenant, only the service names that do not carry
+// any tenant prefix are owned, so that requests without a tenant cannot read the data of the tenants.
+func OwnsService(prefix, service string) (string, bool) {
+	if prefix != "" {
+		if !strings.HasPrefix(service, prefix) {
+			return "", false
+		}
+		return strings.TrimPrefix(service, prefix), true
+	}
+	if i := strings.Index(service, tenantKeySeparator); i > 0 && tenancy.WellFormed(service[:i]) {
+		return "", false
+	}
+	return service, true
+}
+
+// TenantKeyPrefix returns the prefix of the stored service names of the tenant from the context,
+// or an empty string if there is no tenant.
+func TenantKeyPrefix(ctx context.Context) string {
+	return tenantKeyPrefix(ctx)
+}
+
+// TenantKeyPrefixWriter is a span Writer that partitions the data of each tenant
+// by prefixing the service name of the spans with the tenant from the context.
+// It is used by backends that index spans by service name, such as Cassandra and Badger.
+type TenantKeyPrefixWriter struct {
+	spanWriter Writer
+}
+
+// NewTenantKeyPrefixWriter creates a TenantKeyPrefixWriter.
+func NewTenantKeyPrefixWriter(spanWriter Writer) *TenantKeyPrefixWriter {
+	return &TenantKeyPrefixWriter{spanWriter: spanWriter}
+}
+
+// WriteSpan calls WriteSpan on the wrapped span writer with the service name prefixed by the tenant.
+func (w *TenantKeyPrefixWriter) WriteSpan(ctx context.Context, span *model.Span) error {
+	return w.spanWriter.WriteSpan(ctx, prefixSpan(tenantKeyPrefix(ctx), span))
+}
+
+// WriteSpans calls WriteSpans on the wrapped span writer with the service names prefixed by the tenant.
+func (w *TenantKeyPrefixWriter) WriteSpans(ctx context.Context, spans []*model.Span) error {
+	prefix := tenantKeyPrefix(ctx)
+	if prefix == "" {
+		return WriteSpans(ctx, w.spanWriter, spans)
+	}
+	prefixed := make([]*model.Span, len(spans))
+	for i, span := range spans {
+		prefixed[i] = prefixSpan(prefix, span)
+	}
+	return WriteSpans(ctx, w.spanWriter, prefixed)
+}
+
+// prefixSpan returns a copy of the span with the prefixed service name, leaving the original span untouched.
+func prefixSpan(prefix string, span *model.Span) *model.Span {
+	if prefix == "" || span.Process == nil {
+		return span
+	}
+	spanCopy := *span
+	process := *span.Process
+	process.ServiceName = prefix + process.ServiceName
+	spanCopy.Process = &process
+	return &spanCopy
+}
+
+// TenantKeyPrefixReader is a span Reader that only returns the data of the tenant from the context,
+// as written by a TenantKeyPrefixWriter, with the tenant prefix removed from the service names.
+type TenantKeyPrefixReader struct {
+	spanReader Reader
+}
+
+// NewTenantKeyPrefixReader creates a TenantKeyPrefixReader.
+func NewTenantKeyPrefixReader(spanReader Reader) *TenantKeyPrefixReader {
+	return &TenantKeyPrefixReader{spanReader: spanReader}
+}
+
+// GetTrace returns the spans of the trace that belong to the tenant.
+func (r *TenantKeyPrefixReader) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	trace, err := r.spanReader.GetTrace(ctx, traceID)
+	if err != nil {
+		return nil, err
+	}
+	if trace = filterTrace(tenantKeyPrefix(ctx), trace); trace == nil {
+		return nil, ErrTraceNotFound
+	}
+	return trace, nil
+}
+
+// GetServices returns the services of the tenant.
+func (r *TenantKeyPrefixReader) GetServices(ctx context.Context) ([]string, error) {
+	services, err := r.spanReader.GetServices(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prefix := tenantKeyPrefix(ctx)
+	tenantServices := []string{}
+	for _, service := range services {
+		if service, ok := OwnsService(prefix, service); ok {
+			tenantServices = append(tenantServices, service)
+		}
+	}
+	return tenantServices, nil
+}
+
+// GetOperations returns the operations of the given service of the tenant.
+func (r *TenantKeyPrefixReader) GetOperations(ctx context.Context, query OperationQueryParameters) ([]Operation, error) {
+	prefix := tenantKeyPrefix(ctx)
+	if _, ok := OwnsService("", query.ServiceName); !ok {
+		return []Operation{}, nil
+	}
+	query.ServiceName = prefix + query.ServiceName
+	return r.spanReader.GetOperations(ctx, query)
+}
+
+// FindTraces returns the traces of the tenant that match the query.
+func (r *TenantKeyPrefixReader) FindTraces(ctx context.Context, query *TraceQueryParameters) ([]*model.Trace, error) {
+	if !ownsQuery(query) {
+		return nil, nil
+	}
+	prefix := tenantKeyPrefix(ctx)
+	traces, err := r.spanReader.FindTraces(ctx, prefixQuery(prefix, query))
+	if err != nil {
+		return nil, err
+	}
+	var tenantTraces []*model.Trace
+	for _, trace := range traces {
+		if trace = filterTrace(prefix, trace); trace != nil {
+			tenantTraces = append(tenantTraces, trace)
+		}
+	}
+	return tenantTraces, nil
+}
+
+// FindTraceIDs returns the IDs of the traces of the tenant that match the query.
+func (r *TenantKeyPrefixReader) FindTraceIDs(ctx context.Context, query *TraceQueryParameters) ([]model.TraceID, error) {
+	if !ownsQuery(query) {
+		return nil, nil
+	}
+	return r.spanReader.FindTraceIDs(ctx, prefixQuery(tenantKeyPrefix(ctx), query))
+}
+
+// ownsQuery returns false if the query names a service with a tenant prefix,
+// which must not be readable directly.
+func ownsQuery(query *TraceQueryParameters) bool {
+	if query == nil || query.ServiceName == "" {
+		return true
+	}
+	_, ok := OwnsService("", query.ServiceName)
+	return ok
+}
+
+func prefixQuery(prefix string, query *TraceQueryParameters) *TraceQueryParameters {
+	if prefix == "" || query == nil {
+		return query
+	}
+	queryCopy := *query
+	queryCopy.ServiceName = prefix + query.ServiceName
+	return &queryCopy
+}
+
+// filterTrace keeps the spans whose service name belongs to the tenant of the prefix
+// and removes the prefix from them. It returns nil when no span is left.
+func filterTrace(prefix string, trace *model.Trace) *model.Trace {
+	var spans []*model.Span
+	for _, span := range trace.Spans {
+		if span.Process == nil {
+			if prefix == "" {
+				spans = append(spans, span)
+			}
+			continue
+		}
+		service, ok := OwnsService(prefix, span.Process.ServiceName)
+		if !ok {
+			continue
+		}
+		if prefix != "" {
+			process := *span.Process
+			process.ServiceName = service
+			span.Process = &process
+		}
+		spans = append(spans, span)
+	}
+	if len(spans) == 0 {
+		return nil
+	}
+	trace.Spans = spans
+	return trace
+}
@@ -0,0 +1,190 @@
+// Copyright (c) 2021 The Jaeger Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package spanstore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/jaegertracing/jaeger/model"
+	"github.com/jaegertracing/jaeger/pkg/tenancy"
+	. "github.com/jaegertracing/jaeger/storage/spanstore"
+)
+
+// recordingSpanStore keeps the written spans in a single trace and records the queries it receives.
+type recordingSpanStore struct {
+	spans        []*model.Span
+	serviceNames []string
+}
+
+func (s *recordingSpanStore) WriteSpan(ctx context.Context, span *model.Span) error {
+	s.spans = append(s.spans, span)
+	return nil
+}
+
+func (s *recordingSpanStore) trace() *model.Trace {
+	spans := make([]*model.Span, len(s.spans))
+	for i, span := range s.spans {
+		spanCopy := *span
+		spans[i] = &spanCopy
+	}
+	return &model.Trace{Spans: spans}
+}
+
+func (s *recordingSpanStore) GetTrace(ctx context.Context, traceID model.TraceID) (*model.Trace, error) {
+	return s.trace(), nil
+}
+
+func (s *recordingSpanStore) GetServices(ctx context.Context) ([]string, error) {
+	var services []string
+	for _, span := range s.spans {
+		services = append(services, span.Process.ServiceName)
+	}
+	return services, nil
+}
+
+func (s *recordingSpanStore) GetOperations(ctx context.Context, query OperationQueryParameters) ([]Operation, error) {
+	s.serviceNames = append(s.serviceNames, query.ServiceName)
+	return nil, nil
+}
+
+func (s *recordingSpanStore) FindTraces(ctx context.Context, query *TraceQueryParameters) ([]*model.Trace, error) {
+	s.serviceNames = append(s.serviceNames, query.ServiceName)
+	return []*model.Trace{s.trace()}, nil
+}
+
+func (s *recordingSpanStore) FindTraceIDs(ctx context.Context, query *TraceQueryParameters) ([]model.TraceID, error) {
+	s.serviceNames = append(s.serviceNames, query.ServiceName)
+	return nil, nil
+}
+
+func TestTenantKeyPrefixWriter(t *testing.T) {
+	store := &recordingSpanStore{}
+	w := NewTenantKeyPrefixWriter(store)
+	span := &model.Span{SpanID: 1, Process: &model.Process{ServiceName: "svc"}}
+
+	require.NoError(t, w.WriteSpan(context.Background(), span))
+	require.NoError(t, w.WriteSpan(tenancy.WithTenant(context.Background(), "acme"), span))
+	require.NoError(t, w.WriteSpans(tenancy.WithTenant(context.Background(), "globex"), []*model.Span{span}))
+
+	require.Len(t, store.spans, 3)
+	assert.Equal(t, "svc", store.spans[0].Process.ServiceName)
+	assert.Equal(t, "acme/svc", store.spans[1].Process.ServiceName)
+	assert.Equal(t, "globex/svc", store.spans[2].Process.ServiceName)
+	assert.Equal(t, "svc", span.Process.ServiceName, "the original span must not be modified")
+}
+
+func TestTenantKeyPrefixReader(t *testing.T) {
+	store := &recordingSpanStore{}
+	w := NewTenantKeyPrefixWriter(store)
+	r := NewTenantKeyPrefixReader(store)
+	ctxAcme := tenancy.WithTenant(context.Background(), "acme")
+	ctxGlobex := tenancy.WithTenant(context.Background(), "globex")
+	ctxInitech := tenancy.WithTenant(context.Background(), "initech")
+
+	process := &model.Process{ServiceName: "svc"}
+	require.NoError(t, w.WriteSpan(ctxAcme, &model.Span{SpanID: 1, Process: process}))
+	require.NoError(t, w.WriteSpan(ctxGlobex, &model.Span{SpanID: 2, Process: process}))
+
+	trace, err := r.GetTrace(ctxAcme, model.TraceID{})
+	require.NoError(t, err)
+	require.Len(t, trace.Spans, 1)
+	assert.Equal(t, model.SpanID(1), trace.Spans[0].SpanID)
+	assert.Equal(t, "svc", trace.Spans[0].Process.ServiceName)
+
+	_, err = r.GetTrace(ctxInitech, model.TraceID{})
+	assert.Equal(t, ErrTraceNotFound, err)
+
+	services, err := r.GetServices(ctxGlobex)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc"}, services)
+
+	services, err = r.GetServices(ctxInitech)
+	require.NoError(t, err)
+	assert.Empty(t, services)
+
+	traces, err := r.FindTraces(ctxGlobex, &TraceQueryParameters{ServiceName: "svc"})
+	require.NoError(t, err)
+	require.Len(t, traces, 1)
+	require.Len(t, traces[0].Spans, 1)
+	assert.Equal(t, model.SpanID(2), traces[0].Spans[0].SpanID)
+
+	traces, err = r.FindTraces(ctxInitech, &TraceQueryParameters{ServiceName: "svc"})
+	require.NoError(t, err)
+	assert.Empty(t, traces)
+
+	_, err = r.GetOperations(ctxAcme, OperationQueryParameters{ServiceName: "svc"})
+	require.NoError(t, err)
+	_, err = r.FindTraceIDs(ctxAcme, &TraceQueryParameters{ServiceName: "svc"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"globex/svc", "initech/svc", "acme/svc", "acme/svc"}, store.serviceNames)
+}
+
+func TestTenantKeyPrefixReaderWithoutTenant(t *testing.T) {
+	store := &recordingSpanStore{}
+	w := NewTenantKeyPrefixWriter(store)
+	r := NewTenantKeyPrefixReader(store)
+	ctx := context.Background()
+
+	require.NoError(t, w.WriteSpan(tenancy.WithTenant(ctx, "acme"), &model.Span{SpanID: 1, Process: &model.Process{ServiceName: "svc"}}))
+	require.NoError(t, w.WriteSpan(ctx, &model.Span{SpanID: 2, Process: &model.Process{ServiceName: "svc"}}))
+	require.NoError(t, w.WriteSpan(ctx, &model.Span{SpanID: 3, Process: &model.Process{ServiceName: "Web/API"}}))
+
+	trace, err := r.GetTrace(ctx, model.TraceID{})
+	require.NoError(t, err)
+	require.Len(t, trace.Spans, 2)
+	assert.Equal(t, model.SpanID(2), trace.Spans[0].SpanID)
+	assert.Equal(t, model.SpanID(3), trace.Spans[1].SpanID)
+
+	services, err := r.GetServices(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"svc", "Web/API"}, services, "the services of the tenants must not be listed")
+
+	traces, err := r.FindTraces(ctx, &TraceQueryParameters{ServiceName: "acme/svc"})
+	require.NoError(t, err)
+	assert.Empty(t, traces)
+	traceIDs, err := r.FindTraceIDs(ctx, &TraceQueryParameters{ServiceName: "acme/svc"})
+	require.NoError(t, err)
+	assert.Empty(t, traceIDs)
+	operations, err := r.GetOperations(ctx, OperationQueryParameters{ServiceName: "acme/svc"})
+	require.NoError(t, err)
+	assert.Empty(t, operations)
+	assert.Empty(t, store.serviceNames, "queries for the services of the tenants must not reach the store")
+}
+
+func TestOwnsService(t *testing.T) {
+	tests := []struct {
+		prefix  string
+		service string
+		owned   string
+		ok      bool
+	}{
+		{prefix: "acme/", service: "acme/svc", owned: "svc", ok: true},
+		{prefix: "acme/", service: "globex/svc", ok: false},
+		{prefix: "acme/", service: "svc", ok: false},
+		{prefix: "", service: "svc", owned: "svc", ok: true},
+		{prefix: "", service: "acme/svc", ok: false},
+		{prefix: "", service: "Web/API", owned: "Web/API", ok: true},
+		{prefix: "", service: "/svc", owned: "/svc", ok: true},
+	}
+	for _, test := range tests {
+		owned, ok := OwnsService(test.prefix, test.service)
+		assert.Equal(t, test.ok, ok, "%+v", test)
+		assert.Equal(t, test.owned, owned, "%+v", test)
+	}
+}